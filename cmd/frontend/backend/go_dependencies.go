@@ -0,0 +1,118 @@
+package backend
+
+import (
+	"context"
+	"sort"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/godeps"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// UpdateGoDependencyIndex parses the Go code of the repository at its default
+// branch, unless it was already indexed at that commit.
+func UpdateGoDependencyIndex(ctx context.Context, repo *types.Repo) error {
+	gitserverRepo := gitserver.Repo{Name: repo.Name}
+	commitID, err := git.ResolveRevision(ctx, gitserverRepo, nil, "", &git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		return err
+	}
+	indexedCommitID, err := db.GoDependencies.IndexedCommit(ctx, repo.ID)
+	if err != nil || indexedCommitID == commitID {
+		return err
+	}
+
+	archive, err := git.Archive(ctx, gitserverRepo, git.ArchiveOptions{Treeish: string(commitID), Format: "tar"})
+	if err != nil {
+		return err
+	}
+	defer archive.Close()
+	deps, err := godeps.Parse(archive)
+	if err != nil {
+		return err
+	}
+	return db.GoDependencies.UpdateIndex(ctx, &db.GoDependencyIndex{
+		RepoID:      repo.ID,
+		Commit:      commitID,
+		ModulePaths: deps.ModulePaths,
+		ImportPaths: deps.ImportPaths,
+	})
+}
+
+// UpdateGoDependencyGraph updates the Go dependency graph of all enabled
+// repositories: it indexes the repositories whose default branch changed, and
+// then resolves the import paths of all repositories again (because a
+// repository that was indexed may be the dependency of another one). It does
+// nothing if the Go dependency graph is disabled.
+func UpdateGoDependencyGraph(ctx context.Context) error {
+	if !conf.GoDependencyGraphEnabled() {
+		return nil
+	}
+	repos, err := db.Repos.List(ctx, db.ReposListOptions{Enabled: true})
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if err := UpdateGoDependencyIndex(ctx, repo); err != nil {
+			log15.Warn("Updating the Go dependency index failed.", "repo", repo.Name, "error", err)
+		}
+	}
+	return resolveGoDependencies(ctx, repos)
+}
+
+// resolveGoDependencies resolves the import paths of the indexes of all
+// repositories to the repositories they depend on, and stores the
+// dependencies that changed.
+func resolveGoDependencies(ctx context.Context, repos []*types.Repo) error {
+	indexes, err := db.GoDependencies.ListIndexes(ctx)
+	if err != nil {
+		return err
+	}
+	resolver := godeps.NewResolver()
+	for _, repo := range repos {
+		resolver.AddRepo(repo.ID, repo.Name)
+	}
+	for _, index := range indexes {
+		resolver.AddModules(index.RepoID, index.ModulePaths)
+	}
+
+	previous, err := db.GoDependencies.ListAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		seen := map[api.RepoID]bool{}
+		var dependencies []api.RepoID
+		for _, importPath := range index.ImportPaths {
+			if id := resolver.Resolve(importPath); id != 0 && id != index.RepoID && !seen[id] {
+				seen[id] = true
+				dependencies = append(dependencies, id)
+			}
+		}
+		sort.Slice(dependencies, func(i, j int) bool { return dependencies[i] < dependencies[j] })
+		if equalRepoIDs(dependencies, previous[index.RepoID]) {
+			continue
+		}
+		if err := db.GoDependencies.Replace(ctx, index.RepoID, dependencies); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func equalRepoIDs(a, b []api.RepoID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,51 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestResolveGoDependencies(t *testing.T) {
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	repos := []*types.Repo{
+		{ID: 1, Name: "github.com/example/app"},
+		{ID: 2, Name: "github.com/gorilla/mux"},
+		{ID: 3, Name: "github.com/golang/net"},
+		{ID: 4, Name: "git.example.com/lib"},
+	}
+	db.Mocks.GoDependencies.ListIndexes = func() ([]*db.GoDependencyIndex, error) {
+		return []*db.GoDependencyIndex{
+			{RepoID: 1, ImportPaths: []string{"example.com/lib/x", "github.com/example/app/cmd", "github.com/gorilla/mux", "golang.org/x/net/context", "golang.org/x/net/http2"}},
+			{RepoID: 2, ImportPaths: []string{"github.com/unknown/repo"}},
+			{RepoID: 3, ImportPaths: []string{"github.com/gorilla/mux"}},
+			{RepoID: 4, ModulePaths: []string{"example.com/lib"}},
+		}, nil
+	}
+	db.Mocks.GoDependencies.ListAll = func() (map[api.RepoID][]api.RepoID, error) {
+		return map[api.RepoID][]api.RepoID{2: {1}, 3: {2}}, nil
+	}
+	replaced := map[api.RepoID][]api.RepoID{}
+	db.Mocks.GoDependencies.Replace = func(repoID api.RepoID, dependencyRepoIDs []api.RepoID) error {
+		replaced[repoID] = dependencyRepoIDs
+		return nil
+	}
+
+	if err := resolveGoDependencies(context.Background(), repos); err != nil {
+		t.Fatal(err)
+	}
+	// The dependencies of repository 3 did not change, so they are not
+	// replaced.
+	want := map[api.RepoID][]api.RepoID{
+		1: {2, 3, 4},
+		2: nil,
+	}
+	if !reflect.DeepEqual(replaced, want) {
+		t.Errorf("got replaced dependencies %v, want %v", replaced, want)
+	}
+}
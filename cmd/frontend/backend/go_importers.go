@@ -2,45 +2,37 @@ package backend
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
-	"net/http"
+	"errors"
 
-	"github.com/die-net/lrucache"
-	"github.com/gregjones/httpcache"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 )
 
 var MockCountGoImporters func(ctx context.Context, repo api.RepoName) (int, error)
 
-// 100 MiB cache, no age-based eviction
-var httpClient = &http.Client{Transport: httpcache.NewTransport(lrucache.New(100*1024*1024, 0))}
+// ErrGoImportersUnknown is returned by CountGoImporters when the Go dependency
+// graph is disabled, since the number of importers is then not known.
+var ErrGoImportersUnknown = errors.New("the number of Go importers is not known because the Go dependency graph is disabled")
 
-// CountGoImporters returns the number of Go importers for the repository. This is a special case
-// used only on Sourcegraph.com for repository badges.
-//
-// TODO: The import path is not always the same as the repository name.
+// CountGoImporters returns the number of repositories whose Go code imports
+// the repository, according to the Go dependency graph (see
+// UpdateGoDependencyGraph). It is used for repository badges. It returns 0 for
+// unknown repositories, and ErrGoImportersUnknown if the Go dependency graph
+// is disabled.
 func CountGoImporters(ctx context.Context, repo api.RepoName) (int, error) {
 	if MockCountGoImporters != nil {
 		return MockCountGoImporters(ctx, repo)
 	}
-	// Assumes the import path is the same as the repo name - not always true!
-	response, err := httpClient.Get("https://api.godoc.org/importers/" + string(repo))
-	if err != nil {
-		return 0, err
-	}
-	var result struct {
-		Results []struct {
-			Path string
-		}
-	}
-	bytes, err := ioutil.ReadAll(response.Body)
-	if err != nil {
-		return 0, err
+	if !conf.GoDependencyGraphEnabled() {
+		return 0, ErrGoImportersUnknown
 	}
-	err = json.Unmarshal(bytes, &result)
-	if err != nil {
+	r, err := db.Repos.GetByName(ctx, repo)
+	if errcode.IsNotFound(err) {
+		return 0, nil
+	} else if err != nil {
 		return 0, err
 	}
-	return len(result.Results), nil
+	return db.GoDependencies.CountDependents(ctx, r.ID)
 }
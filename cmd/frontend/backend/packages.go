@@ -0,0 +1,177 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/pkgdeps"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+func init() {
+	conf.ContributeValidator(func(c conf.Unified) (problems []string) {
+		for _, m := range c.PackageToRepositoryName {
+			if _, err := regexp.Compile(m.From); err != nil {
+				problems = append(problems, fmt.Sprintf("Not a valid regexp: %s. See the valid syntax: https://golang.org/pkg/regexp/", m.From))
+			}
+		}
+		return problems
+	})
+}
+
+// UpdatePackageIndex parses the package manifests of the repository at its
+// default branch, unless it was already indexed at that commit.
+func UpdatePackageIndex(ctx context.Context, repo *types.Repo) error {
+	gitserverRepo := gitserver.Repo{Name: repo.Name}
+	commitID, err := git.ResolveRevision(ctx, gitserverRepo, nil, "", &git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		return err
+	}
+	indexedCommitID, err := db.Packages.IndexedCommit(ctx, repo.ID)
+	if err != nil || indexedCommitID == commitID {
+		return err
+	}
+
+	// Archive only the manifests. They are listed first rather than matched
+	// with glob pathspecs because git archive fails if any pathspec matches no
+	// files.
+	entries, err := git.ReadDir(ctx, gitserverRepo, commitID, "", true)
+	if err != nil {
+		return err
+	}
+	var paths []string
+	for _, fi := range entries {
+		if fi.Mode().IsRegular() && pkgdeps.IsManifest(fi.Name()) {
+			paths = append(paths, ":(literal)"+fi.Name())
+		}
+	}
+	deps := &pkgdeps.Deps{}
+	if len(paths) > 0 {
+		archive, err := git.Archive(ctx, gitserverRepo, git.ArchiveOptions{Treeish: string(commitID), Format: "tar", Paths: paths})
+		if err != nil {
+			return err
+		}
+		defer archive.Close()
+		deps, err = pkgdeps.Parse(archive)
+		if err != nil {
+			return err
+		}
+	}
+	return db.Packages.UpdateIndex(ctx, &db.PackageIndex{
+		RepoID:       repo.ID,
+		Commit:       commitID,
+		Packages:     deps.Packages,
+		Dependencies: deps.Dependencies,
+	})
+}
+
+// UpdatePackageDependencyGraph updates the package dependency graph of all
+// enabled repositories: it indexes the repositories whose default branch
+// changed, and then resolves all packages to repositories again (because a
+// repository that was indexed or a rule that changed may map packages that
+// other repositories depend on). It does nothing if the package dependency
+// graph is disabled.
+func UpdatePackageDependencyGraph(ctx context.Context) error {
+	if !conf.PackageDependencyGraphEnabled() {
+		return nil
+	}
+	repos, err := db.Repos.List(ctx, db.ReposListOptions{Enabled: true})
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if err := UpdatePackageIndex(ctx, repo); err != nil {
+			log15.Warn("Updating the package index failed.", "repo", repo.Name, "error", err)
+		}
+	}
+	return resolvePackages(ctx, repos, conf.Get().PackageToRepositoryName)
+}
+
+// resolvePackages resolves the packages that the repositories define or
+// depend on to the repositories that contain them, and stores them if they
+// changed.
+func resolvePackages(ctx context.Context, repos []*types.Repo, rules []*schema.PackageToRepositoryName) error {
+	resolver := pkgdeps.NewResolver()
+	for _, rule := range rules {
+		from, err := regexp.Compile(rule.From)
+		if err != nil {
+			// A user-visible validation error is shown for the site
+			// configuration (see init).
+			log15.Error("Site config: unable to compile package name mapping regexp", "regexp", rule.From)
+			continue
+		}
+		resolver.AddRule(pkgdeps.Rule{Manager: rule.Manager, From: from, To: rule.To})
+	}
+	enabled := map[api.RepoID]bool{}
+	for _, repo := range repos {
+		resolver.AddRepo(repo.ID, repo.Name)
+		enabled[repo.ID] = true
+	}
+	definitions, err := db.Packages.ListDefinitions(ctx)
+	if err != nil {
+		return err
+	}
+	for _, d := range definitions {
+		// The definitions of repositories that were disabled are stale.
+		if enabled[d.RepoID] {
+			resolver.AddPackages(d.RepoID, []pkgdeps.Package{d.Package})
+		}
+	}
+	dependencies, err := db.Packages.ListDependencies(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Resolve the packages that are defined but that no repository depends
+	// on too, so that they are listed in the packages of their repository.
+	packages := map[pkgdeps.Package]struct{}{}
+	for _, d := range definitions {
+		packages[d.Package] = struct{}{}
+	}
+	for _, p := range dependencies {
+		packages[p] = struct{}{}
+	}
+	var packageRepos []*db.PackageRepo
+	for p := range packages {
+		if id := resolver.Resolve(p); id != 0 {
+			packageRepos = append(packageRepos, &db.PackageRepo{Package: p, RepoID: id})
+		}
+	}
+	sort.Slice(packageRepos, func(i, j int) bool {
+		a, b := packageRepos[i].Package, packageRepos[j].Package
+		if a.Manager != b.Manager {
+			return a.Manager < b.Manager
+		}
+		return a.Name < b.Name
+	})
+
+	previous, err := db.Packages.ListRepos(ctx)
+	if err != nil {
+		return err
+	}
+	if equalPackageRepos(packageRepos, previous) {
+		return nil
+	}
+	return db.Packages.ReplaceRepos(ctx, packageRepos)
+}
+
+func equalPackageRepos(a, b []*db.PackageRepo) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if *a[i] != *b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package backend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/pkgdeps"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestResolvePackages(t *testing.T) {
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	repos := []*types.Repo{
+		{ID: 1, Name: "github.com/example/app"},
+		{ID: 2, Name: "github.com/example/ui"},
+		{ID: 3, Name: "github.com/stevemao/left-pad"},
+	}
+	rules := []*schema.PackageToRepositoryName{
+		{Manager: "npm", From: "^@example/(?P<name>.+)$", To: "github.com/example/{name}"},
+		{Manager: "npm", From: "(", To: "invalid"},
+	}
+	var (
+		app     = pkgdeps.Package{Manager: pkgdeps.NPM, Name: "@example/app"}
+		ui      = pkgdeps.Package{Manager: pkgdeps.NPM, Name: "@example/ui"}
+		leftPad = pkgdeps.Package{Manager: pkgdeps.NPM, Name: "left-pad"}
+		stale   = pkgdeps.Package{Manager: pkgdeps.Pip, Name: "stale"}
+		unknown = pkgdeps.Package{Manager: pkgdeps.Maven, Name: "junit:junit"}
+	)
+	db.Mocks.Packages.ListDefinitions = func() ([]*db.PackageRepo, error) {
+		return []*db.PackageRepo{
+			{Package: app, RepoID: 3}, // overridden by the rule
+			{Package: leftPad, RepoID: 3},
+			{Package: stale, RepoID: 4}, // disabled repository
+		}, nil
+	}
+	db.Mocks.Packages.ListDependencies = func() ([]pkgdeps.Package, error) {
+		return []pkgdeps.Package{unknown, leftPad, stale, ui}, nil
+	}
+	var previous []*db.PackageRepo
+	db.Mocks.Packages.ListRepos = func() ([]*db.PackageRepo, error) {
+		return previous, nil
+	}
+	var replaced []*db.PackageRepo
+	calls := 0
+	db.Mocks.Packages.ReplaceRepos = func(packageRepos []*db.PackageRepo) error {
+		replaced = packageRepos
+		calls++
+		return nil
+	}
+
+	if err := resolvePackages(context.Background(), repos, rules); err != nil {
+		t.Fatal(err)
+	}
+	want := []*db.PackageRepo{
+		{Package: app, RepoID: 1},
+		{Package: ui, RepoID: 2},
+		{Package: leftPad, RepoID: 3},
+	}
+	if !reflect.DeepEqual(replaced, want) {
+		t.Errorf("got package repositories %+v, want %+v", replaced, want)
+	}
+
+	// The package repositories did not change, so they are not replaced.
+	previous = want
+	if err := resolvePackages(context.Background(), repos, rules); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 {
+		t.Errorf("got %d calls to ReplaceRepos, want 1", calls)
+	}
+}
@@ -20,3 +20,23 @@ func (symbols) ListTags(ctx context.Context, args protocol.SearchArgs) ([]protoc
 	}
 	return result.Symbols, err
 }
+
+// Outline returns the symbols of a single file, in the order they are declared
+// in the file.
+func (symbols) Outline(ctx context.Context, args protocol.OutlineArgs) ([]protocol.Symbol, error) {
+	result, err := symbolsclient.DefaultClient.Outline(ctx, args)
+	if result == nil {
+		return nil, err
+	}
+	return result.Symbols, err
+}
+
+// List returns all the symbols of a repository at a commit, ordered by path
+// and line, up to args.First.
+func (symbols) List(ctx context.Context, args protocol.ListArgs) ([]protocol.Symbol, error) {
+	result, err := symbolsclient.DefaultClient.List(ctx, args)
+	if result == nil {
+		return nil, err
+	}
+	return result.Symbols, err
+}
@@ -0,0 +1,268 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbutil"
+)
+
+// A GlobalSymbol is a symbol defined at the default branch of a repository,
+// stored in the global symbol index so that symbols can be searched across
+// all repositories without querying the symbols service of each.
+type GlobalSymbol struct {
+	RepoID    api.RepoID
+	Name      string
+	Kind      string
+	Language  string
+	Path      string
+	Line      int32 // 1-based
+	Character int32 // 0-based column of the name on the line
+	Parent    string
+	Signature string
+	Exported  bool // whether the symbol is exported (or public) and so usable from other packages
+}
+
+// A GlobalSymbolMatch is a symbol found by a global symbol search.
+type GlobalSymbolMatch struct {
+	GlobalSymbol
+	Repo   *types.Repo  // the repository of the symbol
+	Commit api.CommitID // the commit the repository's symbols were indexed at
+}
+
+type globalSymbols struct{}
+
+// globalSymbolsInsertBatchSize is the number of symbols inserted by a
+// single INSERT statement.
+const globalSymbolsInsertBatchSize = 1000
+
+// IndexedCommit returns the commit at which the symbols of the repository
+// were indexed, or "" if they were never indexed.
+func (*globalSymbols) IndexedCommit(ctx context.Context, repoID api.RepoID) (api.CommitID, error) {
+	if Mocks.GlobalSymbols.IndexedCommit != nil {
+		return Mocks.GlobalSymbols.IndexedCommit(repoID)
+	}
+
+	var commit api.CommitID
+	err := dbconn.Global.QueryRowContext(ctx, "SELECT commit FROM global_symbol_repos WHERE repo_id=$1", repoID).Scan(&commit)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return commit, err
+}
+
+// Replace replaces the indexed symbols of the repository with the symbols at
+// commit.
+func (*globalSymbols) Replace(ctx context.Context, repoID api.RepoID, commit api.CommitID, symbols []*GlobalSymbol) error {
+	if Mocks.GlobalSymbols.Replace != nil {
+		return Mocks.GlobalSymbols.Replace(repoID, commit, symbols)
+	}
+
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM global_symbols WHERE repo_id=$1", repoID); err != nil {
+			return err
+		}
+		for len(symbols) > 0 {
+			batch := symbols
+			if len(batch) > globalSymbolsInsertBatchSize {
+				batch = batch[:globalSymbolsInsertBatchSize]
+			}
+			symbols = symbols[len(batch):]
+
+			values := make([]*sqlf.Query, len(batch))
+			for i, s := range batch {
+				values[i] = sqlf.Sprintf("(%s, %s, %s, %s, %s, %s, %s, %s, %s, %s)",
+					repoID, s.Name, s.Kind, s.Language, s.Path, s.Line, s.Character, s.Parent, s.Signature, s.Exported)
+			}
+			q := sqlf.Sprintf("INSERT INTO global_symbols(repo_id, name, kind, language, path, line, character, parent, signature, exported) VALUES %s", sqlf.Join(values, ", "))
+			if _, err := tx.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...); err != nil {
+				return err
+			}
+		}
+		_, err := tx.ExecContext(ctx,
+			"INSERT INTO global_symbol_repos(repo_id, commit) VALUES($1, $2) ON CONFLICT (repo_id) DO UPDATE SET commit=$2, updated_at=now()",
+			repoID, commit,
+		)
+		return err
+	})
+}
+
+// GlobalSymbolsSearchOptions contains options for searching the global
+// symbol index.
+type GlobalSymbolsSearchOptions struct {
+	// Query matches the names of the symbols. It is a regular expression if
+	// IsRegExp, otherwise a substring.
+	Query           string
+	IsRegExp        bool
+	IsCaseSensitive bool
+
+	// ExactName, if set, ranks the symbols named exactly ExactName (ignoring
+	// case) first.
+	ExactName string
+
+	// IncludePatterns are regular expressions that the symbols' file paths
+	// must all match. ExcludePattern is a regular expression that they must
+	// not match.
+	IncludePatterns []string
+	ExcludePattern  string
+
+	// RepoIDs, if non-nil, are the only repositories whose symbols are
+	// searched.
+	RepoIDs []api.RepoID
+
+	// Limit is the maximum number of symbols to return.
+	Limit int
+}
+
+func (o GlobalSymbolsSearchOptions) sqlConditions() []*sqlf.Query {
+	conds := []*sqlf.Query{sqlf.Sprintf("repo.enabled")}
+	switch {
+	case o.IsRegExp && o.IsCaseSensitive:
+		conds = append(conds, sqlf.Sprintf("s.name ~ %s", o.Query))
+	case o.IsRegExp:
+		conds = append(conds, sqlf.Sprintf("s.name ~* %s", o.Query))
+	case o.IsCaseSensitive:
+		conds = append(conds, sqlf.Sprintf("s.name LIKE %s", "%"+escapeLikePattern(o.Query)+"%"))
+	default:
+		conds = append(conds, sqlf.Sprintf("s.name ILIKE %s", "%"+escapeLikePattern(o.Query)+"%"))
+	}
+
+	pathOp := "~*"
+	if o.IsCaseSensitive {
+		pathOp = "~"
+	}
+	for _, p := range o.IncludePatterns {
+		conds = append(conds, sqlf.Sprintf("s.path "+pathOp+" %s", p))
+	}
+	if o.ExcludePattern != "" {
+		conds = append(conds, sqlf.Sprintf("NOT (s.path "+pathOp+" %s)", o.ExcludePattern))
+	}
+	if o.RepoIDs != nil {
+		if len(o.RepoIDs) == 0 {
+			conds = append(conds, sqlf.Sprintf("FALSE"))
+		} else {
+			ids := make([]*sqlf.Query, len(o.RepoIDs))
+			for i, id := range o.RepoIDs {
+				ids[i] = sqlf.Sprintf("%d", id)
+			}
+			conds = append(conds, sqlf.Sprintf("s.repo_id IN (%s)", sqlf.Join(ids, ",")))
+		}
+	}
+	return conds
+}
+
+// globalSymbolsSearchMaxBatches is the maximum number of batches of opt.Limit
+// symbols that Search reads to find opt.Limit symbols in repositories that the
+// actor can see. It bounds the cost of searches by users who can see few of
+// the indexed repositories.
+const globalSymbolsSearchMaxBatches = 10
+
+// Search searches the global symbol index. Symbols named exactly
+// opt.ExactName rank first, then exported symbols, then symbols with shorter
+// names.
+//
+// 🚨 SECURITY: Only symbols in repositories that the actor in ctx can see are
+// returned.
+func (*globalSymbols) Search(ctx context.Context, opt GlobalSymbolsSearchOptions) ([]*GlobalSymbolMatch, error) {
+	if Mocks.GlobalSymbols.Search != nil {
+		return Mocks.GlobalSymbols.Search(opt)
+	}
+
+	// Repository permissions are enforced after the query (see authzFilter),
+	// so the symbols are read in batches until opt.Limit of them are in
+	// repositories that the actor can see.
+	var (
+		results []*GlobalSymbolMatch
+		repos   = make(map[api.RepoID]*types.Repo) // nil for repositories the actor can't see
+	)
+	for batch := 0; batch < globalSymbolsSearchMaxBatches && len(results) < opt.Limit; batch++ {
+		matches, err := searchGlobalSymbols(ctx, opt, batch*opt.Limit)
+		if err != nil {
+			return nil, err
+		}
+
+		var ids []*sqlf.Query
+		for _, m := range matches {
+			if _, ok := repos[m.RepoID]; !ok {
+				repos[m.RepoID] = nil
+				ids = append(ids, sqlf.Sprintf("%d", m.RepoID))
+			}
+		}
+		if len(ids) > 0 {
+			visible, err := Repos.getBySQL(ctx, sqlf.Sprintf("WHERE id IN (%s)", sqlf.Join(ids, ",")))
+			if err != nil {
+				return nil, err
+			}
+			for _, repo := range visible {
+				repos[repo.ID] = repo
+			}
+		}
+
+		for _, m := range matches {
+			if m.Repo = repos[m.RepoID]; m.Repo != nil && len(results) < opt.Limit {
+				results = append(results, m)
+			}
+		}
+		if len(matches) < opt.Limit {
+			break
+		}
+	}
+	return results, nil
+}
+
+// searchGlobalSymbols returns the opt.Limit symbols matching the search
+// options after the first offset symbols, without their repositories.
+func searchGlobalSymbols(ctx context.Context, opt GlobalSymbolsSearchOptions, offset int) ([]*GlobalSymbolMatch, error) {
+	q := sqlf.Sprintf(`
+SELECT s.repo_id, s.name, s.kind, s.language, s.path, s.line, s.character, s.parent, s.signature, s.exported, r.commit
+FROM global_symbols s
+JOIN global_symbol_repos r ON r.repo_id=s.repo_id
+JOIN repo ON repo.id=s.repo_id
+WHERE (%s)
+ORDER BY lower(s.name)=lower(%s) DESC, s.exported DESC, length(s.name) ASC, repo.name ASC, s.path ASC, s.line ASC
+LIMIT %s OFFSET %s`,
+		sqlf.Join(opt.sqlConditions(), ") AND ("),
+		opt.ExactName,
+		opt.Limit,
+		offset,
+	)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []*GlobalSymbolMatch
+	for rows.Next() {
+		var m GlobalSymbolMatch
+		if err := rows.Scan(&m.RepoID, &m.Name, &m.Kind, &m.Language, &m.Path, &m.Line, &m.Character, &m.Parent, &m.Signature, &m.Exported, &m.Commit); err != nil {
+			return nil, err
+		}
+		matches = append(matches, &m)
+	}
+	return matches, rows.Err()
+}
+
+// IsEmpty reports whether the symbols of no repository are indexed.
+func (*globalSymbols) IsEmpty(ctx context.Context) (bool, error) {
+	if Mocks.GlobalSymbols.IsEmpty != nil {
+		return Mocks.GlobalSymbols.IsEmpty()
+	}
+
+	var exists bool
+	err := dbconn.Global.QueryRowContext(ctx, "SELECT EXISTS (SELECT 1 FROM global_symbol_repos)").Scan(&exists)
+	return !exists, err
+}
+
+// MockGlobalSymbols mocks the global symbols store.
+type MockGlobalSymbols struct {
+	IndexedCommit func(repoID api.RepoID) (api.CommitID, error)
+	Replace       func(repoID api.RepoID, commit api.CommitID, symbols []*GlobalSymbol) error
+	Search        func(opt GlobalSymbolsSearchOptions) ([]*GlobalSymbolMatch, error)
+	IsEmpty       func() (bool, error)
+}
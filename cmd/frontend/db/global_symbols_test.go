@@ -0,0 +1,142 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestGlobalSymbols(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	var repos []api.RepoID
+	for _, name := range []api.RepoName{"a", "b"} {
+		if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: name, Enabled: true}); err != nil {
+			t.Fatal(err)
+		}
+		repo, err := Repos.GetByName(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		repos = append(repos, repo.ID)
+	}
+
+	if empty, err := GlobalSymbols.IsEmpty(ctx); err != nil || !empty {
+		t.Fatalf("got IsEmpty %v (error %v), want true", empty, err)
+	}
+	if commit, err := GlobalSymbols.IndexedCommit(ctx, repos[0]); err != nil || commit != "" {
+		t.Fatalf("got indexed commit %q (error %v), want none", commit, err)
+	}
+
+	// The symbols of the first commit are replaced.
+	if err := GlobalSymbols.Replace(ctx, repos[0], "c0", []*GlobalSymbol{{Name: "OldServer", Path: "old.go"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := GlobalSymbols.Replace(ctx, repos[0], "c1", []*GlobalSymbol{
+		{Name: "NewServerWithOptions", Path: "server.go", Line: 3, Exported: true},
+		{Name: "newServer", Path: "server.go", Line: 2},
+		{Name: "NewServer", Path: "server.go", Line: 1, Exported: true},
+		{Name: "NewServer", Path: "server_test.go", Line: 1, Exported: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := GlobalSymbols.Replace(ctx, repos[1], "c2", []*GlobalSymbol{{Name: "newserver", Path: "server.py", Line: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if commit, err := GlobalSymbols.IndexedCommit(ctx, repos[0]); err != nil || commit != "c1" {
+		t.Fatalf("got indexed commit %q (error %v), want c1", commit, err)
+	}
+	if empty, err := GlobalSymbols.IsEmpty(ctx); err != nil || empty {
+		t.Fatalf("got IsEmpty %v (error %v), want false", empty, err)
+	}
+
+	search := func(opt GlobalSymbolsSearchOptions) []string {
+		t.Helper()
+		if opt.Limit == 0 {
+			opt.Limit = 10
+		}
+		matches, err := GlobalSymbols.Search(ctx, opt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, m := range matches {
+			got = append(got, string(m.Commit)+":"+m.Path+":"+m.Name)
+		}
+		return got
+	}
+
+	tests := map[string]struct {
+		opt  GlobalSymbolsSearchOptions
+		want []string
+	}{
+		"exact and exported first": {
+			opt:  GlobalSymbolsSearchOptions{Query: "newserver", ExactName: "newserver"},
+			want: []string{"c1:server.go:NewServer", "c1:server_test.go:NewServer", "c1:server.go:newServer", "c2:server.py:newserver", "c1:server.go:NewServerWithOptions"},
+		},
+		"case sensitive": {
+			opt:  GlobalSymbolsSearchOptions{Query: "newServer", IsCaseSensitive: true},
+			want: []string{"c1:server.go:newServer"},
+		},
+		"regexp": {
+			opt:  GlobalSymbolsSearchOptions{Query: "^NewServer$", IsRegExp: true, IsCaseSensitive: true},
+			want: []string{"c1:server.go:NewServer", "c1:server_test.go:NewServer"},
+		},
+		"like characters are literal": {
+			opt:  GlobalSymbolsSearchOptions{Query: "new_erver"},
+			want: nil,
+		},
+		"file patterns": {
+			opt:  GlobalSymbolsSearchOptions{Query: "NewServer", ExactName: "NewServer", IncludePatterns: []string{`\.go$`}, ExcludePattern: `_test\.go$`},
+			want: []string{"c1:server.go:NewServer", "c1:server.go:newServer", "c1:server.go:NewServerWithOptions"},
+		},
+		"limit": {
+			opt:  GlobalSymbolsSearchOptions{Query: "server", ExactName: "NewServer", Limit: 1},
+			want: []string{"c1:server.go:NewServer"},
+		},
+		"replaced symbols are gone": {
+			opt:  GlobalSymbolsSearchOptions{Query: "OldServer"},
+			want: nil,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := search(test.opt); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	// Symbols of repositories that the actor can't see are not found, and don't
+	// count against the limit.
+	mockAuthzFilter = func(ctx context.Context, repos []*types.Repo, p authz.Perm) ([]*types.Repo, error) {
+		var visible []*types.Repo
+		for _, repo := range repos {
+			if repo.Name != "a" {
+				visible = append(visible, repo)
+			}
+		}
+		return visible, nil
+	}
+	defer func() { mockAuthzFilter = nil }()
+	if got, want := search(GlobalSymbolsSearchOptions{Query: "server", ExactName: "NewServer", Limit: 1}), []string{"c2:server.py:newserver"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q with an invisible repository, want %q", got, want)
+	}
+	mockAuthzFilter = nil
+
+	// Symbols of disabled repositories are not found.
+	if err := Repos.SetEnabled(ctx, repos[1], false); err != nil {
+		t.Fatal(err)
+	}
+	if got := search(GlobalSymbolsSearchOptions{Query: "newserver", IsCaseSensitive: true}); got != nil {
+		t.Errorf("got %q for a disabled repository, want none", got)
+	}
+}
@@ -0,0 +1,183 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbutil"
+)
+
+// A GoDependencyIndex is the result of parsing the Go code of a repository at
+// its default branch, from which its dependencies on other repositories are
+// resolved (see package godeps).
+type GoDependencyIndex struct {
+	RepoID      api.RepoID
+	Commit      api.CommitID
+	ModulePaths []string
+	ImportPaths []string
+}
+
+type goDependencies struct{}
+
+// IndexedCommit returns the commit at which the Go code of the repository was
+// indexed, or "" if it was never indexed.
+func (*goDependencies) IndexedCommit(ctx context.Context, repoID api.RepoID) (api.CommitID, error) {
+	if Mocks.GoDependencies.IndexedCommit != nil {
+		return Mocks.GoDependencies.IndexedCommit(repoID)
+	}
+
+	var commit api.CommitID
+	err := dbconn.Global.QueryRowContext(ctx, "SELECT commit FROM go_dependency_indexes WHERE repo_id=$1", repoID).Scan(&commit)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return commit, err
+}
+
+// UpdateIndex creates or replaces the index of a repository.
+func (*goDependencies) UpdateIndex(ctx context.Context, index *GoDependencyIndex) error {
+	if Mocks.GoDependencies.UpdateIndex != nil {
+		return Mocks.GoDependencies.UpdateIndex(index)
+	}
+
+	// pq.Array stores nil slices as NULL.
+	modulePaths, importPaths := index.ModulePaths, index.ImportPaths
+	if modulePaths == nil {
+		modulePaths = []string{}
+	}
+	if importPaths == nil {
+		importPaths = []string{}
+	}
+	_, err := dbconn.Global.ExecContext(ctx,
+		`INSERT INTO go_dependency_indexes(repo_id, commit, module_paths, import_paths) VALUES($1, $2, $3, $4)
+		ON CONFLICT (repo_id) DO UPDATE SET commit=$2, module_paths=$3, import_paths=$4, updated_at=now()`,
+		index.RepoID, index.Commit, pq.Array(modulePaths), pq.Array(importPaths),
+	)
+	return err
+}
+
+// ListIndexes returns the indexes of all repositories.
+func (*goDependencies) ListIndexes(ctx context.Context) ([]*GoDependencyIndex, error) {
+	if Mocks.GoDependencies.ListIndexes != nil {
+		return Mocks.GoDependencies.ListIndexes()
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT repo_id, commit, module_paths, import_paths FROM go_dependency_indexes ORDER BY repo_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var indexes []*GoDependencyIndex
+	for rows.Next() {
+		var index GoDependencyIndex
+		if err := rows.Scan(&index.RepoID, &index.Commit, pq.Array(&index.ModulePaths), pq.Array(&index.ImportPaths)); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, &index)
+	}
+	return indexes, rows.Err()
+}
+
+// ListAll returns the IDs of the dependencies of all repositories that have
+// any, keyed by repository ID.
+func (*goDependencies) ListAll(ctx context.Context) (map[api.RepoID][]api.RepoID, error) {
+	if Mocks.GoDependencies.ListAll != nil {
+		return Mocks.GoDependencies.ListAll()
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT repo_id, dependency_repo_id FROM go_dependencies ORDER BY repo_id, dependency_repo_id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	dependencies := map[api.RepoID][]api.RepoID{}
+	for rows.Next() {
+		var repoID, dependencyRepoID api.RepoID
+		if err := rows.Scan(&repoID, &dependencyRepoID); err != nil {
+			return nil, err
+		}
+		dependencies[repoID] = append(dependencies[repoID], dependencyRepoID)
+	}
+	return dependencies, rows.Err()
+}
+
+// Replace replaces the dependencies of the repository.
+func (*goDependencies) Replace(ctx context.Context, repoID api.RepoID, dependencyRepoIDs []api.RepoID) error {
+	if Mocks.GoDependencies.Replace != nil {
+		return Mocks.GoDependencies.Replace(repoID, dependencyRepoIDs)
+	}
+
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM go_dependencies WHERE repo_id=$1", repoID); err != nil {
+			return err
+		}
+		if len(dependencyRepoIDs) == 0 {
+			return nil
+		}
+		values := make([]*sqlf.Query, len(dependencyRepoIDs))
+		for i, id := range dependencyRepoIDs {
+			values[i] = sqlf.Sprintf("(%s, %s)", repoID, id)
+		}
+		q := sqlf.Sprintf("INSERT INTO go_dependencies(repo_id, dependency_repo_id) VALUES %s ON CONFLICT DO NOTHING", sqlf.Join(values, ", "))
+		_, err := tx.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+		return err
+	})
+}
+
+// ListDependents returns the enabled repositories that depend on the
+// repository, sorted by name.
+func (*goDependencies) ListDependents(ctx context.Context, repoID api.RepoID, opt *LimitOffset) ([]*types.Repo, error) {
+	if Mocks.GoDependencies.ListDependents != nil {
+		return Mocks.GoDependencies.ListDependents(repoID, opt)
+	}
+	return Repos.getBySQL(ctx, sqlf.Sprintf("WHERE enabled AND id IN (SELECT repo_id FROM go_dependencies WHERE dependency_repo_id=%s) ORDER BY name %s", repoID, opt.SQL()))
+}
+
+// ListDependencies returns the enabled repositories that the repository
+// depends on, sorted by name.
+func (*goDependencies) ListDependencies(ctx context.Context, repoID api.RepoID, opt *LimitOffset) ([]*types.Repo, error) {
+	if Mocks.GoDependencies.ListDependencies != nil {
+		return Mocks.GoDependencies.ListDependencies(repoID, opt)
+	}
+	return Repos.getBySQL(ctx, sqlf.Sprintf("WHERE enabled AND id IN (SELECT dependency_repo_id FROM go_dependencies WHERE repo_id=%s) ORDER BY name %s", repoID, opt.SQL()))
+}
+
+// CountDependents returns the number of enabled repositories that depend on
+// the repository. Like ListDependents, it counts only the repositories that
+// the user may read.
+func (*goDependencies) CountDependents(ctx context.Context, repoID api.RepoID) (int, error) {
+	if Mocks.GoDependencies.CountDependents != nil {
+		return Mocks.GoDependencies.CountDependents(repoID)
+	}
+	return Repos.countBySQL(ctx, sqlf.Sprintf("WHERE enabled AND id IN (SELECT repo_id FROM go_dependencies WHERE dependency_repo_id=%s)", repoID))
+}
+
+// CountDependencies returns the number of enabled repositories that the
+// repository depends on. Like ListDependencies, it counts only the
+// repositories that the user may read.
+func (*goDependencies) CountDependencies(ctx context.Context, repoID api.RepoID) (int, error) {
+	if Mocks.GoDependencies.CountDependencies != nil {
+		return Mocks.GoDependencies.CountDependencies(repoID)
+	}
+	return Repos.countBySQL(ctx, sqlf.Sprintf("WHERE enabled AND id IN (SELECT dependency_repo_id FROM go_dependencies WHERE repo_id=%s)", repoID))
+}
+
+// MockGoDependencies mocks the Go dependencies store.
+type MockGoDependencies struct {
+	IndexedCommit     func(repoID api.RepoID) (api.CommitID, error)
+	UpdateIndex       func(index *GoDependencyIndex) error
+	ListIndexes       func() ([]*GoDependencyIndex, error)
+	ListAll           func() (map[api.RepoID][]api.RepoID, error)
+	Replace           func(repoID api.RepoID, dependencyRepoIDs []api.RepoID) error
+	ListDependents    func(repoID api.RepoID, opt *LimitOffset) ([]*types.Repo, error)
+	ListDependencies  func(repoID api.RepoID, opt *LimitOffset) ([]*types.Repo, error)
+	CountDependents   func(repoID api.RepoID) (int, error)
+	CountDependencies func(repoID api.RepoID) (int, error)
+}
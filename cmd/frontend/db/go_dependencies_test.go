@@ -0,0 +1,116 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestGoDependencies(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	var repos []api.RepoID
+	for _, name := range []api.RepoName{"a", "b", "c"} {
+		if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: name, Enabled: true}); err != nil {
+			t.Fatal(err)
+		}
+		repo, err := Repos.GetByName(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		repos = append(repos, repo.ID)
+	}
+
+	if commit, err := GoDependencies.IndexedCommit(ctx, repos[0]); err != nil || commit != "" {
+		t.Fatalf("got indexed commit %q (error %v), want none", commit, err)
+	}
+	for _, index := range []*GoDependencyIndex{
+		{RepoID: repos[0], Commit: "c0", ModulePaths: []string{}, ImportPaths: []string{"b"}},
+		{RepoID: repos[0], Commit: "c1", ModulePaths: []string{"example.com/a"}, ImportPaths: []string{"b", "c"}},
+	} {
+		if err := GoDependencies.UpdateIndex(ctx, index); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if commit, err := GoDependencies.IndexedCommit(ctx, repos[0]); err != nil || commit != "c1" {
+		t.Fatalf("got indexed commit %q (error %v), want c1", commit, err)
+	}
+	indexes, err := GoDependencies.ListIndexes(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*GoDependencyIndex{{RepoID: repos[0], Commit: "c1", ModulePaths: []string{"example.com/a"}, ImportPaths: []string{"b", "c"}}}; !reflect.DeepEqual(indexes, want) {
+		t.Errorf("got indexes %+v, want %+v", indexes, want)
+	}
+
+	// a depends on b and c, and b depends on c.
+	if err := GoDependencies.Replace(ctx, repos[0], []api.RepoID{repos[1]}); err != nil {
+		t.Fatal(err)
+	}
+	if err := GoDependencies.Replace(ctx, repos[0], []api.RepoID{repos[2], repos[1]}); err != nil {
+		t.Fatal(err)
+	}
+	if err := GoDependencies.Replace(ctx, repos[1], []api.RepoID{repos[2]}); err != nil {
+		t.Fatal(err)
+	}
+	all, err := GoDependencies.ListAll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[api.RepoID][]api.RepoID{repos[0]: {repos[1], repos[2]}, repos[1]: {repos[2]}}; !reflect.DeepEqual(all, want) {
+		t.Errorf("got dependencies %v, want %v", all, want)
+	}
+
+	dependents, err := GoDependencies.ListDependents(ctx, repos[2], &LimitOffset{Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dependents) != 1 || dependents[0].Name != "b" {
+		t.Errorf("got dependents %+v, want the second of a and b", dependents)
+	}
+	dependencies, err := GoDependencies.ListDependencies(ctx, repos[0], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dependencies) != 2 || dependencies[0].Name != "b" || dependencies[1].Name != "c" {
+		t.Errorf("got dependencies %+v, want b and c", dependencies)
+	}
+	if count, err := GoDependencies.CountDependents(ctx, repos[2]); err != nil || count != 2 {
+		t.Errorf("got %d dependents (error %v), want 2", count, err)
+	}
+	if count, err := GoDependencies.CountDependencies(ctx, repos[1]); err != nil || count != 1 {
+		t.Errorf("got %d dependencies (error %v), want 1", count, err)
+	}
+
+	// Repositories that the actor can't see are not counted.
+	mockAuthzFilter = func(ctx context.Context, repos []*types.Repo, p authz.Perm) ([]*types.Repo, error) {
+		var visible []*types.Repo
+		for _, repo := range repos {
+			if repo.Name != "b" {
+				visible = append(visible, repo)
+			}
+		}
+		return visible, nil
+	}
+	defer func() { mockAuthzFilter = nil }()
+	if count, err := GoDependencies.CountDependents(ctx, repos[2]); err != nil || count != 1 {
+		t.Errorf("got %d dependents with an invisible repository (error %v), want 1", count, err)
+	}
+	mockAuthzFilter = nil
+
+	// Dependents that are disabled are not listed.
+	if err := Repos.SetEnabled(ctx, repos[0], false); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := GoDependencies.CountDependents(ctx, repos[2]); err != nil || count != 1 {
+		t.Errorf("got %d dependents (error %v), want 1", count, err)
+	}
+}
@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbutil"
+)
+
+// An LSIFDump is an uploaded LSIF dump of a repository at a commit, whose
+// code intelligence data is stored by document, and the locations of whose
+// results are stored once per result (see package lsif).
+type LSIFDump struct {
+	ID         int64
+	RepoID     api.RepoID
+	Commit     api.CommitID
+	UploadedAt time.Time
+}
+
+type lsifDumps struct{}
+
+// lsifDumpInsertBatchSize is the number of documents or results inserted by
+// a single INSERT statement.
+const lsifDumpInsertBatchSize = 100
+
+// Create stores the documents and results of an LSIF dump of the repository
+// at commit. It replaces the previous dump of the repository at commit, if
+// any. The documents are the JSON-encoded data of each document, keyed by
+// path, and the results are the JSON-encoded locations of each result, keyed
+// by result ID.
+func (*lsifDumps) Create(ctx context.Context, repoID api.RepoID, commit api.CommitID, documents, results map[string][]byte) (*LSIFDump, error) {
+	if Mocks.LSIFDumps.Create != nil {
+		return Mocks.LSIFDumps.Create(repoID, commit, documents, results)
+	}
+
+	dump := &LSIFDump{RepoID: repoID, Commit: commit}
+	err := dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM lsif_dumps WHERE repo_id=$1 AND commit=$2", repoID, commit); err != nil {
+			return err
+		}
+		if err := tx.QueryRowContext(ctx,
+			"INSERT INTO lsif_dumps(repo_id, commit) VALUES($1, $2) RETURNING id, uploaded_at",
+			repoID, commit,
+		).Scan(&dump.ID, &dump.UploadedAt); err != nil {
+			return err
+		}
+
+		if err := insertLSIFDumpRows(ctx, tx, "lsif_documents(dump_id, path, data)", dump.ID, documents); err != nil {
+			return err
+		}
+		return insertLSIFDumpRows(ctx, tx, "lsif_results(dump_id, result_id, locations)", dump.ID, results)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return dump, nil
+}
+
+// insertLSIFDumpRows inserts the rows of a dump into a table whose columns
+// are the dump ID, a key and JSON data, in batches.
+func insertLSIFDumpRows(ctx context.Context, tx *sql.Tx, tableAndColumns string, dumpID int64, rows map[string][]byte) error {
+	values := make([]*sqlf.Query, 0, lsifDumpInsertBatchSize)
+	insert := func() error {
+		q := sqlf.Sprintf("INSERT INTO "+tableAndColumns+" VALUES %s", sqlf.Join(values, ", "))
+		_, err := tx.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+		values = values[:0]
+		return err
+	}
+	for key, data := range rows {
+		values = append(values, sqlf.Sprintf("(%s, %s, %s)", dumpID, key, string(data)))
+		if len(values) == lsifDumpInsertBatchSize {
+			if err := insert(); err != nil {
+				return err
+			}
+		}
+	}
+	if len(values) > 0 {
+		return insert()
+	}
+	return nil
+}
+
+// GetByCommits returns the dumps of the repository at any of the commits.
+func (*lsifDumps) GetByCommits(ctx context.Context, repoID api.RepoID, commits []api.CommitID) ([]*LSIFDump, error) {
+	if Mocks.LSIFDumps.GetByCommits != nil {
+		return Mocks.LSIFDumps.GetByCommits(repoID, commits)
+	}
+
+	commitStrings := make([]string, len(commits))
+	for i, commit := range commits {
+		commitStrings[i] = string(commit)
+	}
+	rows, err := dbconn.Global.QueryContext(ctx,
+		"SELECT id, repo_id, commit, uploaded_at FROM lsif_dumps WHERE repo_id=$1 AND commit = ANY($2)",
+		repoID, pq.Array(commitStrings),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dumps []*LSIFDump
+	for rows.Next() {
+		var d LSIFDump
+		if err := rows.Scan(&d.ID, &d.RepoID, &d.Commit, &d.UploadedAt); err != nil {
+			return nil, err
+		}
+		dumps = append(dumps, &d)
+	}
+	return dumps, rows.Err()
+}
+
+// GetDocument returns the JSON-encoded data of the document at path in the
+// dump, or nil if the dump has no such document.
+func (*lsifDumps) GetDocument(ctx context.Context, dumpID int64, path string) ([]byte, error) {
+	if Mocks.LSIFDumps.GetDocument != nil {
+		return Mocks.LSIFDumps.GetDocument(dumpID, path)
+	}
+
+	var data []byte
+	err := dbconn.Global.QueryRowContext(ctx, "SELECT data FROM lsif_documents WHERE dump_id=$1 AND path=$2", dumpID, path).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return data, err
+}
+
+// GetResult returns the JSON-encoded locations of the result in the dump, or
+// nil if the dump has no such result.
+func (*lsifDumps) GetResult(ctx context.Context, dumpID int64, resultID string) ([]byte, error) {
+	if Mocks.LSIFDumps.GetResult != nil {
+		return Mocks.LSIFDumps.GetResult(dumpID, resultID)
+	}
+
+	var locations []byte
+	err := dbconn.Global.QueryRowContext(ctx, "SELECT locations FROM lsif_results WHERE dump_id=$1 AND result_id=$2", dumpID, resultID).Scan(&locations)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return locations, err
+}
+
+// MockLSIFDumps mocks the LSIF dumps store.
+type MockLSIFDumps struct {
+	Create       func(repoID api.RepoID, commit api.CommitID, documents, results map[string][]byte) (*LSIFDump, error)
+	GetByCommits func(repoID api.RepoID, commits []api.CommitID) ([]*LSIFDump, error)
+	GetDocument  func(dumpID int64, path string) ([]byte, error)
+	GetResult    func(dumpID int64, resultID string) ([]byte, error)
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestLSIFDumps(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: "a", Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Repos.GetByName(ctx, "a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LSIFDumps.Create(ctx, repo.ID, "c1", map[string][]byte{"a.go": []byte(`{"ranges":[]}`), "b.go": []byte(`{"ranges":[]}`)}, map[string][]byte{"r1": []byte(`[]`)}); err != nil {
+		t.Fatal(err)
+	}
+	// A second upload at the same commit replaces the first.
+	dump, err := LSIFDumps.Create(ctx, repo.ID, "c1", map[string][]byte{"a.go": []byte(`{"ranges":[{"start":{"line":1,"character":2}}]}`)}, map[string][]byte{"r2": []byte(`[{"path":"a.go"}]`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dumps, err := LSIFDumps.GetByCommits(ctx, repo.ID, []api.CommitID{"c0", "c1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dumps) != 1 || dumps[0].ID != dump.ID || dumps[0].Commit != "c1" {
+		t.Fatalf("got dumps %+v, want only %+v", dumps, dump)
+	}
+	if dumps, err := LSIFDumps.GetByCommits(ctx, repo.ID, []api.CommitID{"c0"}); err != nil || len(dumps) != 0 {
+		t.Fatalf("got dumps %+v (error %v), want none", dumps, err)
+	}
+
+	data, err := LSIFDumps.GetDocument(ctx, dump.ID, "a.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"ranges": [{"start": {"line": 1, "character": 2}}]}`; string(data) != want {
+		t.Errorf("got document %s, want %s", data, want)
+	}
+	if data, err := LSIFDumps.GetDocument(ctx, dump.ID, "b.go"); err != nil || data != nil {
+		t.Errorf("got document %s (error %v), want none", data, err)
+	}
+
+	locations, err := LSIFDumps.GetResult(ctx, dump.ID, "r2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"path": "a.go"}]`; string(locations) != want {
+		t.Errorf("got result %s, want %s", locations, want)
+	}
+	if locations, err := LSIFDumps.GetResult(ctx, dump.ID, "r1"); err != nil || locations != nil {
+		t.Errorf("got result %s (error %v), want none", locations, err)
+	}
+}
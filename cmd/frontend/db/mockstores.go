@@ -24,4 +24,16 @@ type MockStores struct {
 	OrgInvitations MockOrgInvitations
 
 	ExternalServices MockExternalServices
+
+	GlobalSymbols MockGlobalSymbols
+
+	GoDependencies MockGoDependencies
+
+	LSIFDumps MockLSIFDumps
+
+	Packages MockPackages
+
+	SearchContexts MockSearchContexts
+	SearchHistory  MockSearchHistory
+	SearchInsights MockSearchInsights
 }
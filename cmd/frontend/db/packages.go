@@ -0,0 +1,244 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/pkgdeps"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbutil"
+)
+
+// A PackageIndex is the result of parsing the package manifests of a
+// repository at its default branch (see package pkgdeps).
+type PackageIndex struct {
+	RepoID       api.RepoID
+	Commit       api.CommitID
+	Packages     []pkgdeps.Package // the packages that the repository defines
+	Dependencies []pkgdeps.Package // the packages that the repository depends on
+}
+
+// A PackageRepo is a package and a repository, such as the repository that
+// defines the package or that contains it.
+type PackageRepo struct {
+	Package pkgdeps.Package
+	RepoID  api.RepoID
+}
+
+type packages struct{}
+
+// IndexedCommit returns the commit at which the package manifests of the
+// repository were indexed, or "" if they were never indexed.
+func (*packages) IndexedCommit(ctx context.Context, repoID api.RepoID) (api.CommitID, error) {
+	if Mocks.Packages.IndexedCommit != nil {
+		return Mocks.Packages.IndexedCommit(repoID)
+	}
+
+	var commit api.CommitID
+	err := dbconn.Global.QueryRowContext(ctx, "SELECT commit FROM package_manifest_indexes WHERE repo_id=$1", repoID).Scan(&commit)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return commit, err
+}
+
+// UpdateIndex creates or replaces the index of a repository.
+func (*packages) UpdateIndex(ctx context.Context, index *PackageIndex) error {
+	if Mocks.Packages.UpdateIndex != nil {
+		return Mocks.Packages.UpdateIndex(index)
+	}
+
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO package_manifest_indexes(repo_id, commit) VALUES($1, $2)
+			ON CONFLICT (repo_id) DO UPDATE SET commit=$2, updated_at=now()`,
+			index.RepoID, index.Commit,
+		); err != nil {
+			return err
+		}
+		for _, table := range []struct {
+			name     string
+			packages []pkgdeps.Package
+		}{
+			{"package_definitions", index.Packages},
+			{"package_dependencies", index.Dependencies},
+		} {
+			if _, err := tx.ExecContext(ctx, "DELETE FROM "+table.name+" WHERE repo_id=$1", index.RepoID); err != nil {
+				return err
+			}
+			rows := make([]*PackageRepo, len(table.packages))
+			for i, p := range table.packages {
+				rows[i] = &PackageRepo{Package: p, RepoID: index.RepoID}
+			}
+			if err := insertPackageRepos(ctx, tx, table.name, rows); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListDefinitions returns the packages that the indexed repositories define,
+// sorted by repository ID.
+func (*packages) ListDefinitions(ctx context.Context) ([]*PackageRepo, error) {
+	if Mocks.Packages.ListDefinitions != nil {
+		return Mocks.Packages.ListDefinitions()
+	}
+	return listPackageRepos(ctx, "SELECT manager, name, repo_id FROM package_definitions ORDER BY repo_id, manager, name")
+}
+
+// ListDependencies returns the packages that any indexed repository depends
+// on, sorted.
+func (*packages) ListDependencies(ctx context.Context) ([]pkgdeps.Package, error) {
+	if Mocks.Packages.ListDependencies != nil {
+		return Mocks.Packages.ListDependencies()
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT DISTINCT manager, name FROM package_dependencies ORDER BY manager, name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var packages []pkgdeps.Package
+	for rows.Next() {
+		var p pkgdeps.Package
+		if err := rows.Scan(&p.Manager, &p.Name); err != nil {
+			return nil, err
+		}
+		packages = append(packages, p)
+	}
+	return packages, rows.Err()
+}
+
+// ListRepos returns the repositories that contain packages, sorted by
+// package.
+func (*packages) ListRepos(ctx context.Context) ([]*PackageRepo, error) {
+	if Mocks.Packages.ListRepos != nil {
+		return Mocks.Packages.ListRepos()
+	}
+	return listPackageRepos(ctx, "SELECT manager, name, repo_id FROM package_repos ORDER BY manager, name")
+}
+
+// ReplaceRepos replaces the repositories that contain packages.
+func (*packages) ReplaceRepos(ctx context.Context, packageRepos []*PackageRepo) error {
+	if Mocks.Packages.ReplaceRepos != nil {
+		return Mocks.Packages.ReplaceRepos(packageRepos)
+	}
+
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM package_repos"); err != nil {
+			return err
+		}
+		return insertPackageRepos(ctx, tx, "package_repos", packageRepos)
+	})
+}
+
+// ListByRepo returns the packages that the repository contains, sorted.
+func (*packages) ListByRepo(ctx context.Context, repoID api.RepoID) ([]pkgdeps.Package, error) {
+	if Mocks.Packages.ListByRepo != nil {
+		return Mocks.Packages.ListByRepo(repoID)
+	}
+
+	packageRepos, err := listPackageRepos(ctx, "SELECT manager, name, repo_id FROM package_repos WHERE repo_id=$1 ORDER BY manager, name", repoID)
+	if err != nil {
+		return nil, err
+	}
+	packages := make([]pkgdeps.Package, len(packageRepos))
+	for i, pr := range packageRepos {
+		packages[i] = pr.Package
+	}
+	return packages, nil
+}
+
+// ListDependents returns the enabled repositories that depend on the package,
+// sorted by name.
+func (*packages) ListDependents(ctx context.Context, p pkgdeps.Package, opt *LimitOffset) ([]*types.Repo, error) {
+	if Mocks.Packages.ListDependents != nil {
+		return Mocks.Packages.ListDependents(p, opt)
+	}
+	return Repos.getBySQL(ctx, sqlf.Sprintf("WHERE enabled AND id IN (SELECT repo_id FROM package_dependencies WHERE manager=%s AND name=%s) ORDER BY name %s", p.Manager, p.Name, opt.SQL()))
+}
+
+// ListDependencyRepos returns the enabled repositories (other than the
+// repository itself) that contain the packages that the repository depends
+// on, sorted by name.
+func (*packages) ListDependencyRepos(ctx context.Context, repoID api.RepoID, opt *LimitOffset) ([]*types.Repo, error) {
+	if Mocks.Packages.ListDependencyRepos != nil {
+		return Mocks.Packages.ListDependencyRepos(repoID, opt)
+	}
+	return Repos.getBySQL(ctx, sqlf.Sprintf(`WHERE enabled AND id<>%s AND id IN (
+	SELECT r.repo_id FROM package_repos r
+	JOIN package_dependencies d ON d.manager=r.manager AND d.name=r.name
+	WHERE d.repo_id=%s
+) ORDER BY name %s`, repoID, repoID, opt.SQL()))
+}
+
+// CountDependents returns the number of enabled repositories that depend on
+// the package. Like ListDependents, it counts only the repositories that the
+// user may read.
+func (*packages) CountDependents(ctx context.Context, p pkgdeps.Package) (int, error) {
+	if Mocks.Packages.CountDependents != nil {
+		return Mocks.Packages.CountDependents(p)
+	}
+	return Repos.countBySQL(ctx, sqlf.Sprintf("WHERE enabled AND id IN (SELECT repo_id FROM package_dependencies WHERE manager=%s AND name=%s)", p.Manager, p.Name))
+}
+
+func listPackageRepos(ctx context.Context, query string, args ...interface{}) ([]*PackageRepo, error) {
+	rows, err := dbconn.Global.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var packageRepos []*PackageRepo
+	for rows.Next() {
+		var pr PackageRepo
+		if err := rows.Scan(&pr.Package.Manager, &pr.Package.Name, &pr.RepoID); err != nil {
+			return nil, err
+		}
+		packageRepos = append(packageRepos, &pr)
+	}
+	return packageRepos, rows.Err()
+}
+
+// insertPackageRepos inserts rows into a table with the columns manager, name
+// and repo_id, in batches that stay below the limit on the number of query
+// parameters.
+func insertPackageRepos(ctx context.Context, tx *sql.Tx, table string, packageRepos []*PackageRepo) error {
+	const batchSize = 10000
+	for len(packageRepos) > 0 {
+		batch := packageRepos
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		packageRepos = packageRepos[len(batch):]
+
+		values := make([]*sqlf.Query, len(batch))
+		for i, pr := range batch {
+			values[i] = sqlf.Sprintf("(%s, %s, %s)", pr.Package.Manager, pr.Package.Name, pr.RepoID)
+		}
+		q := sqlf.Sprintf("INSERT INTO "+table+"(manager, name, repo_id) VALUES %s ON CONFLICT DO NOTHING", sqlf.Join(values, ", "))
+		if _, err := tx.ExecContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MockPackages mocks the packages store.
+type MockPackages struct {
+	IndexedCommit       func(repoID api.RepoID) (api.CommitID, error)
+	UpdateIndex         func(index *PackageIndex) error
+	ListDefinitions     func() ([]*PackageRepo, error)
+	ListDependencies    func() ([]pkgdeps.Package, error)
+	ListRepos           func() ([]*PackageRepo, error)
+	ReplaceRepos        func(packageRepos []*PackageRepo) error
+	ListByRepo          func(repoID api.RepoID) ([]pkgdeps.Package, error)
+	ListDependents      func(p pkgdeps.Package, opt *LimitOffset) ([]*types.Repo, error)
+	ListDependencyRepos func(repoID api.RepoID, opt *LimitOffset) ([]*types.Repo, error)
+	CountDependents     func(p pkgdeps.Package) (int, error)
+}
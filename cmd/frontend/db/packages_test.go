@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/authz"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/pkgdeps"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+)
+
+func TestPackages(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	var repos []api.RepoID
+	for _, name := range []api.RepoName{"a", "b", "c"} {
+		if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: name, Enabled: true}); err != nil {
+			t.Fatal(err)
+		}
+		repo, err := Repos.GetByName(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		repos = append(repos, repo.ID)
+	}
+
+	leftPad := pkgdeps.Package{Manager: pkgdeps.NPM, Name: "left-pad"}
+	junit := pkgdeps.Package{Manager: pkgdeps.Maven, Name: "junit:junit"}
+	if commit, err := Packages.IndexedCommit(ctx, repos[0]); err != nil || commit != "" {
+		t.Fatalf("got indexed commit %q (error %v), want none", commit, err)
+	}
+	for _, index := range []*PackageIndex{
+		{RepoID: repos[0], Commit: "c0", Dependencies: []pkgdeps.Package{junit}},
+		{RepoID: repos[0], Commit: "c1", Dependencies: []pkgdeps.Package{leftPad}},
+		{RepoID: repos[1], Commit: "c2", Packages: []pkgdeps.Package{leftPad}},
+		{RepoID: repos[2], Commit: "c3", Dependencies: []pkgdeps.Package{leftPad, junit}},
+	} {
+		if err := Packages.UpdateIndex(ctx, index); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if commit, err := Packages.IndexedCommit(ctx, repos[0]); err != nil || commit != "c1" {
+		t.Fatalf("got indexed commit %q (error %v), want c1", commit, err)
+	}
+	definitions, err := Packages.ListDefinitions(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*PackageRepo{{Package: leftPad, RepoID: repos[1]}}; !reflect.DeepEqual(definitions, want) {
+		t.Errorf("got definitions %+v, want %+v", definitions, want)
+	}
+	dependencies, err := Packages.ListDependencies(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []pkgdeps.Package{junit, leftPad}; !reflect.DeepEqual(dependencies, want) {
+		t.Errorf("got dependencies %+v, want %+v", dependencies, want)
+	}
+
+	for _, packageRepos := range [][]*PackageRepo{
+		{{Package: junit, RepoID: repos[0]}},
+		{{Package: leftPad, RepoID: repos[1]}},
+	} {
+		if err := Packages.ReplaceRepos(ctx, packageRepos); err != nil {
+			t.Fatal(err)
+		}
+	}
+	packageRepos, err := Packages.ListRepos(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []*PackageRepo{{Package: leftPad, RepoID: repos[1]}}; !reflect.DeepEqual(packageRepos, want) {
+		t.Errorf("got package repositories %+v, want %+v", packageRepos, want)
+	}
+	if packages, err := Packages.ListByRepo(ctx, repos[1]); err != nil || !reflect.DeepEqual(packages, []pkgdeps.Package{leftPad}) {
+		t.Errorf("got packages %+v (error %v), want left-pad", packages, err)
+	}
+
+	for _, repoID := range []api.RepoID{repos[0], repos[2]} {
+		dependencyRepos, err := Packages.ListDependencyRepos(ctx, repoID, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dependencyRepos) != 1 || dependencyRepos[0].ID != repos[1] {
+			t.Errorf("got dependency repositories %+v of %d, want b", dependencyRepos, repoID)
+		}
+	}
+	if dependencyRepos, err := Packages.ListDependencyRepos(ctx, repos[1], nil); err != nil || len(dependencyRepos) != 0 {
+		t.Errorf("got dependency repositories %+v (error %v) of b, want none", dependencyRepos, err)
+	}
+
+	dependents, err := Packages.ListDependents(ctx, leftPad, &LimitOffset{Limit: 1, Offset: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dependents) != 1 || dependents[0].Name != "c" {
+		t.Errorf("got dependents %+v, want the second of a and c", dependents)
+	}
+	if count, err := Packages.CountDependents(ctx, leftPad); err != nil || count != 2 {
+		t.Errorf("got %d dependents (error %v), want 2", count, err)
+	}
+
+	// Repositories that the actor can't see are not counted.
+	mockAuthzFilter = func(ctx context.Context, repos []*types.Repo, p authz.Perm) ([]*types.Repo, error) {
+		var visible []*types.Repo
+		for _, repo := range repos {
+			if repo.Name != "c" {
+				visible = append(visible, repo)
+			}
+		}
+		return visible, nil
+	}
+	defer func() { mockAuthzFilter = nil }()
+	if count, err := Packages.CountDependents(ctx, leftPad); err != nil || count != 1 {
+		t.Errorf("got %d dependents with an invisible repository (error %v), want 1", count, err)
+	}
+	mockAuthzFilter = nil
+
+	// Dependents that are disabled are not listed.
+	if err := Repos.SetEnabled(ctx, repos[0], false); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := Packages.CountDependents(ctx, leftPad); err != nil || count != 1 {
+		t.Errorf("got %d dependents (error %v), want 1", count, err)
+	}
+}
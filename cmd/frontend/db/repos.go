@@ -137,6 +137,48 @@ func (s *repos) getBySQL(ctx context.Context, querySuffix *sqlf.Query) ([]*types
 	return authzFilter(ctx, repos, authz.Read)
 }
 
+// countBySQL returns the number of repositories matching querySuffix that the user may read. It
+// counts in the database when authzFilter would not exclude any repositories, and otherwise reads
+// only the columns that authzFilter needs.
+func (s *repos) countBySQL(ctx context.Context, querySuffix *sqlf.Query) (int, error) {
+	if authzFilterIsNoop(ctx) {
+		q := sqlf.Sprintf("SELECT COUNT(*) FROM repo %s", querySuffix)
+		var count int
+		if err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count); err != nil {
+			return 0, err
+		}
+		return count, nil
+	}
+
+	q := sqlf.Sprintf("SELECT id, name, external_id, external_service_type, external_service_id FROM repo %s", querySuffix)
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var repos []*types.Repo
+	for rows.Next() {
+		var repo types.Repo
+		var spec dbExternalRepoSpec
+		if err := rows.Scan(&repo.ID, &repo.Name, &spec.id, &spec.serviceType, &spec.serviceID); err != nil {
+			return 0, err
+		}
+		repo.ExternalRepo = spec.toAPISpec()
+		repos = append(repos, &repo)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+
+	// 🚨 SECURITY: This enforces repository permissions
+	repos, err = authzFilter(ctx, repos, authz.Read)
+	if err != nil {
+		return 0, err
+	}
+	return len(repos), nil
+}
+
 // ReposListOptions specifies the options for listing repositories.
 //
 // Query and IncludePatterns/ExcludePatterns may not be used together.
@@ -54,6 +54,21 @@ func authzFilter(ctx context.Context, repos []*types.Repo, p authz.Perm) ([]*typ
 	return filteredRepos, nil
 }
 
+// authzFilterIsNoop reports whether authzFilter would return every repository passed to it for
+// the current actor, so that callers that only need a count can skip loading the repositories.
+//
+// 🚨 SECURITY: this must never return true when authzFilter could exclude a repository.
+func authzFilterIsNoop(ctx context.Context) bool {
+	if mockAuthzFilter != nil {
+		return false
+	}
+	if isInternalActor(ctx) {
+		return true
+	}
+	authzAllowByDefault, authzProviders := authz.GetProviders()
+	return authzAllowByDefault && len(authzProviders) == 0
+}
+
 // isInternalActor returns true if the actor represents an internal agent (i.e., non-user-bound
 // request that originates from within Sourcegraph itself).
 //
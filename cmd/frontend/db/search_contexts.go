@@ -0,0 +1,379 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/lib/pq"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbutil"
+)
+
+// A SearchContext is a named set of repositories (and revisions) that searches
+// can be restricted to. It is owned by a user or an organization.
+type SearchContext struct {
+	ID              int32
+	Name            string
+	Description     string
+	NamespaceUserID int32 // the user who owns the search context, or 0
+	NamespaceOrgID  int32 // the org that owns the search context, or 0
+	Public          bool  // whether the search context is visible to all users (or only to its owner)
+
+	// RepositoryQueries are search queries (consisting of repo: and -repo:
+	// filters) whose matching repositories are included in the search context,
+	// in addition to its SearchContextRepositories.
+	RepositoryQueries []string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// SearchContextRepository is a repository in a search context, with the
+// revisions of it to search.
+type SearchContextRepository struct {
+	RepoID    api.RepoID
+	RepoName  api.RepoName
+	Revisions []string // "" is the default branch
+}
+
+type searchContexts struct{}
+
+// SearchContextNotFoundError occurs when a search context is not found.
+type SearchContextNotFoundError struct {
+	args []interface{}
+}
+
+// NotFound implements errcode.NotFounder.
+func (err SearchContextNotFoundError) NotFound() bool { return true }
+
+func (err SearchContextNotFoundError) Error() string {
+	return fmt.Sprintf("search context not found: %v", err.args)
+}
+
+// Create creates a search context with the given repositories.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to create
+// search contexts in the search context's namespace.
+func (s *searchContexts) Create(ctx context.Context, sc *SearchContext, repos []*SearchContextRepository) (*SearchContext, error) {
+	created := *sc
+	err := dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(
+			ctx,
+			"INSERT INTO search_contexts(name, description, namespace_user_id, namespace_org_id, public, repository_queries) VALUES($1, $2, $3, $4, $5, $6) RETURNING id, created_at, updated_at",
+			sc.Name, sc.Description, nullInt32Column(sc.NamespaceUserID), nullInt32Column(sc.NamespaceOrgID), sc.Public, pq.Array(sc.RepositoryQueries),
+		).Scan(&created.ID, &created.CreatedAt, &created.UpdatedAt); err != nil {
+			return searchContextConstraintError(err)
+		}
+		return s.setRepositories(ctx, tx, created.ID, repos)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// Update updates the name, description, visibility and repository queries of
+// the search context, and replaces its repositories.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to update
+// the search context.
+func (s *searchContexts) Update(ctx context.Context, sc *SearchContext, repos []*SearchContextRepository) (*SearchContext, error) {
+	updated := *sc
+	err := dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if err := tx.QueryRowContext(
+			ctx,
+			"UPDATE search_contexts SET name=$1, description=$2, public=$3, repository_queries=$4, updated_at=now() WHERE id=$5 AND deleted_at IS NULL RETURNING updated_at",
+			sc.Name, sc.Description, sc.Public, pq.Array(sc.RepositoryQueries), sc.ID,
+		).Scan(&updated.UpdatedAt); err != nil {
+			if err == sql.ErrNoRows {
+				return SearchContextNotFoundError{[]interface{}{sc.ID}}
+			}
+			return searchContextConstraintError(err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM search_context_repos WHERE search_context_id=$1", sc.ID); err != nil {
+			return err
+		}
+		return s.setRepositories(ctx, tx, sc.ID, repos)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (*searchContexts) setRepositories(ctx context.Context, tx *sql.Tx, id int32, repos []*SearchContextRepository) error {
+	for _, repo := range repos {
+		revs := repo.Revisions
+		if len(revs) == 0 {
+			revs = []string{""}
+		}
+		for _, rev := range revs {
+			if _, err := tx.ExecContext(
+				ctx,
+				"INSERT INTO search_context_repos(search_context_id, repo_id, revision) VALUES($1, $2, $3) ON CONFLICT DO NOTHING",
+				id, repo.RepoID, rev,
+			); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// searchContextConstraintError returns a more helpful error for violations of
+// the search_contexts table constraints.
+func searchContextConstraintError(err error) error {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Constraint {
+		case "search_contexts_namespace_name":
+			return errors.New("a search context with this name already exists")
+		case "search_contexts_name_length", "search_contexts_name_valid_chars":
+			return errors.New("search context name must be 1-128 characters, consisting of letters, numbers, and (except at the start or end) any of _.-")
+		}
+	}
+	return err
+}
+
+// Delete deletes a search context. It is no longer the default search context
+// of any users.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to delete
+// the search context.
+func (*searchContexts) Delete(ctx context.Context, id int32) error {
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, "UPDATE search_contexts SET deleted_at=now() WHERE id=$1 AND deleted_at IS NULL", id)
+		if err != nil {
+			return err
+		}
+		nrows, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if nrows == 0 {
+			return SearchContextNotFoundError{[]interface{}{id}}
+		}
+		_, err = tx.ExecContext(ctx, "DELETE FROM search_context_default WHERE search_context_id=$1", id)
+		return err
+	})
+}
+
+// GetByID retrieves the search context with the given ID.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to view
+// this search context.
+func (s *searchContexts) GetByID(ctx context.Context, id int32) (*SearchContext, error) {
+	if Mocks.SearchContexts.GetByID != nil {
+		return Mocks.SearchContexts.GetByID(id)
+	}
+
+	results, err := s.list(ctx, []*sqlf.Query{sqlf.Sprintf("id=%d", id)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, SearchContextNotFoundError{[]interface{}{id}}
+	}
+	return results[0], nil
+}
+
+// GetByName retrieves the search context with the given name in the namespace
+// of the user or org (exactly one of namespaceUserID and namespaceOrgID must be
+// nonzero).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to view
+// this search context.
+func (s *searchContexts) GetByName(ctx context.Context, namespaceUserID, namespaceOrgID int32, name string) (*SearchContext, error) {
+	if Mocks.SearchContexts.GetByName != nil {
+		return Mocks.SearchContexts.GetByName(namespaceUserID, namespaceOrgID, name)
+	}
+
+	results, err := s.list(ctx, []*sqlf.Query{
+		sqlf.Sprintf("COALESCE(namespace_user_id, 0)=%d AND COALESCE(namespace_org_id, 0)=%d AND name=%s", namespaceUserID, namespaceOrgID, name),
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, SearchContextNotFoundError{[]interface{}{name}}
+	}
+	return results[0], nil
+}
+
+// SearchContextsListOptions contains options for listing search contexts.
+type SearchContextsListOptions struct {
+	NamespaceUserID int32 // only list search contexts owned by this user
+	NamespaceOrgID  int32 // only list search contexts owned by this org
+
+	// ViewerUserID is the user whose private search contexts (and those of the
+	// orgs they are a member of) are listed in addition to all public search
+	// contexts. If 0, only public search contexts are listed.
+	ViewerUserID int32
+	// IncludeAllPrivate lists all private search contexts, regardless of
+	// ViewerUserID.
+	IncludeAllPrivate bool
+
+	*LimitOffset
+}
+
+func (o SearchContextsListOptions) sqlConditions() []*sqlf.Query {
+	conds := []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	if o.NamespaceUserID != 0 {
+		conds = append(conds, sqlf.Sprintf("namespace_user_id=%d", o.NamespaceUserID))
+	}
+	if o.NamespaceOrgID != 0 {
+		conds = append(conds, sqlf.Sprintf("namespace_org_id=%d", o.NamespaceOrgID))
+	}
+	if !o.IncludeAllPrivate {
+		conds = append(conds, sqlf.Sprintf(`public
+			OR namespace_user_id=%d
+			OR namespace_org_id IN (SELECT org_id FROM org_members WHERE user_id=%d)`,
+			o.ViewerUserID, o.ViewerUserID,
+		))
+	}
+	return conds
+}
+
+// List lists all search contexts that satisfy the options.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to list with
+// the specified options.
+func (s *searchContexts) List(ctx context.Context, opt SearchContextsListOptions) ([]*SearchContext, error) {
+	if Mocks.SearchContexts.List != nil {
+		return Mocks.SearchContexts.List(opt)
+	}
+	return s.list(ctx, opt.sqlConditions(), opt.LimitOffset)
+}
+
+func (*searchContexts) list(ctx context.Context, conds []*sqlf.Query, limitOffset *LimitOffset) ([]*SearchContext, error) {
+	q := sqlf.Sprintf(`
+SELECT id, name, description, COALESCE(namespace_user_id, 0), COALESCE(namespace_org_id, 0), public, repository_queries, created_at, updated_at FROM search_contexts
+WHERE (%s) AND deleted_at IS NULL
+ORDER BY id ASC
+%s`,
+		sqlf.Join(conds, ") AND ("),
+		limitOffset.SQL(),
+	)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*SearchContext
+	for rows.Next() {
+		var sc SearchContext
+		if err := rows.Scan(&sc.ID, &sc.Name, &sc.Description, &sc.NamespaceUserID, &sc.NamespaceOrgID, &sc.Public, pq.Array(&sc.RepositoryQueries), &sc.CreatedAt, &sc.UpdatedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, &sc)
+	}
+	return results, rows.Err()
+}
+
+// Count counts all search contexts that satisfy the options (ignoring limit
+// and offset).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to count
+// with the specified options.
+func (*searchContexts) Count(ctx context.Context, opt SearchContextsListOptions) (int, error) {
+	q := sqlf.Sprintf("SELECT COUNT(*) FROM search_contexts WHERE (%s) AND deleted_at IS NULL", sqlf.Join(opt.sqlConditions(), ") AND ("))
+	var count int
+	if err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListRepositories lists the repositories (and their revisions) of the search
+// context, ordered by repository name. Deleted repositories are omitted.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to view
+// this search context.
+func (*searchContexts) ListRepositories(ctx context.Context, id int32) ([]*SearchContextRepository, error) {
+	if Mocks.SearchContexts.ListRepositories != nil {
+		return Mocks.SearchContexts.ListRepositories(id)
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, `
+SELECT repo.id, repo.name, array_agg(scr.revision ORDER BY scr.revision) FROM search_context_repos scr
+JOIN repo ON repo.id=scr.repo_id
+WHERE scr.search_context_id=$1 AND repo.deleted_at IS NULL
+GROUP BY repo.id, repo.name
+ORDER BY repo.name ASC`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*SearchContextRepository
+	for rows.Next() {
+		var r SearchContextRepository
+		if err := rows.Scan(&r.RepoID, &r.RepoName, pq.Array(&r.Revisions)); err != nil {
+			return nil, err
+		}
+		results = append(results, &r)
+	}
+	return results, rows.Err()
+}
+
+// GetDefault returns the user's default search context, or nil if the user has
+// none.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to view the
+// user's default search context, and to view the search context itself.
+func (s *searchContexts) GetDefault(ctx context.Context, userID int32) (*SearchContext, error) {
+	if Mocks.SearchContexts.GetDefault != nil {
+		return Mocks.SearchContexts.GetDefault(userID)
+	}
+
+	results, err := s.list(ctx, []*sqlf.Query{
+		sqlf.Sprintf("id=(SELECT search_context_id FROM search_context_default WHERE user_id=%d)", userID),
+	}, nil)
+	if err != nil || len(results) == 0 {
+		return nil, err
+	}
+	return results[0], nil
+}
+
+// SetDefault sets the user's default search context, or unsets it if id is
+// nil.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to change
+// the user's default search context, and that the user is permitted to view
+// the search context.
+func (*searchContexts) SetDefault(ctx context.Context, userID int32, id *int32) error {
+	if id == nil {
+		_, err := dbconn.Global.ExecContext(ctx, "DELETE FROM search_context_default WHERE user_id=$1", userID)
+		return err
+	}
+	_, err := dbconn.Global.ExecContext(
+		ctx,
+		"INSERT INTO search_context_default(user_id, search_context_id) VALUES($1, $2) ON CONFLICT (user_id) DO UPDATE SET search_context_id=$2",
+		userID, *id,
+	)
+	return err
+}
+
+// nullInt32Column returns nil if n is 0, and n otherwise (for use as a
+// nullable foreign key column value).
+func nullInt32Column(n int32) *int32 {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
+
+// MockSearchContexts mocks the search contexts store.
+type MockSearchContexts struct {
+	GetByID          func(id int32) (*SearchContext, error)
+	GetByName        func(namespaceUserID, namespaceOrgID int32, name string) (*SearchContext, error)
+	List             func(opt SearchContextsListOptions) ([]*SearchContext, error)
+	ListRepositories func(id int32) ([]*SearchContextRepository, error)
+	GetDefault       func(userID int32) (*SearchContext, error)
+}
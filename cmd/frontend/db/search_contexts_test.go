@@ -0,0 +1,151 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+)
+
+func TestSearchContexts(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	alice, err := Users.Create(ctx, NewUser{Username: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := Users.Create(ctx, NewUser{Username: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	org, err := Orgs.Create(ctx, "o", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := OrgMembers.Create(ctx, org.ID, bob.ID); err != nil {
+		t.Fatal(err)
+	}
+	repos := mustCreate(ctx, t, &types.Repo{Name: "a/b"}, &types.Repo{Name: "c/d"})
+
+	private, err := SearchContexts.Create(ctx, &SearchContext{Name: "work", NamespaceUserID: alice.ID}, []*SearchContextRepository{
+		{RepoID: repos[1].ID, Revisions: []string{"v1", "v2"}},
+		{RepoID: repos[0].ID},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := SearchContexts.Create(ctx, &SearchContext{Name: "work", NamespaceOrgID: org.ID, Public: true, RepositoryQueries: []string{"repo:^a/"}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orgPrivate, err := SearchContexts.Create(ctx, &SearchContext{Name: "secret", NamespaceOrgID: org.ID}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Create duplicate name", func(t *testing.T) {
+		if _, err := SearchContexts.Create(ctx, &SearchContext{Name: "WORK", NamespaceUserID: alice.ID}, nil); err == nil {
+			t.Fatal("got nil error")
+		}
+	})
+
+	t.Run("GetByName", func(t *testing.T) {
+		sc, err := SearchContexts.GetByName(ctx, 0, org.ID, "work")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(sc, public) {
+			t.Errorf("got %+v, want %+v", sc, public)
+		}
+		if _, err := SearchContexts.GetByName(ctx, bob.ID, 0, "work"); !errcode.IsNotFound(err) {
+			t.Errorf("got err %v, want not found", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		tests := map[string]struct {
+			opt  SearchContextsListOptions
+			want []*SearchContext
+		}{
+			"anonymous": {opt: SearchContextsListOptions{}, want: []*SearchContext{public}},
+			"owner":     {opt: SearchContextsListOptions{ViewerUserID: alice.ID}, want: []*SearchContext{private, public}},
+			"org member": {
+				opt:  SearchContextsListOptions{ViewerUserID: bob.ID},
+				want: []*SearchContext{public, orgPrivate},
+			},
+			"namespace": {
+				opt:  SearchContextsListOptions{NamespaceOrgID: org.ID, IncludeAllPrivate: true},
+				want: []*SearchContext{public, orgPrivate},
+			},
+		}
+		for name, test := range tests {
+			got, err := SearchContexts.List(ctx, test.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("%s: got %+v, want %+v", name, got, test.want)
+			}
+			if n, err := SearchContexts.Count(ctx, test.opt); err != nil {
+				t.Fatal(err)
+			} else if n != len(test.want) {
+				t.Errorf("%s: got count %d, want %d", name, n, len(test.want))
+			}
+		}
+	})
+
+	t.Run("ListRepositories and Update", func(t *testing.T) {
+		got, err := SearchContexts.ListRepositories(ctx, private.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []*SearchContextRepository{
+			{RepoID: repos[0].ID, RepoName: "a/b", Revisions: []string{""}},
+			{RepoID: repos[1].ID, RepoName: "c/d", Revisions: []string{"v1", "v2"}},
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %+v, want %+v", got, want)
+		}
+
+		update := *private
+		update.Description = "d"
+		if _, err := SearchContexts.Update(ctx, &update, []*SearchContextRepository{{RepoID: repos[1].ID}}); err != nil {
+			t.Fatal(err)
+		}
+		got, err = SearchContexts.ListRepositories(ctx, private.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want = []*SearchContextRepository{{RepoID: repos[1].ID, RepoName: "c/d", Revisions: []string{""}}}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("after update: got %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("default and Delete", func(t *testing.T) {
+		if sc, err := SearchContexts.GetDefault(ctx, bob.ID); err != nil || sc != nil {
+			t.Fatalf("got %+v, %v, want no default", sc, err)
+		}
+		if err := SearchContexts.SetDefault(ctx, bob.ID, &public.ID); err != nil {
+			t.Fatal(err)
+		}
+		if sc, err := SearchContexts.GetDefault(ctx, bob.ID); err != nil || sc == nil || sc.ID != public.ID {
+			t.Fatalf("got %+v, %v, want %d", sc, err, public.ID)
+		}
+
+		if err := SearchContexts.Delete(ctx, public.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := SearchContexts.GetByID(ctx, public.ID); !errcode.IsNotFound(err) {
+			t.Errorf("got err %v, want not found", err)
+		}
+		if sc, err := SearchContexts.GetDefault(ctx, bob.ID); err != nil || sc != nil {
+			t.Errorf("got %+v, %v, want no default after delete", sc, err)
+		}
+	})
+}
@@ -0,0 +1,227 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+)
+
+// A SearchHistoryEntry is a search query that a user ran, with information
+// about its results.
+type SearchHistoryEntry struct {
+	ID          int64
+	UserID      int32
+	Query       string
+	ResultCount int32
+	Duration    time.Duration
+	TimedOut    bool // whether the search timed out (and its results are incomplete)
+	CreatedAt   time.Time
+}
+
+type searchHistory struct{}
+
+// SearchHistoryEntryNotFoundError occurs when a search history entry is not
+// found.
+type SearchHistoryEntryNotFoundError struct {
+	args []interface{}
+}
+
+// NotFound implements errcode.NotFounder.
+func (err SearchHistoryEntryNotFoundError) NotFound() bool { return true }
+
+func (err SearchHistoryEntryNotFoundError) Error() string {
+	return fmt.Sprintf("search history entry not found: %v", err.args)
+}
+
+// Create records a search query in the user's search history.
+func (*searchHistory) Create(ctx context.Context, e *SearchHistoryEntry) (*SearchHistoryEntry, error) {
+	if Mocks.SearchHistory.Create != nil {
+		return Mocks.SearchHistory.Create(e)
+	}
+
+	created := *e
+	if err := dbconn.Global.QueryRowContext(
+		ctx,
+		"INSERT INTO search_history(user_id, query, result_count, duration_ms, timed_out) VALUES($1, $2, $3, $4, $5) RETURNING id, created_at",
+		e.UserID, e.Query, e.ResultCount, int64(e.Duration/time.Millisecond), e.TimedOut,
+	).Scan(&created.ID, &created.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetByID retrieves the search history entry with the given ID.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to view the
+// search history of the entry's user.
+func (s *searchHistory) GetByID(ctx context.Context, id int64) (*SearchHistoryEntry, error) {
+	results, err := s.list(ctx, []*sqlf.Query{sqlf.Sprintf("id=%d", id)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, SearchHistoryEntryNotFoundError{[]interface{}{id}}
+	}
+	return results[0], nil
+}
+
+// SearchHistoryListOptions contains options for listing search history
+// entries.
+type SearchHistoryListOptions struct {
+	UserID   int32  // only list the search history of this user (or of all users if 0)
+	Query    string // only list entries whose query contains this string (case-insensitively)
+	TimedOut bool   // only list entries of searches that timed out
+	*LimitOffset
+}
+
+func (o SearchHistoryListOptions) sqlConditions() []*sqlf.Query {
+	conds := []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	if o.UserID != 0 {
+		conds = append(conds, sqlf.Sprintf("user_id=%d", o.UserID))
+	}
+	if o.Query != "" {
+		conds = append(conds, sqlf.Sprintf("query ILIKE %s", "%"+escapeLikePattern(o.Query)+"%"))
+	}
+	if o.TimedOut {
+		conds = append(conds, sqlf.Sprintf("timed_out"))
+	}
+	return conds
+}
+
+// List lists the search history entries that satisfy the options, most recent
+// first.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to list with
+// the specified options.
+func (s *searchHistory) List(ctx context.Context, opt SearchHistoryListOptions) ([]*SearchHistoryEntry, error) {
+	if Mocks.SearchHistory.List != nil {
+		return Mocks.SearchHistory.List(opt)
+	}
+	return s.list(ctx, opt.sqlConditions(), opt.LimitOffset)
+}
+
+func (*searchHistory) list(ctx context.Context, conds []*sqlf.Query, limitOffset *LimitOffset) ([]*SearchHistoryEntry, error) {
+	q := sqlf.Sprintf(`
+SELECT id, user_id, query, result_count, duration_ms, timed_out, created_at FROM search_history
+WHERE (%s)
+ORDER BY created_at DESC, id DESC
+%s`,
+		sqlf.Join(conds, ") AND ("),
+		limitOffset.SQL(),
+	)
+	return scanSearchHistoryEntries(dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...))
+}
+
+// Count counts the search history entries that satisfy the options (ignoring
+// limit and offset).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to count
+// with the specified options.
+func (*searchHistory) Count(ctx context.Context, opt SearchHistoryListOptions) (int, error) {
+	q := sqlf.Sprintf("SELECT COUNT(*) FROM search_history WHERE (%s)", sqlf.Join(opt.sqlConditions(), ") AND ("))
+	var count int
+	if err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// ListRecentQueries lists the distinct queries in the user's search history
+// that start with the prefix (case-insensitively), most recently run first.
+// For each query, its most recent entry is returned.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to view the
+// user's search history.
+func (*searchHistory) ListRecentQueries(ctx context.Context, userID int32, prefix string, limit int) ([]*SearchHistoryEntry, error) {
+	if Mocks.SearchHistory.ListRecentQueries != nil {
+		return Mocks.SearchHistory.ListRecentQueries(userID, prefix, limit)
+	}
+
+	return scanSearchHistoryEntries(dbconn.Global.QueryContext(ctx, `
+SELECT id, user_id, query, result_count, duration_ms, timed_out, created_at FROM (
+	SELECT DISTINCT ON (query) * FROM search_history
+	WHERE user_id=$1 AND query ILIKE $2
+	ORDER BY query, created_at DESC, id DESC
+) AS recent
+ORDER BY created_at DESC, id DESC
+LIMIT $3`,
+		userID, escapeLikePattern(prefix)+"%", limit,
+	))
+}
+
+func scanSearchHistoryEntries(rows *sql.Rows, err error) ([]*SearchHistoryEntry, error) {
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*SearchHistoryEntry
+	for rows.Next() {
+		var (
+			e          SearchHistoryEntry
+			durationMS int64
+		)
+		if err := rows.Scan(&e.ID, &e.UserID, &e.Query, &e.ResultCount, &durationMS, &e.TimedOut, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		e.Duration = time.Duration(durationMS) * time.Millisecond
+		results = append(results, &e)
+	}
+	return results, rows.Err()
+}
+
+// escapeLikePattern escapes the characters in s that have a special meaning in
+// LIKE patterns.
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// Delete deletes a search history entry.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to delete
+// the search history of the entry's user.
+func (*searchHistory) Delete(ctx context.Context, id int64) error {
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM search_history WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	nrows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if nrows == 0 {
+		return SearchHistoryEntryNotFoundError{[]interface{}{id}}
+	}
+	return nil
+}
+
+// DeleteByUser deletes all of the user's search history.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to delete
+// the user's search history.
+func (*searchHistory) DeleteByUser(ctx context.Context, userID int32) error {
+	_, err := dbconn.Global.ExecContext(ctx, "DELETE FROM search_history WHERE user_id=$1", userID)
+	return err
+}
+
+// DeleteOlderThan deletes all search history entries created before t, and
+// returns the number of entries deleted.
+func (*searchHistory) DeleteOlderThan(ctx context.Context, t time.Time) (int64, error) {
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM search_history WHERE created_at < $1", t)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// MockSearchHistory mocks the search history store.
+type MockSearchHistory struct {
+	Create            func(e *SearchHistoryEntry) (*SearchHistoryEntry, error)
+	List              func(opt SearchHistoryListOptions) ([]*SearchHistoryEntry, error)
+	ListRecentQueries func(userID int32, prefix string, limit int) ([]*SearchHistoryEntry, error)
+}
@@ -0,0 +1,142 @@
+package db
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+)
+
+func TestSearchHistory(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	alice, err := Users.Create(ctx, NewUser{Username: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := Users.Create(ctx, NewUser{Username: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	create := func(userID int32, query string, timedOut bool) *SearchHistoryEntry {
+		t.Helper()
+		e, err := SearchHistory.Create(ctx, &SearchHistoryEntry{UserID: userID, Query: query, ResultCount: 3, Duration: 1500 * time.Millisecond, TimedOut: timedOut})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	queries := func(entries []*SearchHistoryEntry) []string {
+		var qs []string
+		for _, e := range entries {
+			qs = append(qs, e.Query)
+		}
+		return qs
+	}
+
+	create(alice.ID, "foo", false)
+	create(alice.ID, "foo bar", true)
+	aliceFoo := create(alice.ID, "foo", false)
+	create(alice.ID, "100%_done", false)
+	create(bob.ID, "foo baz", false)
+
+	t.Run("GetByID", func(t *testing.T) {
+		e, err := SearchHistory.GetByID(ctx, aliceFoo.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(e, aliceFoo) {
+			t.Errorf("got %+v, want %+v", e, aliceFoo)
+		}
+		if e.Duration != 1500*time.Millisecond {
+			t.Errorf("got duration %v, want 1.5s", e.Duration)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		tests := map[string]struct {
+			opt  SearchHistoryListOptions
+			want []string
+		}{
+			"user":      {opt: SearchHistoryListOptions{UserID: alice.ID}, want: []string{"100%_done", "foo", "foo bar", "foo"}},
+			"all users": {opt: SearchHistoryListOptions{Query: "BA"}, want: []string{"foo baz", "foo bar"}},
+			"timed out": {opt: SearchHistoryListOptions{TimedOut: true}, want: []string{"foo bar"}},
+			"escaped":   {opt: SearchHistoryListOptions{Query: "0%_d"}, want: []string{"100%_done"}},
+			"limit":     {opt: SearchHistoryListOptions{UserID: alice.ID, LimitOffset: &LimitOffset{Limit: 1}}, want: []string{"100%_done"}},
+		}
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				entries, err := SearchHistory.List(ctx, test.opt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got := queries(entries); !reflect.DeepEqual(got, test.want) {
+					t.Errorf("got %q, want %q", got, test.want)
+				}
+				count, err := SearchHistory.Count(ctx, test.opt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if test.opt.LimitOffset == nil && count != len(test.want) {
+					t.Errorf("got count %d, want %d", count, len(test.want))
+				}
+			})
+		}
+	})
+
+	t.Run("ListRecentQueries", func(t *testing.T) {
+		entries, err := SearchHistory.ListRecentQueries(ctx, alice.ID, "FOO", 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := queries(entries), []string{"foo", "foo bar"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		if entries[0].ID != aliceFoo.ID {
+			t.Errorf("got entry %d, want the most recent entry %d", entries[0].ID, aliceFoo.ID)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := SearchHistory.Delete(ctx, aliceFoo.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := SearchHistory.Delete(ctx, aliceFoo.ID); !errcode.IsNotFound(err) {
+			t.Errorf("got err %v, want not found", err)
+		}
+	})
+
+	t.Run("DeleteOlderThan", func(t *testing.T) {
+		if n, err := SearchHistory.DeleteOlderThan(ctx, time.Now().Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Errorf("got %d deleted, want 0", n)
+		}
+		if n, err := SearchHistory.DeleteOlderThan(ctx, time.Now().Add(time.Hour)); err != nil {
+			t.Fatal(err)
+		} else if n != 4 {
+			t.Errorf("got %d deleted, want 4", n)
+		}
+	})
+
+	t.Run("DeleteByUser", func(t *testing.T) {
+		create(alice.ID, "a", false)
+		create(bob.ID, "b", false)
+		if err := SearchHistory.DeleteByUser(ctx, alice.ID); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := SearchHistory.List(ctx, SearchHistoryListOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := queries(entries), []string{"b"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+}
@@ -0,0 +1,282 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/keegancsmith/sqlf"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbconn"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbutil"
+)
+
+// A SearchInsight is a search query whose match count is recorded at past
+// points in time, to chart how it changes over time.
+type SearchInsight struct {
+	ID           int32
+	UserID       int32 // the user who owns the insight
+	Title        string
+	Query        string
+	IntervalDays int32 // the number of days between two data points
+	NumPoints    int32 // the number of data points to record (going back from now)
+	CreatedAt    time.Time
+}
+
+// SearchInsightPoint is the match count of a search insight's query in a
+// repository at a point in time.
+type SearchInsightPoint struct {
+	RepoID  api.RepoID
+	Commit  api.CommitID // the last commit before the point in time, or "" if there was none
+	Count   int32
+	Partial bool // whether the repository could not be searched fully, so not all matches were counted
+}
+
+// SearchInsightDataPoint is the match count of a search insight's query in
+// all repositories at a point in time.
+type SearchInsightDataPoint struct {
+	Time            time.Time
+	Count           int32
+	RepositoryCount int32 // the number of repositories with matches
+	Partial         bool  // whether some repositories could not be searched fully
+}
+
+type searchInsights struct{}
+
+// SearchInsightNotFoundError occurs when a search insight is not found.
+type SearchInsightNotFoundError struct {
+	args []interface{}
+}
+
+// NotFound implements errcode.NotFounder.
+func (err SearchInsightNotFoundError) NotFound() bool { return true }
+
+func (err SearchInsightNotFoundError) Error() string {
+	return fmt.Sprintf("search insight not found: %v", err.args)
+}
+
+// Create creates a search insight.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to create
+// search insights for the user.
+func (*searchInsights) Create(ctx context.Context, si *SearchInsight) (*SearchInsight, error) {
+	created := *si
+	if err := dbconn.Global.QueryRowContext(
+		ctx,
+		"INSERT INTO search_insights(user_id, title, query, interval_days, num_points) VALUES($1, $2, $3, $4, $5) RETURNING id, created_at",
+		si.UserID, si.Title, si.Query, si.IntervalDays, si.NumPoints,
+	).Scan(&created.ID, &created.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetByID retrieves the search insight with the given ID.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to view
+// this search insight.
+func (s *searchInsights) GetByID(ctx context.Context, id int32) (*SearchInsight, error) {
+	if Mocks.SearchInsights.GetByID != nil {
+		return Mocks.SearchInsights.GetByID(id)
+	}
+
+	results, err := s.list(ctx, []*sqlf.Query{sqlf.Sprintf("id=%d", id)}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(results) == 0 {
+		return nil, SearchInsightNotFoundError{[]interface{}{id}}
+	}
+	return results[0], nil
+}
+
+// SearchInsightsListOptions contains options for listing search insights.
+type SearchInsightsListOptions struct {
+	UserID int32 // only list the search insights of this user (or of all users if 0)
+	*LimitOffset
+}
+
+func (o SearchInsightsListOptions) sqlConditions() []*sqlf.Query {
+	conds := []*sqlf.Query{sqlf.Sprintf("TRUE")}
+	if o.UserID != 0 {
+		conds = append(conds, sqlf.Sprintf("user_id=%d", o.UserID))
+	}
+	return conds
+}
+
+// List lists the search insights that satisfy the options, oldest first.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to list with
+// the specified options.
+func (s *searchInsights) List(ctx context.Context, opt SearchInsightsListOptions) ([]*SearchInsight, error) {
+	if Mocks.SearchInsights.List != nil {
+		return Mocks.SearchInsights.List(opt)
+	}
+	return s.list(ctx, opt.sqlConditions(), opt.LimitOffset)
+}
+
+func (*searchInsights) list(ctx context.Context, conds []*sqlf.Query, limitOffset *LimitOffset) ([]*SearchInsight, error) {
+	q := sqlf.Sprintf(`
+SELECT id, user_id, title, query, interval_days, num_points, created_at FROM search_insights
+WHERE (%s)
+ORDER BY id ASC
+%s`,
+		sqlf.Join(conds, ") AND ("),
+		limitOffset.SQL(),
+	)
+
+	rows, err := dbconn.Global.QueryContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*SearchInsight
+	for rows.Next() {
+		var si SearchInsight
+		if err := rows.Scan(&si.ID, &si.UserID, &si.Title, &si.Query, &si.IntervalDays, &si.NumPoints, &si.CreatedAt); err != nil {
+			return nil, err
+		}
+		results = append(results, &si)
+	}
+	return results, rows.Err()
+}
+
+// Count counts the search insights that satisfy the options (ignoring limit
+// and offset).
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to count
+// with the specified options.
+func (*searchInsights) Count(ctx context.Context, opt SearchInsightsListOptions) (int, error) {
+	q := sqlf.Sprintf("SELECT COUNT(*) FROM search_insights WHERE (%s)", sqlf.Join(opt.sqlConditions(), ") AND ("))
+	var count int
+	if err := dbconn.Global.QueryRowContext(ctx, q.Query(sqlf.PostgresBindVar), q.Args()...).Scan(&count); err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// Delete deletes a search insight and its data points.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to delete
+// the search insight.
+func (*searchInsights) Delete(ctx context.Context, id int32) error {
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM search_insights WHERE id=$1", id)
+	if err != nil {
+		return err
+	}
+	nrows, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if nrows == 0 {
+		return SearchInsightNotFoundError{[]interface{}{id}}
+	}
+	return nil
+}
+
+// SetPoints stores the match counts of the search insight's query in each
+// repository at a point in time, replacing those previously stored for that
+// time.
+func (*searchInsights) SetPoints(ctx context.Context, id int32, t time.Time, points []*SearchInsightPoint) error {
+	if Mocks.SearchInsights.SetPoints != nil {
+		return Mocks.SearchInsights.SetPoints(id, t, points)
+	}
+
+	return dbutil.Transaction(ctx, dbconn.Global, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM search_insight_points WHERE insight_id=$1 AND time=$2", id, t); err != nil {
+			return err
+		}
+		for _, p := range points {
+			if _, err := tx.ExecContext(ctx,
+				"INSERT INTO search_insight_points(insight_id, time, repo_id, commit, count, partial) VALUES($1, $2, $3, $4, $5, $6)",
+				id, t, p.RepoID, p.Commit, p.Count, p.Partial,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListPointTimes lists the distinct times at which match counts of the search
+// insight's query are stored, oldest first.
+func (*searchInsights) ListPointTimes(ctx context.Context, id int32) ([]time.Time, error) {
+	if Mocks.SearchInsights.ListPointTimes != nil {
+		return Mocks.SearchInsights.ListPointTimes(id)
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, "SELECT DISTINCT time FROM search_insight_points WHERE insight_id=$1 ORDER BY time ASC", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var times []time.Time
+	for rows.Next() {
+		var t time.Time
+		if err := rows.Scan(&t); err != nil {
+			return nil, err
+		}
+		times = append(times, t)
+	}
+	return times, rows.Err()
+}
+
+// ListDataPoints lists the match counts of the search insight's query in all
+// repositories at each stored point in time, oldest first.
+//
+// 🚨 SECURITY: The caller must ensure that the actor is permitted to view
+// this search insight.
+func (*searchInsights) ListDataPoints(ctx context.Context, id int32) ([]*SearchInsightDataPoint, error) {
+	if Mocks.SearchInsights.ListDataPoints != nil {
+		return Mocks.SearchInsights.ListDataPoints(id)
+	}
+
+	rows, err := dbconn.Global.QueryContext(ctx, `
+SELECT time, SUM(count), COUNT(*) FILTER (WHERE count > 0), bool_or(partial) FROM search_insight_points
+WHERE insight_id=$1
+GROUP BY time
+ORDER BY time ASC`,
+		id,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []*SearchInsightDataPoint
+	for rows.Next() {
+		var p SearchInsightDataPoint
+		if err := rows.Scan(&p.Time, &p.Count, &p.RepositoryCount, &p.Partial); err != nil {
+			return nil, err
+		}
+		results = append(results, &p)
+	}
+	return results, rows.Err()
+}
+
+// DeletePointsBefore deletes the search insight's match counts stored for
+// times before t, and returns the number of match counts deleted.
+func (*searchInsights) DeletePointsBefore(ctx context.Context, id int32, t time.Time) (int64, error) {
+	if Mocks.SearchInsights.DeletePointsBefore != nil {
+		return Mocks.SearchInsights.DeletePointsBefore(id, t)
+	}
+
+	res, err := dbconn.Global.ExecContext(ctx, "DELETE FROM search_insight_points WHERE insight_id=$1 AND time < $2", id, t)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// MockSearchInsights mocks the search insights store.
+type MockSearchInsights struct {
+	GetByID            func(id int32) (*SearchInsight, error)
+	List               func(opt SearchInsightsListOptions) ([]*SearchInsight, error)
+	SetPoints          func(id int32, t time.Time, points []*SearchInsightPoint) error
+	ListPointTimes     func(id int32) ([]time.Time, error)
+	ListDataPoints     func(id int32) ([]*SearchInsightDataPoint, error)
+	DeletePointsBefore func(id int32, t time.Time) (int64, error)
+}
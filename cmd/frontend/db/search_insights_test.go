@@ -0,0 +1,151 @@
+package db
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/db/dbtesting"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+)
+
+func TestSearchInsights(t *testing.T) {
+	if testing.Short() {
+		t.Skip()
+	}
+	ctx := dbtesting.TestContext(t)
+
+	alice, err := Users.Create(ctx, NewUser{Username: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := Users.Create(ctx, NewUser{Username: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var repos []api.RepoID
+	for _, name := range []api.RepoName{"a", "b"} {
+		if err := Repos.Upsert(ctx, api.InsertRepoOp{Name: name, Enabled: true}); err != nil {
+			t.Fatal(err)
+		}
+		repo, err := Repos.GetByName(ctx, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		repos = append(repos, repo.ID)
+	}
+
+	create := func(userID int32, title string) *SearchInsight {
+		t.Helper()
+		si, err := SearchInsights.Create(ctx, &SearchInsight{UserID: userID, Title: title, Query: "foo", IntervalDays: 7, NumPoints: 52})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return si
+	}
+	aliceInsight := create(alice.ID, "x")
+	create(alice.ID, "y")
+	create(bob.ID, "z")
+
+	t.Run("GetByID", func(t *testing.T) {
+		si, err := SearchInsights.GetByID(ctx, aliceInsight.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(si, aliceInsight) {
+			t.Errorf("got %+v, want %+v", si, aliceInsight)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		tests := map[string]struct {
+			opt  SearchInsightsListOptions
+			want []string
+		}{
+			"user":      {opt: SearchInsightsListOptions{UserID: alice.ID}, want: []string{"x", "y"}},
+			"all users": {opt: SearchInsightsListOptions{}, want: []string{"x", "y", "z"}},
+			"limit":     {opt: SearchInsightsListOptions{LimitOffset: &LimitOffset{Limit: 1, Offset: 1}}, want: []string{"y"}},
+		}
+		for name, test := range tests {
+			t.Run(name, func(t *testing.T) {
+				insights, err := SearchInsights.List(ctx, test.opt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var got []string
+				for _, si := range insights {
+					got = append(got, si.Title)
+				}
+				if !reflect.DeepEqual(got, test.want) {
+					t.Errorf("got %q, want %q", got, test.want)
+				}
+				count, err := SearchInsights.Count(ctx, test.opt)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if test.opt.LimitOffset == nil && count != len(test.want) {
+					t.Errorf("got count %d, want %d", count, len(test.want))
+				}
+			})
+		}
+	})
+
+	t1 := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	t2 := t1.AddDate(0, 0, 7)
+	t.Run("SetPoints", func(t *testing.T) {
+		set := func(t *testing.T, tm time.Time, points ...*SearchInsightPoint) {
+			t.Helper()
+			if err := SearchInsights.SetPoints(ctx, aliceInsight.ID, tm, points); err != nil {
+				t.Fatal(err)
+			}
+		}
+		set(t, t2, &SearchInsightPoint{RepoID: repos[0], Commit: "c2", Count: 1})
+		// Setting the points of a time replaces those previously set.
+		set(t, t2, &SearchInsightPoint{RepoID: repos[0], Commit: "c2", Count: 2}, &SearchInsightPoint{RepoID: repos[1], Commit: "d2", Count: 0, Partial: true})
+		set(t, t1, &SearchInsightPoint{RepoID: repos[0], Commit: "c1", Count: 3}, &SearchInsightPoint{RepoID: repos[1], Commit: "d1", Count: 4})
+
+		times, err := SearchInsights.ListPointTimes(ctx, aliceInsight.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(times) != 2 || !times[0].Equal(t1) || !times[1].Equal(t2) {
+			t.Errorf("got times %v, want [%v %v]", times, t1, t2)
+		}
+
+		points, err := SearchInsights.ListDataPoints(ctx, aliceInsight.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, p := range points {
+			got = append(got, fmt.Sprintf("%s:%d:%d:%v", p.Time.UTC().Format("2006-01-02"), p.Count, p.RepositoryCount, p.Partial))
+		}
+		if want := []string{"2018-01-01:7:2:false", "2018-01-08:2:1:true"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got data points %q, want %q", got, want)
+		}
+	})
+
+	t.Run("DeletePointsBefore", func(t *testing.T) {
+		if n, err := SearchInsights.DeletePointsBefore(ctx, aliceInsight.ID, t2); err != nil {
+			t.Fatal(err)
+		} else if n != 2 {
+			t.Errorf("got %d deleted, want 2", n)
+		}
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		if err := SearchInsights.Delete(ctx, aliceInsight.ID); err != nil {
+			t.Fatal(err)
+		}
+		if err := SearchInsights.Delete(ctx, aliceInsight.ID); !errcode.IsNotFound(err) {
+			t.Errorf("got err %v, want not found", err)
+		}
+		if times, err := SearchInsights.ListPointTimes(ctx, aliceInsight.ID); err != nil {
+			t.Fatal(err)
+		} else if len(times) != 0 {
+			t.Errorf("got times %v, want the data points to be deleted", times)
+		}
+	})
+}
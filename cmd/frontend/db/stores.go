@@ -3,12 +3,19 @@ package db
 var (
 	AccessTokens              = &accessTokens{}
 	ExternalServices          = &externalServices{}
+	GlobalSymbols             = &globalSymbols{}
+	GoDependencies            = &goDependencies{}
+	LSIFDumps                 = &lsifDumps{}
+	Packages                  = &packages{}
 	DiscussionThreads         = &discussionThreads{}
 	DiscussionComments        = &discussionComments{}
 	DiscussionMailReplyTokens = &discussionMailReplyTokens{}
 	Repos                     = &repos{}
 	Phabricator               = &phabricator{}
 	SavedQueries              = &savedQueries{}
+	SearchContexts            = &searchContexts{}
+	SearchHistory             = &searchHistory{}
+	SearchInsights            = &searchInsights{}
 	Orgs                      = &orgs{}
 	OrgMembers                = &orgMembers{}
 	Settings                  = &settings{}
@@ -133,6 +133,21 @@ func (r *nodeResolver) ToRegistryExtension() (RegistryExtension, bool) {
 	return NodeToRegistryExtension(r.node)
 }
 
+func (r *nodeResolver) ToSearchContext() (*searchContextResolver, bool) {
+	n, ok := r.node.(*searchContextResolver)
+	return n, ok
+}
+
+func (r *nodeResolver) ToSearchHistoryEntry() (*searchHistoryEntryResolver, bool) {
+	n, ok := r.node.(*searchHistoryEntryResolver)
+	return n, ok
+}
+
+func (r *nodeResolver) ToSearchInsight() (*searchInsightResolver, bool) {
+	n, ok := r.node.(*searchInsightResolver)
+	return n, ok
+}
+
 func (r *nodeResolver) ToSite() (*siteResolver, bool) {
 	n, ok := r.node.(*siteResolver)
 	return n, ok
@@ -187,6 +202,12 @@ func nodeByID(ctx context.Context, id graphql.ID) (node, error) {
 		return RegistryExtensionByID(ctx, id)
 	case "SavedQuery":
 		return savedQueryByID(ctx, id)
+	case "SearchContext":
+		return searchContextByID(ctx, id)
+	case "SearchHistoryEntry":
+		return searchHistoryEntryByID(ctx, id)
+	case "SearchInsight":
+		return searchInsightByID(ctx, id)
 	case "Site":
 		return siteByGQLID(ctx, id)
 	default:
@@ -0,0 +1,150 @@
+package graphqlbackend
+
+import (
+	"context"
+	"encoding/json"
+
+	lsp "github.com/sourcegraph/go-lsp"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/lsif"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// Precise code intelligence uses the LSIF dumps uploaded for a repository (see
+// httpapi.serveLSIFUpload). A blob at a commit without a dump uses the dump of
+// its nearest ancestor commit that has one, if the blob has not changed since
+// that commit.
+
+// maxLSIFAncestors is the maximum number of ancestors of a commit that are
+// searched for an LSIF dump.
+const maxLSIFAncestors = 100
+
+// lsifDocument returns the LSIF data of the blob and the dump it is from, or
+// nil if there is none.
+func (r *gitTreeEntryResolver) lsifDocument(ctx context.Context) (*lsif.Document, *db.LSIFDump, error) {
+	repo := gitserver.Repo{Name: r.commit.repo.repo.Name}
+	commits, err := git.Commits(ctx, repo, git.CommitsOptions{Range: string(r.commit.oid), N: maxLSIFAncestors})
+	if err != nil || len(commits) == 0 {
+		return nil, nil, err
+	}
+	commitIDs := make([]api.CommitID, len(commits))
+	for i, c := range commits {
+		commitIDs[i] = c.ID
+	}
+	dumps, err := db.LSIFDumps.GetByCommits(ctx, r.commit.repo.repo.ID, commitIDs)
+	if err != nil || len(dumps) == 0 {
+		return nil, nil, err
+	}
+
+	// Use the dump of the nearest ancestor (the commits are listed newest
+	// first).
+	dumpsByCommit := make(map[api.CommitID]*db.LSIFDump, len(dumps))
+	for _, d := range dumps {
+		dumpsByCommit[d.Commit] = d
+	}
+	var dump *db.LSIFDump
+	dumpIndex := 0
+	for i, id := range commitIDs {
+		if d, ok := dumpsByCommit[id]; ok {
+			dump, dumpIndex = d, i
+			break
+		}
+	}
+
+	// The positions in the dump of an ancestor are only valid if the blob has
+	// not changed since.
+	if dumpIndex > 0 {
+		lastChange, err := git.Commits(ctx, repo, git.CommitsOptions{Range: string(r.commit.oid), N: 1, Path: r.path})
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(lastChange) > 0 {
+			for _, id := range commitIDs[:dumpIndex] {
+				if id == lastChange[0].ID {
+					return nil, nil, nil
+				}
+			}
+		}
+	}
+
+	data, err := db.LSIFDumps.GetDocument(ctx, dump.ID, r.path)
+	if err != nil || data == nil {
+		return nil, nil, err
+	}
+	var document lsif.Document
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, nil, err
+	}
+	return &document, dump, nil
+}
+
+// lsifRangeAt returns the LSIF data of the blob, the dump it is from and the
+// range at the position, or nil if there is none.
+func (r *gitTreeEntryResolver) lsifRangeAt(ctx context.Context, args *positionArgs) (*lsif.Document, *db.LSIFDump, *lsif.DocumentRange, error) {
+	document, dump, err := r.lsifDocument(ctx)
+	if err != nil || document == nil {
+		return nil, nil, nil, err
+	}
+	rng := document.RangeAt(lsif.Position{Line: int(args.Line), Character: int(args.Character)})
+	if rng == nil {
+		return nil, nil, nil, nil
+	}
+	return document, dump, rng, nil
+}
+
+// lsifLocations returns the resolvers of the locations of a definition or
+// reference result of an LSIF dump. They are at the dump's commit, since the
+// files they are in may have changed since.
+func (r *gitTreeEntryResolver) lsifLocations(ctx context.Context, dump *db.LSIFDump, resultID string) ([]*locationResolver, error) {
+	data, err := db.LSIFDumps.GetResult(ctx, dump.ID, resultID)
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var locations []lsif.Location
+	if err := json.Unmarshal(data, &locations); err != nil {
+		return nil, err
+	}
+
+	commit := r.commit
+	if dump.Commit != api.CommitID(r.commit.oid) {
+		commit = &gitCommitResolver{repo: r.commit.repo, oid: gitObjectID(dump.Commit)}
+	}
+	resolvers := make([]*locationResolver, len(locations))
+	for i, l := range locations {
+		rng := toLSPRange(l.Range)
+		resolvers[i] = &locationResolver{
+			resource: &gitTreeEntryResolver{commit: commit, path: l.Path, stat: createFileInfo(l.Path, false)},
+			lspRange: &rng,
+		}
+	}
+	return resolvers, nil
+}
+
+func (r *gitTreeEntryResolver) Hover(ctx context.Context, args *positionArgs) (*hoverResolver, error) {
+	document, _, rng, err := r.lsifRangeAt(ctx, args)
+	if err != nil || rng == nil {
+		return nil, err
+	}
+	text := document.Hover(rng)
+	if text == "" {
+		return nil, nil
+	}
+	return &hoverResolver{markdown: text, lspRange: toLSPRange(rng.Range)}, nil
+}
+
+func toLSPRange(r lsif.Range) lsp.Range {
+	return lsp.Range{
+		Start: lsp.Position{Line: r.Start.Line, Character: r.Start.Character},
+		End:   lsp.Position{Line: r.End.Line, Character: r.End.Character},
+	}
+}
+
+type hoverResolver struct {
+	markdown string
+	lspRange lsp.Range
+}
+
+func (r *hoverResolver) Markdown() *markdownResolver { return &markdownResolver{text: r.markdown} }
+func (r *hoverResolver) Range() *rangeResolver       { return &rangeResolver{lspRange: r.lspRange} }
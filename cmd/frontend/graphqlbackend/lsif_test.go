@@ -0,0 +1,94 @@
+package graphqlbackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/lsif"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestLSIF(t *testing.T) {
+	defer func() { db.Mocks = db.MockStores{} }()
+	defer git.ResetMocks()
+
+	// The history is c3 -> c2 -> c1, and a.go last changed in lastChange.
+	var lastChange api.CommitID
+	git.Mocks.Commits = func(opt git.CommitsOptions) ([]*git.Commit, error) {
+		if opt.Path != "" {
+			return []*git.Commit{{ID: lastChange}}, nil
+		}
+		return []*git.Commit{{ID: "c3"}, {ID: "c2"}, {ID: "c1"}}, nil
+	}
+	db.Mocks.LSIFDumps.GetByCommits = func(repoID api.RepoID, commits []api.CommitID) ([]*db.LSIFDump, error) {
+		return []*db.LSIFDump{{ID: 1, Commit: "c1"}, {ID: 2, Commit: "c2"}}, nil
+	}
+	db.Mocks.LSIFDumps.GetDocument = func(dumpID int64, path string) ([]byte, error) {
+		if dumpID != 2 || path != "a.go" {
+			t.Errorf("got dump %d path %q, want the document of the nearest dump", dumpID, path)
+		}
+		return json.Marshal(&lsif.Document{
+			Ranges: []lsif.DocumentRange{{
+				Range:            lsif.Range{Start: lsif.Position{Line: 1, Character: 5}, End: lsif.Position{Line: 1, Character: 8}},
+				DefinitionResult: "d",
+				HoverResult:      "h",
+			}},
+			Hovers: map[string]string{"h": "func Foo()"},
+		})
+	}
+	db.Mocks.LSIFDumps.GetResult = func(dumpID int64, resultID string) ([]byte, error) {
+		if dumpID != 2 || resultID != "d" {
+			t.Errorf("got dump %d result %q, want the definition result of the range", dumpID, resultID)
+		}
+		return json.Marshal([]lsif.Location{{Path: "b.go", Range: lsif.Range{Start: lsif.Position{Line: 3}, End: lsif.Position{Line: 3, Character: 3}}}})
+	}
+
+	blob := &gitTreeEntryResolver{
+		commit: &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 1, Name: "r"}}, oid: "c3"},
+		path:   "a.go",
+		stat:   createFileInfo("a.go", false),
+	}
+	ctx := context.Background()
+
+	t.Run("unchanged since the dump", func(t *testing.T) {
+		lastChange = "c1"
+		definitions, err := blob.Definitions(ctx, &positionArgs{Line: 1, Character: 6})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !definitions.IsPrecise() {
+			t.Error("want precise definitions")
+		}
+		var got []string
+		for _, l := range definitions.Nodes() {
+			got = append(got, fmt.Sprintf("%s@%s:%d:%d", l.resource.path, l.resource.commit.oid, l.lspRange.Start.Line, l.lspRange.End.Character))
+		}
+		if want := []string{"b.go@c2:3:3"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("got definitions %q, want %q", got, want)
+		}
+
+		hover, err := blob.Hover(ctx, &positionArgs{Line: 1, Character: 8})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hover == nil || hover.Markdown().Text() != "func Foo()" || hover.Range().Start().Character() != 5 {
+			t.Errorf("got hover %+v, want the hover of the range", hover)
+		}
+		if hover, err := blob.Hover(ctx, &positionArgs{Line: 1, Character: 9}); err != nil || hover != nil {
+			t.Errorf("got hover %+v (error %v) outside the range, want none", hover, err)
+		}
+	})
+
+	t.Run("changed since the dump", func(t *testing.T) {
+		lastChange = "c3"
+		if hover, err := blob.Hover(ctx, &positionArgs{Line: 1, Character: 6}); err != nil || hover != nil {
+			t.Errorf("got hover %+v (error %v), want none", hover, err)
+		}
+	})
+}
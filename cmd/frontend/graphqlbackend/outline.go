@@ -0,0 +1,135 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	lsp "github.com/sourcegraph/go-lsp"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gituri"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+)
+
+func (r *gitTreeEntryResolver) Outline(ctx context.Context) (res []*outlineSymbolResolver, err error) {
+	ctx, done := context.WithTimeout(ctx, 5*time.Second)
+	defer done()
+	defer func() {
+		if ctx.Err() != nil && len(res) == 0 {
+			err = errors.New("processing symbols is taking longer than expected. Try again in a while")
+		}
+	}()
+
+	symbols, err := backend.Symbols.Outline(ctx, protocol.OutlineArgs{
+		Repo:     r.commit.repo.repo.Name,
+		CommitID: api.CommitID(r.commit.oid),
+		Path:     r.path,
+	})
+	if err != nil {
+		return nil, err
+	}
+	baseURI, err := gituri.Parse("git://" + string(r.commit.repo.repo.Name) + "?" + string(r.commit.oid))
+	if err != nil {
+		return nil, err
+	}
+	return buildOutline(symbols, func(symbol protocol.Symbol) *symbolResolver {
+		return toSymbolResolver(symbolToLSPSymbolInformation(symbol, baseURI), strings.ToLower(symbol.Language), r.commit)
+	}), nil
+}
+
+// buildOutline arranges the symbols of a file in a tree. A symbol is a child
+// of the symbol whose qualified name (e.g. "A.B" for class B in class A in
+// Java) is the symbol's parent. Some parsers qualify a parent with its package
+// although the parent itself is not (e.g. the members of Go type T in package
+// p, whose parent is "p.T"), or qualify a symbol with its package but its
+// members only with the symbol (e.g. class "p.A" with members whose parent is
+// "A"), so a parent that is not a qualified name in the file is matched by its
+// last component too. Symbols whose parent
+// is not declared in the file are at the top level.
+func buildOutline(symbols []protocol.Symbol, toResolver func(protocol.Symbol) *symbolResolver) []*outlineSymbolResolver {
+	nodes := make([]*outlineSymbolResolver, len(symbols))
+	byQualifiedName := make(map[string]*outlineSymbolResolver, len(symbols))
+	byName := make(map[string]*outlineSymbolResolver, len(symbols))
+	for i, symbol := range symbols {
+		nodes[i] = &outlineSymbolResolver{declRange: declarationRange(symbol), children: []*outlineSymbolResolver{}}
+		name := symbol.Name
+		if symbol.Parent != "" {
+			name = symbol.Parent + "." + symbol.Name
+		}
+		if _, ok := byQualifiedName[name]; !ok {
+			byQualifiedName[name] = nodes[i]
+		}
+		if _, ok := byName[symbol.Name]; !ok {
+			byName[symbol.Name] = nodes[i]
+		}
+	}
+	findParent := func(parent string) *outlineSymbolResolver {
+		if node, ok := byQualifiedName[parent]; ok {
+			return node
+		}
+		if i := strings.LastIndexAny(parent, ".:"); i != -1 {
+			parent = parent[i+1:]
+		}
+		return byName[parent]
+	}
+
+	roots := []*outlineSymbolResolver{}
+	parents := make(map[*outlineSymbolResolver]*outlineSymbolResolver, len(symbols))
+	for i, symbol := range symbols {
+		node := nodes[i]
+		node.symbol = toResolver(symbol)
+		if node.symbol == nil {
+			continue
+		}
+		var parent *outlineSymbolResolver
+		if symbol.Parent != "" {
+			parent = findParent(symbol.Parent)
+		}
+		// Parents matched by their last component can form a cycle (e.g. a
+		// symbol whose parent has the symbol's own name).
+		for p := parent; p != nil; p = parents[p] {
+			if p == node {
+				parent = nil
+				break
+			}
+		}
+		if parent != nil {
+			parents[node] = parent
+			parent.children = append(parent.children, node)
+		} else {
+			roots = append(roots, node)
+		}
+	}
+	return roots
+}
+
+// declarationRange returns the range from the name of symbol to the end of
+// its declaration. It is the range of the name if the end of the declaration
+// is not known. The end is converted to a character offset if it is on the
+// line of the symbol, and is left as a byte offset otherwise, since the other
+// lines are not known (declarations usually end with an ASCII character).
+func declarationRange(symbol protocol.Symbol) lsp.Range {
+	r := symbolNameRange(symbol)
+	if symbol.EndLine != 0 {
+		end := symbol.EndCharacter
+		if symbol.EndLine == symbol.Line {
+			end = byteToCharacterOffset(symbolPatternLine(symbol), end)
+		}
+		r.End = lsp.Position{Line: symbol.EndLine - 1, Character: end}
+	}
+	return r
+}
+
+type outlineSymbolResolver struct {
+	symbol    *symbolResolver
+	declRange lsp.Range
+	children  []*outlineSymbolResolver
+}
+
+func (r *outlineSymbolResolver) Symbol() *symbolResolver { return r.symbol }
+
+func (r *outlineSymbolResolver) Range() *rangeResolver { return &rangeResolver{r.declRange} }
+
+func (r *outlineSymbolResolver) Children() []*outlineSymbolResolver { return r.children }
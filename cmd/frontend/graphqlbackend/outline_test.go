@@ -0,0 +1,76 @@
+package graphqlbackend
+
+import (
+	"reflect"
+	"testing"
+
+	lsp "github.com/sourcegraph/go-lsp"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+)
+
+func TestBuildOutline(t *testing.T) {
+	symbols := []protocol.Symbol{
+		// A Go method declared before its type.
+		{Name: "M", Parent: "p.T", Line: 3, Character: 12, EndLine: 5, EndCharacter: 1},
+		{Name: "T", Line: 7, Character: 5, EndLine: 9, EndCharacter: 1},
+		{Name: "F", Parent: "p.T", Line: 8, Character: 1, EndLine: 8, EndCharacter: 6},
+		// The receiver type is declared in another file.
+		{Name: "N", Parent: "p.U", Line: 11, Character: 12, EndLine: 11, EndCharacter: 20},
+		// ctags symbols, whose parents are not qualified with a package.
+		{Name: "A", Line: 13, Pattern: "/^class A {$/"},
+		{Name: "B", Parent: "A", Line: 14, Pattern: "/^  class B {$/"},
+		{Name: "c", Parent: "A.B", Line: 15, Pattern: "/^    int c;$/"},
+		// A class qualified with its package, whose members are qualified
+		// only with the class.
+		{Name: "C", Parent: "com.example", Line: 17, Pattern: "/^class C {$/"},
+		{Name: "d", Parent: "C", Line: 18, Pattern: "/^  void d() {}$/"},
+		// A symbol whose parent has its own name.
+		{Name: "E", Parent: "E", Line: 20, Pattern: "/^E E;$/"},
+		// Byte offsets after non-ASCII characters.
+		{Name: "Größe", Line: 22, Character: 8, EndLine: 22, EndCharacter: 19, Pattern: "/^var ü, Größe int$/"},
+	}
+	outline := buildOutline(symbols, func(s protocol.Symbol) *symbolResolver {
+		return &symbolResolver{symbol: lsp.SymbolInformation{Name: s.Name}}
+	})
+
+	type node struct {
+		Name     string
+		Range    lsp.Range
+		Children []node
+	}
+	var toNodes func([]*outlineSymbolResolver) []node
+	toNodes = func(rs []*outlineSymbolResolver) []node {
+		var nodes []node
+		for _, r := range rs {
+			nodes = append(nodes, node{Name: r.Symbol().Name(), Range: r.Range().lspRange, Children: toNodes(r.Children())})
+		}
+		return nodes
+	}
+	rng := func(startLine, startCharacter, endLine, endCharacter int) lsp.Range {
+		return lsp.Range{
+			Start: lsp.Position{Line: startLine, Character: startCharacter},
+			End:   lsp.Position{Line: endLine, Character: endCharacter},
+		}
+	}
+
+	want := []node{
+		{Name: "T", Range: rng(6, 5, 8, 1), Children: []node{
+			{Name: "M", Range: rng(2, 12, 4, 1)},
+			{Name: "F", Range: rng(7, 1, 7, 6)},
+		}},
+		{Name: "N", Range: rng(10, 12, 10, 20)},
+		{Name: "A", Range: rng(12, 6, 12, 7), Children: []node{
+			{Name: "B", Range: rng(13, 8, 13, 9), Children: []node{
+				{Name: "c", Range: rng(14, 8, 14, 9)},
+			}},
+		}},
+		{Name: "C", Range: rng(16, 6, 16, 7), Children: []node{
+			{Name: "d", Range: rng(17, 7, 17, 8)},
+		}},
+		{Name: "E", Range: rng(19, 0, 19, 1)},
+		{Name: "Größe", Range: rng(21, 7, 21, 16)},
+	}
+	if got := toNodes(outline); !reflect.DeepEqual(got, want) {
+		t.Errorf("got\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package graphqlbackend
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/pkgdeps"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func (r *repositoryResolver) Packages(ctx context.Context) ([]*packageResolver, error) {
+	packages, err := db.Packages.ListByRepo(ctx, r.repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	resolvers := make([]*packageResolver, len(packages))
+	for i, p := range packages {
+		resolvers[i] = &packageResolver{pkg: p, repo: r.repo}
+	}
+	return resolvers, nil
+}
+
+// packageResolver resolves a package in the package dependency graph.
+type packageResolver struct {
+	pkg  pkgdeps.Package
+	repo *types.Repo // the repository that contains the package
+}
+
+func (r *packageResolver) Manager() string { return r.pkg.Manager }
+
+func (r *packageResolver) Name() string { return r.pkg.Name }
+
+func (r *packageResolver) Repository() *repositoryResolver {
+	return &repositoryResolver{repo: r.repo}
+}
+
+func (r *packageResolver) Dependents(args *struct{ First *int32 }) *repositoryDependencyConnectionResolver {
+	return &repositoryDependencyConnectionResolver{
+		list: func(ctx context.Context, opt *db.LimitOffset) ([]*types.Repo, error) {
+			return db.Packages.ListDependents(ctx, r.pkg, opt)
+		},
+		count: func(ctx context.Context) (int, error) {
+			return db.Packages.CountDependents(ctx, r.pkg)
+		},
+		first: args.First,
+	}
+}
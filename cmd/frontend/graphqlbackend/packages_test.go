@@ -0,0 +1,83 @@
+package graphqlbackend
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/pkgdeps"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestRepository_Packages(t *testing.T) {
+	resetMocks()
+	db.Mocks.Repos.MockGetByName(t, "github.com/stevemao/left-pad", 2)
+	leftPad := pkgdeps.Package{Manager: pkgdeps.NPM, Name: "left-pad"}
+	db.Mocks.Packages.ListByRepo = func(repoID api.RepoID) ([]pkgdeps.Package, error) {
+		if repoID != 2 {
+			t.Errorf("got repo %d, want 2", repoID)
+		}
+		return []pkgdeps.Package{leftPad}, nil
+	}
+	db.Mocks.Packages.ListDependents = func(p pkgdeps.Package, opt *db.LimitOffset) ([]*types.Repo, error) {
+		if p != leftPad || opt == nil || opt.Limit != 2 {
+			t.Errorf("got package %+v and %+v, want left-pad and limit 2", p, opt)
+		}
+		return []*types.Repo{{ID: 3, Name: "a"}}, nil
+	}
+	db.Mocks.Packages.CountDependents = func(p pkgdeps.Package) (int, error) {
+		return 1, nil
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					repository(name: "github.com/stevemao/left-pad") {
+						packages {
+							manager
+							name
+							repository {
+								name
+							}
+							dependents(first: 1) {
+								nodes {
+									name
+								}
+								totalCount
+								pageInfo {
+									hasNextPage
+								}
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"packages": [
+							{
+								"manager": "npm",
+								"name": "left-pad",
+								"repository": {
+									"name": "github.com/stevemao/left-pad"
+								},
+								"dependents": {
+									"nodes": [{"name": "a"}],
+									"totalCount": 1,
+									"pageInfo": {
+										"hasNextPage": false
+									}
+								}
+							}
+						]
+					}
+				}
+			`,
+		},
+	})
+}
@@ -0,0 +1,87 @@
+package graphqlbackend
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func (r *repositoryResolver) Dependents(args *struct{ First *int32 }) *repositoryDependencyConnectionResolver {
+	return &repositoryDependencyConnectionResolver{
+		list: func(ctx context.Context, opt *db.LimitOffset) ([]*types.Repo, error) {
+			return db.GoDependencies.ListDependents(ctx, r.repo.ID, opt)
+		},
+		count: func(ctx context.Context) (int, error) {
+			return db.GoDependencies.CountDependents(ctx, r.repo.ID)
+		},
+		first: args.First,
+	}
+}
+
+func (r *repositoryResolver) Dependencies(args *struct{ First *int32 }) *repositoryDependencyConnectionResolver {
+	return &repositoryDependencyConnectionResolver{
+		list: func(ctx context.Context, opt *db.LimitOffset) ([]*types.Repo, error) {
+			return db.GoDependencies.ListDependencies(ctx, r.repo.ID, opt)
+		},
+		count: func(ctx context.Context) (int, error) {
+			return db.GoDependencies.CountDependencies(ctx, r.repo.ID)
+		},
+		first: args.First,
+	}
+}
+
+// repositoryDependencyConnectionResolver resolves the dependents or the
+// dependencies of a repository in the Go dependency graph, or the dependents
+// of a package in the package dependency graph.
+type repositoryDependencyConnectionResolver struct {
+	list  func(ctx context.Context, opt *db.LimitOffset) ([]*types.Repo, error)
+	count func(ctx context.Context) (int, error)
+	first *int32
+
+	// cache results because they are used by multiple fields
+	once  sync.Once
+	repos []*types.Repo
+	err   error
+}
+
+func (r *repositoryDependencyConnectionResolver) compute(ctx context.Context) ([]*types.Repo, error) {
+	r.once.Do(func() {
+		var opt *db.LimitOffset
+		if r.first != nil {
+			opt = &db.LimitOffset{Limit: int(*r.first) + 1} // +1 to determine whether there is a next page
+		}
+		r.repos, r.err = r.list(ctx, opt)
+	})
+	return r.repos, r.err
+}
+
+func (r *repositoryDependencyConnectionResolver) Nodes(ctx context.Context) ([]*repositoryResolver, error) {
+	repos, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.first != nil && len(repos) > int(*r.first) {
+		repos = repos[:*r.first]
+	}
+	resolvers := make([]*repositoryResolver, len(repos))
+	for i, repo := range repos {
+		resolvers[i] = &repositoryResolver{repo: repo}
+	}
+	return resolvers, nil
+}
+
+func (r *repositoryDependencyConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := r.count(ctx)
+	return int32(count), err
+}
+
+func (r *repositoryDependencyConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	repos, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.first != nil && len(repos) > int(*r.first)), nil
+}
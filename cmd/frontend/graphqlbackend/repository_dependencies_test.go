@@ -0,0 +1,59 @@
+package graphqlbackend
+
+import (
+	"testing"
+
+	"github.com/graph-gophers/graphql-go/gqltesting"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestRepository_Dependents(t *testing.T) {
+	resetMocks()
+	db.Mocks.Repos.MockGetByName(t, "github.com/gorilla/mux", 2)
+	db.Mocks.GoDependencies.ListDependents = func(repoID api.RepoID, opt *db.LimitOffset) ([]*types.Repo, error) {
+		if repoID != 2 || opt == nil || opt.Limit != 2 {
+			t.Errorf("got repo %d and %+v, want repo 2 and limit 2", repoID, opt)
+		}
+		return []*types.Repo{{ID: 3, Name: "a"}, {ID: 4, Name: "b"}}, nil
+	}
+	db.Mocks.GoDependencies.CountDependents = func(repoID api.RepoID) (int, error) {
+		return 5, nil
+	}
+
+	gqltesting.RunTests(t, []*gqltesting.Test{
+		{
+			Schema: GraphQLSchema,
+			Query: `
+				{
+					repository(name: "github.com/gorilla/mux") {
+						dependents(first: 1) {
+							nodes {
+								name
+							}
+							totalCount
+							pageInfo {
+								hasNextPage
+							}
+						}
+					}
+				}
+			`,
+			ExpectedResult: `
+				{
+					"repository": {
+						"dependents": {
+							"nodes": [{"name": "a"}],
+							"totalCount": 5,
+							"pageInfo": {
+								"hasNextPage": true
+							}
+						}
+					}
+				}
+			`,
+		},
+	})
+}
@@ -536,6 +536,80 @@ type SettingsMutation {
     ): SavedQuery!
     # Delete the saved query with the given ID in the settings.
     deleteSavedQuery(id: ID!, disableSubscriptionNotifications: Boolean = false): EmptyResponse
+    # Creates a search context owned by a user or organization.
+    #
+    # Only the user, members of the organization, and site admins may perform this mutation.
+    createSearchContext(
+        # The ID of the user or organization that will own the search context.
+        namespace: ID!
+        # The search context.
+        input: SearchContextInput!
+    ): SearchContext!
+    # Updates a search context, replacing its repositories.
+    #
+    # Only the owning user, members of the owning organization, and site admins may perform this mutation.
+    updateSearchContext(id: ID!, input: SearchContextInput!): SearchContext!
+    # Deletes a search context.
+    #
+    # Only the owning user, members of the owning organization, and site admins may perform this mutation.
+    deleteSearchContext(id: ID!): EmptyResponse
+    # Sets the search context that the user's searches are restricted to when they don't specify a context:
+    # filter. If searchContext is null, the user's default search context is unset.
+    #
+    # Only the user and site admins may perform this mutation.
+    setDefaultSearchContext(user: ID!, searchContext: ID): EmptyResponse
+    # Deletes an entry from a user's search history.
+    #
+    # Only the user and site admins may perform this mutation.
+    deleteSearchHistoryEntry(id: ID!): EmptyResponse
+    # Deletes all of a user's search history.
+    #
+    # Only the user and site admins may perform this mutation.
+    clearSearchHistory(user: ID!): EmptyResponse
+    # Creates a search insight, which records the match count of a search query at past points in time. The
+    # data points are recorded in the background, newest first.
+    #
+    # Only the user and site admins may perform this mutation.
+    createSearchInsight(
+        # The user who owns the search insight.
+        user: ID!
+        # The title of the search insight.
+        title: String!
+        # The search query. Only queries for file contents or paths (type:file or type:path) are supported.
+        query: String!
+        # The number of days between two data points.
+        intervalDays: Int = 7
+        # The number of data points to record, going back from now.
+        numPoints: Int = 52
+    ): SearchInsight!
+    # Deletes a search insight and its data points.
+    #
+    # Only the owning user and site admins may perform this mutation.
+    deleteSearchInsight(id: ID!): EmptyResponse
+}
+
+# Input for creating or updating a search context.
+input SearchContextInput {
+    # The name of the search context, which must be unique among the search contexts of its owner.
+    name: String!
+    # The description of the search context.
+    description: String!
+    # Whether the search context is visible to all users. Otherwise it is only visible to its owner (the user or
+    # the members of the organization).
+    public: Boolean!
+    # The repositories (and revisions) in the search context.
+    repositories: [SearchContextRepositoryInput!]!
+    # Search queries consisting of repo: and -repo: filters. The repositories matching any of these queries are
+    # included in the search context, at their default branch.
+    repositoryQueries: [String!]!
+}
+
+# A repository (and revisions) in a search context.
+input SearchContextRepositoryInput {
+    # The ID of the repository.
+    repository: ID!
+    # The revisions to search. If empty, the repository's default branch is searched.
+    revisions: [String!]!
 }
 
 # An edit to a JSON property in a settings JSON object. The JSON property to edit can be nested.
@@ -772,6 +846,16 @@ type Query {
     savedQueries: [SavedQuery!]!
     # All repository groups for the current user, merged from all configurations.
     repoGroups: [RepoGroup!]!
+    # Lists the search contexts that the viewer may view.
+    searchContexts(
+        # Returns the first n search contexts from the list.
+        first: Int
+        # When present, lists only the search contexts owned by this user or organization.
+        namespace: ID
+    ): SearchContextConnection!
+    # Looks up a search context by its spec (such as "@alice/work"). Returns null if there is no such search
+    # context or the viewer may not view it.
+    searchContextBySpec(spec: String!): SearchContext
     # The current site.
     site: Site!
     # Retrieve responses to surveys.
@@ -810,17 +894,117 @@ type ParentSourcegraphDetails {
 # A search.
 type Search {
     # The results.
-    results: SearchResults!
+    #
+    # If the search times out, the results found before the timeout are returned and
+    # SearchResults.progress describes which repositories remain to be searched. To resume the
+    # search in those repositories, pass SearchProgress.resumeCursor as the after argument of the
+    # same search query.
+    results(
+        # Resume a search that timed out (SearchProgress.resumeCursor).
+        after: String
+    ): SearchResults!
     # The suggestions.
     suggestions(first: Int): [SearchSuggestion!]!
     # A subset of results (excluding actual search results) which are heavily
     # cached and thus quicker to query. Useful for e.g. querying sparkline
     # data.
     stats: SearchResultsStats!
+    # Counts the matches of the query in all repositories it searches, grouped by repository, file,
+    # language or commit author. The matches themselves are not returned, so all of them can be
+    # counted within the search timeout.
+    #
+    # Matches in files (type:file, the default, or type:path) can be grouped by repository, file or
+    # language. Matching commits (type:commit or type:diff) can be grouped by repository or author.
+    aggregate(
+        # How to group the matches.
+        by: SearchAggregationGroupBy!
+    ): SearchAggregation!
+    # Finds the files whose paths match a fuzzy query, like "Go to file" in editors. A path matches if
+    # it contains the characters of the query in order (ignoring case and spaces), and matches are
+    # ranked by how well the characters line up with the start of path components and words.
+    #
+    # The files of the first revision of each repository that the search query selects are searched
+    # (e.g., with "repo:^github\.com/foo/bar$@v1.0" or "repogroup:sample"). The search query's
+    # pattern and file filters are ignored.
+    fuzzyFiles(
+        # The fuzzy query.
+        query: String!
+        # Returns the first n matches (at most 500).
+        first: Int = 20
+    ): FuzzyFileMatches!
+}
+
+# How to group the matches of a search aggregation.
+enum SearchAggregationGroupBy {
+    # Group matches by repository.
+    REPO
+    # Group matches by file.
+    FILE
+    # Group matches by the language of their file.
+    LANGUAGE
+    # Group matching commits by author. Requires type:commit (the default) or type:diff.
+    AUTHOR
+}
+
+# The number of matches of a search query, grouped by repository, file, language or commit author.
+type SearchAggregation {
+    # The groups of matches, by decreasing number of matches.
+    groups: [SearchAggregationGroup!]!
+    # The total number of matches.
+    totalCount: Int!
+    # Whether all matches were counted. This is false if a match count limit was hit, or if some
+    # repositories could not be searched fully, e.g. because the search timed out or they are still
+    # being cloned.
+    complete: Boolean!
+    # Repositories that are busy cloning onto gitserver, whose matches were not counted.
+    cloning: [Repository!]!
+    # Repositories or commits that do not exist, whose matches were not counted.
+    missing: [Repository!]!
+    # Repositories or commits which we did not manage to search in time, whose matches were not
+    # (all) counted.
+    timedout: [Repository!]!
+}
+
+# A group of matches in a search aggregation.
+type SearchAggregationGroup {
+    # The label of the group: the name of the repository, the path of the file, the name of the
+    # language, or the name and email of the commit author.
+    label: String!
+    # The repository of the group when grouping by repository or file, or null otherwise.
+    repository: Repository
+    # The number of matches in the group. For commits, this is the number of matching commits.
+    count: Int!
+}
+
+# The result of a fuzzy file path search.
+type FuzzyFileMatches {
+    # The best matches, best first.
+    matches: [FuzzyFileMatch!]!
+    # Whether there were more matches than returned.
+    limitHit: Boolean!
+    # Repositories that are busy cloning onto gitserver, which were not searched.
+    cloning: [Repository!]!
+    # Repositories or commits that do not exist, which were not searched.
+    missing: [Repository!]!
+    # Repositories or commits which we did not manage to search in time.
+    timedout: [Repository!]!
+}
+
+# A file whose path matches a fuzzy file path query.
+type FuzzyFileMatch {
+    # The file.
+    file: GitBlob!
+    # The repository containing the file.
+    repository: Repository!
+    # The score of the match. Higher scores are better matches.
+    score: Float!
+    # The ranges of the path that match the characters of the query, as [offset, length] tuples
+    # measured in characters (not bytes).
+    pathMatchRanges: [[Int!]!]!
 }
 
 # A search result.
-union SearchResult = FileMatch | CommitSearchResult | Repository
+union SearchResult = FileMatch | CommitSearchResult | HistorySearchResult | Repository
 
 # An object representing a markdown string.
 type Markdown {
@@ -900,12 +1084,34 @@ type SearchResults {
     indexUnavailable: Boolean!
     # An alert message that should be displayed before any results.
     alert: SearchAlert
+    # The progress of the search, which tells whether the results are complete or whether the
+    # search timed out before all repositories were searched.
+    progress: SearchProgress!
     # The time it took to generate these results.
     elapsedMilliseconds: Int!
     # Dynamic filters generated by the search results
     dynamicFilters: [SearchFilter!]!
 }
 
+# The progress of a search. A search that times out returns the results found before the
+# timeout, and can be resumed to search the remaining repositories.
+type SearchProgress {
+    # Whether all repositories were searched. If false, the results are partial.
+    complete: Boolean!
+    # The number of repositories to search. For a resumed search, this is the number of
+    # repositories of the original search.
+    repositoriesTotal: Int!
+    # The number of repositories whose search finished (including those that could not be searched
+    # because they are cloning or missing). For a resumed search, this includes the repositories
+    # searched before the search was resumed.
+    repositoriesCompleted: Int!
+    # The repositories that were not (fully) searched before the timeout.
+    repositoriesRemaining: [Repository!]!
+    # An opaque cursor that resumes the search in the remaining repositories (see Search.results),
+    # or null if the search is complete.
+    resumeCursor: String
+}
+
 # Statistics about search results.
 type SearchResultsStats {
     # The approximate number of results returned.
@@ -929,7 +1135,82 @@ type SearchFilter {
 }
 
 # A search suggestion.
-union SearchSuggestion = Repository | File | Symbol
+union SearchSuggestion = Repository | File | Symbol | SearchHistoryEntry
+
+# An entry in a user's search history: a search query that the user ran.
+type SearchHistoryEntry implements Node {
+    # The unique ID for the search history entry.
+    id: ID!
+    # The user who ran the search.
+    user: User!
+    # The search query.
+    query: String!
+    # The number of results of the search.
+    resultCount: Int!
+    # How long the search took, in milliseconds.
+    durationMilliseconds: Int!
+    # Whether the search timed out (and its results were incomplete).
+    timedOut: Boolean!
+    # The date when the search was run.
+    createdAt: String!
+}
+
+# A list of search history entries.
+type SearchHistoryEntryConnection {
+    # A list of search history entries.
+    nodes: [SearchHistoryEntry!]!
+    # The total count of search history entries in the connection. This total count may be larger than the number
+    # of nodes in this object when the result is paginated.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
+# A search query whose match count is recorded at past points in time, to chart how it changes over time
+# (e.g., the usage of a deprecated API).
+type SearchInsight implements Node {
+    # The unique ID for the search insight.
+    id: ID!
+    # The user who owns the search insight.
+    user: User!
+    # The title of the search insight.
+    title: String!
+    # The search query.
+    query: String!
+    # The number of days between two data points.
+    intervalDays: Int!
+    # The number of data points to record, going back from now.
+    numPoints: Int!
+    # The data points that have been recorded, oldest first. Data points that have not been recorded yet are
+    # omitted.
+    dataPoints: [SearchInsightDataPoint!]!
+    # The date when the search insight was created.
+    createdAt: String!
+}
+
+# The match count of a search insight's query at a point in time.
+type SearchInsightDataPoint {
+    # The point in time. In each repository, the last commit before this time was searched.
+    time: String!
+    # The number of matches in all repositories.
+    count: Int!
+    # The number of repositories with matches.
+    repositoryCount: Int!
+    # Whether some repositories could not be searched fully (e.g. because the search timed out or
+    # they are still being cloned), so not all matches were counted.
+    partial: Boolean!
+}
+
+# A list of search insights.
+type SearchInsightConnection {
+    # A list of search insights.
+    nodes: [SearchInsight!]!
+    # The total count of search insights in the connection. This total count may be larger than the number of
+    # nodes in this object when the result is paginated.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
 
 # A search-related alert message.
 type SearchAlert {
@@ -980,6 +1261,55 @@ type RepoGroup {
     repositories: [String!]!
 }
 
+# A named set of repositories (and revisions) that searches can be restricted to with the context: filter.
+type SearchContext implements Node {
+    # The unique ID for the search context.
+    id: ID!
+    # The name of the search context.
+    name: String!
+    # The description of the search context.
+    description: String!
+    # The value of the context: filter that restricts a search to this search context (such as "@alice/work").
+    spec: String!
+    # The user or organization that owns the search context.
+    namespace: Namespace!
+    # Whether the search context is visible to all users.
+    public: Boolean!
+    # The repositories (and revisions) in the search context, excluding those matched by its repository
+    # queries.
+    repositories: [SearchContextRepository!]!
+    # Search queries whose matching repositories are included in the search context.
+    repositoryQueries: [String!]!
+    # Whether the viewer may modify or delete the search context.
+    viewerCanAdminister: Boolean!
+    # The date when the search context was created.
+    createdAt: String!
+    # The date when the search context was last updated.
+    updatedAt: String!
+}
+
+# A repository in a search context.
+type SearchContextRepository {
+    # The repository.
+    repository: Repository!
+    # The revisions of the repository to search. The empty string denotes the default branch.
+    revisions: [String!]!
+}
+
+# A list of search contexts.
+type SearchContextConnection {
+    # A list of search contexts.
+    nodes: [SearchContext!]!
+    # The total count of search contexts in the connection. This total count may be larger than the number of
+    # nodes in this object when the result is paginated.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
+# A user or organization that owns an entity.
+union Namespace = User | Org
+
 # A diff between two diffable Git objects.
 type Diff {
     # The diff's repository.
@@ -1012,6 +1342,35 @@ type CommitSearchResult implements GenericSearchResultInterface {
     diffPreview: HighlightedString
 }
 
+# A search result of a type:history search: a file whose content matched the search query at some
+# commit in the history, even if the matching lines were later removed or the file was deleted.
+type HistorySearchResult implements GenericSearchResultInterface {
+    # Base64 data uri to an icon.
+    icon: String!
+    # A markdown string that is rendered prominently.
+    label: Markdown!
+    # The URL of the file at the last revision where it matched.
+    url: String!
+    # A markdown string of that is rendered less prominently.
+    detail: Markdown!
+    # The matching lines of the file when they were introduced and at the last revision where it matched.
+    matches: [SearchResultMatch!]!
+    # The repository containing the file.
+    repository: Repository!
+    # The path of the file.
+    path: String!
+    # The oldest commit that added lines matching the search query to the file.
+    introducingCommit: GitCommit!
+    # The matching lines of the file at the introducing commit.
+    introducedMatches: [LineMatch!]!
+    # The commit that removed the last lines matching the search query from the file (or deleted the
+    # file), or null if the file still matches.
+    removingCommit: GitCommit
+    # The matching lines of the file at the last revision where it matched (the parent of the removing
+    # commit, or the newest commit that changed matching lines if the file still matches).
+    lastMatches: [LineMatch!]!
+}
+
 # A search result that is a diff between two diffable Git objects.
 type DiffSearchResult {
     # The diff that matched the search query.
@@ -1196,6 +1555,24 @@ type Repository implements Node & GenericSearchResultInterface {
         # Returns the first n contributors from the list.
         first: Int
     ): RepositoryContributorConnection!
+    # The repositories whose Go code imports the packages of this repository, according to the Go dependency graph
+    # of the repositories on this site. The graph is built by parsing the Go import statements and go.mod files of
+    # the default branch of all repositories, and requires the goDependencyGraph experimental feature.
+    dependents(
+        # Returns the first n repositories from the list.
+        first: Int
+    ): RepositoryDependencyConnection!
+    # The repositories whose packages the Go code of this repository imports, according to the Go dependency graph
+    # (see dependents).
+    dependencies(
+        # Returns the first n repositories from the list.
+        first: Int
+    ): RepositoryDependencyConnection!
+    # The npm, Maven and pip packages that this repository contains, according to the package dependency graph of
+    # the repositories on this site. The graph is built by parsing the package.json, pom.xml, requirements.txt and
+    # setup.py files of the default branch of all repositories, and requires the packageDependencyGraph experimental
+    # feature.
+    packages: [Package!]!
     # Link to another Sourcegraph instance location where this repository is located.
     redirectURL: String
     # Whether the viewer has admin privileges on this repository.
@@ -1435,6 +1812,33 @@ type RepositoryContributorConnection {
     pageInfo: PageInfo!
 }
 
+# A list of repositories that depend on or are dependencies of a repository, or that depend on a package.
+type RepositoryDependencyConnection {
+    # A list of repositories.
+    nodes: [Repository!]!
+    # The total count of repositories in the connection. This total count may be larger than the number of nodes
+    # in this object when the result is paginated.
+    totalCount: Int!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
+# A package of a package manager (npm, Maven or pip) in the package dependency graph.
+type Package {
+    # The package manager of the package: "npm", "maven" or "pip".
+    manager: String!
+    # The name of the package. Maven package names are "groupId:artifactId", and pip package names are normalized
+    # to lowercase with runs of "-", "_" and "." replaced by "-".
+    name: String!
+    # The repository that contains the package.
+    repository: Repository!
+    # The repositories whose package manifests depend on the package.
+    dependents(
+        # Returns the first n repositories from the list.
+        first: Int
+    ): RepositoryDependencyConnection!
+}
+
 # A contributor to a repository.
 type RepositoryContributor {
     # The personal information for the contributor.
@@ -1472,6 +1876,18 @@ type Symbol {
     canonicalURL: String!
 }
 
+# A symbol in the outline of a file, with the symbols declared inside it.
+type OutlineSymbol {
+    # The symbol. Its location is the range of its name.
+    symbol: Symbol!
+    # The range from the name of the symbol to the end of its declaration. If the end of the declaration is
+    # not known (which depends on the language), this is the range of the name of the symbol.
+    range: Range!
+    # The symbols declared inside this symbol (e.g., the fields and methods of a class), in the order they are
+    # declared.
+    children: [OutlineSymbol!]!
+}
+
 # A location inside a resource (in a repository at a specific commit).
 type Location {
     # The file that this location refers to.
@@ -1484,6 +1900,26 @@ type Location {
     canonicalURL: String!
 }
 
+# A list of locations, such as the definitions of or references to a symbol.
+type LocationConnection {
+    # A list of locations.
+    nodes: [Location!]!
+    # Whether the locations were computed from an understanding of the code. If false, they were found by
+    # searching for the name of the symbol, so some may be wrong (e.g., another symbol with the same name) and
+    # some may be missing.
+    isPrecise: Boolean!
+    # Pagination information.
+    pageInfo: PageInfo!
+}
+
+# The hover of a symbol, such as its type and documentation.
+type Hover {
+    # The contents of the hover.
+    markdown: Markdown!
+    # The range of the symbol that the hover is for.
+    range: Range!
+}
+
 # A range inside a file. The start position is inclusive, and the end position is exclusive.
 type Range {
     # The start position of the range (inclusive).
@@ -1965,6 +2401,40 @@ type GitBlob implements TreeEntry & File2 {
         # Return symbols matching the query.
         query: String
     ): SymbolConnection!
+    # The symbols declared at the top level of this blob, in the order they are declared, with the symbols
+    # declared inside them as children. Only this blob is parsed if the symbols of its commit have not been
+    # computed yet, so this is faster than symbols for showing the outline of a single file.
+    outline: [OutlineSymbol!]!
+    # The definitions of the symbol at the position in this blob. They are precise if an LSIF dump was uploaded
+    # for this commit (or for an ancestor commit, if this blob has not changed since). Otherwise they are found
+    # by searching for symbols named like the token at the position, in this repository first and then in other
+    # repositories (if global symbol search is enabled), so they are imprecise (see LocationConnection.isPrecise).
+    definitions(
+        # The line number (zero-based) of the position.
+        line: Int!
+        # The character offset (zero-based) in the line of the position.
+        character: Int!
+    ): LocationConnection!
+    # The references to the symbol at the position in this blob. They are precise if an LSIF dump was uploaded
+    # for this commit (or for an ancestor commit, if this blob has not changed since). Otherwise they are found by
+    # searching this repository for the token at the position as a whole word, so they are imprecise (see
+    # LocationConnection.isPrecise).
+    references(
+        # The line number (zero-based) of the position.
+        line: Int!
+        # The character offset (zero-based) in the line of the position.
+        character: Int!
+        # Returns the first n references.
+        first: Int
+    ): LocationConnection!
+    # The hover of the symbol at the position in this blob, or null if there is none. Hovers are only available
+    # from an uploaded LSIF dump (as for definitions).
+    hover(
+        # The line number (zero-based) of the position.
+        line: Int!
+        # The character offset (zero-based) in the line of the position.
+        character: Int!
+    ): Hover
     # Always false, since a blob is a file, not directory.
     isSingleChild(
         # Returns the first n files in the tree.
@@ -2000,8 +2470,40 @@ type FileMatch {
     symbols: [Symbol!]!
     # The line matches.
     lineMatches: [LineMatch!]!
+    # When searching multiple revisions of the repository (e.g. with the ref glob in
+    # repo:foo@*refs/heads/*), the refs whose trees contain this file with the same contents.
+    # Empty otherwise. Only text searches (of file contents and paths) search multiple revisions
+    # this way, and at most 50 distinct commits are searched per repository (the search reports
+    # that the limit was hit if the revisions resolve to more). Symbol searches only search the
+    # first revision (the default branch if it is a ref glob), so their results have no source refs.
+    sourceRefs: [GitRef!]!
     # Whether or not the limit was hit.
     limitHit: Boolean!
+    # The relevance score of the file match, which determines the order of the search results, with an
+    # explanation of how it was computed. Null if the file match was not ranked (such as when the
+    # searchRanking experimental feature is disabled).
+    #
+    # This field is intended for debugging search ranking. Its value may change in future releases.
+    score: SearchResultScore
+}
+
+# The relevance score of a search result.
+type SearchResultScore {
+    # The score, which is the sum of the values of its components. Results with higher scores are ranked
+    # higher.
+    value: Float!
+    # The signals that contributed to the score.
+    components: [SearchResultScoreComponent!]!
+}
+
+# A signal that contributed to the relevance score of a search result.
+type SearchResultScoreComponent {
+    # The name of the signal (such as "symbolDefinition", "pathDepth" or "recency").
+    name: String!
+    # The value that the signal contributed to the score.
+    value: Float!
+    # A human-readable explanation of the value.
+    description: String!
 }
 
 # A line match.
@@ -2012,6 +2514,10 @@ type LineMatch {
     lineNumber: Int!
     # Tuples of [offset, length] measured in characters (not bytes).
     offsetAndLengths: [[Int!]!]!
+    # For results of a search over the diff between two revisions (e.g. repo:foo@main...feature/x), "+" if
+    # the line was added and "-" if the line was removed. For removed lines, lineNumber refers to the file
+    # in the base revision. Null for unchanged lines and for other searches.
+    diffOp: String
     # Whether or not the limit was hit.
     limitHit: Boolean!
 }
@@ -2119,6 +2625,11 @@ type User implements Node & SettingsSubject {
     # Only the currently authenticated user can access this field. Site admins are not able to access sessions for
     # other users.
     session: Session!
+    # The search context that the user's searches are restricted to when they don't specify a context: filter,
+    # or null if the user has none.
+    #
+    # Only the user and site admins can access this field.
+    defaultSearchContext: SearchContext
     # Whether the viewer has admin privileges on this user. The user has admin privileges on their own user, and
     # site admins have admin privileges on all users.
     viewerCanAdminister: Boolean!
@@ -2131,6 +2642,24 @@ type User implements Node & SettingsSubject {
     #
     # Only the user and site admins can access this field.
     surveyResponses: [SurveyResponse!]!
+    # The user's search history, most recent first.
+    #
+    # Only the user and site admins can access this field.
+    searchHistory(
+        # Returns the first n entries from the list.
+        first: Int
+        # Returns only entries whose query contains this string (case-insensitively).
+        query: String
+        # Returns only entries of searches that timed out.
+        timedOut: Boolean = false
+    ): SearchHistoryEntryConnection!
+    # The user's search insights, oldest first.
+    #
+    # Only the user and site admins can access this field.
+    searchInsights(
+        # Returns the first n search insights from the list.
+        first: Int
+    ): SearchInsightConnection!
     # The URL to view this user's customer information (for Sourcegraph.com site admins).
     #
     # Only Sourcegraph.com site admins may query this field.
@@ -2667,6 +3196,18 @@ type Site implements SettingsSubject {
         # Returns the first n access tokens from the list.
         first: Int
     ): AccessTokenConnection!
+    # The search history of all users on this site, most recent first. Searches that timed out can be listed to
+    # find slow queries.
+    #
+    # Only site admins can access this field.
+    searchHistory(
+        # Returns the first n entries from the list.
+        first: Int
+        # Returns only entries whose query contains this string (case-insensitively).
+        query: String
+        # Returns only entries of searches that timed out.
+        timedOut: Boolean = false
+    ): SearchHistoryEntryConnection!
     # A list of all authentication providers. This information is visible to all viewers and does not contain any
     # secret information.
     authProviders: AuthProviderConnection!
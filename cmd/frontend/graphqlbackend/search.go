@@ -11,7 +11,6 @@ import (
 	"strings"
 	"sync"
 
-	"github.com/pkg/errors"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/envvar"
@@ -54,10 +53,14 @@ func maxReposToSearch() int {
 func (r *schemaResolver) Search(args *struct {
 	Query string
 }) (interface {
-	Results(context.Context) (*searchResultsResolver, error)
+	Results(context.Context, *searchResultsArgs) (*searchResultsResolver, error)
 	Suggestions(context.Context, *searchSuggestionsArgs) ([]*searchSuggestionResolver, error)
 	//lint:ignore U1000 is used by graphql via reflection
 	Stats(context.Context) (*searchResultsStats, error)
+	//lint:ignore U1000 is used by graphql via reflection
+	Aggregate(context.Context, *searchAggregateArgs) (*searchAggregationResolver, error)
+	//lint:ignore U1000 is used by graphql via reflection
+	FuzzyFiles(context.Context, *searchFuzzyFilesArgs) (*fuzzyFileMatchesResolver, error)
 }, error) {
 	if strings.HasPrefix(args.Query, "!hier!") {
 		return newSearcherResolver(strings.TrimPrefix(args.Query, "!hier!"))
@@ -88,12 +91,24 @@ func asString(v *searchquerytypes.Value) string {
 type searchResolver struct {
 	query *query.Query // the parsed search query
 
+	// export is set for search results exports (see ExportSearchResults),
+	// which return all results.
+	export bool
+
 	// Cached resolveRepositories results.
 	reposMu                   sync.Mutex
 	repoRevs, missingRepoRevs []*search.RepositoryRevisions
 	repoResults               []*searchSuggestionResolver
 	repoOverLimit             bool
 	repoErr                   error
+
+	// repoPredicatesCommon records the repositories that were excluded
+	// because the repository predicates (repohasfile: etc.) could not be
+	// evaluated for them, such as repositories that are still being cloned.
+	repoPredicatesCommon searchResultsCommon
+
+	// cursor is set when resuming a search that timed out (see searchCursor).
+	cursor *searchCursor
 }
 
 // rawQuery returns the original query string input.
@@ -102,6 +117,9 @@ func (r *searchResolver) rawQuery() string {
 }
 
 func (r *searchResolver) countIsSet() bool {
+	if r.export {
+		return true
+	}
 	count, _ := r.query.StringValues(query.FieldCount)
 	max, _ := r.query.StringValues(query.FieldMax)
 	return len(count) > 0 || len(max) > 0
@@ -110,6 +128,9 @@ func (r *searchResolver) countIsSet() bool {
 const defaultMaxSearchResults = 30
 
 func (r *searchResolver) maxResults() int32 {
+	if r.export {
+		return maxExportResultsPerRepo
+	}
 	count, _ := r.query.StringValues(query.FieldCount)
 	if len(count) > 0 {
 		n, _ := strconv.Atoi(count[0])
@@ -228,6 +249,16 @@ func (r *searchResolver) resolveRepositories(ctx context.Context, effectiveRepoF
 	archivedStr, _ := r.query.StringValue(query.FieldArchived)
 	archived := parseYesNoOnly(archivedStr)
 
+	predicates, err := parseRepoPredicates(r.query)
+	if err != nil {
+		return nil, nil, nil, false, &badRequestError{err}
+	}
+
+	searchContextRepos, err := r.resolveSearchContext(ctx)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+
 	tr.LazyPrintf("resolveRepositories - start")
 	repoRevs, missingRepoRevs, repoResults, overLimit, err = resolveRepositories(ctx, resolveRepoOp{
 		repoFilters:      repoFilters,
@@ -237,16 +268,53 @@ func (r *searchResolver) resolveRepositories(ctx context.Context, effectiveRepoF
 		noForks:          fork == No || fork == False,
 		onlyArchived:     archived == Only || archived == True,
 		noArchived:       archived == No || archived == False,
+
+		searchContextRepos: searchContextRepos,
 	})
 	tr.LazyPrintf("resolveRepositories - done")
+
+	var predicatesCommon searchResultsCommon
+	if err == nil && predicates != nil {
+		// The repository list limit applies before the predicates are
+		// evaluated, since they are too expensive to evaluate for all repos.
+		tr.LazyPrintf("Repository predicates - start")
+		repoRevs, repoResults, predicatesCommon, err = filterByRepoPredicates(ctx, predicates, repoRevs, repoResults)
+		tr.LazyPrintf("Repository predicates - done")
+	}
+
 	if effectiveRepoFieldValues == nil {
 		r.repoRevs = repoRevs
 		r.missingRepoRevs = missingRepoRevs
 		r.repoResults = repoResults
 		r.repoOverLimit = overLimit
 		r.repoErr = err
+		r.repoPredicatesCommon = predicatesCommon
 	}
-	return repoRevs, missingRepoRevs, repoResults, overLimit, err
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	return repoRevs, missingRepoRevs, repoResults, overLimit, nil
+}
+
+// filterByRepoPredicates returns the repositories (and their suggestion
+// resolvers) that satisfy the predicates. The repositories that the
+// predicates could not be evaluated for are excluded and recorded in common.
+func filterByRepoPredicates(ctx context.Context, predicates *repoPredicates, repoRevs []*search.RepositoryRevisions, repoResults []*searchSuggestionResolver) ([]*search.RepositoryRevisions, []*searchSuggestionResolver, searchResultsCommon, error) {
+	filtered, common, err := predicates.filter(ctx, repoRevs)
+	if err != nil {
+		return nil, nil, searchResultsCommon{}, err
+	}
+	keep := make(map[api.RepoName]bool, len(filtered))
+	for _, repoRev := range filtered {
+		keep[repoRev.Repo.Name] = true
+	}
+	filteredResults := make([]*searchSuggestionResolver, 0, len(filtered))
+	for _, res := range repoResults {
+		if repo, _ := res.ToRepository(); keep[repo.repo.Name] {
+			filteredResults = append(filteredResults, res)
+		}
+	}
+	return filtered, filteredResults, common, nil
 }
 
 // a patternRevspec maps an include pattern to a list of revisions
@@ -350,6 +418,10 @@ type resolveRepoOp struct {
 	onlyForks        bool
 	noArchived       bool
 	onlyArchived     bool
+
+	// searchContextRepos are the repositories (and revisions) of the search
+	// context that the search is restricted to, or nil if there is none.
+	searchContextRepos []*db.SearchContextRepository
 }
 
 func resolveRepositories(ctx context.Context, op resolveRepoOp) (repoRevisions, missingRepoRevisions []*search.RepositoryRevisions, repoResolvers []*searchSuggestionResolver, overLimit bool, err error) {
@@ -390,12 +462,48 @@ func resolveRepositories(ctx context.Context, op resolveRepoOp) (repoRevisions,
 		}
 	}
 
+	// If the search is restricted to a search context, take the intersection
+	// of the search context's repositories and the set of repos specified with
+	// repo: (as for repo groups).
+	var searchContextPatternRevs []patternRevspec
+	if op.searchContextRepos != nil {
+		if len(op.searchContextRepos) == 0 {
+			return nil, nil, nil, false, nil
+		}
+		patterns := make([]string, 0, len(op.searchContextRepos))
+		for _, repo := range op.searchContextRepos {
+			pattern := "^" + regexp.QuoteMeta(string(repo.RepoName)) + "$"
+			patterns = append(patterns, pattern)
+
+			if len(repo.Revisions) == 1 && repo.Revisions[0] == "" {
+				continue // default branch
+			}
+			revs := make([]search.RevisionSpecifier, 0, len(repo.Revisions))
+			for _, rev := range repo.Revisions {
+				revs = append(revs, search.RevisionSpecifier{RevSpec: rev})
+			}
+			searchContextPatternRevs = append(searchContextPatternRevs, patternRevspec{
+				includePattern: regexp.MustCompile("(?i:" + pattern + ")"),
+				revs:           revs,
+			})
+		}
+		includePatterns = append(includePatterns, unionRegExps(patterns))
+
+		// Ensure we don't omit any repos explicitly included via the search context.
+		if len(patterns) > maxRepoListSize {
+			maxRepoListSize = len(patterns)
+		}
+	}
+
 	// note that this mutates the strings in includePatterns, stripping their
 	// revision specs, if they had any.
 	includePatternRevs, err := findPatternRevs(includePatterns)
 	if err != nil {
 		return nil, nil, nil, false, err
 	}
+	// The search context's revisions are intersected with those specified with
+	// repo:.
+	includePatternRevs = append(includePatternRevs, searchContextPatternRevs...)
 
 	tr.LazyPrintf("Repos.List - start")
 	repos, err := backend.Repos.List(ctx, db.ReposListOptions{
@@ -600,11 +708,16 @@ func (r *searchSuggestionResolver) ToSymbol() (*symbolResolver, bool) {
 	return res, ok
 }
 
+func (r *searchSuggestionResolver) ToSearchHistoryEntry() (*searchHistoryEntryResolver, bool) {
+	res, ok := r.result.(*searchHistoryEntryResolver)
+	return res, ok
+}
+
 // newSearchResultResolver returns a new searchResultResolver wrapping the
 // given result.
 //
-// A panic occurs if the type of result is not a *repositoryResolver or
-// *gitTreeEntryResolver.
+// A panic occurs if the type of result is not a *repositoryResolver,
+// *gitTreeEntryResolver, *symbolResolver or *searchHistoryEntryResolver.
 func newSearchResultResolver(result interface{}, score int) *searchSuggestionResolver {
 	switch r := result.(type) {
 	case *repositoryResolver:
@@ -616,6 +729,9 @@ func newSearchResultResolver(result interface{}, score int) *searchSuggestionRes
 	case *symbolResolver:
 		return &searchSuggestionResolver{result: r, score: score, length: len(r.symbol.Name + " " + r.symbol.ContainerName), label: r.symbol.Name + " " + r.symbol.ContainerName}
 
+	case *searchHistoryEntryResolver:
+		return &searchSuggestionResolver{result: r, score: score, length: len(r.entry.Query), label: r.entry.Query}
+
 	default:
 		panic("never here")
 	}
@@ -710,5 +826,3 @@ func handleRepoSearchResult(common *searchResultsCommon, repoRev search.Reposito
 	}
 	return nil
 }
-
-var errMultipleRevsNotSupported = errors.New("not yet supported: searching multiple revs in the same repo")
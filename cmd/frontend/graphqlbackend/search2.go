@@ -54,7 +54,7 @@ func newSearcherResolver(qStr string) (*searcherResolver, error) {
 	}, nil
 }
 
-func (r *searcherResolver) Results(ctx context.Context) (*searchResultsResolver, error) {
+func (r *searcherResolver) Results(ctx context.Context, args *searchResultsArgs) (*searchResultsResolver, error) {
 	sCtx := &searchContext{}
 	start := time.Now()
 
@@ -119,25 +119,20 @@ func (r *searcherResolver) Stats(ctx context.Context) (stats *searchResultsStats
 	return nil, errors.New("search stats not implemented")
 }
 
+func (r *searcherResolver) Aggregate(ctx context.Context, args *searchAggregateArgs) (*searchAggregationResolver, error) {
+	return nil, errors.New("search aggregation not implemented")
+}
+
+func (r *searcherResolver) FuzzyFiles(ctx context.Context, args *searchFuzzyFilesArgs) (*fuzzyFileMatchesResolver, error) {
+	return nil, errors.New("fuzzy file search not implemented")
+}
+
 func toSearchResultResolvers(ctx context.Context, sCtx *searchContext, r *search.Result) ([]*searchResultResolver, error) {
 	results := make([]*searchResultResolver, 0, len(r.Files))
 
 	for _, file := range r.Files {
 		fileLimitHit := false
-		lines := make([]*lineMatch, 0, len(file.LineMatches))
-		for _, l := range file.LineMatches {
-			offsets := make([][2]int32, len(l.LineFragments))
-			for k, m := range l.LineFragments {
-				offset := utf8.RuneCount(l.Line[:m.LineOffset])
-				length := utf8.RuneCount(l.Line[m.LineOffset : m.LineOffset+m.MatchLength])
-				offsets[k] = [2]int32{int32(offset), int32(length)}
-			}
-			lines = append(lines, &lineMatch{
-				JPreview:          string(l.Line),
-				JLineNumber:       int32(l.LineNumber - 1),
-				JOffsetAndLengths: offsets,
-			})
-		}
+		lines := toLineMatches(file.LineMatches)
 
 		repo, err := sCtx.GetRepo(ctx, file.Repository.Name)
 		if err != nil {
@@ -159,6 +154,27 @@ func toSearchResultResolvers(ctx context.Context, sCtx *searchContext, r *search
 	return results, nil
 }
 
+// toLineMatches converts the line matches of a search.FileMatch, whose
+// offsets are in bytes, to line match resolvers, whose offsets are in
+// characters.
+func toLineMatches(lms []search.LineMatch) []*lineMatch {
+	lines := make([]*lineMatch, 0, len(lms))
+	for _, l := range lms {
+		offsets := make([][2]int32, len(l.LineFragments))
+		for k, m := range l.LineFragments {
+			offset := utf8.RuneCount(l.Line[:m.LineOffset])
+			length := utf8.RuneCount(l.Line[m.LineOffset : m.LineOffset+m.MatchLength])
+			offsets[k] = [2]int32{int32(offset), int32(length)}
+		}
+		lines = append(lines, &lineMatch{
+			JPreview:          string(l.Line),
+			JLineNumber:       int32(l.LineNumber - 1),
+			JOffsetAndLengths: offsets,
+		})
+	}
+	return lines
+}
+
 func toSearchResultsCommon(ctx context.Context, sCtx *searchContext, opts *search.Options, r *search.Result) (*searchResultsCommon, error) {
 	var (
 		repos    = map[api.RepoName]struct{}{}
@@ -373,6 +389,10 @@ type SearchProviders struct {
 
 	// Index is a search.Searcher for Zoekt.
 	Index *backend.Zoekt
+
+	// TextJIT is a search.Searcher for our searcher service replicas. It is
+	// the Fallback of Text.
+	TextJIT *backend.TextJIT
 }
 
 var (
@@ -405,25 +425,28 @@ func Search() *SearchProviders {
 			searcherURLs = endpoint.New(searcherURL)
 		}
 
-		text := &backend.Text{
-			Index: index,
-			Fallback: &backend.TextJIT{
-				Endpoints: searcherURLs,
-				Resolve: func(ctx context.Context, name api.RepoName, spec string) (api.CommitID, error) {
-					// Do not trigger a repo-updater lookup (e.g.,
-					// backend.{GitRepo,Repos.ResolveRev}) because that would
-					// slow this operation down by a lot (if we're looping
-					// over many repos). This means that it'll fail if a repo
-					// is not on gitserver.
-					return git.ResolveRevision(ctx, gitserver.Repo{Name: name}, nil, spec, &git.ResolveRevisionOptions{NoEnsureRevision: true})
-				},
+		textJIT := &backend.TextJIT{
+			Endpoints: searcherURLs,
+			Resolve: func(ctx context.Context, name api.RepoName, spec string) (api.CommitID, error) {
+				// Do not trigger a repo-updater lookup (e.g.,
+				// backend.{GitRepo,Repos.ResolveRev}) because that would
+				// slow this operation down by a lot (if we're looping
+				// over many repos). This means that it'll fail if a repo
+				// is not on gitserver.
+				return git.ResolveRevision(ctx, gitserver.Repo{Name: name}, nil, spec, &git.ResolveRevisionOptions{NoEnsureRevision: true})
 			},
+			IndexedBranch: conf.SearchIndexBranch,
+		}
+		text := &backend.Text{
+			Index:    index,
+			Fallback: textJIT,
 		}
 
 		searchP = &SearchProviders{
 			Text:         text,
 			SearcherURLs: searcherURLs,
 			Index:        index,
+			TextJIT:      textJIT,
 		}
 	})
 	return searchP
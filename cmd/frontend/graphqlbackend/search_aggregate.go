@@ -0,0 +1,317 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/inventory/filelang"
+	"github.com/sourcegraph/sourcegraph/pkg/trace"
+)
+
+// The values of the GraphQL enum SearchAggregationGroupBy.
+const (
+	aggregateByRepo     = "REPO"
+	aggregateByFile     = "FILE"
+	aggregateByLanguage = "LANGUAGE"
+	aggregateByAuthor   = "AUTHOR"
+)
+
+// maxCountOnlyCommitsPerRepo is the maximum number of commits that are
+// counted in each repository by commit and diff aggregations.
+const maxCountOnlyCommitsPerRepo = 10000
+
+// unknownLanguage is the label of the group of files whose language is not
+// known when aggregating by language.
+const unknownLanguage = "Unknown"
+
+var langsByFilename = filelang.Langs.CompileByFilename()
+
+type searchAggregateArgs struct {
+	By string
+}
+
+// Aggregate counts the matches of the search query, grouped by repository,
+// file, language or commit author. Unlike Results it does not return the
+// matches (and their previews), so the matches in every repository can be
+// counted instead of only the first results.
+func (r *searchResolver) Aggregate(ctx context.Context, args *searchAggregateArgs) (res *searchAggregationResolver, err error) {
+	tr, ctx := trace.New(ctx, "graphql.SearchAggregate", fmt.Sprintf("%s by %s", r.rawQuery(), args.By))
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	resultType, err := r.aggregateResultType(args.By)
+	if err != nil {
+		return nil, &badRequestError{err}
+	}
+
+	ctx, cancel, err := r.withTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	repos, missingRepoRevs, _, overLimit, err := r.resolveRepositories(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if overLimit {
+		return nil, &badRequestError{fmt.Errorf("too many matching repositories to aggregate (the limit is %d), use repo: to narrow the query", maxReposToSearch())}
+	}
+	tr.LazyPrintf("aggregating %d repos, %d missing", len(repos), len(missingRepoRevs))
+
+	p, err := r.countOnlyPatternInfo(resultType)
+	if err != nil {
+		return nil, err
+	}
+	if p.BooleanPattern != nil && len(booleanUnsupportedResultTypes([]string{resultType})) > 0 {
+		return nil, &badRequestError{fmt.Errorf("AND, OR and NOT are not supported for type:%s", resultType)}
+	}
+	searchArgs := &search.Args{
+		Pattern: p,
+		Repos:   repos,
+		Query:   r.query,
+		// Counts are only exact if every repository is searched fully, so
+		// give all searches the full timeout.
+		UseFullDeadline: true,
+	}
+
+	agg := newSearchAggregator(args.By)
+	var common *searchResultsCommon
+	switch resultType {
+	case "file", "path":
+		var fileMatches []*fileMatchResolver
+		fileMatches, common, err = searchFilesInRepos(ctx, searchArgs)
+		for _, fm := range fileMatches {
+			agg.addFileMatch(fm)
+		}
+	case "commit", "diff":
+		var results []*searchResultResolver
+		if resultType == "commit" {
+			results, common, err = searchCommitLogInRepos(ctx, searchArgs)
+		} else {
+			results, common, err = searchCommitDiffsInRepos(ctx, searchArgs)
+		}
+		for _, result := range results {
+			agg.addCommit(result.diff)
+		}
+	}
+	// Timeouts are reported through complete, so don't report an error for
+	// them.
+	if err != nil && !isContextError(ctx, err) {
+		return nil, err
+	}
+	if common == nil {
+		common = &searchResultsCommon{}
+	}
+	common.update(r.repoPredicatesCommon)
+	for _, repoRev := range missingRepoRevs {
+		common.missing = append(common.missing, repoRev.Repo)
+	}
+	return &searchAggregationResolver{
+		groups:              agg.sortedGroups(),
+		searchResultsCommon: *common,
+		complete:            err == nil && common.countedAll(),
+	}, nil
+}
+
+// countOnlyPatternInfo returns the pattern of a search that counts all matches
+// of the result type in the query.
+func (r *searchResolver) countOnlyPatternInfo(resultType string) (*search.PatternInfo, error) {
+	p, err := r.getPatternInfo(nil)
+	if err != nil {
+		return nil, err
+	}
+	p.CountOnly = true
+	p.FileMatchLimit = math.MaxInt32
+	switch resultType {
+	case "file":
+		p.PatternMatchesContent = true
+	case "path":
+		p.PatternMatchesPath = true
+	case "commit", "diff":
+		// Commit searches return full results, which are counted
+		// afterwards, so they are limited per repository. A repository
+		// with more matching commits is reported as incomplete (limitHit).
+		p.FileMatchLimit = maxCountOnlyCommitsPerRepo
+	}
+	if err := p.Validate(); err != nil {
+		return nil, &badRequestError{err}
+	}
+	return p, nil
+}
+
+// countedAll reports whether all matches of a count-only search were counted,
+// because every repository was searched fully.
+func (c *searchResultsCommon) countedAll() bool {
+	return !c.limitHit && len(c.partial) == 0 && len(c.timedout) == 0 && len(c.cloning) == 0 && len(c.missing) == 0 && !c.indexUnavailable
+}
+
+// uncountedRepos returns the names of the repositories whose matches were not
+// all counted by a count-only search (see countedAll).
+func (c *searchResultsCommon) uncountedRepos() map[api.RepoName]bool {
+	uncounted := make(map[api.RepoName]bool, len(c.partial))
+	for name := range c.partial {
+		uncounted[name] = true
+	}
+	for _, repos := range [][]*types.Repo{c.timedout, c.cloning, c.missing} {
+		for _, repo := range repos {
+			uncounted[repo.Name] = true
+		}
+	}
+	return uncounted
+}
+
+// aggregateResultType returns the type of results whose matches are counted
+// when aggregating by the given grouping. Matches in files (type:file or
+// type:path) can be grouped by repository, file or language. Matching commits
+// (type:commit or type:diff) can be grouped by repository or author.
+func (r *searchResolver) aggregateResultType(by string) (string, error) {
+	resultTypes, _ := r.query.StringValues(query.FieldType)
+	resultType := "file"
+	switch len(resultTypes) {
+	case 0:
+		if by == aggregateByAuthor {
+			resultType = "commit"
+		}
+	case 1:
+		resultType = resultTypes[0]
+	default:
+		return "", errors.New("aggregation does not support multiple type: filters")
+	}
+
+	switch resultType {
+	case "file", "path":
+		if by == aggregateByAuthor {
+			return "", errors.New("aggregation by author requires type:commit or type:diff")
+		}
+	case "commit", "diff":
+		if by == aggregateByFile || by == aggregateByLanguage {
+			return "", fmt.Errorf("aggregation of type:%s results by %s is not supported", resultType, strings.ToLower(by))
+		}
+	default:
+		return "", fmt.Errorf("aggregation of type:%s results is not supported", resultType)
+	}
+	return resultType, nil
+}
+
+// searchAggregator groups and counts search matches.
+type searchAggregator struct {
+	by     string // a SearchAggregationGroupBy value
+	groups map[string]*searchAggregationGroupResolver
+}
+
+func newSearchAggregator(by string) *searchAggregator {
+	return &searchAggregator{by: by, groups: map[string]*searchAggregationGroupResolver{}}
+}
+
+func (a *searchAggregator) add(key, label string, repo *types.Repo, count int32) {
+	g, ok := a.groups[key]
+	if !ok {
+		g = &searchAggregationGroupResolver{label: label, repo: repo}
+		a.groups[key] = g
+	}
+	g.count += count
+}
+
+// addFileMatch counts the matches of a file match from a search with
+// PatternInfo.CountOnly. A file whose path matches (but whose contents don't)
+// counts as one match.
+func (a *searchAggregator) addFileMatch(fm *fileMatchResolver) {
+	count := fm.JMatchCount
+	if count == 0 {
+		count = 1
+	}
+	switch a.by {
+	case aggregateByRepo:
+		a.add(string(fm.repo.Name), string(fm.repo.Name), fm.repo, count)
+	case aggregateByFile:
+		a.add(string(fm.repo.Name)+"/"+fm.JPath, fm.JPath, fm.repo, count)
+	case aggregateByLanguage:
+		lang := unknownLanguage
+		if langs := langsByFilename(path.Base(fm.JPath)); len(langs) > 0 {
+			lang = langs[0].Name
+		}
+		a.add(lang, lang, nil, count)
+	}
+}
+
+// addCommit counts a matching commit.
+func (a *searchAggregator) addCommit(c *commitSearchResultResolver) {
+	switch a.by {
+	case aggregateByRepo:
+		repo := c.commit.repo.repo
+		a.add(string(repo.Name), string(repo.Name), repo, 1)
+	case aggregateByAuthor:
+		person := c.commit.author.person
+		a.add(strings.ToLower(person.email), fmt.Sprintf("%s <%s>", person.name, person.email), nil, 1)
+	}
+}
+
+// sortedGroups returns the groups by decreasing match count (and then by
+// label).
+func (a *searchAggregator) sortedGroups() []*searchAggregationGroupResolver {
+	groups := make([]*searchAggregationGroupResolver, 0, len(a.groups))
+	for _, g := range a.groups {
+		groups = append(groups, g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].count != groups[j].count {
+			return groups[i].count > groups[j].count
+		}
+		if groups[i].label != groups[j].label {
+			return groups[i].label < groups[j].label
+		}
+		return groups[i].repo != nil && groups[j].repo != nil && groups[i].repo.Name < groups[j].repo.Name
+	})
+	return groups
+}
+
+// searchAggregationResolver is a resolver for the GraphQL type
+// `SearchAggregation`.
+type searchAggregationResolver struct {
+	groups []*searchAggregationGroupResolver
+	searchResultsCommon
+	complete bool
+}
+
+func (r *searchAggregationResolver) Groups() []*searchAggregationGroupResolver { return r.groups }
+
+func (r *searchAggregationResolver) TotalCount() int32 {
+	var total int32
+	for _, g := range r.groups {
+		total += g.count
+	}
+	return total
+}
+
+func (r *searchAggregationResolver) Complete() bool { return r.complete }
+
+// searchAggregationGroupResolver is a resolver for the GraphQL type
+// `SearchAggregationGroup`.
+type searchAggregationGroupResolver struct {
+	label string
+	repo  *types.Repo // or nil when grouping by language or author
+	count int32
+}
+
+func (r *searchAggregationGroupResolver) Label() string { return r.label }
+
+func (r *searchAggregationGroupResolver) Repository() *repositoryResolver {
+	if r.repo == nil {
+		return nil
+	}
+	return &repositoryResolver{repo: r.repo}
+}
+
+func (r *searchAggregationGroupResolver) Count() int32 { return r.count }
@@ -0,0 +1,127 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestSearchAggregate(t *testing.T) {
+	db.Mocks.Repos.List = func(_ context.Context, op db.ReposListOptions) ([]*types.Repo, error) {
+		return []*types.Repo{{Name: "a"}, {Name: "b"}}, nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	repoA, repoB := &types.Repo{Name: "a"}, &types.Repo{Name: "b"}
+	mockSearchFilesInRepos = func(args *search.Args) ([]*fileMatchResolver, *searchResultsCommon, error) {
+		if !args.Pattern.CountOnly || args.Pattern.FileMatchLimit != math.MaxInt32 || !args.UseFullDeadline {
+			t.Errorf("got pattern %+v, want an unlimited count-only search", args.Pattern)
+		}
+		return []*fileMatchResolver{
+			{repo: repoA, JPath: "x.go", JMatchCount: 3},
+			{repo: repoA, JPath: "y.go", JMatchCount: 2},
+			{repo: repoB, JPath: "x.go", JMatchCount: 4},
+			{repo: repoB, JPath: "x.zzz"}, // path match
+		}, &searchResultsCommon{}, nil
+	}
+	defer func() { mockSearchFilesInRepos = nil }()
+
+	mockSearchCommitLogInRepos = func(args *search.Args) ([]*searchResultResolver, *searchResultsCommon, error) {
+		if args.Pattern.FileMatchLimit != maxCountOnlyCommitsPerRepo {
+			t.Errorf("got commit limit %d, want %d", args.Pattern.FileMatchLimit, maxCountOnlyCommitsPerRepo)
+		}
+		commit := func(repo *types.Repo, name, email string) *searchResultResolver {
+			return &searchResultResolver{diff: &commitSearchResultResolver{commit: &gitCommitResolver{
+				repo:   &repositoryResolver{repo: repo},
+				author: signatureResolver{person: &personResolver{name: name, email: email}},
+			}}}
+		}
+		return []*searchResultResolver{
+			commit(repoA, "Alice", "alice@example.com"),
+			commit(repoB, "Alice", "Alice@example.com"),
+			commit(repoB, "Bob", "bob@example.com"),
+		}, &searchResultsCommon{timedout: []*types.Repo{repoB}}, nil
+	}
+	defer func() { mockSearchCommitLogInRepos = nil }()
+
+	tests := []struct {
+		query        string
+		by           string
+		wantGroups   []string
+		wantTotal    int32
+		wantComplete bool
+	}{
+		{query: "foo", by: aggregateByRepo, wantGroups: []string{"a:5", "b:5"}, wantTotal: 10, wantComplete: true},
+		{query: "foo", by: aggregateByFile, wantGroups: []string{"b:x.go:4", "a:x.go:3", "a:y.go:2", "b:x.zzz:1"}, wantTotal: 10, wantComplete: true},
+		{query: "foo", by: aggregateByLanguage, wantGroups: []string{"Go:9", "Unknown:1"}, wantTotal: 10, wantComplete: true},
+		{query: "foo", by: aggregateByAuthor, wantGroups: []string{"Alice <alice@example.com>:2", "Bob <bob@example.com>:1"}, wantTotal: 3},
+		{query: "type:commit foo", by: aggregateByRepo, wantGroups: []string{"b:2", "a:1"}, wantTotal: 3},
+	}
+	for _, test := range tests {
+		t.Run(test.query+" by "+test.by, func(t *testing.T) {
+			r, err := (&schemaResolver{}).Search(&struct{ Query string }{Query: test.query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			agg, err := r.Aggregate(context.Background(), &searchAggregateArgs{By: test.by})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var groups []string
+			for _, g := range agg.Groups() {
+				label := g.Label()
+				if repo := g.Repository(); repo != nil && test.by == aggregateByFile {
+					label = repo.Name() + ":" + label
+				}
+				groups = append(groups, fmt.Sprintf("%s:%d", label, g.Count()))
+			}
+			if !reflect.DeepEqual(groups, test.wantGroups) {
+				t.Errorf("got groups %q, want %q", groups, test.wantGroups)
+			}
+			if agg.TotalCount() != test.wantTotal {
+				t.Errorf("got total count %d, want %d", agg.TotalCount(), test.wantTotal)
+			}
+			if agg.Complete() != test.wantComplete {
+				t.Errorf("got complete %v, want %v", agg.Complete(), test.wantComplete)
+			}
+		})
+	}
+}
+
+func TestSearchAggregate_resultType(t *testing.T) {
+	tests := []struct {
+		query   string
+		by      string
+		want    string
+		wantErr bool
+	}{
+		{query: "foo", by: aggregateByRepo, want: "file"},
+		{query: "type:path foo", by: aggregateByLanguage, want: "path"},
+		{query: "foo", by: aggregateByAuthor, want: "commit"},
+		{query: "type:diff foo", by: aggregateByAuthor, want: "diff"},
+		{query: "type:diff foo", by: aggregateByRepo, want: "diff"},
+		{query: "type:file foo", by: aggregateByAuthor, wantErr: true},
+		{query: "type:commit foo", by: aggregateByFile, wantErr: true},
+		{query: "type:symbol foo", by: aggregateByRepo, wantErr: true},
+		{query: "type:file type:path foo", by: aggregateByRepo, wantErr: true},
+	}
+	for _, test := range tests {
+		r, err := (&schemaResolver{}).Search(&struct{ Query string }{Query: test.query})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := r.(*searchResolver).aggregateResultType(test.by)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q by %s: got err %v, want error %v", test.query, test.by, err, test.wantErr)
+		}
+		if got != test.want {
+			t.Errorf("%q by %s: got %q, want %q", test.query, test.by, got, test.want)
+		}
+	}
+}
@@ -45,6 +45,13 @@ func (r *searchResolver) alertForNoResolvedRepos(ctx context.Context) (*searchAl
 	fork, _ := r.query.StringValue(query.FieldFork)
 	onlyForks, noForks := fork == "only", fork == "no"
 
+	if contextSpec, _ := r.query.StringValue(query.FieldContext); contextSpec != "" && contextSpec != globalSearchContextSpec && len(repoFilters) == 0 && len(repoGroupFilters) == 0 {
+		return &searchAlert{
+			title:       fmt.Sprintf("Add repositories to context:%s to see results", contextSpec),
+			description: fmt.Sprintf("The search context %q contains no repositories.", contextSpec),
+		}, nil
+	}
+
 	// Handle repogroup-only scenarios.
 	if len(repoFilters) == 0 && len(repoGroupFilters) == 0 {
 		return &searchAlert{
@@ -297,7 +304,7 @@ outer:
 		newExpr := addQueryRegexpField(r.query, query.FieldRepo, repoParentPattern)
 		alert.proposedQueries = append(alert.proposedQueries, &searchQueryDescription{
 			description: "in repositories under " + repoParent + more,
-			query:       syntax.ExprString(newExpr),
+			query:       queryString(r.query, newExpr),
 		})
 	}
 	if len(alert.proposedQueries) == 0 || ctx.Err() == context.DeadlineExceeded {
@@ -315,7 +322,7 @@ outer:
 			newExpr := addQueryRegexpField(r.query, query.FieldRepo, "^"+regexp.QuoteMeta(pathToPropose)+"$")
 			alert.proposedQueries = append(alert.proposedQueries, &searchQueryDescription{
 				description: "in the repository " + strings.TrimPrefix(pathToPropose, "github.com/"),
-				query:       syntax.ExprString(newExpr),
+				query:       queryString(r.query, newExpr),
 			})
 		}
 	}
@@ -344,8 +351,37 @@ func (r *searchResolver) alertForMissingRepoRevs(missingRepoRevs []*search.Repos
 	}
 }
 
+// alertForUnsupportedBooleanQuery returns an alert for a query whose search
+// patterns are combined with AND, OR or NOT but which asks for result types
+// whose searches can't evaluate such a combination.
+func (r *searchResolver) alertForUnsupportedBooleanQuery(resultTypes []string) *searchAlert {
+	return &searchAlert{
+		title:       fmt.Sprintf("AND, OR and NOT are not supported for type:%s", strings.Join(resultTypes, ", type:")),
+		description: "Search patterns combined with AND, OR or NOT only match file contents, file paths and repository names. Remove the operators or search for a different type of result.",
+	}
+}
+
 func omitQueryFields(r *searchResolver, field string) string {
-	return syntax.ExprString(omitQueryExprWithField(r.query, field))
+	return queryString(r.query, omitQueryExprWithField(r.query, field))
+}
+
+// queryString returns the query string for expr, which are the expressions of
+// q with some fields added or omitted. The search patterns of a query using
+// AND, OR or NOT are kept combined as in q.
+func queryString(q *query.Query, expr []*syntax.Expr) string {
+	if q.Pattern == nil {
+		return syntax.ExprString(expr)
+	}
+	fields := make([]*syntax.Expr, 0, len(expr))
+	for _, e := range expr {
+		if e.Field != query.FieldDefault {
+			fields = append(fields, e)
+		}
+	}
+	if len(fields) == 0 {
+		return q.Pattern.String()
+	}
+	return syntax.ExprString(fields) + " " + q.Pattern.String()
 }
 
 func omitQueryExprWithField(query *query.Query, field string) []*syntax.Expr {
@@ -95,3 +95,28 @@ func TestAddQueryRegexpField(t *testing.T) {
 		})
 	}
 }
+
+func TestQueryString(t *testing.T) {
+	tests := []struct {
+		query     string
+		omitField string
+		want      string
+	}{
+		{query: "foo repo:p", omitField: "repo", want: "foo"},
+		{query: "foo repo:p file:x", omitField: "repo", want: "foo file:x"},
+		{query: "(a OR b) repo:p", omitField: "repo", want: "(a OR b)"},
+		{query: "repo:p file:x AND NOT a", omitField: "repo", want: "file:x NOT a"},
+		{query: "repo:p AND NOT file:x", omitField: "repo", want: "-file:x"},
+	}
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s, omit %s", test.query, test.omitField), func(t *testing.T) {
+			query, err := query.ParseAndCheck(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := queryString(query, omitQueryExprWithField(query, test.omitField)); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
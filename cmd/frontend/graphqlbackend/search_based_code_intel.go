@@ -0,0 +1,414 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	lsp "github.com/sourcegraph/go-lsp"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/gituri"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+)
+
+// Search-based code intelligence finds the definitions of and references to
+// the token at a position by searching for its name, when there is no LSIF
+// dump to compute them precisely (see lsifDocument). The results are
+// imprecise: a different symbol with the same name matches too, and uses of
+// the symbol under another name (e.g., an import alias) are missed.
+
+// maxSearchBasedDefinitions is the maximum number of definitions returned by
+// a search-based definitions request.
+const maxSearchBasedDefinitions = 20
+
+type positionArgs struct {
+	Line      int32
+	Character int32
+}
+
+func (r *gitTreeEntryResolver) Definitions(ctx context.Context, args *positionArgs) (*locationConnectionResolver, error) {
+	_, dump, rng, err := r.lsifRangeAt(ctx, args)
+	if err != nil {
+		return nil, err
+	}
+	if rng != nil && rng.DefinitionResult != "" {
+		locations, err := r.lsifLocations(ctx, dump, rng.DefinitionResult)
+		if err != nil {
+			return nil, err
+		}
+		return &locationConnectionResolver{locations: locations, first: maxSearchBasedDefinitions, precise: true}, nil
+	}
+
+	token, err := r.tokenAt(ctx, args)
+	if err != nil || token == "" {
+		return &locationConnectionResolver{first: maxSearchBasedDefinitions}, err
+	}
+
+	ctx, done := context.WithTimeout(ctx, 5*time.Second)
+	defer done()
+
+	// Definitions in the same repository are the most likely, so look for
+	// definitions in other repositories only if there are none.
+	locations, err := r.searchBasedDefinitionsInRepo(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if len(locations) == 0 {
+		locations, err = r.searchBasedDefinitionsInOtherRepos(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &locationConnectionResolver{locations: locations, first: maxSearchBasedDefinitions}, nil
+}
+
+// searchBasedDefinitionsInRepo returns the locations of the symbols named
+// token in the blob's repository at its commit, those closest to the blob
+// first.
+func (r *gitTreeEntryResolver) searchBasedDefinitionsInRepo(ctx context.Context, token string) ([]*locationResolver, error) {
+	symbols, err := backend.Symbols.ListTags(ctx, protocol.SearchArgs{
+		Repo:            r.commit.repo.repo.Name,
+		CommitID:        api.CommitID(r.commit.oid),
+		Query:           "^" + regexp.QuoteMeta(token) + "$",
+		IsRegExp:        true,
+		IsCaseSensitive: true,
+		IncludePatterns: sameLanguageIncludePatterns(r.path),
+		First:           maxSearchBasedDefinitions + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return closerPath(r.path, symbols[i].Path, symbols[j].Path)
+	})
+
+	baseURI, err := gituri.Parse("git://" + string(r.commit.repo.repo.Name) + "?" + string(r.commit.oid))
+	if err != nil {
+		return nil, err
+	}
+	var locations []*locationResolver
+	for _, symbol := range symbols {
+		if resolver := toSymbolResolver(symbolToLSPSymbolInformation(symbol, baseURI), strings.ToLower(symbol.Language), r.commit); resolver != nil {
+			locations = append(locations, resolver.location)
+		}
+	}
+	return locations, nil
+}
+
+// searchBasedDefinitionsInOtherRepos returns the locations of the symbols
+// named token in other repositories, exported symbols first. It uses the
+// global symbol index, so it returns nothing unless global symbol search is
+// enabled.
+//
+// Only the dependencies of the blob's repository (from the Go dependency
+// graph and the package manifests) are searched. As a last resort, if none of
+// its dependencies are known (e.g. because it was not indexed yet), all
+// repositories are searched.
+func (r *gitTreeEntryResolver) searchBasedDefinitionsInOtherRepos(ctx context.Context, token string) ([]*locationResolver, error) {
+	if !conf.GlobalSymbolSearchEnabled() {
+		return nil, nil
+	}
+	repoIDs, err := dependencyRepoIDs(ctx, r.commit.repo.repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := db.GlobalSymbols.Search(ctx, db.GlobalSymbolsSearchOptions{
+		Query:           "^" + regexp.QuoteMeta(token) + "$",
+		IsRegExp:        true,
+		IsCaseSensitive: true,
+		ExactName:       token,
+		IncludePatterns: sameLanguageIncludePatterns(r.path),
+		RepoIDs:         repoIDs,
+		Limit:           maxSearchBasedDefinitions + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	otherRepoMatches := matches[:0]
+	for _, m := range matches {
+		if m.RepoID != r.commit.repo.repo.ID {
+			otherRepoMatches = append(otherRepoMatches, m)
+		}
+	}
+	fileMatches, _, err := globalSymbolMatchesToFileMatches(otherRepoMatches)
+	if err != nil {
+		return nil, err
+	}
+	var locations []*locationResolver
+	for _, fileMatch := range fileMatches {
+		for _, symbol := range fileMatch.symbols {
+			locations = append(locations, symbol.location)
+		}
+	}
+	return locations, nil
+}
+
+// dependencyRepoIDs returns the IDs of the repositories that the repository
+// depends on, or nil if none are known.
+func dependencyRepoIDs(ctx context.Context, repoID api.RepoID) ([]api.RepoID, error) {
+	goDependencies, err := db.GoDependencies.ListDependencies(ctx, repoID, nil)
+	if err != nil {
+		return nil, err
+	}
+	packageDependencies, err := db.Packages.ListDependencyRepos(ctx, repoID, nil)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		repoIDs []api.RepoID
+		seen    = make(map[api.RepoID]bool)
+	)
+	for _, repo := range append(goDependencies, packageDependencies...) {
+		if !seen[repo.ID] {
+			seen[repo.ID] = true
+			repoIDs = append(repoIDs, repo.ID)
+		}
+	}
+	return repoIDs, nil
+}
+
+type referencesArgs struct {
+	positionArgs
+	First *int32
+}
+
+func (r *gitTreeEntryResolver) References(ctx context.Context, args *referencesArgs) (*locationConnectionResolver, error) {
+	first := limitOrDefault(args.First)
+	_, dump, rng, err := r.lsifRangeAt(ctx, &args.positionArgs)
+	if err != nil {
+		return nil, err
+	}
+	if rng != nil && rng.ReferenceResult != "" {
+		locations, err := r.lsifLocations(ctx, dump, rng.ReferenceResult)
+		if err != nil {
+			return nil, err
+		}
+		return &locationConnectionResolver{locations: locations, first: first, precise: true}, nil
+	}
+
+	token, err := r.tokenAt(ctx, &args.positionArgs)
+	if err != nil || token == "" {
+		return &locationConnectionResolver{first: first}, err
+	}
+
+	ctx, done := context.WithTimeout(ctx, defaultTimeout)
+	defer done()
+
+	// Find the references by searching for the token as a whole word in the
+	// files of the same language in the repository. The occurrences are then
+	// found in the matching lines, since the matches include the characters
+	// around the token (see referencesPattern).
+	q, err := query.ParseAndCheck("")
+	if err != nil {
+		return nil, err
+	}
+	fileMatches, _, err := searchFilesInRepos(ctx, &search.Args{
+		Pattern: &search.PatternInfo{
+			Pattern:                      referencesPattern(token),
+			IsRegExp:                     true,
+			IsCaseSensitive:              true,
+			FileMatchLimit:               int32(first + 1),
+			IncludePatterns:              sameLanguageIncludePatterns(r.path),
+			PathPatternsAreRegExps:       true,
+			PathPatternsAreCaseSensitive: true,
+			PatternMatchesContent:        true,
+		},
+		Repos: []*search.RepositoryRevisions{{
+			Repo: r.commit.repo.repo,
+			Revs: []search.RevisionSpecifier{{RevSpec: string(r.commit.oid)}},
+		}},
+		Query: q,
+	})
+	if err != nil && !isContextError(ctx, err) {
+		return nil, err
+	}
+
+	// References in the same file are the most relevant.
+	sort.SliceStable(fileMatches, func(i, j int) bool {
+		return closerPath(r.path, fileMatches[i].JPath, fileMatches[j].JPath)
+	})
+	var locations []*locationResolver
+	for _, fileMatch := range fileMatches {
+		entry := &gitTreeEntryResolver{
+			commit: r.commit,
+			path:   fileMatch.JPath,
+			stat:   createFileInfo(fileMatch.JPath, false),
+		}
+		for _, lm := range fileMatch.JLineMatches {
+			for _, start := range wordOccurrences(lm.JPreview, token) {
+				locations = append(locations, &locationResolver{
+					resource: entry,
+					lspRange: &lsp.Range{
+						Start: lsp.Position{Line: int(lm.JLineNumber), Character: start},
+						End:   lsp.Position{Line: int(lm.JLineNumber), Character: start + utf8.RuneCountInString(token)},
+					},
+				})
+			}
+		}
+	}
+	return &locationConnectionResolver{locations: locations, first: first}, nil
+}
+
+// referencesPattern returns a regular expression that matches token as a whole
+// word on a line. Unlike \b, the word boundaries are not limited to ASCII (see
+// isIdentifierRune), so they match the characters before and after token too.
+func referencesPattern(token string) string {
+	return `(?m:(?:[^\pL\pN_$\n]|^)` + regexp.QuoteMeta(token) + `(?:[^\pL\pN_$\n]|$))`
+}
+
+// wordOccurrences returns the character offsets of the occurrences of token
+// in line as a whole word.
+func wordOccurrences(line, token string) []int {
+	if token == "" {
+		return nil
+	}
+	var offsets []int
+	for i := 0; i <= len(line)-len(token); {
+		j := strings.Index(line[i:], token)
+		if j < 0 {
+			break
+		}
+		start, end := i+j, i+j+len(token)
+		before, _ := utf8.DecodeLastRuneInString(line[:start])
+		after, _ := utf8.DecodeRuneInString(line[end:])
+		if (start == 0 || !isIdentifierRune(before)) && (end == len(line) || !isIdentifierRune(after)) {
+			offsets = append(offsets, utf8.RuneCountInString(line[:start]))
+		}
+		_, size := utf8.DecodeRuneInString(line[start:])
+		i = start + size
+	}
+	return offsets
+}
+
+// tokenAt returns the identifier at the position in the blob, or "" if there
+// is none.
+func (r *gitTreeEntryResolver) tokenAt(ctx context.Context, args *positionArgs) (string, error) {
+	if args.Line < 0 || args.Character < 0 {
+		return "", errors.New("invalid position")
+	}
+	content, err := r.Content(ctx)
+	if err != nil {
+		return "", err
+	}
+	return tokenAtPosition(content, int(args.Line), int(args.Character)), nil
+}
+
+// tokenAtPosition returns the identifier that contains or ends at the
+// position (with a 0-based line and character offset in the line, like the
+// offsets of the locations that are returned), or "" if there is none.
+// Numbers are not identifiers.
+func tokenAtPosition(content string, line, character int) string {
+	lines := strings.SplitN(content, "\n", line+2)
+	if line >= len(lines) {
+		return ""
+	}
+	text := strings.TrimSuffix(lines[line], "\r")
+	offset := 0
+	for ; character > 0; character-- {
+		if offset == len(text) {
+			return ""
+		}
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		offset += size
+	}
+
+	start := offset
+	for start > 0 {
+		r, size := utf8.DecodeLastRuneInString(text[:start])
+		if !isIdentifierRune(r) {
+			break
+		}
+		start -= size
+	}
+	end := offset
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if !isIdentifierRune(r) {
+			break
+		}
+		end += size
+	}
+	token := text[start:end]
+	if first, _ := utf8.DecodeRuneInString(token); token == "" || unicode.IsNumber(first) {
+		return ""
+	}
+	return token
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsNumber(r)
+}
+
+// sameLanguageIncludePatterns returns the include patterns that match the
+// files with the same extension as the file at filePath, which are very
+// likely in the same language. Definitions and references in other languages
+// are almost always false matches.
+func sameLanguageIncludePatterns(filePath string) []string {
+	ext := path.Ext(filePath)
+	if ext == "" {
+		return nil
+	}
+	return []string{regexp.QuoteMeta(ext) + "$"}
+}
+
+// pathDistance returns the number of directories between the files at paths
+// a and b: 0 for the same file, 1 for files in the same directory, and so on.
+func pathDistance(a, b string) int {
+	if a == b {
+		return 0
+	}
+	as, bs := strings.Split(path.Dir(a), "/"), strings.Split(path.Dir(b), "/")
+	common := 0
+	for common < len(as) && common < len(bs) && as[common] == bs[common] {
+		common++
+	}
+	return 1 + (len(as) - common) + (len(bs) - common)
+}
+
+// closerPath reports whether the file at path a is closer to the file at from
+// than the file at path b, breaking ties by path.
+func closerPath(from, a, b string) bool {
+	if da, db := pathDistance(from, a), pathDistance(from, b); da != db {
+		return da < db
+	}
+	return a < b
+}
+
+// locationConnectionResolver resolves a list of locations, such as the
+// definitions of or references to a symbol.
+type locationConnectionResolver struct {
+	locations []*locationResolver
+	first     int
+
+	// precise is whether the locations are from an LSIF dump rather than
+	// found by searching for the name of the token.
+	precise bool
+}
+
+func (r *locationConnectionResolver) Nodes() []*locationResolver {
+	if len(r.locations) > r.first {
+		return r.locations[:r.first]
+	}
+	if r.locations == nil {
+		return []*locationResolver{}
+	}
+	return r.locations
+}
+
+func (r *locationConnectionResolver) IsPrecise() bool { return r.precise }
+
+func (r *locationConnectionResolver) PageInfo() *graphqlutil.PageInfo {
+	return graphqlutil.HasNextPage(len(r.locations) > r.first)
+}
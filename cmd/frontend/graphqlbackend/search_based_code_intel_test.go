@@ -0,0 +1,150 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestTokenAtPosition(t *testing.T) {
+	content := "package p\n\nfunc NewServer(opt *Options) *server_t {\r\n\treturn nil // 42 ünïcode\n}"
+	tests := []struct {
+		line, character int
+		want            string
+	}{
+		{line: 2, character: 5, want: "NewServer"},  // start of the token
+		{line: 2, character: 9, want: "NewServer"},  // inside the token
+		{line: 2, character: 14, want: "NewServer"}, // just after the token
+		{line: 2, character: 21, want: "Options"},
+		{line: 2, character: 32, want: "server_t"},
+		{line: 2, character: 40, want: ""}, // past the end of the line (excluding \r)
+		{line: 3, character: 0, want: ""},  // whitespace
+		{line: 3, character: 15, want: ""}, // number
+		{line: 3, character: 20, want: "ünïcode"},
+		{line: 3, character: 25, want: "ünïcode"}, // offsets are in characters, not bytes
+		{line: 3, character: 26, want: ""},
+		{line: 1, character: 0, want: ""}, // empty line
+		{line: 9, character: 0, want: ""}, // past the end of the file
+	}
+	for _, test := range tests {
+		if got := tokenAtPosition(content, test.line, test.character); got != test.want {
+			t.Errorf("%d:%d: got %q, want %q", test.line, test.character, got, test.want)
+		}
+	}
+}
+
+func TestCloserPath(t *testing.T) {
+	paths := []string{"z.go", "a/b/d/x.go", "a/c/x.go", "a/b/y.go", "a/b/x.go", "a/b/c/x.go"}
+	sort.Slice(paths, func(i, j int) bool { return closerPath("a/b/x.go", paths[i], paths[j]) })
+	want := []string{"a/b/x.go", "a/b/y.go", "a/b/c/x.go", "a/b/d/x.go", "a/c/x.go", "z.go"}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("got %q, want %q", paths, want)
+	}
+}
+
+func TestSameLanguageIncludePatterns(t *testing.T) {
+	if got, want := sameLanguageIncludePatterns("a/b.test.go"), []string{`\.go$`}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := sameLanguageIncludePatterns("Makefile"); got != nil {
+		t.Errorf("got %q, want none", got)
+	}
+}
+
+func TestReferencesPattern(t *testing.T) {
+	re := regexp.MustCompile(referencesPattern("größe"))
+	tests := map[string]bool{
+		"größe":        true,
+		"x := größe+1": true,
+		"(größe)":      true,
+		"großegröße":   false, // the boundaries are not limited to ASCII
+		"größeß":       false,
+		"größe2":       false,
+		"$größe":       false,
+		"_größe":       false,
+		"a\ngröße\nb":  true,
+	}
+	for line, want := range tests {
+		if got := re.MatchString(line); got != want {
+			t.Errorf("%q: got %v, want %v", line, got, want)
+		}
+	}
+}
+
+func TestWordOccurrences(t *testing.T) {
+	tests := []struct {
+		line, token string
+		want        []int
+	}{
+		{line: "f(a,a) + ab + ba", token: "a", want: []int{2, 4}},
+		{line: "ä := größe*größe // größer", token: "größe", want: []int{5, 11}},
+		{line: "größeß", token: "größe"},
+		{line: "", token: "a"},
+	}
+	for _, test := range tests {
+		if got := wordOccurrences(test.line, test.token); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q in %q: got %v, want %v", test.token, test.line, got, test.want)
+		}
+	}
+}
+
+func TestSearchBasedDefinitionsInOtherRepos(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		ExperimentalFeatures: &schema.ExperimentalFeatures{GlobalSymbolSearch: "enabled"},
+	}})
+	defer conf.Mock(nil)
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	var goDependencies, packageDependencies []*types.Repo
+	db.Mocks.GoDependencies.ListDependencies = func(repoID api.RepoID, opt *db.LimitOffset) ([]*types.Repo, error) {
+		return goDependencies, nil
+	}
+	db.Mocks.Packages.ListDependencyRepos = func(repoID api.RepoID, opt *db.LimitOffset) ([]*types.Repo, error) {
+		return packageDependencies, nil
+	}
+	var searchedRepoIDs []api.RepoID
+	db.Mocks.GlobalSymbols.Search = func(opt db.GlobalSymbolsSearchOptions) ([]*db.GlobalSymbolMatch, error) {
+		searchedRepoIDs = opt.RepoIDs
+		return []*db.GlobalSymbolMatch{
+			{GlobalSymbol: db.GlobalSymbol{RepoID: 1, Name: "F", Path: "a.go", Line: 1}, Repo: &types.Repo{ID: 1, Name: "a"}, Commit: "c1"},
+			{GlobalSymbol: db.GlobalSymbol{RepoID: 2, Name: "F", Path: "b.go", Line: 1}, Repo: &types.Repo{ID: 2, Name: "b"}, Commit: "c2"},
+		}, nil
+	}
+
+	r := &gitTreeEntryResolver{
+		commit: &gitCommitResolver{repo: &repositoryResolver{repo: &types.Repo{ID: 1, Name: "a"}}, oid: "c1"},
+		path:   "a.go",
+	}
+
+	// Only the dependencies are searched.
+	goDependencies = []*types.Repo{{ID: 2, Name: "b"}, {ID: 3, Name: "c"}}
+	packageDependencies = []*types.Repo{{ID: 3, Name: "c"}, {ID: 4, Name: "d"}}
+	locations, err := r.searchBasedDefinitionsInOtherRepos(context.Background(), "F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []api.RepoID{2, 3, 4}; !reflect.DeepEqual(searchedRepoIDs, want) {
+		t.Errorf("searched repositories %v, want %v", searchedRepoIDs, want)
+	}
+	// The symbols of the repository itself are omitted.
+	if len(locations) != 1 {
+		t.Errorf("got %d locations, want 1", len(locations))
+	}
+
+	// All repositories are searched if no dependencies are known.
+	goDependencies, packageDependencies = nil, nil
+	if _, err := r.searchBasedDefinitionsInOtherRepos(context.Background(), "F"); err != nil {
+		t.Fatal(err)
+	}
+	if searchedRepoIDs != nil {
+		t.Errorf("searched repositories %v, want all", searchedRepoIDs)
+	}
+}
@@ -138,35 +138,11 @@ func searchCommitsInRepo(ctx context.Context, op commitSearchOp) (results []*com
 		args = append(args, "--regexp-ignore-case")
 	}
 
-	for _, rev := range op.repoRevs.Revs {
-		switch {
-		case rev.RevSpec != "":
-			if strings.HasPrefix(rev.RevSpec, "-") {
-				// A revspec starting with "-" would be interpreted as a `git log` flag.
-				// It would not be a security vulnerability because the flags are checked
-				// against a whitelist, but it could cause unexpected errors by (e.g.)
-				// changing the format of `git log` to a format that our parser doesn't
-				// expect.
-				return nil, false, false, fmt.Errorf("invalid revspec: %q", rev.RevSpec)
-			}
-			args = append(args, rev.RevSpec)
-
-		case rev.RefGlob != "":
-			args = append(args, "--glob="+rev.RefGlob)
-
-		case rev.ExcludeRefGlob != "":
-			args = append(args, "--exclude="+rev.ExcludeRefGlob)
-		}
-	}
-
-	beforeValues, _ := op.query.StringValues(query.FieldBefore)
-	for _, s := range beforeValues {
-		args = append(args, "--until="+s)
-	}
-	afterValues, _ := op.query.StringValues(query.FieldAfter)
-	for _, s := range afterValues {
-		args = append(args, "--since="+s)
+	revArgs, err := commitLogRevisionArgs(op.repoRevs, op.query)
+	if err != nil {
+		return nil, false, false, err
 	}
+	args = append(args, revArgs...)
 
 	// Helper for adding git log flags --grep, --author, and --committer, which all behave similarly.
 	var hasSeenGrepLikeFields, hasSeenInvertedGrepLikeFields bool
@@ -310,6 +286,54 @@ func searchCommitsInRepo(ctx context.Context, op commitSearchOp) (results []*com
 	return results, limitHit, timedOut, nil
 }
 
+// commitLogRevisionArgs returns the `git log` args that select the commits of the
+// repository revisions, limited by the before: and after: fields of the query.
+func commitLogRevisionArgs(repoRevs search.RepositoryRevisions, q *query.Query) ([]string, error) {
+	var args []string
+
+	// --exclude only applies to the next --glob, so repeat the exclusions
+	// before each --glob.
+	var excludeArgs []string
+	for _, rev := range repoRevs.Revs {
+		if rev.ExcludeRefGlob != "" {
+			glob := rev.ExcludeRefGlob
+			if !strings.HasPrefix(glob, "refs/") {
+				// Unlike --glob, --exclude does not imply a leading "refs/".
+				glob = "refs/" + glob
+			}
+			excludeArgs = append(excludeArgs, "--exclude="+glob)
+		}
+	}
+	for _, rev := range repoRevs.Revs {
+		switch {
+		case rev.RevSpec != "":
+			if strings.HasPrefix(rev.RevSpec, "-") {
+				// A revspec starting with "-" would be interpreted as a `git log` flag.
+				// It would not be a security vulnerability because the flags are checked
+				// against a whitelist, but it could cause unexpected errors by (e.g.)
+				// changing the format of `git log` to a format that our parser doesn't
+				// expect.
+				return nil, fmt.Errorf("invalid revspec: %q", rev.RevSpec)
+			}
+			args = append(args, rev.RevSpec)
+
+		case rev.RefGlob != "":
+			args = append(args, excludeArgs...)
+			args = append(args, "--glob="+rev.RefGlob)
+		}
+	}
+
+	beforeValues, _ := q.StringValues(query.FieldBefore)
+	for _, s := range beforeValues {
+		args = append(args, "--until="+s)
+	}
+	afterValues, _ := q.StringValues(query.FieldAfter)
+	for _, s := range afterValues {
+		args = append(args, "--since="+s)
+	}
+	return args, nil
+}
+
 func cleanDiffPreview(highlights []*highlightedRange, rawDiffResult string) (string, []*highlightedRange) {
 	// A map of line number to number of lines that have been ignored before the particular line number.
 	var lineByCountIgnored = make(map[int]int32)
@@ -94,6 +94,41 @@ func (r *commitSearchResultResolver) String() string {
 	return fmt.Sprintf("{commit: %+v diffPreview: %+v messagePreview: %+v}", r.commit, r.diffPreview, r.messagePreview)
 }
 
+func TestSearchCommitsInRepo_refGlobs(t *testing.T) {
+	git.Mocks.RawLogDiffSearch = func(opt git.RawLogDiffSearchOptions) ([]*git.LogCommitSearchResult, bool, error) {
+		if want := []string{
+			"--no-prefix",
+			"--max-count=" + strconv.Itoa(defaultMaxSearchResults+1),
+			"--unified=0",
+			"--regexp-ignore-case",
+			"--exclude=refs/heads/wip-*",
+			"--glob=refs/heads/*",
+			"--exclude=refs/heads/wip-*",
+			"--glob=refs/tags/*",
+		}; !reflect.DeepEqual(opt.Args, want) {
+			t.Errorf("got %v, want %v", opt.Args, want)
+		}
+		return nil, true, nil
+	}
+	defer git.ResetMocks()
+
+	query, err := query.ParseAndCheck("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, repoRevs := search.ParseRepositoryRevisions("repo@*refs/heads/*:^heads/wip-*:*refs/tags/*")
+	_, _, _, err = searchCommitsInRepo(context.Background(), commitSearchOp{
+		repoRevs:          search.RepositoryRevisions{Repo: &types.Repo{ID: 1, Name: "repo"}, Revs: repoRevs},
+		info:              &search.PatternInfo{Pattern: "p", FileMatchLimit: int32(defaultMaxSearchResults)},
+		query:             query,
+		diff:              true,
+		textSearchOptions: git.TextSearchOptions{Pattern: "p"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestExpandUsernamesToEmails(t *testing.T) {
 	resetMocks()
 	db.Mocks.Users.GetByUsername = func(ctx context.Context, username string) (*types.User, error) {
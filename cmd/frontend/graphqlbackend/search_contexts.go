@@ -0,0 +1,614 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+)
+
+// globalSearchContextSpec is the spec of the implicit search context that
+// contains all repositories. Specifying it in a query (context:global)
+// overrides the user's default search context.
+const globalSearchContextSpec = "global"
+
+// parseSearchContextSpec parses a search context spec of the form
+// "@namespace/name", where namespace is the username of the user or the name
+// of the organization that owns the search context.
+func parseSearchContextSpec(spec string) (namespaceName, name string, err error) {
+	if !strings.HasPrefix(spec, "@") {
+		return "", "", fmt.Errorf("invalid search context %q (must be of the form @user/name or @org/name)", spec)
+	}
+	i := strings.Index(spec, "/")
+	if i == -1 || i == 1 || i == len(spec)-1 {
+		return "", "", fmt.Errorf("invalid search context %q (must be of the form @user/name or @org/name)", spec)
+	}
+	return spec[1:i], spec[i+1:], nil
+}
+
+// searchContextBySpec looks up the search context with the given spec.
+//
+// 🚨 SECURITY: A search context that the current user may not view is
+// reported as not found.
+func searchContextBySpec(ctx context.Context, spec string) (*db.SearchContext, error) {
+	namespaceName, name, err := parseSearchContextSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var namespaceUserID, namespaceOrgID int32
+	if user, err := db.Users.GetByUsername(ctx, namespaceName); err == nil {
+		namespaceUserID = user.ID
+	} else if !errcode.IsNotFound(err) {
+		return nil, err
+	} else if org, err := db.Orgs.GetByName(ctx, namespaceName); err == nil {
+		namespaceOrgID = org.ID
+	} else if _, ok := err.(*db.OrgNotFoundError); ok {
+		return nil, &searchContextNotFoundError{spec: spec}
+	} else {
+		return nil, err
+	}
+
+	sc, err := db.SearchContexts.GetByName(ctx, namespaceUserID, namespaceOrgID, name)
+	if errcode.IsNotFound(err) {
+		return nil, &searchContextNotFoundError{spec: spec}
+	} else if err != nil {
+		return nil, err
+	}
+	if ok, err := viewerCanReadSearchContext(ctx, sc); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, &searchContextNotFoundError{spec: spec}
+	}
+	return sc, nil
+}
+
+type searchContextNotFoundError struct {
+	spec string
+}
+
+func (e *searchContextNotFoundError) Error() string {
+	return fmt.Sprintf("search context %q not found", e.spec)
+}
+
+func (e *searchContextNotFoundError) NotFound() bool { return true }
+
+// searchContextVisibleToUser reports whether the user (or anonymous visitor,
+// if userID is 0) may view the search context. Public search contexts are
+// visible to everyone, and private search contexts are only visible to their
+// owner (the user or the members of the organization).
+func searchContextVisibleToUser(ctx context.Context, sc *db.SearchContext, userID int32) (bool, error) {
+	if sc.Public {
+		return true, nil
+	}
+	if userID == 0 {
+		return false, nil
+	}
+	if sc.NamespaceUserID != 0 {
+		return sc.NamespaceUserID == userID, nil
+	}
+	if _, err := db.OrgMembers.GetByOrgIDAndUserID(ctx, sc.NamespaceOrgID, userID); errcode.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// viewerCanReadSearchContext reports whether the current user may view the
+// search context. Site admins may view all search contexts.
+func viewerCanReadSearchContext(ctx context.Context, sc *db.SearchContext) (bool, error) {
+	if backend.CheckCurrentUserIsSiteAdmin(ctx) == nil {
+		return true, nil
+	}
+	return searchContextVisibleToUser(ctx, sc, actor.FromContext(ctx).UID)
+}
+
+// checkSearchContextNamespaceAccess returns an error if the current user may
+// not create or modify search contexts in the namespace of the user or
+// organization.
+func checkSearchContextNamespaceAccess(ctx context.Context, namespaceUserID, namespaceOrgID int32) error {
+	if namespaceUserID != 0 {
+		// 🚨 SECURITY: Only the user and site admins may modify the user's search contexts.
+		return backend.CheckSiteAdminOrSameUser(ctx, namespaceUserID)
+	}
+	// 🚨 SECURITY: Only org members and site admins may modify the org's search contexts.
+	return backend.CheckOrgAccess(ctx, namespaceOrgID)
+}
+
+// searchContextRepositories returns the repositories (and revisions) to
+// search for the search context. Repositories matching the search context's
+// repository queries are searched at their default branch, unless they are
+// also listed explicitly.
+func searchContextRepositories(ctx context.Context, sc *db.SearchContext) ([]*db.SearchContextRepository, error) {
+	repos, err := db.SearchContexts.ListRepositories(ctx, sc.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[api.RepoID]bool, len(repos))
+	for _, repo := range repos {
+		seen[repo.RepoID] = true
+	}
+	for _, q := range sc.RepositoryQueries {
+		includePatterns, excludePatterns, err := searchContextRepositoryQueryPatterns(q)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := backend.Repos.List(ctx, db.ReposListOptions{
+			IncludePatterns: includePatterns,
+			ExcludePattern:  unionRegExps(excludePatterns),
+			Enabled:         true,
+			LimitOffset:     &db.LimitOffset{Limit: maxReposToSearch()},
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, repo := range matches {
+			if !seen[repo.ID] {
+				seen[repo.ID] = true
+				repos = append(repos, &db.SearchContextRepository{RepoID: repo.ID, RepoName: repo.Name, Revisions: []string{""}})
+			}
+		}
+	}
+	return repos, nil
+}
+
+// searchContextRepositoryQueryPatterns returns the repo: and -repo: patterns
+// of a search context's repository query. Only those fields are permitted.
+func searchContextRepositoryQueryPatterns(q string) (includePatterns, excludePatterns []string, err error) {
+	parsed, err := query.ParseAndCheck(q)
+	if err != nil {
+		return nil, nil, err
+	}
+	for field := range parsed.Fields {
+		if field != query.FieldRepo {
+			return nil, nil, fmt.Errorf("invalid search context repository query %q (only repo: and -repo: filters are allowed)", q)
+		}
+	}
+	includePatterns, excludePatterns = parsed.RegexpPatterns(query.FieldRepo)
+	return includePatterns, excludePatterns, nil
+}
+
+// resolveSearchContext returns the repositories of the search context that
+// the query is restricted to: the one given by the context: field, or else the
+// current user's default search context. It returns nil if the query is not
+// restricted to a search context.
+func (r *searchResolver) resolveSearchContext(ctx context.Context) ([]*db.SearchContextRepository, error) {
+	spec, _ := r.query.StringValue(query.FieldContext)
+	if spec == globalSearchContextSpec {
+		return nil, nil
+	}
+
+	var sc *db.SearchContext
+	if spec != "" {
+		var err error
+		sc, err = searchContextBySpec(ctx, spec)
+		if err != nil {
+			return nil, &badRequestError{err}
+		}
+	} else if a := actor.FromContext(ctx); a.IsAuthenticated() {
+		var err error
+		sc, err = db.SearchContexts.GetDefault(ctx, a.UID)
+		if err != nil || sc == nil {
+			return nil, err
+		}
+		// 🚨 SECURITY: The user may have lost access to their default search
+		// context (e.g., by leaving the org that owns it).
+		if ok, err := searchContextVisibleToUser(ctx, sc, a.UID); err != nil || !ok {
+			return nil, err
+		}
+	} else {
+		return nil, nil
+	}
+
+	repos, err := searchContextRepositories(ctx, sc)
+	if err != nil {
+		return nil, err
+	}
+	if repos == nil {
+		// Distinguish an empty search context (which matches no repositories)
+		// from no search context.
+		repos = []*db.SearchContextRepository{}
+	}
+	return repos, nil
+}
+
+func (r *schemaResolver) SearchContextBySpec(ctx context.Context, args *struct{ Spec string }) (*searchContextResolver, error) {
+	sc, err := searchContextBySpec(ctx, args.Spec)
+	if errcode.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &searchContextResolver{sc: sc}, nil
+}
+
+func searchContextByID(ctx context.Context, id graphql.ID) (*searchContextResolver, error) {
+	searchContextID, err := unmarshalSearchContextID(id)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := db.SearchContexts.GetByID(ctx, searchContextID)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Only users who may view the search context may retrieve it.
+	if ok, err := viewerCanReadSearchContext(ctx, sc); err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, &searchContextNotFoundError{spec: string(id)}
+	}
+	return &searchContextResolver{sc: sc}, nil
+}
+
+func marshalSearchContextID(id int32) graphql.ID { return relay.MarshalID("SearchContext", id) }
+
+func unmarshalSearchContextID(id graphql.ID) (searchContextID int32, err error) {
+	err = relay.UnmarshalSpec(id, &searchContextID)
+	return
+}
+
+type searchContextInput struct {
+	Name              string
+	Description       string
+	Public            bool
+	Repositories      []*searchContextRepositoryInput
+	RepositoryQueries []string
+}
+
+type searchContextRepositoryInput struct {
+	Repository graphql.ID
+	Revisions  []string
+}
+
+// toDB validates the input and converts it to the search context fields and
+// repositories to store.
+func (input *searchContextInput) toDB(ctx context.Context, sc *db.SearchContext) ([]*db.SearchContextRepository, error) {
+	for _, q := range input.RepositoryQueries {
+		if _, _, err := searchContextRepositoryQueryPatterns(q); err != nil {
+			return nil, err
+		}
+	}
+	sc.Name = input.Name
+	sc.Description = input.Description
+	sc.Public = input.Public
+	sc.RepositoryQueries = input.RepositoryQueries
+
+	repos := make([]*db.SearchContextRepository, 0, len(input.Repositories))
+	for _, repoInput := range input.Repositories {
+		repoID, err := unmarshalRepositoryID(repoInput.Repository)
+		if err != nil {
+			return nil, err
+		}
+		// 🚨 SECURITY: Check that the current user may view the repository.
+		repo, err := backend.Repos.Get(ctx, repoID)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, &db.SearchContextRepository{RepoID: repo.ID, RepoName: repo.Name, Revisions: repoInput.Revisions})
+	}
+	return repos, nil
+}
+
+func (r *schemaResolver) CreateSearchContext(ctx context.Context, args *struct {
+	Namespace graphql.ID
+	Input     *searchContextInput
+}) (*searchContextResolver, error) {
+	var sc db.SearchContext
+	switch kind := relay.UnmarshalKind(args.Namespace); kind {
+	case "User":
+		if err := relay.UnmarshalSpec(args.Namespace, &sc.NamespaceUserID); err != nil {
+			return nil, err
+		}
+	case "Org":
+		if err := relay.UnmarshalSpec(args.Namespace, &sc.NamespaceOrgID); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("invalid search context namespace of type %q (must be a user or organization)", kind)
+	}
+	// 🚨 SECURITY: Check that the current user may create search contexts in the namespace.
+	if err := checkSearchContextNamespaceAccess(ctx, sc.NamespaceUserID, sc.NamespaceOrgID); err != nil {
+		return nil, err
+	}
+
+	repos, err := args.Input.toDB(ctx, &sc)
+	if err != nil {
+		return nil, err
+	}
+	created, err := db.SearchContexts.Create(ctx, &sc, repos)
+	if err != nil {
+		return nil, err
+	}
+	return &searchContextResolver{sc: created}, nil
+}
+
+// searchContextForUpdate looks up the search context with the given ID and
+// checks that the current user may modify it.
+func searchContextForUpdate(ctx context.Context, id graphql.ID) (*db.SearchContext, error) {
+	searchContextID, err := unmarshalSearchContextID(id)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := db.SearchContexts.GetByID(ctx, searchContextID)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Check that the current user may modify search contexts in the namespace.
+	if err := checkSearchContextNamespaceAccess(ctx, sc.NamespaceUserID, sc.NamespaceOrgID); err != nil {
+		return nil, err
+	}
+	return sc, nil
+}
+
+func (r *schemaResolver) UpdateSearchContext(ctx context.Context, args *struct {
+	ID    graphql.ID
+	Input *searchContextInput
+}) (*searchContextResolver, error) {
+	sc, err := searchContextForUpdate(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+	repos, err := args.Input.toDB(ctx, sc)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := db.SearchContexts.Update(ctx, sc, repos)
+	if err != nil {
+		return nil, err
+	}
+	return &searchContextResolver{sc: updated}, nil
+}
+
+func (r *schemaResolver) DeleteSearchContext(ctx context.Context, args *struct{ ID graphql.ID }) (*EmptyResponse, error) {
+	sc, err := searchContextForUpdate(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.SearchContexts.Delete(ctx, sc.ID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+func (r *schemaResolver) SetDefaultSearchContext(ctx context.Context, args *struct {
+	User          graphql.ID
+	SearchContext *graphql.ID
+}) (*EmptyResponse, error) {
+	userID, err := UnmarshalUserID(args.User)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Only the user and site admins may set the user's default search context.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	var searchContextID *int32
+	if args.SearchContext != nil {
+		id, err := unmarshalSearchContextID(*args.SearchContext)
+		if err != nil {
+			return nil, err
+		}
+		sc, err := db.SearchContexts.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		// 🚨 SECURITY: The user must be able to view their default search context.
+		if ok, err := searchContextVisibleToUser(ctx, sc, userID); err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, errors.New("the user does not have access to the search context")
+		}
+		searchContextID = &sc.ID
+	}
+	if err := db.SearchContexts.SetDefault(ctx, userID, searchContextID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+func (r *schemaResolver) SearchContexts(ctx context.Context, args *struct {
+	graphqlutil.ConnectionArgs
+	Namespace *graphql.ID
+}) (*searchContextConnectionResolver, error) {
+	var opt db.SearchContextsListOptions
+	if args.Namespace != nil {
+		switch kind := relay.UnmarshalKind(*args.Namespace); kind {
+		case "User":
+			if err := relay.UnmarshalSpec(*args.Namespace, &opt.NamespaceUserID); err != nil {
+				return nil, err
+			}
+		case "Org":
+			if err := relay.UnmarshalSpec(*args.Namespace, &opt.NamespaceOrgID); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("invalid search context namespace of type %q (must be a user or organization)", kind)
+		}
+	}
+	// 🚨 SECURITY: Only list the search contexts that the current user may view.
+	opt.ViewerUserID = actor.FromContext(ctx).UID
+	opt.IncludeAllPrivate = backend.CheckCurrentUserIsSiteAdmin(ctx) == nil
+	args.ConnectionArgs.Set(&opt.LimitOffset)
+	return &searchContextConnectionResolver{opt: opt}, nil
+}
+
+// searchContextConnectionResolver resolves a list of search contexts.
+//
+// 🚨 SECURITY: When instantiating a searchContextConnectionResolver value, the
+// caller MUST check permissions.
+type searchContextConnectionResolver struct {
+	opt db.SearchContextsListOptions
+
+	// cache results because they are used by multiple fields
+	once           sync.Once
+	searchContexts []*db.SearchContext
+	err            error
+}
+
+func (r *searchContextConnectionResolver) compute(ctx context.Context) ([]*db.SearchContext, error) {
+	r.once.Do(func() {
+		opt2 := r.opt
+		if opt2.LimitOffset != nil {
+			tmp := *opt2.LimitOffset
+			opt2.LimitOffset = &tmp
+			opt2.Limit++ // so we can detect if there is a next page
+		}
+
+		r.searchContexts, r.err = db.SearchContexts.List(ctx, opt2)
+	})
+	return r.searchContexts, r.err
+}
+
+func (r *searchContextConnectionResolver) Nodes(ctx context.Context) ([]*searchContextResolver, error) {
+	searchContexts, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.opt.LimitOffset != nil && len(searchContexts) > r.opt.Limit {
+		searchContexts = searchContexts[:r.opt.Limit]
+	}
+
+	l := make([]*searchContextResolver, 0, len(searchContexts))
+	for _, sc := range searchContexts {
+		l = append(l, &searchContextResolver{sc: sc})
+	}
+	return l, nil
+}
+
+func (r *searchContextConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.SearchContexts.Count(ctx, r.opt)
+	return int32(count), err
+}
+
+func (r *searchContextConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	searchContexts, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt.LimitOffset != nil && len(searchContexts) > r.opt.Limit), nil
+}
+
+func (r *UserResolver) DefaultSearchContext(ctx context.Context) (*searchContextResolver, error) {
+	// 🚨 SECURITY: Only the user and site admins may view the user's default search context.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, r.user.ID); err != nil {
+		return nil, err
+	}
+	sc, err := db.SearchContexts.GetDefault(ctx, r.user.ID)
+	if err != nil || sc == nil {
+		return nil, err
+	}
+	if ok, err := searchContextVisibleToUser(ctx, sc, r.user.ID); err != nil || !ok {
+		return nil, err
+	}
+	return &searchContextResolver{sc: sc}, nil
+}
+
+// searchContextResolver resolves a search context.
+//
+// 🚨 SECURITY: When instantiating a searchContextResolver value, the caller
+// MUST check that the current user may view the search context.
+type searchContextResolver struct {
+	sc *db.SearchContext
+}
+
+func (r *searchContextResolver) ID() graphql.ID { return marshalSearchContextID(r.sc.ID) }
+
+func (r *searchContextResolver) Name() string { return r.sc.Name }
+
+func (r *searchContextResolver) Description() string { return r.sc.Description }
+
+func (r *searchContextResolver) Spec(ctx context.Context) (string, error) {
+	namespace, err := r.Namespace(ctx)
+	if err != nil {
+		return "", err
+	}
+	if user, ok := namespace.ToUser(); ok {
+		return "@" + user.Username() + "/" + r.sc.Name, nil
+	}
+	org, _ := namespace.ToOrg()
+	return "@" + org.Name() + "/" + r.sc.Name, nil
+}
+
+func (r *searchContextResolver) Namespace(ctx context.Context) (*searchContextNamespaceResolver, error) {
+	if r.sc.NamespaceUserID != 0 {
+		user, err := UserByIDInt32(ctx, r.sc.NamespaceUserID)
+		if err != nil {
+			return nil, err
+		}
+		return &searchContextNamespaceResolver{user: user}, nil
+	}
+	org, err := OrgByIDInt32(ctx, r.sc.NamespaceOrgID)
+	if err != nil {
+		return nil, err
+	}
+	return &searchContextNamespaceResolver{org: org}, nil
+}
+
+func (r *searchContextResolver) Public() bool { return r.sc.Public }
+
+func (r *searchContextResolver) Repositories(ctx context.Context) ([]*searchContextRepositoryResolver, error) {
+	repos, err := db.SearchContexts.ListRepositories(ctx, r.sc.ID)
+	if err != nil {
+		return nil, err
+	}
+	l := make([]*searchContextRepositoryResolver, 0, len(repos))
+	for _, repo := range repos {
+		// 🚨 SECURITY: Omit repositories that the current user may not view.
+		repoResolver, err := repositoryByIDInt32(ctx, repo.RepoID)
+		if errcode.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		l = append(l, &searchContextRepositoryResolver{repository: repoResolver, revisions: repo.Revisions})
+	}
+	return l, nil
+}
+
+func (r *searchContextResolver) RepositoryQueries() []string {
+	if r.sc.RepositoryQueries == nil {
+		return []string{}
+	}
+	return r.sc.RepositoryQueries
+}
+
+func (r *searchContextResolver) ViewerCanAdminister(ctx context.Context) bool {
+	return checkSearchContextNamespaceAccess(ctx, r.sc.NamespaceUserID, r.sc.NamespaceOrgID) == nil
+}
+
+func (r *searchContextResolver) CreatedAt() string { return r.sc.CreatedAt.Format(time.RFC3339) }
+
+func (r *searchContextResolver) UpdatedAt() string { return r.sc.UpdatedAt.Format(time.RFC3339) }
+
+// searchContextNamespaceResolver resolves the GraphQL union type Namespace.
+type searchContextNamespaceResolver struct {
+	user *UserResolver
+	org  *OrgResolver
+}
+
+func (r *searchContextNamespaceResolver) ToUser() (*UserResolver, bool) { return r.user, r.user != nil }
+func (r *searchContextNamespaceResolver) ToOrg() (*OrgResolver, bool)   { return r.org, r.org != nil }
+
+type searchContextRepositoryResolver struct {
+	repository *repositoryResolver
+	revisions  []string
+}
+
+func (r *searchContextRepositoryResolver) Repository() *repositoryResolver { return r.repository }
+
+func (r *searchContextRepositoryResolver) Revisions() []string { return r.revisions }
@@ -0,0 +1,100 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestParseSearchContextSpec(t *testing.T) {
+	tests := map[string]struct {
+		namespaceName, name string
+		wantErr             bool
+	}{
+		"@alice/work": {namespaceName: "alice", name: "work"},
+		"@o/a.b-c":    {namespaceName: "o", name: "a.b-c"},
+		"alice/work":  {wantErr: true},
+		"@alice":      {wantErr: true},
+		"@/work":      {wantErr: true},
+		"@alice/":     {wantErr: true},
+	}
+	for spec, test := range tests {
+		namespaceName, name, err := parseSearchContextSpec(spec)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: got err %v, want error %v", spec, err, test.wantErr)
+			continue
+		}
+		if namespaceName != test.namespaceName || name != test.name {
+			t.Errorf("%s: got (%q, %q), want (%q, %q)", spec, namespaceName, name, test.namespaceName, test.name)
+		}
+	}
+}
+
+func TestSearchContextRepositoryQueryPatterns(t *testing.T) {
+	include, exclude, err := searchContextRepositoryQueryPatterns("repo:^a/ -repo:b$")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"^a/"}; !reflect.DeepEqual(include, want) {
+		t.Errorf("got include %q, want %q", include, want)
+	}
+	if want := []string{"b$"}; !reflect.DeepEqual(exclude, want) {
+		t.Errorf("got exclude %q, want %q", exclude, want)
+	}
+
+	for _, q := range []string{"repo:a file:b", "foo", "repo:("} {
+		if _, _, err := searchContextRepositoryQueryPatterns(q); err == nil {
+			t.Errorf("%q: got nil error", q)
+		}
+	}
+}
+
+func TestResolveRepositories_searchContext(t *testing.T) {
+	defer func() { db.Mocks = db.MockStores{} }()
+	db.Mocks.Repos.List = func(_ context.Context, op db.ReposListOptions) ([]*types.Repo, error) {
+		if want := []string{"a", `^a/b$|^a/c$|^d/e$`}; !reflect.DeepEqual(op.IncludePatterns, want) {
+			t.Errorf("got include patterns %q, want %q", op.IncludePatterns, want)
+		}
+		return []*types.Repo{{ID: 1, Name: "a/b"}, {ID: 2, Name: "a/c"}}, nil
+	}
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		return api.CommitID("deadbeef"), nil
+	}
+	defer git.ResetMocks()
+
+	repoRevs, _, _, _, err := resolveRepositories(context.Background(), resolveRepoOp{
+		repoFilters: []string{"a"},
+		searchContextRepos: []*db.SearchContextRepository{
+			{RepoName: "a/b", Revisions: []string{""}},
+			{RepoName: "a/c", Revisions: []string{"v1", "v2"}},
+			{RepoName: "d/e", Revisions: []string{""}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*search.RepositoryRevisions{
+		{Repo: &types.Repo{ID: 1, Name: "a/b"}, Revs: []search.RevisionSpecifier{{RevSpec: ""}}},
+		{Repo: &types.Repo{ID: 2, Name: "a/c"}, Revs: []search.RevisionSpecifier{{RevSpec: "v1"}, {RevSpec: "v2"}}},
+	}
+	if !reflect.DeepEqual(repoRevs, want) {
+		t.Errorf("got %+v, want %+v", repoRevs, want)
+	}
+
+	// An empty search context matches no repositories.
+	repoRevs, _, _, _, err = resolveRepositories(context.Background(), resolveRepoOp{
+		searchContextRepos: []*db.SearchContextRepository{},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(repoRevs) != 0 {
+		t.Errorf("got %+v, want no repositories", repoRevs)
+	}
+}
@@ -0,0 +1,218 @@
+package graphqlbackend
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neelance/parallel"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+)
+
+const (
+	// maxExportResultsPerRepo is the result limit of each repository searched
+	// by an export, which replaces the limit from count:. The results of a
+	// repository are collected in memory before they are exported, so this
+	// (times exportConcurrency) bounds the memory used by an export.
+	maxExportResultsPerRepo = 10000
+
+	// exportConcurrency is the number of repositories an export searches
+	// concurrently.
+	exportConcurrency = 4
+
+	// exportTimeout is the timeout of exports, which replaces the timeout
+	// from timeout:.
+	exportTimeout = 10 * time.Minute
+)
+
+// An ExportedMatch is a single row of a search results export.
+type ExportedMatch struct {
+	Repo      string `json:"repo"`
+	Rev       string `json:"rev"`    // the revision searched ("" for the default branch), the commit ID of commit matches, or the last matching commit of history matches
+	Path      string `json:"path"`   // "" for repository and commit matches
+	Line      int    `json:"line"`   // 1-based, or 0 if the match isn't on a line
+	Column    int    `json:"column"` // 1-based, or 0 if the match isn't on a line
+	Preview   string `json:"preview"`
+	MatchType string `json:"matchType"` // one of the ExportMatchType* constants
+}
+
+// All ExportedMatch.MatchType values.
+const (
+	ExportMatchTypeContent = "content"
+	ExportMatchTypePath    = "path"
+	ExportMatchTypeSymbol  = "symbol"
+	ExportMatchTypeRepo    = "repo"
+	ExportMatchTypeCommit  = "commit"
+)
+
+// ExportSearchResults runs the search query without the usual result limit
+// and calls emit for every match. Each repository is searched separately and
+// its matches are emitted as soon as its search has finished, so the matches
+// are grouped by repository (in no particular order of the repositories). It
+// reports whether the export is incomplete, because the result limit of a
+// repository was hit or some repositories could not be searched in time.
+//
+// 🚨 SECURITY: The repositories searched are those visible to the actor in
+// ctx, exactly as for GraphQL search.
+func ExportSearchResults(ctx context.Context, rawQuery string, emit func(*ExportedMatch) error) (incomplete bool, err error) {
+	q, err := query.ParseAndCheck(rawQuery)
+	if err != nil {
+		return false, &badRequestError{err}
+	}
+	ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+	defer cancel()
+
+	r := &searchResolver{query: q, export: true}
+	if ok, err := r.useGlobalSymbolIndex(ctx, ""); err != nil {
+		return false, err
+	} else if ok {
+		// The global symbol index answers the query for all repositories at
+		// once.
+		results, err := r.doResults(ctx, "")
+		if err != nil {
+			return false, err
+		}
+		if err := emitExportedMatches(results.results, emit); err != nil {
+			return false, err
+		}
+		return results.LimitHit() || len(results.timedout) > 0, nil
+	}
+
+	repos, _, _, overLimit, err := r.resolveRepositories(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	incomplete = overLimit || len(r.repoPredicatesCommon.timedout) > 0
+
+	var (
+		mu      sync.Mutex
+		emitErr error
+		run     = parallel.NewRun(exportConcurrency)
+	)
+	for _, repoRev := range repos {
+		repoRev := repoRev // shadow so it doesn't change in the goroutine
+		run.Acquire()
+		goroutine.Go(func() {
+			defer run.Release()
+			// The repository is resolved already, so the search of this
+			// repository uses it instead of resolving the query's repositories.
+			sub := &searchResolver{
+				query:       q,
+				export:      true,
+				repoRevs:    []*search.RepositoryRevisions{repoRev},
+				repoResults: []*searchSuggestionResolver{},
+			}
+			results, err := sub.doResults(ctx, "")
+			if err != nil {
+				run.Error(err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if emitErr != nil {
+				return
+			}
+			if results.LimitHit() || len(results.timedout) > 0 {
+				incomplete = true
+			}
+			emitErr = emitExportedMatches(results.results, emit)
+		})
+	}
+	if err := run.Wait(); err != nil {
+		return false, err
+	}
+	if emitErr != nil {
+		return false, emitErr
+	}
+	return incomplete, nil
+}
+
+// emitExportedMatches calls emit for every match of the search results.
+func emitExportedMatches(results []*searchResultResolver, emit func(*ExportedMatch) error) error {
+	for _, result := range results {
+		for _, m := range exportedMatches(result) {
+			if err := emit(m); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// exportedMatches returns the export rows for a search result.
+func exportedMatches(result *searchResultResolver) []*ExportedMatch {
+	switch {
+	case result.repo != nil:
+		return []*ExportedMatch{{
+			Repo:      string(result.repo.repo.Name),
+			MatchType: ExportMatchTypeRepo,
+		}}
+
+	case result.fileMatch != nil:
+		fm := result.fileMatch
+		base := ExportedMatch{Repo: string(fm.repo.Name), Path: fm.JPath}
+		if fm.inputRev != nil && *fm.inputRev != "" {
+			base.Rev = *fm.inputRev
+		}
+
+		var matches []*ExportedMatch
+		for _, sym := range fm.symbols {
+			m := base
+			start := sym.symbol.Location.Range.Start
+			m.Line, m.Column = start.Line+1, start.Character+1
+			m.Preview = sym.symbol.Name
+			m.MatchType = ExportMatchTypeSymbol
+			matches = append(matches, &m)
+		}
+		for _, lm := range fm.JLineMatches {
+			for _, ol := range lm.JOffsetAndLengths {
+				m := base
+				m.Line, m.Column = int(lm.JLineNumber)+1, int(ol[0])+1
+				m.Preview = lm.JPreview
+				m.MatchType = ExportMatchTypeContent
+				matches = append(matches, &m)
+			}
+		}
+		if len(matches) == 0 {
+			m := base
+			m.MatchType = ExportMatchTypePath
+			matches = append(matches, &m)
+		}
+		return matches
+
+	case result.diff != nil:
+		commit := result.diff.commit
+		preview := commit.message
+		if i := strings.Index(preview, "\n"); i != -1 {
+			preview = preview[:i]
+		}
+		return []*ExportedMatch{{
+			Repo:      string(commit.repo.repo.Name),
+			Rev:       string(commit.oid),
+			Preview:   preview,
+			MatchType: ExportMatchTypeCommit,
+		}}
+
+	case result.history != nil:
+		h := result.history
+		var matches []*ExportedMatch
+		for _, lm := range h.lastMatches {
+			for _, ol := range lm.JOffsetAndLengths {
+				matches = append(matches, &ExportedMatch{
+					Repo:      string(h.repo.repo.Name),
+					Rev:       string(h.lastMatchesCommit),
+					Path:      h.path,
+					Line:      int(lm.JLineNumber) + 1,
+					Column:    int(ol[0]) + 1,
+					Preview:   lm.JPreview,
+					MatchType: ExportMatchTypeContent,
+				})
+			}
+		}
+		return matches
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package graphqlbackend
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestExportedMatches(t *testing.T) {
+	repo := &types.Repo{Name: "r"}
+	rev := "v1"
+	tests := []struct {
+		name   string
+		result *searchResultResolver
+		want   []*ExportedMatch
+	}{
+		{
+			name:   "repo",
+			result: &searchResultResolver{repo: &repositoryResolver{repo: repo}},
+			want:   []*ExportedMatch{{Repo: "r", MatchType: "repo"}},
+		},
+		{
+			name:   "path",
+			result: &searchResultResolver{fileMatch: &fileMatchResolver{JPath: "a.go", repo: repo, inputRev: &rev}},
+			want:   []*ExportedMatch{{Repo: "r", Rev: "v1", Path: "a.go", MatchType: "path"}},
+		},
+		{
+			name: "content",
+			result: &searchResultResolver{fileMatch: &fileMatchResolver{JPath: "a.go", repo: repo, JLineMatches: []*lineMatch{
+				{JPreview: "foo bar foo", JLineNumber: 2, JOffsetAndLengths: [][2]int32{{0, 3}, {8, 3}}},
+			}}},
+			want: []*ExportedMatch{
+				{Repo: "r", Path: "a.go", Line: 3, Column: 1, Preview: "foo bar foo", MatchType: "content"},
+				{Repo: "r", Path: "a.go", Line: 3, Column: 9, Preview: "foo bar foo", MatchType: "content"},
+			},
+		},
+		{
+			name: "commit",
+			result: &searchResultResolver{diff: &commitSearchResultResolver{commit: &gitCommitResolver{
+				repo:    &repositoryResolver{repo: repo},
+				oid:     "c",
+				message: "subject\n\nbody",
+			}}},
+			want: []*ExportedMatch{{Repo: "r", Rev: "c", Preview: "subject", MatchType: "commit"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := exportedMatches(test.result); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/golang/groupcache/lru"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/fuzzypath"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/trace"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+const (
+	defaultFuzzyFileMatches = 20
+	maxFuzzyFileMatches     = 500
+)
+
+type searchFuzzyFilesArgs struct {
+	Query string
+	First *int32
+}
+
+// FuzzyFiles finds the files whose paths match a fuzzy query (like "Go to file" in editors) in
+// the repositories that the search query selects. Only the repo:, repogroup: and context: fields
+// (and the other fields that select repositories) of the search query are used.
+func (r *searchResolver) FuzzyFiles(ctx context.Context, args *searchFuzzyFilesArgs) (res *fuzzyFileMatchesResolver, err error) {
+	tr, ctx := trace.New(ctx, "graphql.SearchFuzzyFiles", fmt.Sprintf("%q in %s", args.Query, r.rawQuery()))
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	first := defaultFuzzyFileMatches
+	if args.First != nil {
+		first = int(*args.First)
+	}
+	if first <= 0 || first > maxFuzzyFileMatches {
+		return nil, &badRequestError{fmt.Errorf("first must be between 1 and %d", maxFuzzyFileMatches)}
+	}
+
+	ctx, cancel, err := r.withTimeout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	repos, missingRepoRevs, _, overLimit, err := r.resolveRepositories(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if overLimit {
+		return nil, &badRequestError{fmt.Errorf("too many matching repositories to search (the limit is %d), use repo: to narrow the query", maxReposToSearch())}
+	}
+	tr.LazyPrintf("searching %d repos, %d missing", len(repos), len(missingRepoRevs))
+
+	matches, common, err := searchFuzzyFilesInRepos(ctx, repos, args.Query, first+1)
+	if err != nil && !isContextError(ctx, err) {
+		return nil, err
+	}
+	if common == nil {
+		common = &searchResultsCommon{}
+	}
+	for _, repoRev := range missingRepoRevs {
+		common.missing = append(common.missing, repoRev.Repo)
+	}
+	if len(matches) > first {
+		matches = matches[:first]
+		common.limitHit = true
+	}
+	return &fuzzyFileMatchesResolver{matches: matches, searchResultsCommon: *common}, nil
+}
+
+// searchFuzzyFilesInRepos returns the best limit matches of the fuzzy query in the first revision
+// of each repository, best first.
+func searchFuzzyFilesInRepos(ctx context.Context, repos []*search.RepositoryRevisions, query string, limit int) ([]*fuzzyFileMatchResolver, *searchResultsCommon, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		err     error
+		matches []*fuzzyFileMatchResolver
+		common  = &searchResultsCommon{}
+	)
+	for _, repoRev := range repos {
+		wg.Add(1)
+		go func(repoRev search.RepositoryRevisions) {
+			defer wg.Done()
+			repoMatches, searchErr := searchFuzzyFilesInRepo(ctx, repoRev, query, limit)
+			if ctx.Err() == context.Canceled {
+				// Another repository had a fatal error, so we can just ignore these results.
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if fatalErr := handleRepoSearchResult(common, repoRev, false, ctx.Err() == context.DeadlineExceeded, searchErr); fatalErr != nil {
+				err = errors.Wrapf(searchErr, "failed to search file paths %s", repoRev.String())
+				cancel()
+			}
+			matches = append(matches, repoMatches...)
+		}(*repoRev)
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Order by match quality, then by repository name.
+	sort.Slice(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if fuzzypath.Less(a.match, b.match) {
+			return true
+		}
+		if fuzzypath.Less(b.match, a.match) {
+			return false
+		}
+		return a.commit.repo.repo.Name < b.commit.repo.repo.Name
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches, common, nil
+}
+
+func searchFuzzyFilesInRepo(ctx context.Context, repoRev search.RepositoryRevisions, query string, limit int) ([]*fuzzyFileMatchResolver, error) {
+	// Only the first revision is searched, because the same files usually exist in all
+	// revisions.
+	var inputRev *string
+	rev := "HEAD"
+	if len(repoRev.Revs) > 0 {
+		if repoRev.Revs[0].RefGlob != "" || repoRev.Revs[0].ExcludeRefGlob != "" {
+			return nil, errors.New("fuzzy file search does not support ref globs")
+		}
+		if spec := repoRev.Revs[0].RevSpec; spec != "" {
+			rev, inputRev = spec, &spec
+		}
+	}
+	commitID, err := git.ResolveRevision(ctx, repoRev.GitserverRepo(), nil, rev, &git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		return nil, err
+	}
+
+	ix, err := fuzzyPathIndex(ctx, repoRev.GitserverRepo(), commitID)
+	if err != nil {
+		return nil, err
+	}
+	commit := &gitCommitResolver{
+		repo:     &repositoryResolver{repo: repoRev.Repo},
+		oid:      gitObjectID(commitID),
+		inputRev: inputRev,
+	}
+	var matches []*fuzzyFileMatchResolver
+	for _, m := range ix.Search(query, limit) {
+		matches = append(matches, &fuzzyFileMatchResolver{commit: commit, match: m})
+	}
+	return matches, nil
+}
+
+// maxFuzzyPathIndexCacheSize is the maximum total size (in bytes) of the cached fuzzy path
+// indexes. The path lists of large repositories are big, so the number of cached indexes alone
+// does not bound the memory used by the cache.
+const maxFuzzyPathIndexCacheSize = 100 << 20
+
+// fuzzyPathIndexCache caches the fuzzy path index of recently searched repositories and commits.
+// Commits are immutable, so the cached indexes never need to be invalidated. The least recently
+// used indexes are evicted when the total size of the cached indexes (fuzzyPathIndexCacheSize)
+// exceeds maxFuzzyPathIndexCacheSize.
+var (
+	fuzzyPathIndexCacheMu   sync.Mutex
+	fuzzyPathIndexCache     = newFuzzyPathIndexCache()
+	fuzzyPathIndexCacheSize int
+)
+
+func newFuzzyPathIndexCache() *lru.Cache {
+	c := lru.New(0) // no limit on the number of entries
+	c.OnEvicted = func(key lru.Key, value interface{}) {
+		fuzzyPathIndexCacheSize -= value.(*fuzzypath.Index).Size()
+	}
+	return c
+}
+
+// fuzzyPathIndex returns the fuzzy path index of the files in the repository at the commit.
+func fuzzyPathIndex(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (*fuzzypath.Index, error) {
+	key := string(repo.Name) + "@" + string(commit)
+	fuzzyPathIndexCacheMu.Lock()
+	v, ok := fuzzyPathIndexCache.Get(key)
+	fuzzyPathIndexCacheMu.Unlock()
+	if ok {
+		return v.(*fuzzypath.Index), nil
+	}
+
+	// With recurse, ReadDir runs `git ls-tree -r` at the repository root (which is cached for
+	// large repositories).
+	entries, err := git.ReadDir(ctx, repo, commit, "", true)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if !e.IsDir() {
+			paths = append(paths, e.Name())
+		}
+	}
+	ix := fuzzypath.NewIndex(paths)
+
+	size := ix.Size()
+	if size > maxFuzzyPathIndexCacheSize {
+		// Caching the index would evict all other indexes.
+		return ix, nil
+	}
+	fuzzyPathIndexCacheMu.Lock()
+	if _, ok := fuzzyPathIndexCache.Get(key); !ok {
+		fuzzyPathIndexCache.Add(key, ix)
+		fuzzyPathIndexCacheSize += size
+		for fuzzyPathIndexCacheSize > maxFuzzyPathIndexCacheSize {
+			fuzzyPathIndexCache.RemoveOldest()
+		}
+	}
+	fuzzyPathIndexCacheMu.Unlock()
+	return ix, nil
+}
+
+// fuzzyFileMatchesResolver is a resolver for the GraphQL type `FuzzyFileMatches`.
+type fuzzyFileMatchesResolver struct {
+	matches []*fuzzyFileMatchResolver
+	searchResultsCommon
+}
+
+func (r *fuzzyFileMatchesResolver) Matches() []*fuzzyFileMatchResolver { return r.matches }
+
+// fuzzyFileMatchResolver is a resolver for the GraphQL type `FuzzyFileMatch`.
+type fuzzyFileMatchResolver struct {
+	commit *gitCommitResolver
+	match  fuzzypath.Match
+}
+
+func (r *fuzzyFileMatchResolver) File() *gitTreeEntryResolver {
+	return &gitTreeEntryResolver{
+		commit: r.commit,
+		path:   r.match.Path,
+		stat:   createFileInfo(r.match.Path, false),
+	}
+}
+
+func (r *fuzzyFileMatchResolver) Repository() *repositoryResolver { return r.commit.repo }
+
+func (r *fuzzyFileMatchResolver) Score() float64 { return r.match.Score }
+
+// PathMatchRanges returns the ranges of the path that match the query, measured in characters
+// (not bytes).
+func (r *fuzzyFileMatchResolver) PathMatchRanges() [][]int32 {
+	ranges := make([][]int32, len(r.match.Ranges))
+	for i, rg := range r.match.Ranges {
+		offset := utf8.RuneCountInString(r.match.Path[:rg[0]])
+		length := utf8.RuneCountInString(r.match.Path[rg[0] : rg[0]+rg[1]])
+		ranges[i] = []int32{int32(offset), int32(length)}
+	}
+	return ranges
+}
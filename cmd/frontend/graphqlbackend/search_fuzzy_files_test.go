@@ -0,0 +1,91 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestSearchFuzzyFiles(t *testing.T) {
+	db.Mocks.Repos.List = func(_ context.Context, op db.ReposListOptions) ([]*types.Repo, error) {
+		return []*types.Repo{{ID: 1, Name: "b"}, {ID: 2, Name: "a"}}, nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	const commitID = api.CommitID("fuzzyfuzzyfuzzyfuzzyfuzzyfuzzyfuzzyfuzz")
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		if spec != "HEAD" {
+			t.Errorf("got revision %q, want HEAD", spec)
+		}
+		return commitID, nil
+	}
+	var readDirCalls int
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		readDirCalls++
+		if commit != commitID || name != "" || !recurse {
+			t.Errorf("got ReadDir(%q, %q, %v), want a recursive listing of the root", commit, name, recurse)
+		}
+		return []os.FileInfo{
+			createFileInfo("cmd", true),
+			createFileInfo("cmd/main.go", false),
+			createFileInfo("main_test.go", false),
+			createFileInfo("README.md", false),
+		}, nil
+	}
+	defer git.ResetMocks()
+
+	search := func(query string, first int32) *fuzzyFileMatchesResolver {
+		t.Helper()
+		r, err := (&schemaResolver{}).Search(&struct{ Query string }{Query: "repo:."})
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := r.FuzzyFiles(context.Background(), &searchFuzzyFilesArgs{Query: query, First: &first})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := search("main", 3)
+	var got []string
+	for _, m := range res.Matches() {
+		got = append(got, m.Repository().Name()+":"+m.File().Path())
+	}
+	// Ties are broken by repository name.
+	if want := []string{"a:cmd/main.go", "b:cmd/main.go", "a:main_test.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !res.LimitHit() {
+		t.Error("!limitHit")
+	}
+	if got, want := res.Matches()[0].PathMatchRanges(), [][]int32{{4, 4}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got ranges %v, want %v", got, want)
+	}
+	if readDirCalls != 2 {
+		t.Errorf("got %d ReadDir calls, want 1 per repository", readDirCalls)
+	}
+
+	// The path index of each repository and commit is cached.
+	if res := search("readme", 3); len(res.Matches()) != 2 || res.LimitHit() {
+		t.Errorf("got %d matches (limitHit=%v), want README.md in each repository", len(res.Matches()), res.LimitHit())
+	}
+	if readDirCalls != 2 {
+		t.Errorf("got %d ReadDir calls, want the cached path indexes to be used", readDirCalls)
+	}
+
+	r, err := (&schemaResolver{}).Search(&struct{ Query string }{Query: "repo:."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := int32(0)
+	if _, err := r.FuzzyFiles(context.Background(), &searchFuzzyFilesArgs{Query: "main", First: &first}); err == nil {
+		t.Error("got no error for first: 0")
+	}
+}
@@ -0,0 +1,260 @@
+package graphqlbackend
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/gituri"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// maxGlobalSymbolsPerRepo is the maximum number of symbols of a single
+// repository that are stored in the global symbol index.
+const maxGlobalSymbolsPerRepo = 100000
+
+// UpdateGlobalSymbolIndex indexes the symbols at the default branch of the
+// repository in the global symbol index, unless they are already indexed at
+// its current commit.
+func UpdateGlobalSymbolIndex(ctx context.Context, repo *types.Repo) error {
+	commitID, err := git.ResolveRevision(ctx, gitserver.Repo{Name: repo.Name}, nil, "", &git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		return err
+	}
+	indexedCommitID, err := db.GlobalSymbols.IndexedCommit(ctx, repo.ID)
+	if err != nil || indexedCommitID == commitID {
+		return err
+	}
+
+	symbols, err := backend.Symbols.List(ctx, protocol.ListArgs{Repo: repo.Name, CommitID: commitID, First: maxGlobalSymbolsPerRepo})
+	if err != nil {
+		return err
+	}
+	globalSymbols := make([]*db.GlobalSymbol, len(symbols))
+	for i, s := range symbols {
+		globalSymbols[i] = &db.GlobalSymbol{
+			RepoID:    repo.ID,
+			Name:      s.Name,
+			Kind:      s.Kind,
+			Language:  s.Language,
+			Path:      s.Path,
+			Line:      int32(s.Line),
+			Character: int32(symbolNameRange(s).Start.Character),
+			Parent:    s.Parent,
+			Signature: s.Signature,
+			Exported:  isExportedSymbol(s),
+		}
+	}
+	return db.GlobalSymbols.Replace(ctx, repo.ID, commitID, globalSymbols)
+}
+
+// UpdateGlobalSymbolIndexes updates the global symbol index for all
+// repositories that indexed search indexes. It does nothing if global symbol
+// search is disabled.
+func UpdateGlobalSymbolIndexes(ctx context.Context) error {
+	if !conf.GlobalSymbolSearchEnabled() {
+		return nil
+	}
+	index := true
+	repos, err := db.Repos.List(ctx, db.ReposListOptions{Enabled: true, Index: &index})
+	if err != nil {
+		return err
+	}
+	for _, repo := range repos {
+		if err := UpdateGlobalSymbolIndex(ctx, repo); err != nil {
+			log15.Warn("Updating the global symbol index failed.", "repo", repo.Name, "error", err)
+		}
+	}
+	return nil
+}
+
+// isExportedSymbol reports whether the symbol is exported (or public), using
+// the visibility reported by the parser if any and else the naming
+// conventions of its language. Symbols are exported unless known otherwise.
+func isExportedSymbol(s protocol.Symbol) bool {
+	switch strings.ToLower(s.Access) {
+	case "public", "export":
+		return true
+	case "private", "protected", "package", "internal":
+		return false
+	}
+	switch strings.ToLower(s.Language) {
+	case "go":
+		r, _ := utf8.DecodeRuneInString(s.Name)
+		return unicode.IsUpper(r)
+	case "python":
+		return !strings.HasPrefix(s.Name, "_")
+	}
+	return true
+}
+
+// globalSymbolSearchExcludedFields are the fields of queries that restrict
+// the repositories to search, which the global symbol index can't do.
+var globalSymbolSearchExcludedFields = []string{
+	query.FieldRepo,
+	query.FieldRepoGroup,
+	query.FieldFork,
+	query.FieldArchived,
+	query.FieldRepoHasFile,
+	query.FieldRepoHasCommitAfter,
+	query.FieldRepoHasLang,
+	query.FieldIndex,
+}
+
+// useGlobalSymbolIndex reports whether the query can be answered by the
+// global symbol index instead of searching the symbols of each repository:
+// it is a symbol search across all repositories at their default branches.
+func (r *searchResolver) useGlobalSymbolIndex(ctx context.Context, forceOnlyResultType string) (bool, error) {
+	if !conf.GlobalSymbolSearchEnabled() || !conf.SearchIndexEnabled() || r.cursor != nil || r.query.Pattern != nil {
+		return false, nil
+	}
+	if forceOnlyResultType != "symbol" {
+		resultTypes, _ := r.query.StringValues(query.FieldType)
+		if len(resultTypes) != 1 || resultTypes[0] != "symbol" {
+			return false, nil
+		}
+	}
+	for _, field := range globalSymbolSearchExcludedFields {
+		if len(r.query.Fields[field]) > 0 {
+			return false, nil
+		}
+	}
+	if selectValue, _ := r.query.StringValue(query.FieldSelect); selectValue != "" {
+		return false, nil
+	}
+	if searchContextRepos, err := r.resolveSearchContext(ctx); err != nil || searchContextRepos != nil {
+		return false, err
+	}
+	empty, err := db.GlobalSymbols.IsEmpty(ctx)
+	return !empty, err
+}
+
+// searchGlobalSymbolIndex searches the global symbol index for the symbols
+// matching the query, best first.
+func (r *searchResolver) searchGlobalSymbolIndex(ctx context.Context, start time.Time) (*searchResultsResolver, error) {
+	p, err := r.getPatternInfo(nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, &badRequestError{err}
+	}
+	common := searchResultsCommon{maxResultsCount: r.maxResults()}
+	if p.Pattern == "" {
+		return &searchResultsResolver{start: start, searchResultsCommon: common, progress: newSearchProgress(r.rawQuery(), nil, nil, &common)}, nil
+	}
+
+	opt := db.GlobalSymbolsSearchOptions{
+		Query:           p.Pattern,
+		IsRegExp:        p.IsRegExp,
+		IsCaseSensitive: p.IsCaseSensitive,
+		IncludePatterns: p.IncludePatterns,
+		ExcludePattern:  p.ExcludePattern,
+		Limit:           int(r.maxResults()) + 1,
+	}
+	if regexp.QuoteMeta(p.Pattern) == p.Pattern {
+		opt.ExactName = p.Pattern
+	}
+	matches, err := db.GlobalSymbols.Search(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > int(r.maxResults()) {
+		common.limitHit = true
+		matches = matches[:r.maxResults()]
+	}
+
+	fileMatches, repos, err := globalSymbolMatchesToFileMatches(matches)
+	if err != nil {
+		return nil, err
+	}
+	common.searched = repos
+	common.indexed = repos
+	results := make([]*searchResultResolver, len(fileMatches))
+	for i, fileMatch := range fileMatches {
+		results[i] = &searchResultResolver{fileMatch: fileMatch}
+	}
+	return &searchResultsResolver{
+		start:               start,
+		searchResultsCommon: common,
+		results:             results,
+		progress:            newSearchProgress(r.rawQuery(), nil, nil, &common),
+	}, nil
+}
+
+// globalSymbolMatchesToFileMatches groups the symbols by file, keeping the
+// order of the first symbol of each file. It also returns the repositories of
+// the symbols. Symbols without a repository (which the actor can't see) are
+// omitted.
+func globalSymbolMatchesToFileMatches(matches []*db.GlobalSymbolMatch) ([]*fileMatchResolver, []*types.Repo, error) {
+	var (
+		repos            []*types.Repo
+		reposByID        = make(map[api.RepoID]*types.Repo)
+		fileMatches      []*fileMatchResolver
+		fileMatchesByURI = make(map[string]*fileMatchResolver)
+	)
+	for _, m := range matches {
+		if m.Repo == nil {
+			// 🚨 SECURITY: The actor can't see the repository.
+			continue
+		}
+		repo := m.Repo
+		if _, ok := reposByID[m.RepoID]; !ok {
+			reposByID[m.RepoID] = repo
+			repos = append(repos, repo)
+		}
+
+		baseURI, err := gituri.Parse("git://" + string(repo.Name) + "?" + url.QueryEscape(string(m.Commit)))
+		if err != nil {
+			return nil, nil, err
+		}
+		commit := &gitCommitResolver{
+			repo: &repositoryResolver{repo: repo},
+			oid:  gitObjectID(m.Commit),
+			// NOTE: Not all fields are set, for performance.
+		}
+		symbol := protocol.Symbol{
+			Name:      m.Name,
+			Path:      m.Path,
+			Line:      int(m.Line),
+			Kind:      m.Kind,
+			Language:  m.Language,
+			Parent:    m.Parent,
+			Signature: m.Signature,
+			Character: int(m.Character),
+			EndLine:   int(m.Line), // the character is known
+		}
+		symbolRes := toSymbolResolver(symbolToLSPSymbolInformation(symbol, baseURI), strings.ToLower(m.Language), commit)
+		if symbolRes == nil {
+			continue
+		}
+		uri := makeFileMatchURIFromSymbol(symbolRes, "")
+		if fileMatch, ok := fileMatchesByURI[uri]; ok {
+			fileMatch.symbols = append(fileMatch.symbols, symbolRes)
+			continue
+		}
+		fileMatch := &fileMatchResolver{
+			JPath:    m.Path,
+			symbols:  []*symbolResolver{symbolRes},
+			uri:      uri,
+			repo:     repo,
+			commitID: m.Commit,
+		}
+		fileMatchesByURI[uri] = fileMatch
+		fileMatches = append(fileMatches, fileMatch)
+	}
+	return fileMatches, repos, nil
+}
@@ -0,0 +1,113 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestIsExportedSymbol(t *testing.T) {
+	tests := map[string]struct {
+		symbol protocol.Symbol
+		want   bool
+	}{
+		"go exported":      {symbol: protocol.Symbol{Name: "NewServer", Language: "Go"}, want: true},
+		"go unexported":    {symbol: protocol.Symbol{Name: "newServer", Language: "Go"}},
+		"java public":      {symbol: protocol.Symbol{Name: "newServer", Language: "Java", Access: "public"}, want: true},
+		"java private":     {symbol: protocol.Symbol{Name: "NewServer", Language: "Java", Access: "private"}},
+		"python private":   {symbol: protocol.Symbol{Name: "_new_server", Language: "Python"}},
+		"python public":    {symbol: protocol.Symbol{Name: "new_server", Language: "Python"}, want: true},
+		"unknown language": {symbol: protocol.Symbol{Name: "newServer", Language: "JavaScript"}, want: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := isExportedSymbol(test.symbol); got != test.want {
+				t.Errorf("got %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSearchGlobalSymbolIndex(t *testing.T) {
+	yes := true
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		SearchIndexEnabled:   &yes,
+		ExperimentalFeatures: &schema.ExperimentalFeatures{GlobalSymbolSearch: "enabled"},
+	}})
+	defer conf.Mock(nil)
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	db.Mocks.GlobalSymbols.IsEmpty = func() (bool, error) { return false, nil }
+
+	t.Run("use the index", func(t *testing.T) {
+		for query, want := range map[string]bool{
+			"type:symbol NewServer":             true,
+			"type:symbol NewServer file:\\.go$": true,
+			"NewServer":                         false,
+			"type:symbol type:file NewServer":   false,
+			"type:symbol repo:foo NewServer":    false,
+			"type:symbol -repo:foo NewServer":   false,
+			"type:symbol fork:yes NewServer":    false,
+		} {
+			r, err := (&schemaResolver{}).Search(&struct{ Query string }{Query: query})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := r.(*searchResolver).useGlobalSymbolIndex(context.Background(), "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != want {
+				t.Errorf("%s: got %v, want %v", query, got, want)
+			}
+		}
+	})
+
+	t.Run("results", func(t *testing.T) {
+		db.Mocks.GlobalSymbols.Search = func(opt db.GlobalSymbolsSearchOptions) ([]*db.GlobalSymbolMatch, error) {
+			if want := (db.GlobalSymbolsSearchOptions{Query: "NewServer", IsRegExp: true, IsCaseSensitive: true, ExactName: "NewServer", Limit: int(defaultMaxSearchResults) + 1}); !reflect.DeepEqual(opt, want) {
+				t.Errorf("got %+v, want %+v", opt, want)
+			}
+			repo1, repo2 := &types.Repo{ID: 1, Name: "repo1"}, &types.Repo{ID: 2, Name: "repo2"}
+			return []*db.GlobalSymbolMatch{
+				{GlobalSymbol: db.GlobalSymbol{RepoID: 2, Name: "NewServer", Path: "b.go", Line: 3, Character: 5, Exported: true}, Repo: repo2, Commit: "c2"},
+				{GlobalSymbol: db.GlobalSymbol{RepoID: 1, Name: "NewServer", Path: "a.go", Line: 1, Exported: true}, Repo: repo1, Commit: "c1"},
+				{GlobalSymbol: db.GlobalSymbol{RepoID: 2, Name: "NewServerWithOptions", Path: "b.go", Line: 7}, Repo: repo2, Commit: "c2"},
+			}, nil
+		}
+
+		r, err := (&schemaResolver{}).Search(&struct{ Query string }{Query: "type:symbol NewServer"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		results, err := r.Results(context.Background(), &searchResultsArgs{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, result := range results.results {
+			for _, symbol := range result.fileMatch.symbols {
+				start := symbol.symbol.Location.Range.Start
+				got = append(got, fmt.Sprintf("%s@%s/%s:%s:%d:%d", result.fileMatch.repo.Name, result.fileMatch.commitID, result.fileMatch.JPath, symbol.symbol.Name, start.Line, start.Character))
+			}
+		}
+		want := []string{
+			"repo2@c2/b.go:NewServer:2:5",
+			"repo2@c2/b.go:NewServerWithOptions:6:0",
+			"repo1@c1/a.go:NewServer:0:0",
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+		if results.limitHit {
+			t.Error("limitHit")
+		}
+	})
+}
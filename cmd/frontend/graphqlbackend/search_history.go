@@ -0,0 +1,257 @@
+package graphqlbackend
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// maxRecentSearchSuggestions is the maximum number of recent searches from the
+// user's search history that are suggested.
+const maxRecentSearchSuggestions = 3
+
+// recentSearchSuggestionScore is the score of the most recent search that is
+// suggested. It ranks recent searches above the other suggestions, because
+// users often rerun their searches.
+const recentSearchSuggestionScore = 1000
+
+// recordSearchHistory records the search in the search history of the user
+// who ran it. Searches that failed for reasons other than timing out are not
+// recorded.
+func recordSearchHistory(ctx context.Context, query string, results *searchResultsResolver, err error, duration time.Duration) {
+	a := actor.FromContext(ctx)
+	if !a.IsAuthenticated() || strings.TrimSpace(query) == "" || !conf.SearchHistoryEnabled() {
+		return
+	}
+
+	e := &db.SearchHistoryEntry{UserID: a.UID, Query: query, Duration: duration}
+	switch {
+	case err == nil:
+		e.ResultCount = results.ResultCount()
+		e.TimedOut = len(results.timedout) > 0
+	case errors.Cause(err) == context.DeadlineExceeded:
+		e.TimedOut = true
+	default:
+		return
+	}
+
+	// Record the search in the background, so that it does not delay the
+	// results.
+	goroutine.Go(func() {
+		if _, err := db.SearchHistory.Create(context.Background(), e); err != nil {
+			log15.Error("Recording search history failed.", "user", e.UserID, "error", err)
+		}
+	})
+}
+
+// suggestRecentSearches returns suggestions for the recent searches in the
+// user's search history that start with the query.
+func (r *searchResolver) suggestRecentSearches(ctx context.Context) ([]*searchSuggestionResolver, error) {
+	a := actor.FromContext(ctx)
+	if !a.IsAuthenticated() || !conf.SearchHistoryEnabled() {
+		return nil, nil
+	}
+
+	// 🚨 SECURITY: Only the actor's own search history is suggested.
+	entries, err := db.SearchHistory.ListRecentQueries(ctx, a.UID, r.rawQuery(), maxRecentSearchSuggestions+1)
+	if err != nil {
+		return nil, err
+	}
+	var suggestions []*searchSuggestionResolver
+	for _, e := range entries {
+		// Don't suggest the query that has already been typed.
+		if e.Query == r.rawQuery() || len(suggestions) == maxRecentSearchSuggestions {
+			continue
+		}
+		suggestions = append(suggestions, newSearchResultResolver(&searchHistoryEntryResolver{entry: e}, recentSearchSuggestionScore-len(suggestions)))
+	}
+	return suggestions, nil
+}
+
+type searchHistoryArgs struct {
+	graphqlutil.ConnectionArgs
+	Query    *string
+	TimedOut bool
+}
+
+func (a *searchHistoryArgs) listOptions(userID int32) db.SearchHistoryListOptions {
+	opt := db.SearchHistoryListOptions{UserID: userID, TimedOut: a.TimedOut}
+	if a.Query != nil {
+		opt.Query = *a.Query
+	}
+	a.ConnectionArgs.Set(&opt.LimitOffset)
+	return opt
+}
+
+func (r *UserResolver) SearchHistory(ctx context.Context, args *searchHistoryArgs) (*searchHistoryEntryConnectionResolver, error) {
+	// 🚨 SECURITY: Only the user and site admins can view the user's search history.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, r.user.ID); err != nil {
+		return nil, err
+	}
+	return &searchHistoryEntryConnectionResolver{opt: args.listOptions(r.user.ID)}, nil
+}
+
+func (r *siteResolver) SearchHistory(ctx context.Context, args *searchHistoryArgs) (*searchHistoryEntryConnectionResolver, error) {
+	// 🚨 SECURITY: Only site admins can view the search history of all users.
+	if err := backend.CheckCurrentUserIsSiteAdmin(ctx); err != nil {
+		return nil, err
+	}
+	return &searchHistoryEntryConnectionResolver{opt: args.listOptions(0)}, nil
+}
+
+func (r *schemaResolver) DeleteSearchHistoryEntry(ctx context.Context, args *struct {
+	ID graphql.ID
+}) (*EmptyResponse, error) {
+	id, err := unmarshalSearchHistoryEntryID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	e, err := db.SearchHistory.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Only the user and site admins can delete the user's search history.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, e.UserID); err != nil {
+		return nil, err
+	}
+	if err := db.SearchHistory.Delete(ctx, id); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+func (r *schemaResolver) ClearSearchHistory(ctx context.Context, args *struct {
+	User graphql.ID
+}) (*EmptyResponse, error) {
+	userID, err := UnmarshalUserID(args.User)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Only the user and site admins can delete the user's search history.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := db.SearchHistory.DeleteByUser(ctx, userID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+// searchHistoryEntryConnectionResolver resolves a list of search history
+// entries.
+//
+// 🚨 SECURITY: When instantiating a searchHistoryEntryConnectionResolver value,
+// the caller MUST check permissions.
+type searchHistoryEntryConnectionResolver struct {
+	opt db.SearchHistoryListOptions
+
+	// cache results because they are used by multiple fields
+	once    sync.Once
+	entries []*db.SearchHistoryEntry
+	err     error
+}
+
+func (r *searchHistoryEntryConnectionResolver) compute(ctx context.Context) ([]*db.SearchHistoryEntry, error) {
+	r.once.Do(func() {
+		opt2 := r.opt
+		if opt2.LimitOffset != nil {
+			tmp := *opt2.LimitOffset
+			opt2.LimitOffset = &tmp
+			opt2.Limit++ // so we can detect if there is a next page
+		}
+
+		r.entries, r.err = db.SearchHistory.List(ctx, opt2)
+	})
+	return r.entries, r.err
+}
+
+func (r *searchHistoryEntryConnectionResolver) Nodes(ctx context.Context) ([]*searchHistoryEntryResolver, error) {
+	entries, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.opt.LimitOffset != nil && len(entries) > r.opt.Limit {
+		entries = entries[:r.opt.Limit]
+	}
+
+	l := make([]*searchHistoryEntryResolver, len(entries))
+	for i, e := range entries {
+		l[i] = &searchHistoryEntryResolver{entry: e}
+	}
+	return l, nil
+}
+
+func (r *searchHistoryEntryConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.SearchHistory.Count(ctx, r.opt)
+	return int32(count), err
+}
+
+func (r *searchHistoryEntryConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	entries, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt.LimitOffset != nil && len(entries) > r.opt.Limit), nil
+}
+
+// searchHistoryEntryResolver resolves an entry in a user's search history.
+type searchHistoryEntryResolver struct {
+	entry *db.SearchHistoryEntry
+}
+
+func searchHistoryEntryByID(ctx context.Context, id graphql.ID) (*searchHistoryEntryResolver, error) {
+	entryID, err := unmarshalSearchHistoryEntryID(id)
+	if err != nil {
+		return nil, err
+	}
+	e, err := db.SearchHistory.GetByID(ctx, entryID)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Only the user and site admins can view the user's search history.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, e.UserID); err != nil {
+		return nil, err
+	}
+	return &searchHistoryEntryResolver{entry: e}, nil
+}
+
+func marshalSearchHistoryEntryID(id int64) graphql.ID {
+	return relay.MarshalID("SearchHistoryEntry", id)
+}
+
+func unmarshalSearchHistoryEntryID(id graphql.ID) (entryID int64, err error) {
+	err = relay.UnmarshalSpec(id, &entryID)
+	return
+}
+
+func (r *searchHistoryEntryResolver) ID() graphql.ID { return marshalSearchHistoryEntryID(r.entry.ID) }
+
+func (r *searchHistoryEntryResolver) User(ctx context.Context) (*UserResolver, error) {
+	return UserByIDInt32(ctx, r.entry.UserID)
+}
+
+func (r *searchHistoryEntryResolver) Query() string { return r.entry.Query }
+
+func (r *searchHistoryEntryResolver) ResultCount() int32 { return r.entry.ResultCount }
+
+func (r *searchHistoryEntryResolver) DurationMilliseconds() int32 {
+	return int32(r.entry.Duration / time.Millisecond)
+}
+
+func (r *searchHistoryEntryResolver) TimedOut() bool { return r.entry.TimedOut }
+
+func (r *searchHistoryEntryResolver) CreatedAt() string {
+	return r.entry.CreatedAt.Format(time.RFC3339)
+}
@@ -0,0 +1,109 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestRecordSearchHistory(t *testing.T) {
+	created := make(chan *db.SearchHistoryEntry, 1)
+	db.Mocks.SearchHistory.Create = func(e *db.SearchHistoryEntry) (*db.SearchHistoryEntry, error) {
+		created <- e
+		return e, nil
+	}
+	defer func() { db.Mocks.SearchHistory = db.MockSearchHistory{} }()
+
+	userCtx := actor.WithActor(context.Background(), &actor.Actor{UID: 1})
+	results := &searchResultsResolver{results: []*searchResultResolver{
+		{repo: &repositoryResolver{repo: &types.Repo{Name: "a"}}},
+		{repo: &repositoryResolver{repo: &types.Repo{Name: "b"}}},
+	}}
+	timedOutResults := &searchResultsResolver{searchResultsCommon: searchResultsCommon{timedout: []*types.Repo{{Name: "c"}}}}
+
+	tests := map[string]struct {
+		ctx      context.Context
+		query    string
+		results  *searchResultsResolver
+		err      error
+		disabled bool
+		want     *db.SearchHistoryEntry
+	}{
+		"results": {
+			ctx: userCtx, query: "foo", results: results,
+			want: &db.SearchHistoryEntry{UserID: 1, Query: "foo", ResultCount: 2, Duration: time.Second},
+		},
+		"timed out repositories": {
+			ctx: userCtx, query: "foo", results: timedOutResults,
+			want: &db.SearchHistoryEntry{UserID: 1, Query: "foo", Duration: time.Second, TimedOut: true},
+		},
+		"deadline exceeded": {
+			ctx: userCtx, query: "foo", err: context.DeadlineExceeded,
+			want: &db.SearchHistoryEntry{UserID: 1, Query: "foo", Duration: time.Second, TimedOut: true},
+		},
+		"error":            {ctx: userCtx, query: "foo", err: errors.New("x")},
+		"anonymous":        {ctx: context.Background(), query: "foo", results: results},
+		"empty query":      {ctx: userCtx, query: " ", results: results},
+		"history disabled": {ctx: userCtx, query: "foo", results: results, disabled: true},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{SearchHistory: &schema.SearchHistory{Disabled: test.disabled}}})
+			defer conf.Mock(nil)
+
+			recordSearchHistory(test.ctx, test.query, test.results, test.err, time.Second)
+			var got *db.SearchHistoryEntry
+			select {
+			case got = <-created:
+			case <-time.After(100 * time.Millisecond):
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSearchSuggestions_recentSearches(t *testing.T) {
+	db.Mocks.SearchHistory.ListRecentQueries = func(userID int32, prefix string, limit int) ([]*db.SearchHistoryEntry, error) {
+		if userID != 1 || prefix != "foo" {
+			t.Errorf("got (%d, %q), want (1, \"foo\")", userID, prefix)
+		}
+		return []*db.SearchHistoryEntry{{Query: "foo bar"}, {Query: "foo"}, {Query: "foo baz"}, {Query: "foo qux"}, {Query: "foo quux"}}, nil
+	}
+	defer func() { db.Mocks.SearchHistory = db.MockSearchHistory{} }()
+
+	r, err := (&schemaResolver{}).Search(&struct{ Query string }{Query: "foo"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	suggestions, err := r.(*searchResolver).suggestRecentSearches(actor.WithActor(context.Background(), &actor.Actor{UID: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, s := range suggestions {
+		e, _ := s.ToSearchHistoryEntry()
+		got = append(got, e.Query())
+	}
+	if want := []string{"foo bar", "foo baz", "foo qux"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if suggestions[0].score <= suggestions[1].score {
+		t.Errorf("got scores %d, %d, want the most recent search to score highest", suggestions[0].score, suggestions[1].score)
+	}
+
+	// Anonymous users have no search history.
+	suggestions, err = r.(*searchResolver).suggestRecentSearches(context.Background())
+	if err != nil || suggestions != nil {
+		t.Errorf("got (%v, %v), want no suggestions", suggestions, err)
+	}
+}
@@ -0,0 +1,405 @@
+package graphqlbackend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend/graphqlutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+const (
+	// maxSearchInsightPoints is the maximum number of data points of a search
+	// insight.
+	maxSearchInsightPoints = 520
+
+	// maxSearchInsightPointsPerRun is the maximum number of data points of a
+	// search insight that are recorded by each run of RecordSearchInsights,
+	// so that new insights don't delay the others for long.
+	maxSearchInsightPointsPerRun = 5
+)
+
+// searchInsightResultType returns the type of results whose matches are
+// counted for the query of a search insight. Only matches in files are
+// supported, because only those can be searched at past commits.
+func (r *searchResolver) searchInsightResultType() (string, error) {
+	resultType, err := r.aggregateResultType(aggregateByRepo)
+	if err != nil {
+		return "", err
+	}
+	if resultType != "file" && resultType != "path" {
+		return "", fmt.Errorf("search insights do not support type:%s queries", resultType)
+	}
+	return resultType, nil
+}
+
+// RecordSearchInsights records the missing data points of all search
+// insights, newest first. Data points that are no longer needed are deleted.
+func RecordSearchInsights(ctx context.Context) error {
+	insights, err := db.SearchInsights.List(ctx, db.SearchInsightsListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, si := range insights {
+		if err := recordSearchInsight(ctx, si, time.Now()); err != nil {
+			log15.Warn("Recording search insight failed.", "insight", si.ID, "error", err)
+		}
+	}
+	return nil
+}
+
+func recordSearchInsight(ctx context.Context, si *db.SearchInsight, now time.Time) error {
+	times := searchInsightPointTimes(si, now)
+	if _, err := db.SearchInsights.DeletePointsBefore(ctx, si.ID, times[len(times)-1]); err != nil {
+		return err
+	}
+
+	recordedTimes, err := db.SearchInsights.ListPointTimes(ctx, si.ID)
+	if err != nil {
+		return err
+	}
+	recorded := make(map[int64]bool, len(recordedTimes))
+	for _, t := range recordedTimes {
+		recorded[t.Unix()] = true
+	}
+
+	n := 0
+	for _, t := range times {
+		if recorded[t.Unix()] {
+			continue
+		}
+		if n == maxSearchInsightPointsPerRun {
+			break
+		}
+		n++
+		points, err := searchInsightPoints(ctx, si, t)
+		if err != nil {
+			return err
+		}
+		if err := db.SearchInsights.SetPoints(ctx, si.ID, t, points); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// searchInsightPointTimes returns the points in time of the search insight's
+// data points, newest first. They are aligned to multiples of the interval, so
+// that they don't change between runs.
+func searchInsightPointTimes(si *db.SearchInsight, now time.Time) []time.Time {
+	interval := time.Duration(si.IntervalDays) * 24 * time.Hour
+	last := now.UTC().Truncate(interval)
+	times := make([]time.Time, si.NumPoints)
+	for i := range times {
+		times[i] = last.Add(-time.Duration(i) * interval)
+	}
+	return times
+}
+
+// searchInsightPoints counts the matches of the search insight's query in each
+// repository at the last commit before t. Repositories that could not be
+// searched fully have partial points, so that one failing repository doesn't
+// keep the data point from being recorded.
+func searchInsightPoints(ctx context.Context, si *db.SearchInsight, t time.Time) ([]*db.SearchInsightPoint, error) {
+	// 🚨 SECURITY: Search as the owner of the insight, so that only the
+	// repositories visible to them are searched.
+	ctx = actor.WithActor(ctx, &actor.Actor{UID: si.UserID})
+	ctx, cancel := context.WithTimeout(ctx, maxTimeout)
+	defer cancel()
+
+	q, err := query.ParseAndCheck(si.Query)
+	if err != nil {
+		return nil, err
+	}
+	r := &searchResolver{query: q}
+	resultType, err := r.searchInsightResultType()
+	if err != nil {
+		return nil, err
+	}
+	p, err := r.countOnlyPatternInfo(resultType)
+	if err != nil {
+		return nil, err
+	}
+
+	repos, missingRepoRevs, _, overLimit, err := r.resolveRepositories(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	if overLimit {
+		return nil, fmt.Errorf("too many matching repositories (the limit is %d)", maxReposToSearch())
+	}
+
+	// Repositories without the revision or without commits before t have no
+	// matches.
+	var points []*db.SearchInsightPoint
+	for _, repoRev := range missingRepoRevs {
+		points = append(points, &db.SearchInsightPoint{RepoID: repoRev.Repo.ID})
+	}
+	var searchRepos []*search.RepositoryRevisions
+	for _, repoRev := range repos {
+		rev := "HEAD"
+		if len(repoRev.Revs) > 0 && repoRev.Revs[0].RevSpec != "" {
+			rev = repoRev.Revs[0].RevSpec
+		}
+		commits, err := git.Commits(ctx, gitserver.Repo{Name: repoRev.Repo.Name}, git.CommitsOptions{Range: rev, N: 1, Before: t.Format(time.RFC3339)})
+		if err != nil {
+			log15.Warn("Listing commits for search insight failed.", "insight", si.ID, "repo", repoRev.Repo.Name, "error", err)
+			points = append(points, &db.SearchInsightPoint{RepoID: repoRev.Repo.ID, Partial: true})
+			continue
+		}
+		if len(commits) == 0 {
+			points = append(points, &db.SearchInsightPoint{RepoID: repoRev.Repo.ID})
+			continue
+		}
+		searchRepos = append(searchRepos, &search.RepositoryRevisions{
+			Repo: repoRev.Repo,
+			Revs: []search.RevisionSpecifier{{RevSpec: string(commits[0].ID)}},
+		})
+	}
+	if len(searchRepos) == 0 {
+		return points, nil
+	}
+
+	fileMatches, common, err := searchFilesInRepos(ctx, &search.Args{
+		Pattern:         p,
+		Repos:           searchRepos,
+		Query:           r.query,
+		UseFullDeadline: true,
+	})
+	if err != nil && !isContextError(ctx, err) {
+		return nil, err
+	}
+	if common == nil {
+		common = &searchResultsCommon{}
+	}
+	uncounted := common.uncountedRepos()
+	// If not all matches were counted, but it is not known in which
+	// repositories, all points are partial.
+	allPartial := err != nil || (!common.countedAll() && len(uncounted) == 0)
+	agg := newSearchAggregator(aggregateByRepo)
+	for _, fm := range fileMatches {
+		agg.addFileMatch(fm)
+	}
+	for _, repoRev := range searchRepos {
+		point := &db.SearchInsightPoint{
+			RepoID:  repoRev.Repo.ID,
+			Commit:  api.CommitID(repoRev.Revs[0].RevSpec),
+			Partial: allPartial || uncounted[repoRev.Repo.Name],
+		}
+		if g, ok := agg.groups[string(repoRev.Repo.Name)]; ok {
+			point.Count = g.count
+		}
+		points = append(points, point)
+	}
+	return points, nil
+}
+
+func (r *UserResolver) SearchInsights(ctx context.Context, args *graphqlutil.ConnectionArgs) (*searchInsightConnectionResolver, error) {
+	// 🚨 SECURITY: Only the user and site admins can view the user's search insights.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, r.user.ID); err != nil {
+		return nil, err
+	}
+	opt := db.SearchInsightsListOptions{UserID: r.user.ID}
+	args.Set(&opt.LimitOffset)
+	return &searchInsightConnectionResolver{opt: opt}, nil
+}
+
+func (r *schemaResolver) CreateSearchInsight(ctx context.Context, args *struct {
+	User         graphql.ID
+	Title        string
+	Query        string
+	IntervalDays int32
+	NumPoints    int32
+}) (*searchInsightResolver, error) {
+	userID, err := UnmarshalUserID(args.User)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Only the user and site admins can create search insights for the user.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSpace(args.Title) == "" {
+		return nil, errors.New("search insight title must not be empty")
+	}
+	if args.IntervalDays <= 0 {
+		return nil, errors.New("search insight interval must be at least 1 day")
+	}
+	if args.NumPoints <= 0 || args.NumPoints > maxSearchInsightPoints {
+		return nil, fmt.Errorf("search insight must have between 1 and %d data points", maxSearchInsightPoints)
+	}
+	q, err := query.ParseAndCheck(args.Query)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := (&searchResolver{query: q}).searchInsightResultType(); err != nil {
+		return nil, err
+	}
+
+	si, err := db.SearchInsights.Create(ctx, &db.SearchInsight{
+		UserID:       userID,
+		Title:        args.Title,
+		Query:        args.Query,
+		IntervalDays: args.IntervalDays,
+		NumPoints:    args.NumPoints,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &searchInsightResolver{insight: si}, nil
+}
+
+func (r *schemaResolver) DeleteSearchInsight(ctx context.Context, args *struct {
+	ID graphql.ID
+}) (*EmptyResponse, error) {
+	si, err := searchInsightByID(ctx, args.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.SearchInsights.Delete(ctx, si.insight.ID); err != nil {
+		return nil, err
+	}
+	return &EmptyResponse{}, nil
+}
+
+// searchInsightConnectionResolver resolves a list of search insights.
+//
+// 🚨 SECURITY: When instantiating a searchInsightConnectionResolver value, the
+// caller MUST check permissions.
+type searchInsightConnectionResolver struct {
+	opt db.SearchInsightsListOptions
+
+	// cache results because they are used by multiple fields
+	once     sync.Once
+	insights []*db.SearchInsight
+	err      error
+}
+
+func (r *searchInsightConnectionResolver) compute(ctx context.Context) ([]*db.SearchInsight, error) {
+	r.once.Do(func() {
+		opt2 := r.opt
+		if opt2.LimitOffset != nil {
+			tmp := *opt2.LimitOffset
+			opt2.LimitOffset = &tmp
+			opt2.Limit++ // so we can detect if there is a next page
+		}
+
+		r.insights, r.err = db.SearchInsights.List(ctx, opt2)
+	})
+	return r.insights, r.err
+}
+
+func (r *searchInsightConnectionResolver) Nodes(ctx context.Context) ([]*searchInsightResolver, error) {
+	insights, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if r.opt.LimitOffset != nil && len(insights) > r.opt.Limit {
+		insights = insights[:r.opt.Limit]
+	}
+
+	l := make([]*searchInsightResolver, len(insights))
+	for i, si := range insights {
+		l[i] = &searchInsightResolver{insight: si}
+	}
+	return l, nil
+}
+
+func (r *searchInsightConnectionResolver) TotalCount(ctx context.Context) (int32, error) {
+	count, err := db.SearchInsights.Count(ctx, r.opt)
+	return int32(count), err
+}
+
+func (r *searchInsightConnectionResolver) PageInfo(ctx context.Context) (*graphqlutil.PageInfo, error) {
+	insights, err := r.compute(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return graphqlutil.HasNextPage(r.opt.LimitOffset != nil && len(insights) > r.opt.Limit), nil
+}
+
+// searchInsightResolver resolves a search insight.
+type searchInsightResolver struct {
+	insight *db.SearchInsight
+}
+
+func searchInsightByID(ctx context.Context, id graphql.ID) (*searchInsightResolver, error) {
+	insightID, err := unmarshalSearchInsightID(id)
+	if err != nil {
+		return nil, err
+	}
+	si, err := db.SearchInsights.GetByID(ctx, insightID)
+	if err != nil {
+		return nil, err
+	}
+	// 🚨 SECURITY: Only the user and site admins can view the user's search insights.
+	if err := backend.CheckSiteAdminOrSameUser(ctx, si.UserID); err != nil {
+		return nil, err
+	}
+	return &searchInsightResolver{insight: si}, nil
+}
+
+func marshalSearchInsightID(id int32) graphql.ID { return relay.MarshalID("SearchInsight", id) }
+
+func unmarshalSearchInsightID(id graphql.ID) (insightID int32, err error) {
+	err = relay.UnmarshalSpec(id, &insightID)
+	return
+}
+
+func (r *searchInsightResolver) ID() graphql.ID { return marshalSearchInsightID(r.insight.ID) }
+
+func (r *searchInsightResolver) User(ctx context.Context) (*UserResolver, error) {
+	return UserByIDInt32(ctx, r.insight.UserID)
+}
+
+func (r *searchInsightResolver) Title() string { return r.insight.Title }
+
+func (r *searchInsightResolver) Query() string { return r.insight.Query }
+
+func (r *searchInsightResolver) IntervalDays() int32 { return r.insight.IntervalDays }
+
+func (r *searchInsightResolver) NumPoints() int32 { return r.insight.NumPoints }
+
+func (r *searchInsightResolver) DataPoints(ctx context.Context) ([]*searchInsightDataPointResolver, error) {
+	points, err := db.SearchInsights.ListDataPoints(ctx, r.insight.ID)
+	if err != nil {
+		return nil, err
+	}
+	l := make([]*searchInsightDataPointResolver, len(points))
+	for i, p := range points {
+		l[i] = &searchInsightDataPointResolver{point: p}
+	}
+	return l, nil
+}
+
+func (r *searchInsightResolver) CreatedAt() string {
+	return r.insight.CreatedAt.Format(time.RFC3339)
+}
+
+// searchInsightDataPointResolver resolves a data point of a search insight.
+type searchInsightDataPointResolver struct {
+	point *db.SearchInsightDataPoint
+}
+
+func (r *searchInsightDataPointResolver) Time() string { return r.point.Time.Format(time.RFC3339) }
+
+func (r *searchInsightDataPointResolver) Count() int32 { return r.point.Count }
+
+func (r *searchInsightDataPointResolver) RepositoryCount() int32 { return r.point.RepositoryCount }
+
+func (r *searchInsightDataPointResolver) Partial() bool { return r.point.Partial }
@@ -0,0 +1,129 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestSearchInsightPointTimes(t *testing.T) {
+	now := time.Date(2018, 6, 13, 15, 4, 5, 0, time.UTC) // a Wednesday
+	times := searchInsightPointTimes(&db.SearchInsight{IntervalDays: 7, NumPoints: 3}, now)
+	var got []string
+	for _, tm := range times {
+		got = append(got, tm.Format("2006-01-02T15:04"))
+	}
+	// Weekly points are aligned to Mondays.
+	if want := []string{"2018-06-11T00:00", "2018-06-04T00:00", "2018-05-28T00:00"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// The points don't change until the next interval starts.
+	if later := searchInsightPointTimes(&db.SearchInsight{IntervalDays: 7, NumPoints: 3}, now.AddDate(0, 0, 4)); !reflect.DeepEqual(later, times) {
+		t.Errorf("got %v, want %v", later, times)
+	}
+}
+
+func TestRecordSearchInsight(t *testing.T) {
+	now := time.Date(2018, 6, 13, 15, 4, 5, 0, time.UTC)
+	si := &db.SearchInsight{ID: 1, UserID: 2, Query: "foo", IntervalDays: 1, NumPoints: 10}
+	times := searchInsightPointTimes(si, now)
+
+	db.Mocks.Repos.List = func(_ context.Context, op db.ReposListOptions) ([]*types.Repo, error) {
+		return []*types.Repo{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}, nil
+	}
+	db.Mocks.SearchContexts.GetDefault = func(userID int32) (*db.SearchContext, error) {
+		if userID != si.UserID {
+			t.Errorf("got user %d, want the search to run as the owner %d", userID, si.UserID)
+		}
+		return nil, nil
+	}
+	db.Mocks.SearchInsights.DeletePointsBefore = func(id int32, before time.Time) (int64, error) {
+		if !before.Equal(times[len(times)-1]) {
+			t.Errorf("got %v, want the oldest point time %v", before, times[len(times)-1])
+		}
+		return 0, nil
+	}
+	db.Mocks.SearchInsights.ListPointTimes = func(id int32) ([]time.Time, error) {
+		return []time.Time{times[1]}, nil
+	}
+	recorded := map[time.Time][]*db.SearchInsightPoint{}
+	db.Mocks.SearchInsights.SetPoints = func(id int32, pointTime time.Time, points []*db.SearchInsightPoint) error {
+		recorded[pointTime] = points
+		return nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	git.Mocks.Commits = func(opt git.CommitsOptions) ([]*git.Commit, error) {
+		if opt.Range != "HEAD" || opt.N != 1 {
+			t.Errorf("got options %+v, want the last commit of HEAD", opt)
+		}
+		return []*git.Commit{{ID: api.CommitID("c" + opt.Before)}}, nil
+	}
+	defer git.ResetMocks()
+
+	mockSearchFilesInRepos = func(args *search.Args) ([]*fileMatchResolver, *searchResultsCommon, error) {
+		var (
+			results []*fileMatchResolver
+			common  searchResultsCommon
+		)
+		for _, repoRev := range args.Repos {
+			if !args.Pattern.CountOnly || len(repoRev.Revs) != 1 || repoRev.Revs[0].RevSpec[0] != 'c' {
+				t.Errorf("got pattern %+v and revisions %+v, want a count-only search of commits", args.Pattern, repoRev.Revs)
+			}
+			if repoRev.Repo.Name == "a" {
+				results = append(results, &fileMatchResolver{repo: repoRev.Repo, JPath: "x", JMatchCount: 3})
+			} else {
+				common.timedout = append(common.timedout, repoRev.Repo)
+			}
+		}
+		return results, &common, nil
+	}
+	defer func() { mockSearchFilesInRepos = nil }()
+
+	if err := recordSearchInsight(context.Background(), si, now); err != nil {
+		t.Fatal(err)
+	}
+	if len(recorded) != maxSearchInsightPointsPerRun {
+		t.Fatalf("got %d data points recorded, want %d", len(recorded), maxSearchInsightPointsPerRun)
+	}
+	if _, ok := recorded[times[1]]; ok {
+		t.Error("got the already recorded data point recorded again")
+	}
+	if _, ok := recorded[times[maxSearchInsightPointsPerRun]]; !ok {
+		t.Error("got the newest missing data points not recorded")
+	}
+	points := recorded[times[0]]
+	if len(points) != 2 || points[0].RepoID != 1 || points[0].Count != 3 || points[0].Partial || points[1].RepoID != 2 || points[1].Count != 0 || !points[1].Partial {
+		t.Errorf("got points %+v, want 3 matches in a and a partial count in b, which timed out", points)
+	}
+	if want := api.CommitID("c" + times[0].Format(time.RFC3339)); points[0].Commit != want {
+		t.Errorf("got commit %q, want %q", points[0].Commit, want)
+	}
+}
+
+func TestSearchInsightResultType(t *testing.T) {
+	tests := map[string]string{
+		"file":   "foo",
+		"path":   "type:path foo",
+		"commit": "type:commit foo",
+		"symbol": "type:symbol foo",
+	}
+	for name, q := range tests {
+		r, err := (&schemaResolver{}).Search(&struct{ Query string }{Query: q})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = r.(*searchResolver).searchInsightResultType()
+		if wantErr := name != "file" && name != "path"; (err != nil) != wantErr {
+			t.Errorf("%q: got err %v, want error %v", q, err, wantErr)
+		}
+	}
+}
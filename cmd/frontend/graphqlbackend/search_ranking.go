@@ -0,0 +1,374 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/neelance/parallel"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/inventory/filelang"
+	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/trace"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// This file contains the relevance ranking of file matches. A file match's score is
+// the sum of the scores of several signals. The weights below are tuned so that no
+// single signal dominates the others.
+const (
+	// A file that defines a symbol matching the query is likely what the user is
+	// looking for.
+	rankingSymbolDefinitionScore = 5
+
+	// Zoekt's scores are on the order of thousands (e.g., 7000 for a match on a
+	// symbol definition and 500 for a match on a word boundary).
+	rankingZoektScoreScale = 1000
+
+	rankingMatchDensityWeight = 1    // per doubling of the number of matches
+	rankingPathDepthPenalty   = 0.25 // per directory level
+	rankingTestPenalty        = 3
+	rankingVendorPenalty      = 5
+
+	// Recently changed files score up to rankingRecencyWeight, which halves with
+	// every rankingRecencyHalfLife since the file's last change.
+	rankingRecencyWeight   = 2
+	rankingRecencyHalfLife = 180 * 24 * time.Hour
+
+	rankingPopularityWeight = 1 // per factor of 10 in popularity
+
+	// The signals that require calls to the symbols service and gitserver are only
+	// fetched for the file matches with the highest scores from the other signals,
+	// and only for a limited time.
+	maxRankingEnrichedFileMatches = 100
+	rankingEnrichmentTimeout      = 500 * time.Millisecond
+)
+
+// A searchResultScore is the relevance score of a search result, with the
+// components that it is the sum of.
+type searchResultScore struct {
+	value      float64
+	components []*searchResultScoreComponent
+}
+
+type searchResultScoreComponent struct {
+	name        string
+	value       float64
+	description string
+}
+
+func (s *searchResultScore) add(name string, value float64, description string) {
+	s.value += value
+	s.components = append(s.components, &searchResultScoreComponent{name: name, value: value, description: description})
+}
+
+func (s *searchResultScore) Value() float64 { return s.value }
+
+func (s *searchResultScore) Components() []*searchResultScoreComponent { return s.components }
+
+func (c *searchResultScoreComponent) Name() string { return c.name }
+
+func (c *searchResultScoreComponent) Value() float64 { return c.value }
+
+func (c *searchResultScoreComponent) Description() string { return c.description }
+
+// fileMatchRankingSignals are the signals of a file match that are fetched from
+// the symbols service and gitserver.
+type fileMatchRankingSignals struct {
+	symbolDefinitions int        // the number of symbols matching the query that the file defines
+	lastChanged       *time.Time // when the file was last changed, or nil if unknown
+}
+
+// isTestPath reports whether the path is likely to be a test, test fixture or
+// test data file.
+var isTestPath = regexp.MustCompile(`(?i)(^|/)(tests?|testdata|testing|__tests__|spec|fixtures?)/|[_.-](test|spec)s?\.[^/]*$|(^|/)test_[^/]*$`).MatchString
+
+// repositoryPopularity returns a function that returns the popularity of a
+// repository, as configured in the search.repositoryPopularity site
+// configuration property.
+func repositoryPopularity() func(api.RepoName) float64 {
+	type pattern struct {
+		re         *regexp.Regexp
+		popularity float64
+	}
+	var patterns []pattern
+	for expr, popularity := range conf.Get().SearchRepositoryPopularity {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			log15.Warn("Invalid regular expression in search.repositoryPopularity site configuration.", "regexp", expr, "error", err)
+			continue
+		}
+		patterns = append(patterns, pattern{re: re, popularity: popularity})
+	}
+	return func(repo api.RepoName) float64 {
+		var max float64
+		for _, p := range patterns {
+			if p.popularity > max && p.re.MatchString(string(repo)) {
+				max = p.popularity
+			}
+		}
+		return max
+	}
+}
+
+// scoreFileMatch computes the relevance score of the file match from its
+// signals (which may be nil if they were not fetched).
+func scoreFileMatch(fm *fileMatchResolver, signals *fileMatchRankingSignals, popularity float64, now time.Time) *searchResultScore {
+	score := &searchResultScore{}
+
+	if fm.zoektScore != nil {
+		// Zoekt's score already accounts for symbol definitions and the quality
+		// and number of matches.
+		score.add("zoekt", *fm.zoektScore/rankingZoektScoreScale, fmt.Sprintf("indexed search score %.0f", *fm.zoektScore))
+	} else {
+		symbolDefinitions := len(fm.symbols)
+		if signals != nil && signals.symbolDefinitions > symbolDefinitions {
+			symbolDefinitions = signals.symbolDefinitions
+		}
+		if symbolDefinitions > 0 {
+			score.add("symbolDefinition", rankingSymbolDefinitionScore, fmt.Sprintf("defines %d matching symbols", symbolDefinitions))
+		}
+
+		var matches int
+		for _, lm := range fm.JLineMatches {
+			matches += len(lm.JOffsetAndLengths)
+		}
+		if matches > 0 {
+			score.add("matchDensity", rankingMatchDensityWeight*math.Log2(1+float64(matches)), fmt.Sprintf("%d matches on %d lines", matches, len(fm.JLineMatches)))
+		}
+	}
+
+	if depth := strings.Count(fm.JPath, "/"); depth > 0 {
+		score.add("pathDepth", -rankingPathDepthPenalty*float64(depth), fmt.Sprintf("%d directories deep", depth))
+	}
+	if filelang.IsVendored(fm.JPath, false) {
+		score.add("vendor", -rankingVendorPenalty, "vendored file")
+	} else if isTestPath(fm.JPath) {
+		score.add("test", -rankingTestPenalty, "test file")
+	}
+
+	if signals != nil && signals.lastChanged != nil {
+		age := now.Sub(*signals.lastChanged)
+		if age < 0 {
+			age = 0
+		}
+		score.add("recency", rankingRecencyWeight*math.Pow(0.5, float64(age)/float64(rankingRecencyHalfLife)), fmt.Sprintf("last changed %s", signals.lastChanged.Format("2006-01-02")))
+	}
+
+	if popularity > 0 {
+		score.add("repositoryPopularity", rankingPopularityWeight*math.Log10(1+popularity), fmt.Sprintf("repository popularity %g", popularity))
+	}
+
+	return score
+}
+
+// rankFileMatches computes the relevance scores of the file matches among the
+// results, which determine their order (see sortResults).
+func rankFileMatches(ctx context.Context, pattern *search.PatternInfo, results []*searchResultResolver) {
+	var fileMatches []*fileMatchResolver
+	for _, result := range results {
+		if result.fileMatch != nil {
+			fileMatches = append(fileMatches, result.fileMatch)
+		}
+	}
+	if len(fileMatches) == 0 {
+		return
+	}
+
+	now := time.Now()
+	popularity := repositoryPopularity()
+	for _, fm := range fileMatches {
+		fm.score = scoreFileMatch(fm, nil, popularity(fm.repo.Name), now)
+	}
+
+	// Fetch the expensive signals for the file matches that are the most likely
+	// to be ranked highly.
+	if len(fileMatches) > maxRankingEnrichedFileMatches {
+		sort.SliceStable(fileMatches, func(i, j int) bool { return fileMatches[i].score.value > fileMatches[j].score.value })
+		fileMatches = fileMatches[:maxRankingEnrichedFileMatches]
+	}
+	ctx, cancel := context.WithTimeout(ctx, rankingEnrichmentTimeout)
+	defer cancel()
+	signals := fetchRankingSignals(ctx, pattern, fileMatches)
+	for _, fm := range fileMatches {
+		if s := signals[fm]; s != nil {
+			fm.score = scoreFileMatch(fm, s, popularity(fm.repo.Name), now)
+		}
+	}
+}
+
+var mockFetchRankingSignals func(fileMatches []*fileMatchResolver) map[*fileMatchResolver]*fileMatchRankingSignals
+
+// rankingSignalsCache caches the ranking signals of file matches. The keys
+// contain the revision that was searched, which may be a branch, so the
+// signals expire.
+var rankingSignalsCache = rcache.NewWithTTL("search_ranking", 3600) // 1h
+
+// fetchRankingSignals fetches the ranking signals of the file matches from the
+// symbols service and gitserver, or from rankingSignalsCache. Errors are
+// ignored, so that ranking does not cause searches to fail: the signals that
+// could not be fetched are omitted.
+func fetchRankingSignals(ctx context.Context, pattern *search.PatternInfo, fileMatches []*fileMatchResolver) map[*fileMatchResolver]*fileMatchRankingSignals {
+	if mockFetchRankingSignals != nil {
+		return mockFetchRankingSignals(fileMatches)
+	}
+
+	tr, ctx := trace.New(ctx, "fetchRankingSignals", fmt.Sprintf("%d file matches", len(fileMatches)))
+	defer tr.Finish()
+
+	var (
+		run     = parallel.NewRun(20)
+		mu      sync.Mutex
+		signals = make(map[*fileMatchResolver]*fileMatchRankingSignals, len(fileMatches))
+		fetched [][2]string // the cache entries of the fetched signals
+	)
+	for _, fm := range fileMatches {
+		signals[fm] = &fileMatchRankingSignals{}
+	}
+
+	// Only content matches can be symbol definitions.
+	fetchSymbols := pattern != nil && pattern.Pattern != "" && pattern.PatternMatchesContent
+	symbolsKey := func(fm *fileMatchResolver) string {
+		return fmt.Sprintf("symbols:%s@%s:%s:%t:%t:%s", fm.repo.Name, fileMatchRev(fm), fm.JPath, pattern.IsRegExp, pattern.IsCaseSensitive, pattern.Pattern)
+	}
+	lastChangedKey := func(fm *fileMatchResolver) string {
+		return fmt.Sprintf("lastChanged:%s@%s:%s", fm.repo.Name, fileMatchRev(fm), fm.JPath)
+	}
+
+	keys := make([]string, 0, 2*len(fileMatches))
+	for _, fm := range fileMatches {
+		keys = append(keys, lastChangedKey(fm))
+		if fetchSymbols {
+			keys = append(keys, symbolsKey(fm))
+		}
+	}
+	cached := make(map[string][]byte, len(keys))
+	for i, v := range rankingSignalsCache.GetMulti(keys...) {
+		if v != nil {
+			cached[keys[i]] = v
+		}
+	}
+
+	// Group the file matches whose symbol definitions aren't cached by
+	// repository and revision, so that the symbols service is called once per
+	// revision.
+	type repoRev struct {
+		repo api.RepoName
+		rev  string
+	}
+	byRepoRev := map[repoRev][]*fileMatchResolver{}
+	if fetchSymbols {
+		for _, fm := range fileMatches {
+			if v, ok := cached[symbolsKey(fm)]; ok {
+				signals[fm].symbolDefinitions, _ = strconv.Atoi(string(v))
+				continue
+			}
+			k := repoRev{repo: fm.repo.Name, rev: fileMatchRev(fm)}
+			byRepoRev[k] = append(byRepoRev[k], fm)
+		}
+	}
+
+	for k, fms := range byRepoRev {
+		k, fms := k, fms
+		run.Acquire()
+		goroutine.Go(func() {
+			defer run.Release()
+			commitID, err := git.ResolveRevision(ctx, gitserver.Repo{Name: k.repo}, nil, k.rev, &git.ResolveRevisionOptions{NoEnsureRevision: true})
+			if err != nil {
+				return
+			}
+			paths := make([]string, len(fms))
+			for i, fm := range fms {
+				paths[i] = regexp.QuoteMeta(fm.JPath)
+			}
+			symbols, err := backend.Symbols.ListTags(ctx, protocol.SearchArgs{
+				Repo:            k.repo,
+				CommitID:        commitID,
+				Query:           pattern.Pattern,
+				IsCaseSensitive: pattern.IsCaseSensitive,
+				IsRegExp:        pattern.IsRegExp,
+				IncludePatterns: []string{"^(?:" + strings.Join(paths, "|") + ")$"},
+				First:           1000,
+			})
+			if err != nil {
+				tr.LazyPrintf("symbols %s@%s: %s", k.repo, commitID, err)
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, fm := range fms {
+				for _, symbol := range symbols {
+					if symbol.Path == fm.JPath {
+						signals[fm].symbolDefinitions++
+					}
+				}
+				fetched = append(fetched, [2]string{symbolsKey(fm), strconv.Itoa(signals[fm].symbolDefinitions)})
+			}
+		})
+	}
+
+	for _, fm := range fileMatches {
+		if v, ok := cached[lastChangedKey(fm)]; ok {
+			// An empty value means that the file has no commits.
+			if lastChanged, err := time.Parse(time.RFC3339, string(v)); err == nil {
+				signals[fm].lastChanged = &lastChanged
+			}
+			continue
+		}
+
+		fm := fm
+		run.Acquire()
+		goroutine.Go(func() {
+			defer run.Release()
+			commits, err := git.Commits(ctx, gitserver.Repo{Name: fm.repo.Name}, git.CommitsOptions{Range: fileMatchRev(fm), Path: fm.JPath, N: 1})
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if len(commits) == 0 {
+				fetched = append(fetched, [2]string{lastChangedKey(fm), ""})
+				return
+			}
+			lastChanged := commits[0].Author.Date
+			if commits[0].Committer != nil {
+				lastChanged = commits[0].Committer.Date
+			}
+			signals[fm].lastChanged = &lastChanged
+			fetched = append(fetched, [2]string{lastChangedKey(fm), lastChanged.Format(time.RFC3339)})
+		})
+	}
+	run.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(fetched) > 0 {
+		rankingSignalsCache.SetMulti(fetched...)
+	}
+	return signals
+}
+
+// fileMatchRev returns the revision of the file match's repository that was
+// searched.
+func fileMatchRev(fm *fileMatchResolver) string {
+	if fm.commitID != "" {
+		return string(fm.commitID)
+	}
+	if fm.inputRev != nil && *fm.inputRev != "" {
+		return *fm.inputRev
+	}
+	return "HEAD"
+}
@@ -0,0 +1,135 @@
+package graphqlbackend
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestScoreFileMatch(t *testing.T) {
+	now := time.Date(2019, 3, 1, 0, 0, 0, 0, time.UTC)
+	lastChanged := now.Add(-rankingRecencyHalfLife)
+	zoektScore := 7000.0
+	repo := &types.Repo{Name: "r"}
+
+	componentValues := func(s *searchResultScore) map[string]float64 {
+		m := map[string]float64{}
+		for _, c := range s.components {
+			m[c.name] = math.Round(c.value*1e6) / 1e6
+		}
+		return m
+	}
+
+	tests := map[string]struct {
+		fm         *fileMatchResolver
+		signals    *fileMatchRankingSignals
+		popularity float64
+		want       map[string]float64
+	}{
+		"definition": {
+			fm: &fileMatchResolver{repo: repo, JPath: "client.go", JLineMatches: []*lineMatch{
+				{JOffsetAndLengths: [][2]int32{{0, 3}, {5, 3}}},
+				{JOffsetAndLengths: [][2]int32{{0, 3}}},
+			}},
+			signals: &fileMatchRankingSignals{symbolDefinitions: 1, lastChanged: &lastChanged},
+			want:    map[string]float64{"symbolDefinition": 5, "matchDensity": 2, "recency": 1},
+		},
+		"test file in subdirectory": {
+			fm:   &fileMatchResolver{repo: repo, JPath: "a/b/client_test.go"},
+			want: map[string]float64{"pathDepth": -0.5, "test": -3},
+		},
+		"vendored file": {
+			fm:   &fileMatchResolver{repo: repo, JPath: "vendor/x.go"},
+			want: map[string]float64{"pathDepth": -0.25, "vendor": -5},
+		},
+		"zoekt": {
+			fm:         &fileMatchResolver{repo: repo, JPath: "x.go", zoektScore: &zoektScore, JLineMatches: []*lineMatch{{JOffsetAndLengths: [][2]int32{{0, 3}}}}},
+			signals:    &fileMatchRankingSignals{symbolDefinitions: 1},
+			popularity: 999,
+			want:       map[string]float64{"zoekt": 7, "repositoryPopularity": 3},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			score := scoreFileMatch(test.fm, test.signals, test.popularity, now)
+			if got := componentValues(score); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got components %v, want %v", got, test.want)
+			}
+			var sum float64
+			for _, v := range test.want {
+				sum += v
+			}
+			if math.Abs(score.value-sum) > 1e-6 {
+				t.Errorf("got score %v, want %v", score.value, sum)
+			}
+		})
+	}
+}
+
+func TestIsTestPath(t *testing.T) {
+	for path, want := range map[string]bool{
+		"foo_test.go":          true,
+		"src/foo.spec.ts":      true,
+		"test/foo.go":          true,
+		"a/__tests__/b.js":     true,
+		"a/testdata/b.txt":     true,
+		"test_foo.py":          true,
+		"foo.go":               false,
+		"contest/foo.go":       false,
+		"pkg/testing.go":       false,
+		"src/attestation.java": false,
+	} {
+		if got := isTestPath(path); got != want {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestRankFileMatches(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{
+		SearchRepositoryPopularity: map[string]float64{"^popular$": 99},
+	}})
+	defer conf.Mock(nil)
+
+	now := time.Now()
+	mockFetchRankingSignals = func(fileMatches []*fileMatchResolver) map[*fileMatchResolver]*fileMatchRankingSignals {
+		signals := map[*fileMatchResolver]*fileMatchRankingSignals{}
+		for _, fm := range fileMatches {
+			if fm.JPath == "client.go" {
+				signals[fm] = &fileMatchRankingSignals{symbolDefinitions: 1, lastChanged: &now}
+			}
+		}
+		return signals
+	}
+	defer func() { mockFetchRankingSignals = nil }()
+
+	results := []*searchResultResolver{
+		{fileMatch: &fileMatchResolver{repo: &types.Repo{Name: "a"}, JPath: "internal/x/client_test.go"}},
+		{fileMatch: &fileMatchResolver{repo: &types.Repo{Name: "a"}, JPath: "other.go"}},
+		{fileMatch: &fileMatchResolver{repo: &types.Repo{Name: "b"}, JPath: "client.go"}},
+		{fileMatch: &fileMatchResolver{repo: &types.Repo{Name: "popular"}, JPath: "other.go"}},
+		{repo: &repositoryResolver{repo: &types.Repo{Name: "z"}}},
+	}
+	rankFileMatches(context.Background(), &search.PatternInfo{Pattern: "client", PatternMatchesContent: true}, results)
+	sortResults(results)
+
+	var got []string
+	for _, r := range results {
+		repo, file := getSearchResultURIs(r)
+		got = append(got, repo+"/"+file)
+	}
+	want := []string{"z/", "b/client.go", "popular/other.go", "a/other.go", "a/internal/x/client_test.go"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got order %q, want %q", got, want)
+	}
+	if results[1].fileMatch.Score() == nil || len(results[1].fileMatch.Score().Components()) != 2 {
+		t.Errorf("got score %+v, want symbolDefinition and recency components", results[1].fileMatch.Score())
+	}
+}
@@ -0,0 +1,206 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/neelance/parallel"
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/trace"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// searchesMultipleRevisions reports whether repoRev needs to be searched with
+// searchFilesInRepoRevisions instead of searchFilesInRepo, because it has more
+// than one revision or a ref glob.
+func searchesMultipleRevisions(repoRev *search.RepositoryRevisions) bool {
+	if len(repoRev.Revs) > 1 {
+		return true
+	}
+	for _, rev := range repoRev.Revs {
+		if rev.RefGlob != "" || rev.ExcludeRefGlob != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// maxRevisionsToSearch is the maximum number of distinct commits that are
+// searched in a single repository. Each commit is fetched and searched
+// separately, so a ref glob that matches many refs (such as *refs/heads/* in a
+// repository with thousands of branches) would otherwise be very expensive.
+const maxRevisionsToSearch = 50
+
+// A searchRevision is a commit to search, with the names of the refs (or
+// revspecs) that resolved to it.
+type searchRevision struct {
+	commit api.CommitID
+	refs   []string
+}
+
+// resolveSearchRevisions resolves the revspecs of repoRev and expands its ref
+// globs against the repository's branches and tags, returning the distinct
+// commits to search. The revspecs come first, in the order given, followed by
+// the refs matched by the ref globs in name order. At most
+// maxRevisionsToSearch commits are returned, and overLimit reports whether
+// there were more.
+func resolveSearchRevisions(ctx context.Context, repoRev *search.RepositoryRevisions) (revs []*searchRevision, overLimit bool, err error) {
+	byCommit := map[api.CommitID]*searchRevision{}
+	add := func(commit api.CommitID, ref string) {
+		if rev, ok := byCommit[commit]; ok {
+			rev.refs = append(rev.refs, ref)
+			return
+		}
+		if len(revs) == maxRevisionsToSearch {
+			overLimit = true
+			return
+		}
+		rev := &searchRevision{commit: commit, refs: []string{ref}}
+		byCommit[commit] = rev
+		revs = append(revs, rev)
+	}
+
+	hasRefGlobs := false
+	for _, rev := range repoRev.Revs {
+		if rev.RefGlob != "" || rev.ExcludeRefGlob != "" {
+			hasRefGlobs = hasRefGlobs || rev.RefGlob != ""
+			continue
+		}
+		if _, _, _, isDiff := rev.DiffRange(); isDiff {
+			return nil, false, fmt.Errorf("the revision range %q may not be combined with other revisions", rev.RevSpec)
+		}
+		spec := rev.RevSpec
+		if spec == "" {
+			spec = "HEAD"
+		}
+		commit, err := git.ResolveRevision(ctx, repoRev.GitserverRepo(), nil, spec, &git.ResolveRevisionOptions{NoEnsureRevision: true})
+		if err != nil {
+			return nil, false, err
+		}
+		add(commit, spec)
+	}
+	if !hasRefGlobs {
+		return revs, overLimit, nil
+	}
+
+	globs, err := search.CompileRefGlobs(repoRev.Revs)
+	if err != nil {
+		return nil, false, err
+	}
+	branches, err := git.ListBranches(ctx, repoRev.GitserverRepo(), git.BranchesOptions{})
+	if err != nil {
+		return nil, false, err
+	}
+	tags, err := git.ListTags(ctx, repoRev.GitserverRepo())
+	if err != nil {
+		return nil, false, err
+	}
+	refs := make(map[string]api.CommitID, len(branches)+len(tags))
+	for _, b := range branches {
+		refs["refs/heads/"+b.Name] = b.Head
+	}
+	for _, t := range tags {
+		refs["refs/tags/"+t.Name] = t.CommitID
+	}
+	names := make([]string, 0, len(refs))
+	for name := range refs {
+		if globs.Match(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(refs[name], name)
+	}
+	return revs, overLimit, nil
+}
+
+// searchFilesInRepoRevisions searches all revisions of repoRev (see
+// resolveSearchRevisions). A file match that is identical in several
+// revisions (i.e., the same blob at the same path) is only returned once, for
+// the first revision containing it, and lists all refs containing it in its
+// sourceRefs. If there are more than maxRevisionsToSearch revisions, only the
+// first ones are searched and limitHit is true.
+func searchFilesInRepoRevisions(ctx context.Context, repoRev *search.RepositoryRevisions, info *search.PatternInfo, fetchTimeout time.Duration) (matches []*fileMatchResolver, limitHit bool, err error) {
+	tr, ctx := trace.New(ctx, "searchFilesInRepoRevisions", repoRev.String())
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	revs, overLimit, err := resolveSearchRevisions(ctx, repoRev)
+	if err != nil {
+		return nil, false, err
+	}
+	tr.LazyPrintf("%d distinct revisions (overLimit=%v)", len(revs), overLimit)
+
+	type revResult struct {
+		matches  []*fileMatchResolver
+		oids     map[string]git.OID
+		limitHit bool
+	}
+	var (
+		results = make([]revResult, len(revs))
+		run     = parallel.NewRun(8)
+	)
+	for i, rev := range revs {
+		i, rev := i, rev
+		run.Acquire()
+		goroutine.Go(func() {
+			defer run.Release()
+			// Search by ref name (instead of commit ID) so that results link to
+			// the ref.
+			revMatches, revLimitHit, err := searchFilesInRepo(ctx, repoRev.Repo, repoRev.GitserverRepo(), rev.refs[0], info, fetchTimeout)
+			if err != nil {
+				run.Error(errors.Wrapf(err, "searching %s", rev.refs[0]))
+				return
+			}
+			paths := make([]string, len(revMatches))
+			for j, fm := range revMatches {
+				paths[j] = fm.JPath
+			}
+			oids, err := git.BlobOIDs(ctx, repoRev.GitserverRepo(), rev.commit, paths)
+			if err != nil {
+				run.Error(err)
+				return
+			}
+			results[i] = revResult{matches: revMatches, oids: oids, limitHit: revLimitHit}
+		})
+	}
+	if err := run.Wait(); err != nil {
+		return nil, false, err
+	}
+
+	type blobKey struct {
+		path string
+		oid  git.OID
+	}
+	seen := map[blobKey]*fileMatchResolver{}
+	limitHit = overLimit
+	for i, result := range results {
+		limitHit = limitHit || result.limitHit
+		for _, fm := range result.matches {
+			oid, ok := result.oids[fm.JPath]
+			if ok {
+				key := blobKey{path: fm.JPath, oid: oid}
+				if first, ok := seen[key]; ok {
+					first.sourceRefs = append(first.sourceRefs, revs[i].refs...)
+					continue
+				}
+				seen[key] = fm
+			}
+			fm.sourceRefs = append([]string{}, revs[i].refs...)
+			matches = append(matches, fm)
+		}
+	}
+	if len(matches) > int(info.FileMatchLimit) {
+		matches = matches[:info.FileMatchLimit]
+		limitHit = true
+	}
+	return matches, limitHit, nil
+}
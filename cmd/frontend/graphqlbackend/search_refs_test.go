@@ -0,0 +1,97 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestSearchesMultipleRevisions(t *testing.T) {
+	tests := map[string]bool{
+		"repo":                  false,
+		"repo@":                 false,
+		"repo@v1":               false,
+		"repo@main...feature/x": false,
+		"repo@v1:v2":            true,
+		"repo@*refs/heads/*":    true,
+	}
+	for repospec, want := range tests {
+		if got := searchesMultipleRevisions(makeRepositoryRevisions(repospec)[0]); got != want {
+			t.Errorf("%s: got %v, want %v", repospec, got, want)
+		}
+	}
+}
+
+func TestSearchFilesInRepoRevisions(t *testing.T) {
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		return map[string]api.CommitID{"v1": "c1", "v2": "c2", "v3": "c1"}[spec], nil
+	}
+	oids := map[api.CommitID]map[string]git.OID{
+		"c1": {"a.go": git.OID{1}, "b.go": git.OID{2}},
+		"c2": {"a.go": git.OID{1}, "b.go": git.OID{3}},
+	}
+	git.Mocks.BlobOIDs = func(commit api.CommitID, paths []string) (map[string]git.OID, error) {
+		return oids[commit], nil
+	}
+	defer git.ResetMocks()
+	mockSearchFilesInRepo = func(ctx context.Context, repo *types.Repo, gitserverRepo gitserver.Repo, rev string, info *search.PatternInfo, fetchTimeout time.Duration) (matches []*fileMatchResolver, limitHit bool, err error) {
+		for _, path := range []string{"a.go", "b.go"} {
+			matches = append(matches, &fileMatchResolver{JPath: path, uri: fileMatchURI(repo.Name, rev, path), repo: repo})
+		}
+		return matches, false, nil
+	}
+	defer func() { mockSearchFilesInRepo = nil }()
+
+	matches, limitHit, err := searchFilesInRepoRevisions(context.Background(), makeRepositoryRevisions("repo@v1:v2:v3")[0], &search.PatternInfo{FileMatchLimit: 10}, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string][]string{}
+	for _, fm := range matches {
+		got[fm.uri] = fm.sourceRefs
+	}
+	want := map[string][]string{
+		"git://repo?v1#a.go": {"v1", "v3", "v2"}, // same blob in both commits
+		"git://repo?v1#b.go": {"v1", "v3"},
+		"git://repo?v2#b.go": {"v2"},
+	}
+	if !reflect.DeepEqual(got, want) || limitHit {
+		t.Errorf("got %v (limitHit=%v), want %v", got, limitHit, want)
+	}
+}
+
+func TestResolveSearchRevisions_limit(t *testing.T) {
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		if spec == "same" {
+			return "c0", nil
+		}
+		return api.CommitID("c" + spec), nil
+	}
+	defer git.ResetMocks()
+
+	specs := make([]string, maxRevisionsToSearch+1)
+	for i := range specs {
+		specs[i] = strconv.Itoa(i)
+	}
+	repoRev := makeRepositoryRevisions("repo@" + strings.Join(append(specs, "same"), ":"))[0]
+	revs, overLimit, err := resolveSearchRevisions(context.Background(), repoRev)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revs) != maxRevisionsToSearch || !overLimit {
+		t.Errorf("got %d revisions (overLimit=%v), want %d (overLimit=true)", len(revs), overLimit, maxRevisionsToSearch)
+	}
+	// Refs of commits that are searched are still recorded after the limit.
+	if want := []string{"0", "same"}; !reflect.DeepEqual(revs[0].refs, want) {
+		t.Errorf("got refs %q, want %q", revs[0].refs, want)
+	}
+}
@@ -0,0 +1,287 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/zoekt"
+	zoektquery "github.com/google/zoekt/query"
+	"github.com/neelance/parallel"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/trace"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// repoPredicates are the repohasfile:, repohascommitafter: and repohaslang:
+// filters of a search query. Unlike repo:, which matches repository names,
+// they match repositories by the contents of their default branch.
+type repoPredicates struct {
+	hasFile     []string // each pattern must match the path of some file
+	hasNoFile   []string // no pattern may match the path of any file
+	commitAfter string   // if set, there must be a commit after this date (in any format git log --after accepts)
+}
+
+// parseRepoPredicates returns the repository predicates of q, or nil if it
+// has none.
+func parseRepoPredicates(q *query.Query) (*repoPredicates, error) {
+	hasFile, hasNoFile := q.RegexpPatterns(query.FieldRepoHasFile)
+	langInclude, langExclude, err := langIncludeExcludePatterns(q.StringValues(query.FieldRepoHasLang))
+	if err != nil {
+		return nil, err
+	}
+	commitAfter, _ := q.StringValue(query.FieldRepoHasCommitAfter)
+
+	p := &repoPredicates{
+		hasFile:     append(hasFile, langInclude...),
+		hasNoFile:   append(hasNoFile, langExclude...),
+		commitAfter: commitAfter,
+	}
+	if len(p.hasFile) == 0 && len(p.hasNoFile) == 0 && p.commitAfter == "" {
+		return nil, nil
+	}
+	return p, nil
+}
+
+// matchFiles reports whether the paths of the files in a repository satisfy
+// the repohasfile: and repohaslang: predicates.
+func (p *repoPredicates) matchFiles(paths []string) (bool, error) {
+	match := func(pattern string) (bool, error) {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			return false, err
+		}
+		for _, path := range paths {
+			if re.MatchString(path) {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	for _, pattern := range p.hasFile {
+		if ok, err := match(pattern); err != nil || !ok {
+			return false, err
+		}
+	}
+	for _, pattern := range p.hasNoFile {
+		if ok, err := match(pattern); err != nil || ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// filter returns the subset of repos that satisfy the predicates, in the
+// same order. The file predicates are evaluated with zoekt file name queries
+// for repositories whose default branch is indexed, and by listing the files
+// on gitserver for the rest. repohascommitafter: is always evaluated on
+// gitserver.
+//
+// Repositories that the predicates could not be evaluated for (such as
+// repositories that are still being cloned, or that timed out) are excluded
+// and recorded in common, like the main search does for repositories that
+// could not be searched.
+func (p *repoPredicates) filter(ctx context.Context, repos []*search.RepositoryRevisions) (filtered []*search.RepositoryRevisions, common searchResultsCommon, err error) {
+	tr, ctx := trace.New(ctx, "repoPredicates.filter", fmt.Sprintf("%+v, numRepos: %d", *p, len(repos)))
+	defer func() {
+		tr.SetError(err)
+		tr.LazyPrintf("numFiltered: %d, numCloning: %d, numMissing: %d, numTimedOut: %d", len(filtered), len(common.cloning), len(common.missing), len(common.timedout))
+		tr.Finish()
+	}()
+
+	// The predicates apply to the default branch, regardless of which
+	// revisions the query searches.
+	heads := make([]*search.RepositoryRevisions, len(repos))
+	for i, repoRev := range repos {
+		heads[i] = &search.RepositoryRevisions{Repo: repoRev.Repo, Revs: []search.RevisionSpecifier{{RevSpec: ""}}}
+	}
+
+	hasFilePredicates := len(p.hasFile) > 0 || len(p.hasNoFile) > 0
+	matched := make(map[api.RepoName]bool, len(repos))
+	filesMatched := map[api.RepoName]bool{} // indexed repos known to satisfy the file predicates
+	unindexed := heads
+	if hasFilePredicates {
+		var indexed []*search.RepositoryRevisions
+		indexed, unindexed, err = zoektIndexedRepos(ctx, heads)
+		if err != nil {
+			return nil, common, err
+		}
+		indexedMatches, complete, err := p.zoektMatchFiles(ctx, indexed)
+		if err != nil {
+			return nil, common, err
+		}
+		for _, repoRev := range indexed {
+			if !complete {
+				// Not all shards were searched in time, so it is unknown
+				// which indexed repositories match.
+				common.timedout = append(common.timedout, repoRev.Repo)
+				continue
+			}
+			if !indexedMatches[strings.ToLower(string(repoRev.Repo.Name))] {
+				continue
+			}
+			if p.commitAfter != "" {
+				// The commit predicate must still be checked on gitserver.
+				filesMatched[repoRev.Repo.Name] = true
+				unindexed = append(unindexed, repoRev)
+				continue
+			}
+			matched[repoRev.Repo.Name] = true
+		}
+	}
+
+	var (
+		run = parallel.NewRun(20)
+		mu  sync.Mutex
+	)
+	for _, repoRev := range unindexed {
+		repoRev := repoRev
+		checkFiles := hasFilePredicates && !filesMatched[repoRev.Repo.Name]
+		run.Acquire()
+		goroutine.Go(func() {
+			defer run.Release()
+			ok, err := p.gitserverMatch(ctx, repoRev.GitserverRepo(), checkFiles)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if fatalErr := handleRepoSearchResult(&common, *repoRev, false, false, err); fatalErr != nil {
+					run.Error(fatalErr)
+				}
+				return
+			}
+			if ok {
+				matched[repoRev.Repo.Name] = true
+			}
+		})
+	}
+	if err := run.Wait(); err != nil {
+		return nil, searchResultsCommon{}, err
+	}
+
+	for _, repoRev := range repos {
+		if matched[repoRev.Repo.Name] {
+			filtered = append(filtered, repoRev)
+		}
+	}
+	return filtered, common, nil
+}
+
+// zoektMatchFiles returns the (lowercase) names of the indexed repos that
+// satisfy the file predicates. If zoekt could not search all shards in time,
+// complete is false and the matches must not be used.
+func (p *repoPredicates) zoektMatchFiles(ctx context.Context, repos []*search.RepositoryRevisions) (matched map[string]bool, complete bool, err error) {
+	if len(repos) == 0 {
+		return nil, true, nil
+	}
+	repoSet := &zoektquery.RepoSet{Set: make(map[string]bool, len(repos))}
+	for _, repoRev := range repos {
+		repoSet.Set[string(repoRev.Repo.Name)] = true
+	}
+
+	// reposWithFile returns the repos in repoSet with a file whose path
+	// matches pattern. One match per repository is sufficient.
+	reposWithFile := func(pattern string) (matches map[string]bool, complete bool, err error) {
+		// These are the flags used by zoekt (see queryToZoektQuery).
+		re, err := syntax.Parse(pattern, syntax.ClassNL|syntax.PerlX|syntax.UnicodeGroups)
+		if err != nil {
+			return nil, false, err
+		}
+		q := &zoektquery.Regexp{Regexp: re, FileName: true}
+
+		ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		resp, err := Search().Index.Client.Search(ctx, zoektquery.NewAnd(repoSet, q), &zoekt.SearchOptions{
+			ShardMaxMatchCount: 1,
+			TotalMaxMatchCount: len(repos),
+			MaxDocDisplayCount: len(repos),
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		if resp.ShardsSkipped > 0 {
+			return nil, false, nil
+		}
+		matches = make(map[string]bool, len(resp.Files))
+		for _, file := range resp.Files {
+			matches[strings.ToLower(file.Repository)] = true
+		}
+		return matches, true, nil
+	}
+
+	matched = make(map[string]bool, len(repos))
+	for _, repoRev := range repos {
+		matched[strings.ToLower(string(repoRev.Repo.Name))] = true
+	}
+	for _, pattern := range p.hasFile {
+		matches, complete, err := reposWithFile(pattern)
+		if err != nil || !complete {
+			return nil, complete, err
+		}
+		for name := range matched {
+			if !matches[name] {
+				delete(matched, name)
+			}
+		}
+	}
+	for _, pattern := range p.hasNoFile {
+		matches, complete, err := reposWithFile(pattern)
+		if err != nil || !complete {
+			return nil, complete, err
+		}
+		for name := range matches {
+			delete(matched, name)
+		}
+	}
+	return matched, true, nil
+}
+
+// gitserverMatch reports whether repo satisfies the predicates, checking the
+// file predicates only if checkFiles is set. Empty repositories do not match.
+// Errors for repositories that are cloning or missing on gitserver are
+// returned (see handleRepoSearchResult).
+func (p *repoPredicates) gitserverMatch(ctx context.Context, repo gitserver.Repo, checkFiles bool) (bool, error) {
+	commit, err := git.ResolveRevision(ctx, repo, nil, "HEAD", &git.ResolveRevisionOptions{NoEnsureRevision: true})
+	if err != nil {
+		if git.IsRevisionNotFound(err) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if checkFiles {
+		// With recurse, ReadDir runs `git ls-tree -r` at the repository root
+		// (which is cached for large repositories).
+		entries, err := git.ReadDir(ctx, repo, commit, "", true)
+		if err != nil {
+			return false, err
+		}
+		paths := make([]string, 0, len(entries))
+		for _, e := range entries {
+			if !e.IsDir() {
+				paths = append(paths, e.Name())
+			}
+		}
+		if ok, err := p.matchFiles(paths); err != nil || !ok {
+			return false, err
+		}
+	}
+
+	if p.commitAfter != "" {
+		commits, err := git.Commits(ctx, repo, git.CommitsOptions{Range: string(commit), N: 1, After: p.commitAfter})
+		if err != nil {
+			return false, err
+		}
+		if len(commits) == 0 {
+			return false, nil
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,129 @@
+package graphqlbackend
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/util"
+)
+
+func TestParseRepoPredicates(t *testing.T) {
+	tests := map[string]struct {
+		want    *repoPredicates
+		wantErr bool
+	}{
+		"foo":                          {want: nil},
+		"repohasfile:^Dockerfile$ foo": {want: &repoPredicates{hasFile: []string{"^Dockerfile$"}}},
+		"-repohasfile:vendor/":         {want: &repoPredicates{hasNoFile: []string{"vendor/"}}},
+		`repohascommitafter:"2 weeks ago"`: {
+			want: &repoPredicates{commitAfter: "2 weeks ago"},
+		},
+		"repolang:go":              {want: &repoPredicates{hasFile: []string{`\.go$`}}},
+		"-repohaslang:go":          {want: &repoPredicates{hasNoFile: []string{`\.go$`}}},
+		"repohaslang:notalanguage": {wantErr: true},
+	}
+	for input, test := range tests {
+		t.Run(input, func(t *testing.T) {
+			q, err := query.ParseAndCheck(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := parseRepoPredicates(q)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got err %v, want error %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRepoPredicates_matchFiles(t *testing.T) {
+	paths := []string{"Dockerfile", "cmd/main.go", "vendor/x/x.go"}
+	tests := []struct {
+		predicates *repoPredicates
+		want       bool
+	}{
+		{&repoPredicates{hasFile: []string{"^dockerfile$"}}, true},
+		{&repoPredicates{hasFile: []string{"^Dockerfile$", `\.go$`}}, true},
+		{&repoPredicates{hasFile: []string{"^Dockerfile$", `\.py$`}}, false},
+		{&repoPredicates{hasNoFile: []string{"^vendor/"}}, false},
+		{&repoPredicates{hasFile: []string{"main"}, hasNoFile: []string{"^Godeps/"}}, true},
+	}
+	for _, test := range tests {
+		got, err := test.predicates.matchFiles(paths)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != test.want {
+			t.Errorf("%+v: got %v, want %v", *test.predicates, got, test.want)
+		}
+	}
+
+	if _, err := (&repoPredicates{hasFile: []string{"("}}).matchFiles(paths); err == nil {
+		t.Error("got nil error for invalid regexp")
+	}
+}
+
+func TestRepoPredicates_filter(t *testing.T) {
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		if spec != "HEAD" {
+			t.Errorf("got spec %q, want HEAD", spec)
+		}
+		return api.CommitID("c"), nil
+	}
+	git.Mocks.ReadDir = func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error) {
+		if commit != "c" || name != "" || !recurse {
+			t.Errorf("unexpected ReadDir(%q, %q, %v)", commit, name, recurse)
+		}
+		return []os.FileInfo{
+			&util.FileInfo{Name_: "cmd", Mode_: os.ModeDir},
+			&util.FileInfo{Name_: "cmd/main.go"},
+		}, nil
+	}
+	defer git.ResetMocks()
+
+	repos := []*search.RepositoryRevisions{
+		{Repo: &types.Repo{Name: "a"}, Revs: []search.RevisionSpecifier{{RevSpec: "v1"}}},
+	}
+	got, _, err := (&repoPredicates{hasFile: []string{`\.go$`}}).filter(context.Background(), repos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, repos) {
+		t.Errorf("got %+v, want %+v", got, repos)
+	}
+
+	got, _, err = (&repoPredicates{hasFile: []string{"^cmd$"}}).filter(context.Background(), repos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %+v, want no repositories (directories are not files)", got)
+	}
+
+	// Repositories that are still being cloned are excluded and reported,
+	// instead of failing the search.
+	git.Mocks.ResolveRevision = func(spec string, opt *git.ResolveRevisionOptions) (api.CommitID, error) {
+		return "", &vcs.RepoNotExistError{Repo: "a", CloneInProgress: true}
+	}
+	got, common, err := (&repoPredicates{hasFile: []string{`\.go$`}}).filter(context.Background(), repos)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %+v, want no repositories", got)
+	}
+	if len(common.cloning) != 1 || common.cloning[0] != repos[0].Repo {
+		t.Errorf("got cloning %+v, want a", common.cloning)
+	}
+}
@@ -3,9 +3,13 @@ package graphqlbackend
 import (
 	"context"
 	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	searchquery "github.com/sourcegraph/sourcegraph/pkg/search/query"
 )
 
 var mockSearchRepositories func(args *search.Args) ([]*searchResultResolver, *searchResultsCommon, error)
@@ -14,7 +18,8 @@ var repoIcon = "data:image/svg+xml;base64,PHN2ZyB2ZXJzaW9uPSIxLjEiIGlkPSJMYXllcl
 // searchRepositories searches for repositories by name.
 //
 // For a repository to match a query, the repository's name must match all of the repo: patterns AND the
-// default patterns (i.e., the patterns that are not prefixed with any search field).
+// default patterns (i.e., the patterns that are not prefixed with any search field). If the default patterns
+// are combined with AND, OR and NOT, the name must match that boolean combination of them.
 func searchRepositories(ctx context.Context, args *search.Args, limit int32) (res []*searchResultResolver, common *searchResultsCommon, err error) {
 	if mockSearchRepositories != nil {
 		return mockSearchRepositories(args)
@@ -23,6 +28,7 @@ func searchRepositories(ctx context.Context, args *search.Args, limit int32) (re
 	fieldWhitelist := map[string]struct{}{
 		query.FieldRepo:      {},
 		query.FieldRepoGroup: {},
+		query.FieldContext:   {},
 		query.FieldType:      {},
 		query.FieldDefault:   {},
 		query.FieldIndex:     {},
@@ -31,18 +37,30 @@ func searchRepositories(ctx context.Context, args *search.Args, limit int32) (re
 		query.FieldTimeout:   {},
 		query.FieldFork:      {},
 		query.FieldArchived:  {},
+
+		// The repository predicates have already been applied to args.Repos.
+		query.FieldRepoHasFile:        {},
+		query.FieldRepoHasCommitAfter: {},
+		query.FieldRepoHasLang:        {},
 	}
 	// Don't return repo results if the search contains fields that aren't on the whitelist.
-	// Matching repositories based whether they contain files at a certain path (etc.) is not yet implemented.
 	for field := range args.Query.Fields {
 		if _, ok := fieldWhitelist[field]; !ok {
 			return nil, nil, nil
 		}
 	}
 
-	pattern, err := regexp.Compile(args.Pattern.Pattern)
-	if err != nil {
-		return nil, nil, err
+	var match func(name string) bool
+	if args.Pattern.BooleanPattern != nil {
+		if match, err = compileBooleanPattern(args.Pattern.BooleanPattern); err != nil {
+			return nil, nil, err
+		}
+	} else {
+		pattern, err := regexp.Compile(args.Pattern.Pattern)
+		if err != nil {
+			return nil, nil, err
+		}
+		match = pattern.MatchString
 	}
 
 	common = &searchResultsCommon{}
@@ -52,9 +70,78 @@ func searchRepositories(ctx context.Context, args *search.Args, limit int32) (re
 			common.limitHit = true
 			break
 		}
-		if pattern.MatchString(string(repo.Repo.Name)) {
+		if match(string(repo.Repo.Name)) {
 			results = append(results, &searchResultResolver{repo: &repositoryResolver{repo: repo.Repo, icon: repoIcon}})
 		}
 	}
 	return results, common, nil
 }
+
+// compileBooleanPattern returns a function that reports whether a string
+// matches the boolean combination of search patterns q (see
+// search.PatternInfo.BooleanPattern).
+func compileBooleanPattern(q searchquery.Q) (func(string) bool, error) {
+	compileAll := func(qs []searchquery.Q) ([]func(string) bool, error) {
+		matchers := make([]func(string) bool, len(qs))
+		for i, q := range qs {
+			var err error
+			if matchers[i], err = compileBooleanPattern(q); err != nil {
+				return nil, err
+			}
+		}
+		return matchers, nil
+	}
+
+	switch q := q.(type) {
+	case *searchquery.And:
+		matchers, err := compileAll(q.Children)
+		if err != nil {
+			return nil, err
+		}
+		return func(s string) bool {
+			for _, match := range matchers {
+				if !match(s) {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case *searchquery.Or:
+		matchers, err := compileAll(q.Children)
+		if err != nil {
+			return nil, err
+		}
+		return func(s string) bool {
+			for _, match := range matchers {
+				if match(s) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case *searchquery.Not:
+		match, err := compileBooleanPattern(q.Child)
+		if err != nil {
+			return nil, err
+		}
+		return func(s string) bool { return !match(s) }, nil
+	case *searchquery.Substring:
+		if q.CaseSensitive {
+			pattern := q.Pattern
+			return func(s string) bool { return strings.Contains(s, pattern) }, nil
+		}
+		pattern := strings.ToLower(q.Pattern)
+		return func(s string) bool { return strings.Contains(strings.ToLower(s), pattern) }, nil
+	case *searchquery.Regexp:
+		pattern := q.Regexp.String()
+		if !q.CaseSensitive {
+			pattern = "(?i:" + pattern + ")"
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+	return nil, errors.Errorf("unexpected search pattern %T: %v", q, q)
+}
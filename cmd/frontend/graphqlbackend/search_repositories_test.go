@@ -0,0 +1,38 @@
+package graphqlbackend
+
+import (
+	"regexp/syntax"
+	"testing"
+
+	searchquery "github.com/sourcegraph/sourcegraph/pkg/search/query"
+)
+
+func TestCompileBooleanPattern(t *testing.T) {
+	re, err := syntax.Parse("^github\\.com/", syntax.Perl)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// github.com/ AND (mux OR http) AND NOT Test
+	q := searchquery.NewAnd(
+		&searchquery.Regexp{Regexp: re},
+		searchquery.NewOr(&searchquery.Substring{Pattern: "mux"}, &searchquery.Substring{Pattern: "http"}),
+		&searchquery.Not{Child: &searchquery.Substring{Pattern: "Test", CaseSensitive: true}},
+	)
+	match, err := compileBooleanPattern(q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]bool{
+		"github.com/gorilla/mux":      true,
+		"GitHub.com/gorilla/MUX":      true,
+		"github.com/golang/net/http":  true,
+		"gitlab.com/gorilla/mux":      false,
+		"github.com/gorilla/muxTest":  false,
+		"github.com/gorilla/muxtest":  true,
+		"github.com/gorilla/sessions": false,
+	} {
+		if got := match(name); got != want {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+}
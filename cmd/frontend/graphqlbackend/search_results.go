@@ -4,8 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math"
 	"path"
 	"regexp"
+	regexpsyntax "regexp/syntax"
 	"sort"
 	"strconv"
 	"strings"
@@ -24,10 +26,14 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/syntax"
+	querytypes "github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/types"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
 	"github.com/sourcegraph/sourcegraph/pkg/rcache"
+	searchquery "github.com/sourcegraph/sourcegraph/pkg/search/query"
 	"github.com/sourcegraph/sourcegraph/pkg/trace"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
 )
@@ -146,8 +152,9 @@ func (c *searchResultsCommon) update(other searchResultsCommon) {
 type searchResultsResolver struct {
 	results []*searchResultResolver
 	searchResultsCommon
-	alert *searchAlert
-	start time.Time // when the results started being computed
+	alert    *searchAlert
+	progress *searchProgressResolver
+	start    time.Time // when the results started being computed
 }
 
 func (sr *searchResultsResolver) Results() []*searchResultResolver {
@@ -172,6 +179,14 @@ func (sr *searchResultsResolver) ApproximateResultCount() string {
 
 func (sr *searchResultsResolver) Alert() *searchAlert { return sr.alert }
 
+func (sr *searchResultsResolver) Progress() *searchProgressResolver {
+	if sr.progress == nil {
+		// No repositories were searched.
+		return &searchProgressResolver{}
+	}
+	return sr.progress
+}
+
 func (sr *searchResultsResolver) ElapsedMilliseconds() int32 {
 	return int32(time.Since(sr.start).Nanoseconds() / int64(time.Millisecond))
 }
@@ -406,6 +421,8 @@ loop:
 		case r.diff != nil:
 			// Diff searches are cheap, because we implicitly have author date info.
 			addPoint(r.diff.commit.author.date)
+		case r.history != nil:
+			addPoint(r.history.historySearchDate())
 		case r.fileMatch != nil:
 			// File match searches are more expensive, because we must blame the
 			// (first) line in order to know its placement in our sparkline.
@@ -439,9 +456,25 @@ loop:
 	return sparkline, nil
 }
 
-func (r *searchResolver) Results(ctx context.Context) (*searchResultsResolver, error) {
+type searchResultsArgs struct {
+	After *string
+}
+
+func (r *searchResolver) Results(ctx context.Context, args *searchResultsArgs) (*searchResultsResolver, error) {
+	if args != nil && args.After != nil {
+		cursor, err := unmarshalSearchCursor(*args.After)
+		if err != nil {
+			return nil, &badRequestError{err}
+		}
+		if cursor.Query != r.rawQuery() {
+			return nil, &badRequestError{errors.New("the search cursor is for a different search query")}
+		}
+		r.cursor = cursor
+	}
+
 	start := time.Now()
 	rr, err := r.doResults(ctx, "")
+	recordSearchHistory(ctx, r.rawQuery(), rr, err, time.Since(start))
 	if err != nil {
 		log15.Debug("graphql search failed", "query", r.rawQuery(), "duration", time.Since(start), "error", err)
 		return nil, err
@@ -560,8 +593,17 @@ type getPatternInfoOptions struct {
 
 // getPatternInfo gets the search pattern info for the query in the resolver.
 func (r *searchResolver) getPatternInfo(opts *getPatternInfoOptions) (*search.PatternInfo, error) {
-	var patternsToCombine []string
-	if opts == nil || !opts.forceFileSearch {
+	var (
+		patternsToCombine []string
+		booleanPattern    searchquery.Q
+	)
+	if (opts == nil || !opts.forceFileSearch) && r.query.Pattern != nil {
+		var err error
+		booleanPattern, patternsToCombine, err = booleanPatternToQuery(r.query.Pattern, r.query.IsCaseSensitive())
+		if err != nil {
+			return nil, err
+		}
+	} else if opts == nil || !opts.forceFileSearch {
 		for _, v := range r.query.Values(query.FieldDefault) {
 			// Treat quoted strings as literal strings to match, not regexps.
 			var pattern string
@@ -610,6 +652,11 @@ func (r *searchResolver) getPatternInfo(opts *getPatternInfoOptions) (*search.Pa
 		IncludePatterns:              includePatterns,
 		PathPatternsAreRegExps:       true,
 		PathPatternsAreCaseSensitive: r.query.IsCaseSensitive(),
+		BooleanPattern:               booleanPattern,
+	}
+	if booleanPattern != nil {
+		// Any line matching one of the patterns is highlighted.
+		patternInfo.Pattern = unionRegExps(patternsToCombine)
 	}
 	if len(excludePatterns) > 0 {
 		patternInfo.ExcludePattern = unionRegExps(excludePatterns)
@@ -630,6 +677,11 @@ func (r *searchResolver) searchTimeoutFieldSet() bool {
 }
 
 func (r *searchResolver) withTimeout(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	if r.export {
+		ctx, cancel := context.WithTimeout(ctx, exportTimeout)
+		return ctx, cancel, nil
+	}
+
 	d := defaultTimeout
 	timeout, _ := r.query.StringValue(query.FieldTimeout)
 	if timeout != "" {
@@ -650,6 +702,26 @@ func (r *searchResolver) withTimeout(ctx context.Context) (context.Context, cont
 	return ctx, cancel, nil
 }
 
+// booleanUnsupportedResultTypes returns the result types among resultTypes
+// whose searches don't evaluate search patterns combined with AND, OR and
+// NOT. Only text, path and repository searches do.
+func booleanUnsupportedResultTypes(resultTypes []string) []string {
+	var unsupported []string
+	for _, resultType := range resultTypes {
+		switch resultType {
+		case "symbol", "diff", "commit", "history":
+			seen := false
+			for _, t := range unsupported {
+				seen = seen || t == resultType
+			}
+			if !seen {
+				unsupported = append(unsupported, resultType)
+			}
+		}
+	}
+	return unsupported
+}
+
 func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType string) (res *searchResultsResolver, err error) {
 	tr, ctx := trace.New(ctx, "graphql.SearchResults", r.rawQuery())
 	defer func() {
@@ -659,12 +731,25 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 
 	start := time.Now()
 
+	// The search timeout (and the cancellation of optional searches) does not
+	// apply to ranking the results, which has its own timeout.
+	rankCtx := ctx
+
 	ctx, cancel, err := r.withTimeout(ctx)
 	if err != nil {
 		return nil, err
 	}
 	defer cancel()
 
+	// A symbol search across all repositories is answered by the global
+	// symbol index, which isn't limited by the number of repositories.
+	if ok, err := r.useGlobalSymbolIndex(ctx, forceOnlyResultType); err != nil {
+		return nil, err
+	} else if ok {
+		tr.LazyPrintf("searching the global symbol index")
+		return r.searchGlobalSymbolIndex(ctx, start)
+	}
+
 	repos, missingRepoRevs, _, overLimit, err := r.resolveRepositories(ctx, nil)
 	if err != nil {
 		return nil, err
@@ -684,6 +769,12 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		}
 		return &searchResultsResolver{alert: alert, start: start}, nil
 	}
+	if r.cursor != nil {
+		// Resume the search in the repositories that a previous search did not finish searching.
+		repos = r.cursor.resumeRepos(repos)
+		missingRepoRevs = nil
+		tr.LazyPrintf("resuming search in %d repos", len(repos))
+	}
 
 	p, err := r.getPatternInfo(nil)
 	if err != nil {
@@ -698,6 +789,12 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 	if err := args.Pattern.Validate(); err != nil {
 		return nil, &badRequestError{err}
 	}
+	selectValue, _ := r.query.StringValue(query.FieldSelect)
+	selector, err := parseSearchSelector(selectValue)
+	if err != nil {
+		return nil, &badRequestError{err}
+	}
+	args.SelectRepo = selector != nil && selector.kind == selectRepo
 
 	// Determine which types of results to return.
 	var resultTypes []string
@@ -705,10 +802,17 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		resultTypes = []string{forceOnlyResultType}
 	} else {
 		resultTypes, _ = r.query.StringValues(query.FieldType)
-		if len(resultTypes) == 0 {
+		if len(resultTypes) == 0 && selector != nil {
+			resultTypes = selector.resultTypes()
+		} else if len(resultTypes) == 0 {
 			resultTypes = []string{"file", "path", "repo", "ref"}
 		}
 	}
+	if args.Pattern.BooleanPattern != nil {
+		if unsupported := booleanUnsupportedResultTypes(resultTypes); len(unsupported) > 0 {
+			return &searchResultsResolver{alert: r.alertForUnsupportedBooleanQuery(unsupported), start: start}, nil
+		}
+	}
 	seenResultTypes := make(map[string]struct{}, len(resultTypes))
 	for _, resultType := range resultTypes {
 		if resultType == "file" {
@@ -734,6 +838,10 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		fileMatchesMu sync.Mutex
 	)
 
+	// The repositories that the repository predicates could not be evaluated
+	// for are reported like the repositories that could not be searched.
+	common.update(r.repoPredicatesCommon)
+
 	waitGroup := func(required bool) *sync.WaitGroup {
 		if args.UseFullDeadline {
 			// When a custom timeout is specified, all searches are required and get the full timeout.
@@ -754,6 +862,10 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		seenResultTypes[resultType] = struct{}{}
 		switch resultType {
 		case "repo":
+			if r.cursor != nil {
+				// The repository name matches were returned before the search was resumed.
+				continue
+			}
 			// Search for repos
 			wg := waitGroup(true)
 			wg.Add(1)
@@ -873,6 +985,30 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 					commonMu.Unlock()
 				}
 			})
+		case "history":
+			wg := waitGroup(len(resultTypes) == 1)
+			wg.Add(1)
+			goroutine.Go(func() {
+				defer wg.Done()
+
+				historyResults, historyCommon, err := searchHistoryInRepos(ctx, &args)
+				// Timeouts are reported through searchResultsCommon so don't report an error for them
+				if err != nil && !isContextError(ctx, err) {
+					multiErrMu.Lock()
+					multiErr = multierror.Append(multiErr, errors.Wrap(err, "history search failed"))
+					multiErrMu.Unlock()
+				}
+				if historyResults != nil {
+					resultsMu.Lock()
+					results = append(results, historyResults...)
+					resultsMu.Unlock()
+				}
+				if historyCommon != nil {
+					commonMu.Lock()
+					common.update(*historyCommon)
+					commonMu.Unlock()
+				}
+			})
 		case "commit":
 			wg := waitGroup(len(resultTypes) == 1)
 			wg.Add(1)
@@ -930,6 +1066,21 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		multiErr = nil
 	}
 
+	if !r.export {
+		// The repositories that timed out are searched again when the search is resumed, so
+		// their partial results would be repeated on the next page.
+		results = withoutResultsInRepos(results, common.timedout)
+	}
+
+	if selector != nil {
+		var limitHit bool
+		results, limitHit = selector.project(results, int(r.maxResults()))
+		common.limitHit = common.limitHit || limitHit
+	}
+
+	if conf.SearchRankingEnabled() {
+		rankFileMatches(rankCtx, args.Pattern, results)
+	}
 	sortResults(results)
 
 	resultsResolver := searchResultsResolver{
@@ -937,6 +1088,7 @@ func (r *searchResolver) doResults(ctx context.Context, forceOnlyResultType stri
 		searchResultsCommon: common,
 		results:             results,
 		alert:               alert,
+		progress:            newSearchProgress(r.rawQuery(), r.cursor, repos, &common),
 	}
 
 	return &resultsResolver, multiErr.ErrorOrNil()
@@ -952,9 +1104,25 @@ func isContextError(ctx context.Context, err error) bool {
 //
 // Note: Any new result types added here also need to be handled properly in search_results.go:301 (sparklines)
 type searchResultResolver struct {
-	repo      *repositoryResolver         // repo name match
-	fileMatch *fileMatchResolver          // text match
-	diff      *commitSearchResultResolver // diff or commit match
+	repo      *repositoryResolver          // repo name match
+	fileMatch *fileMatchResolver           // text match
+	diff      *commitSearchResultResolver  // diff or commit match
+	history   *historySearchResultResolver // history match
+}
+
+// searchResultRepo returns the repository of the search result.
+func searchResultRepo(r *searchResultResolver) *types.Repo {
+	switch {
+	case r.repo != nil:
+		return r.repo.repo
+	case r.fileMatch != nil:
+		return r.fileMatch.repo
+	case r.diff != nil:
+		return r.diff.commit.repo.repo
+	case r.history != nil:
+		return r.history.repo.repo
+	}
+	return nil
 }
 
 // getSearchResultURIs returns the repo name and file uri respectiveley
@@ -965,14 +1133,22 @@ func getSearchResultURIs(c *searchResultResolver) (string, string) {
 	if c.repo != nil {
 		return string(c.repo.repo.Name), ""
 	}
-	// Diffs aren't going to be returned with other types of results
+	// Diffs and history matches aren't going to be returned with other types of results
 	// and are already ordered in the desired order, so we'll just leave them in place.
 	return "~", "~" // lexicographically last in ASCII
 }
 
 // compareSearchResults checks to see if a is less than b.
 // It is implemented separately for easier testing.
+//
+// Repository matches come first, followed by file matches in order of their
+// relevance score (see rankFileMatches), followed by diffs. Ties are broken by
+// repository name and file path.
 func compareSearchResults(a, b *searchResultResolver) bool {
+	if ascore, bscore := searchResultScoreValue(a), searchResultScoreValue(b); ascore != bscore {
+		return ascore > bscore
+	}
+
 	arepo, afile := getSearchResultURIs(a)
 	brepo, bfile := getSearchResultURIs(b)
 
@@ -984,8 +1160,24 @@ func compareSearchResults(a, b *searchResultResolver) bool {
 
 }
 
+// searchResultScoreValue returns the value by which search results are ordered
+// (in descending order) before they are ordered by repository name and file path.
+func searchResultScoreValue(r *searchResultResolver) float64 {
+	switch {
+	case r.repo != nil:
+		return math.Inf(1)
+	case r.fileMatch != nil:
+		if r.fileMatch.score != nil {
+			return r.fileMatch.score.value
+		}
+		return 0
+	default:
+		return math.Inf(-1)
+	}
+}
+
 func sortResults(r []*searchResultResolver) {
-	sort.Slice(r, func(i, j int) bool { return compareSearchResults(r[i], r[j]) })
+	sort.SliceStable(r, func(i, j int) bool { return compareSearchResults(r[i], r[j]) })
 }
 
 func (g *searchResultResolver) ToRepository() (*repositoryResolver, bool) {
@@ -997,6 +1189,9 @@ func (g *searchResultResolver) ToFileMatch() (*fileMatchResolver, bool) {
 func (g *searchResultResolver) ToCommitSearchResult() (*commitSearchResultResolver, bool) {
 	return g.diff, g.diff != nil
 }
+func (g *searchResultResolver) ToHistorySearchResult() (*historySearchResultResolver, bool) {
+	return g.history, g.history != nil
+}
 
 func (g *searchResultResolver) resultCount() int32 {
 	switch {
@@ -1012,6 +1207,56 @@ func (g *searchResultResolver) resultCount() int32 {
 	}
 }
 
+// booleanPatternToQuery converts a boolean combination of search patterns
+// (e.g. "(a OR b) AND NOT c") to the query matched against file contents. It
+// also returns the regexp sources of the patterns which are not negated.
+func booleanPatternToQuery(p *querytypes.PatternExpr, caseSensitive bool) (q searchquery.Q, positive []string, err error) {
+	var convert func(p *querytypes.PatternExpr, negated bool) (searchquery.Q, error)
+	convert = func(p *querytypes.PatternExpr, negated bool) (searchquery.Q, error) {
+		switch p.Kind {
+		case 0:
+			var pattern string
+			switch {
+			case p.Value.String != nil:
+				pattern = regexp.QuoteMeta(*p.Value.String)
+			case p.Value.Regexp != nil:
+				pattern = p.Value.Regexp.String()
+			}
+			if !negated {
+				positive = append(positive, pattern)
+			}
+			if p.Value.String != nil {
+				return &searchquery.Substring{Pattern: *p.Value.String, CaseSensitive: caseSensitive, Content: true}, nil
+			}
+			re, err := regexpsyntax.Parse(pattern, regexpsyntax.Perl)
+			if err != nil {
+				return nil, err
+			}
+			return &searchquery.Regexp{Regexp: re, CaseSensitive: caseSensitive, Content: true}, nil
+
+		case syntax.OperatorNot:
+			child, err := convert(p.Operands[0], !negated)
+			if err != nil {
+				return nil, err
+			}
+			return &searchquery.Not{Child: child}, nil
+		}
+
+		children := make([]searchquery.Q, len(p.Operands))
+		for i, o := range p.Operands {
+			if children[i], err = convert(o, negated); err != nil {
+				return nil, err
+			}
+		}
+		if p.Kind == syntax.OperatorOr {
+			return searchquery.NewOr(children...), nil
+		}
+		return searchquery.NewAnd(children...), nil
+	}
+	q, err = convert(p, false)
+	return q, positive, err
+}
+
 // regexpPatternMatchingExprsInOrder returns a regexp that matches lines that contain
 // non-overlapping matches for each pattern in order.
 func regexpPatternMatchingExprsInOrder(patterns []string) string {
@@ -21,7 +21,7 @@ func TestSearchResults(t *testing.T) {
 		if err != nil {
 			t.Fatal("Search:", err)
 		}
-		results, err := r.Results(context.Background())
+		results, err := r.Results(context.Background(), &searchResultsArgs{})
 		if err != nil {
 			t.Fatal("Results:", err)
 		}
@@ -105,11 +105,14 @@ func TestSearchResults(t *testing.T) {
 				t.Errorf("got %q, want %q", args.Pattern.Pattern, want)
 			}
 			return []*fileMatchResolver{
-				{uri: "git://repo?rev#dir/file", JPath: "dir/file", JLineMatches: []*lineMatch{{JLineNumber: 123}}},
+				{uri: "git://repo?rev#dir/file", JPath: "dir/file", JLineMatches: []*lineMatch{{JLineNumber: 123}}, repo: &types.Repo{Name: "repo"}},
 			}, &searchResultsCommon{}, nil
 		}
 		defer func() { mockSearchFilesInRepos = nil }()
 
+		mockFetchRankingSignals = func([]*fileMatchResolver) map[*fileMatchResolver]*fileMatchRankingSignals { return nil }
+		defer func() { mockFetchRankingSignals = nil }()
+
 		testCallResults(t, `foo\d "bar*"`, []string{"dir/file:123"})
 		if !calledReposList {
 			t.Error("!calledReposList")
@@ -143,6 +146,36 @@ func TestRegexpPatternMatchingExprsInOrder(t *testing.T) {
 	}
 }
 
+func TestBooleanPatternToQuery(t *testing.T) {
+	tests := []struct {
+		query        string
+		want         string
+		wantPositive []string
+	}{
+		{query: "a OR b", want: `(or regex:"a" regex:"b")`, wantPositive: []string{"a", "b"}},
+		{query: `(a OR "b.") AND NOT c`, want: `(and (or regex:"a" content_substr:"b.") (not regex:"c"))`, wantPositive: []string{"a", `b\.`}},
+		{query: "NOT (a OR NOT b)", want: `(not (or regex:"a" (not regex:"b")))`, wantPositive: []string{"b"}},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			q, err := query.ParseAndCheck(test.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, positive, err := booleanPatternToQuery(q.Pattern, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.String() != test.want {
+				t.Errorf("got %s, want %s", got, test.want)
+			}
+			if !reflect.DeepEqual(positive, test.wantPositive) {
+				t.Errorf("got positive %q, want %q", positive, test.wantPositive)
+			}
+		})
+	}
+}
+
 func TestSearchResolver_getPatternInfo(t *testing.T) {
 	normalize := func(p *search.PatternInfo) {
 		if len(p.IncludePatterns) == 0 {
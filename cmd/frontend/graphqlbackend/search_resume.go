@@ -0,0 +1,140 @@
+package graphqlbackend
+
+import (
+	"errors"
+
+	graphql "github.com/graph-gophers/graphql-go"
+	"github.com/graph-gophers/graphql-go/relay"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+// A search that times out returns the results it found before the deadline, along with a
+// cursor that resumes the search in the repositories that were not (fully) searched. This
+// file contains the cursor and the progress statistics of a search.
+
+const searchCursorKind = "SearchCursor"
+
+// searchCursor is the state of a search that timed out, which is needed to resume it. It is
+// marshaled into an opaque string for clients.
+type searchCursor struct {
+	Query     string       `json:"q"` // the resumed search must have the same query
+	Repos     []api.RepoID `json:"r"` // the repositories that remain to be searched
+	Total     int32        `json:"t"` // the number of repositories of the original search
+	Completed int32        `json:"c"` // the number of repositories searched by all previous pages
+}
+
+func marshalSearchCursor(c *searchCursor) string {
+	return string(relay.MarshalID(searchCursorKind, c))
+}
+
+func unmarshalSearchCursor(s string) (*searchCursor, error) {
+	if relay.UnmarshalKind(graphql.ID(s)) != searchCursorKind {
+		return nil, errors.New("invalid search cursor")
+	}
+	var c searchCursor
+	if err := relay.UnmarshalSpec(graphql.ID(s), &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// resumeRepos returns the repositories of repos that remain to be searched according to the
+// cursor, in the order of repos.
+func (c *searchCursor) resumeRepos(repos []*search.RepositoryRevisions) []*search.RepositoryRevisions {
+	remaining := make(map[api.RepoID]struct{}, len(c.Repos))
+	for _, id := range c.Repos {
+		remaining[id] = struct{}{}
+	}
+	var resumed []*search.RepositoryRevisions
+	for _, repoRev := range repos {
+		if _, ok := remaining[repoRev.Repo.ID]; ok {
+			resumed = append(resumed, repoRev)
+		}
+	}
+	return resumed
+}
+
+// newSearchProgress returns the progress of a search (or a page of a resumed search, if cursor
+// is non-nil) of repos whose results are described by common.
+func newSearchProgress(query string, cursor *searchCursor, repos []*search.RepositoryRevisions, common *searchResultsCommon) *searchProgressResolver {
+	total, completed := int32(len(repos)), int32(0)
+	if cursor != nil {
+		total, completed = cursor.Total, cursor.Completed
+	}
+
+	// A repository that timed out for one result type (e.g., in a symbol search) is searched
+	// again for all result types when the search is resumed.
+	timedout := make(map[api.RepoID]struct{}, len(common.timedout))
+	for _, repo := range common.timedout {
+		timedout[repo.ID] = struct{}{}
+	}
+	var remaining []*types.Repo
+	for _, repoRev := range repos {
+		if _, ok := timedout[repoRev.Repo.ID]; ok {
+			remaining = append(remaining, repoRev.Repo)
+		}
+	}
+
+	p := &searchProgressResolver{
+		total:     total,
+		completed: completed + int32(len(repos)-len(remaining)),
+		remaining: remaining,
+	}
+	if len(remaining) > 0 {
+		c := &searchCursor{Query: query, Total: total, Completed: p.completed}
+		for _, repo := range remaining {
+			c.Repos = append(c.Repos, repo.ID)
+		}
+		p.cursor = marshalSearchCursor(c)
+	}
+	return p
+}
+
+// withoutResultsInRepos returns the results that are not in the repos. A repository that timed
+// out is in the cursor of the search, so its partial results are omitted: the resumed search
+// returns all of its results.
+func withoutResultsInRepos(results []*searchResultResolver, repos []*types.Repo) []*searchResultResolver {
+	if len(repos) == 0 {
+		return results
+	}
+	omit := make(map[api.RepoID]struct{}, len(repos))
+	for _, repo := range repos {
+		omit[repo.ID] = struct{}{}
+	}
+	kept := results[:0]
+	for _, r := range results {
+		if repo := searchResultRepo(r); repo != nil {
+			if _, ok := omit[repo.ID]; ok {
+				continue
+			}
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// searchProgressResolver is a resolver for the GraphQL type `SearchProgress`.
+type searchProgressResolver struct {
+	total, completed int32
+	remaining        []*types.Repo
+	cursor           string // empty if the search is complete
+}
+
+func (p *searchProgressResolver) Complete() bool { return len(p.remaining) == 0 }
+
+func (p *searchProgressResolver) RepositoriesTotal() int32 { return p.total }
+
+func (p *searchProgressResolver) RepositoriesCompleted() int32 { return p.completed }
+
+func (p *searchProgressResolver) RepositoriesRemaining() []*repositoryResolver {
+	return toRepositoryResolvers(p.remaining)
+}
+
+func (p *searchProgressResolver) ResumeCursor() *string {
+	if p.cursor == "" {
+		return nil
+	}
+	return &p.cursor
+}
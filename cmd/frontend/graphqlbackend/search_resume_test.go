@@ -0,0 +1,100 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestSearchResults_resume(t *testing.T) {
+	repos := []*types.Repo{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}, {ID: 3, Name: "c"}}
+	db.Mocks.Repos.List = func(_ context.Context, op db.ReposListOptions) ([]*types.Repo, error) {
+		return repos, nil
+	}
+	defer func() { db.Mocks = db.MockStores{} }()
+
+	// The first search times out in b (after finding a match) and c; the resumed search
+	// times out in c.
+	var searchedRepos [][]api.RepoName
+	mockSearchFilesInRepos = func(args *search.Args) ([]*fileMatchResolver, *searchResultsCommon, error) {
+		var (
+			names   []api.RepoName
+			matches []*fileMatchResolver
+		)
+		for _, repoRev := range args.Repos {
+			names = append(names, repoRev.Repo.Name)
+			if repoRev.Repo.ID != 3 {
+				matches = append(matches, &fileMatchResolver{uri: "git://" + string(repoRev.Repo.Name) + "?#f", JPath: "f", repo: repoRev.Repo})
+			}
+		}
+		searchedRepos = append(searchedRepos, names)
+		common := &searchResultsCommon{timedout: []*types.Repo{repos[2]}}
+		if len(searchedRepos) == 1 {
+			common.timedout = append(common.timedout, repos[1])
+		}
+		return matches, common, nil
+	}
+	defer func() { mockSearchFilesInRepos = nil }()
+	mockFetchRankingSignals = func([]*fileMatchResolver) map[*fileMatchResolver]*fileMatchRankingSignals { return nil }
+	defer func() { mockFetchRankingSignals = nil }()
+
+	results := func(query string, after *string) (*searchResultsResolver, error) {
+		r, err := (&schemaResolver{}).Search(&struct{ Query string }{Query: query})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r.Results(context.Background(), &searchResultsArgs{After: after})
+	}
+	resultRepos := func(res *searchResultsResolver) []string {
+		var names []string
+		for _, r := range res.Results() {
+			names = append(names, string(searchResultRepo(r).Name))
+		}
+		return names
+	}
+	progress := func(p *searchProgressResolver) []interface{} {
+		var remaining []string
+		for _, repo := range p.RepositoriesRemaining() {
+			remaining = append(remaining, repo.Name())
+		}
+		return []interface{}{p.Complete(), p.RepositoriesTotal(), p.RepositoriesCompleted(), remaining, p.ResumeCursor() != nil}
+	}
+
+	res, err := results("type:file x", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultRepos(res); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("got results in %v, want the results found before the timeout, except in repos that timed out", got)
+	}
+	if got, want := progress(res.Progress()), []interface{}{false, int32(3), int32(1), []string{"b", "c"}, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got progress %v, want %v", got, want)
+	}
+
+	res, err = results("type:file x", res.Progress().ResumeCursor())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := resultRepos(res); !reflect.DeepEqual(got, []string{"b"}) {
+		t.Errorf("got results in %v, want the results in the resumed repos that didn't time out", got)
+	}
+	if got, want := progress(res.Progress()), []interface{}{false, int32(3), int32(2), []string{"c"}, true}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got progress %v, want %v", got, want)
+	}
+	if want := [][]api.RepoName{{"a", "b", "c"}, {"b", "c"}}; !reflect.DeepEqual(searchedRepos, want) {
+		t.Errorf("got searched repos %v, want %v", searchedRepos, want)
+	}
+
+	if _, err := results("type:file y", res.Progress().ResumeCursor()); err == nil {
+		t.Error("got no error for a cursor of a different query")
+	}
+	invalid := "x"
+	if _, err := results("type:file x", &invalid); err == nil {
+		t.Error("got no error for an invalid cursor")
+	}
+}
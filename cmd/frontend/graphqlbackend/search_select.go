@@ -0,0 +1,164 @@
+package graphqlbackend
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// searchSelector is the projection of search results requested with the
+// select: field, e.g. select:repo or select:symbol.function.
+type searchSelector struct {
+	kind       string // selectRepo, selectFile, selectSymbol or selectCommitAuthor
+	symbolKind string // for selectSymbol, the symbol kind to keep (e.g. "FUNCTION"), or "" for all
+}
+
+// All select: values (excluding the symbol kind of select:symbol.KIND).
+const (
+	selectRepo         = "repo"
+	selectFile         = "file"
+	selectSymbol       = "symbol"
+	selectCommitAuthor = "commit.author"
+)
+
+// symbolKinds is the set of values of the SymbolKind GraphQL enum.
+var symbolKinds = map[string]bool{
+	"UNKNOWN": true, "FILE": true, "MODULE": true, "NAMESPACE": true, "PACKAGE": true,
+	"CLASS": true, "METHOD": true, "PROPERTY": true, "FIELD": true, "CONSTRUCTOR": true,
+	"ENUM": true, "INTERFACE": true, "FUNCTION": true, "VARIABLE": true, "CONSTANT": true,
+	"STRING": true, "NUMBER": true, "BOOLEAN": true, "ARRAY": true, "OBJECT": true,
+	"KEY": true, "NULL": true, "ENUMMEMBER": true, "STRUCT": true, "EVENT": true,
+	"OPERATOR": true, "TYPEPARAMETER": true,
+}
+
+// parseSearchSelector parses the value of the select: field. It returns nil
+// if value is empty.
+func parseSearchSelector(value string) (*searchSelector, error) {
+	switch value {
+	case "":
+		return nil, nil
+	case selectRepo, selectFile, selectSymbol, selectCommitAuthor:
+		return &searchSelector{kind: value}, nil
+	}
+	if kind := strings.TrimPrefix(value, selectSymbol+"."); kind != value {
+		if kind = strings.ToUpper(kind); symbolKinds[kind] {
+			return &searchSelector{kind: selectSymbol, symbolKind: kind}, nil
+		}
+		return nil, fmt.Errorf("invalid select:%s (unknown symbol kind)", value)
+	}
+	return nil, fmt.Errorf("invalid select:%s (valid values are: repo, file, symbol, symbol.KIND, commit.author)", value)
+}
+
+// resultTypes returns the result types to search for when the query does not
+// specify type:.
+func (s *searchSelector) resultTypes() []string {
+	switch s.kind {
+	case selectFile:
+		return []string{"file", "path"}
+	case selectSymbol:
+		return []string{"symbol"}
+	case selectCommitAuthor:
+		return []string{"commit"}
+	}
+	return []string{"file", "path", "repo"}
+}
+
+// project returns the deduplicated projection of results, keeping at most
+// limit of the projected results. Results which can't be projected (e.g.
+// repository matches for select:file) are dropped.
+func (s *searchSelector) project(results []*searchResultResolver, limit int) (projected []*searchResultResolver, limitHit bool) {
+	seen := map[string]bool{}
+	add := func(key string, r *searchResultResolver) {
+		if !seen[key] {
+			seen[key] = true
+			projected = append(projected, r)
+		}
+	}
+
+	for _, r := range results {
+		switch s.kind {
+		case selectRepo:
+			if repo := searchResultRepo(r); repo != nil {
+				add(string(repo.Name), &searchResultResolver{repo: &repositoryResolver{repo: repo}})
+			}
+
+		case selectFile:
+			if r.fileMatch != nil {
+				add(r.fileMatch.uri, &searchResultResolver{fileMatch: &fileMatchResolver{
+					JPath:      r.fileMatch.JPath,
+					uri:        r.fileMatch.uri,
+					repo:       r.fileMatch.repo,
+					commitID:   r.fileMatch.commitID,
+					inputRev:   r.fileMatch.inputRev,
+					sourceRefs: r.fileMatch.sourceRefs,
+				}})
+			}
+
+		case selectSymbol:
+			if r.fileMatch == nil || len(r.fileMatch.symbols) == 0 {
+				continue
+			}
+			fm := *r.fileMatch
+			fm.JLineMatches = nil
+			fm.symbols = nil
+			for _, sym := range r.fileMatch.symbols {
+				if s.symbolKind == "" || sym.Kind() == s.symbolKind {
+					fm.symbols = append(fm.symbols, sym)
+				}
+			}
+			if len(fm.symbols) > 0 {
+				add(fm.uri, &searchResultResolver{fileMatch: &fm})
+			}
+
+		case selectCommitAuthor:
+			if r.diff != nil && r.diff.commit.author.person != nil {
+				add(strings.ToLower(r.diff.commit.author.person.email), r)
+			}
+		}
+	}
+
+	if s.kind == selectSymbol {
+		// Symbols are the projected units, so count those against the limit.
+		count := 0
+		for i, r := range projected {
+			if count+len(r.fileMatch.symbols) > limit {
+				r.fileMatch.symbols = r.fileMatch.symbols[:limit-count]
+				if len(r.fileMatch.symbols) > 0 {
+					i++
+				}
+				return projected[:i], true
+			}
+			count += len(r.fileMatch.symbols)
+		}
+		return projected, false
+	}
+	if len(projected) > limit {
+		// Keep the same units regardless of the order results arrived in.
+		sortResults(projected)
+		return projected[:limit], true
+	}
+	return projected, false
+}
+
+// firstMatchPerRepo returns the first file match of each repository in
+// unflattened, keeping at most limit repositories. It is used instead of
+// flattenFileMatches for select:repo.
+func firstMatchPerRepo(unflattened [][]*fileMatchResolver, limit int) []*fileMatchResolver {
+	seen := map[string]bool{}
+	var matches []*fileMatchResolver
+	for _, fms := range unflattened {
+		for _, fm := range fms {
+			if name := string(fm.repo.Name); !seen[name] {
+				seen[name] = true
+				matches = append(matches, fm)
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].repo.Name < matches[j].repo.Name
+	})
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
@@ -0,0 +1,104 @@
+package graphqlbackend
+
+import (
+	"reflect"
+	"testing"
+
+	lsp "github.com/sourcegraph/go-lsp"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+)
+
+func TestParseSearchSelector(t *testing.T) {
+	tests := map[string]struct {
+		want    *searchSelector
+		wantErr bool
+	}{
+		"":                {want: nil},
+		"repo":            {want: &searchSelector{kind: selectRepo}},
+		"file":            {want: &searchSelector{kind: selectFile}},
+		"symbol":          {want: &searchSelector{kind: selectSymbol}},
+		"symbol.function": {want: &searchSelector{kind: selectSymbol, symbolKind: "FUNCTION"}},
+		"commit.author":   {want: &searchSelector{kind: selectCommitAuthor}},
+		"symbol.foo":      {wantErr: true},
+		"foo":             {wantErr: true},
+	}
+	for value, test := range tests {
+		t.Run(value, func(t *testing.T) {
+			got, err := parseSearchSelector(value)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("got err %v, want error %v", err, test.wantErr)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestSearchSelector_project(t *testing.T) {
+	repoA := &types.Repo{Name: "a"}
+	repoB := &types.Repo{Name: "b"}
+	fileMatch := func(repo *types.Repo, path string, symbols ...*symbolResolver) *searchResultResolver {
+		return &searchResultResolver{fileMatch: &fileMatchResolver{
+			JPath:        path,
+			JLineMatches: []*lineMatch{{JPreview: "x"}},
+			symbols:      symbols,
+			uri:          fileMatchURI(repo.Name, "", path),
+			repo:         repo,
+		}}
+	}
+	symbol := func(name string, kind lsp.SymbolKind) *symbolResolver {
+		return &symbolResolver{symbol: lsp.SymbolInformation{Name: name, Kind: kind}}
+	}
+	results := []*searchResultResolver{
+		fileMatch(repoA, "x.go", symbol("f", lsp.SKFunction), symbol("T", lsp.SKStruct)),
+		fileMatch(repoA, "y.go", symbol("g", lsp.SKFunction)),
+		fileMatch(repoB, "x.go"),
+		{repo: &repositoryResolver{repo: repoB}},
+	}
+
+	t.Run("repo", func(t *testing.T) {
+		got, limitHit := (&searchSelector{kind: selectRepo}).project(results, 10)
+		var names []string
+		for _, r := range got {
+			names = append(names, string(r.repo.repo.Name))
+		}
+		if want := []string{"a", "b"}; !reflect.DeepEqual(names, want) || limitHit {
+			t.Errorf("got %v (limitHit=%v), want %v", names, limitHit, want)
+		}
+
+		if got, limitHit := (&searchSelector{kind: selectRepo}).project(results, 1); len(got) != 1 || !limitHit {
+			t.Errorf("got %d results (limitHit=%v), want 1 (limitHit=true)", len(got), limitHit)
+		}
+	})
+
+	t.Run("file", func(t *testing.T) {
+		got, _ := (&searchSelector{kind: selectFile}).project(results, 10)
+		if len(got) != 3 {
+			t.Fatalf("got %d results, want 3", len(got))
+		}
+		for _, r := range got {
+			if r.fileMatch == nil || len(r.fileMatch.JLineMatches) != 0 || len(r.fileMatch.symbols) != 0 {
+				t.Errorf("got %+v, want a path-only file match", r)
+			}
+		}
+	})
+
+	t.Run("symbol.function", func(t *testing.T) {
+		got, limitHit := (&searchSelector{kind: selectSymbol, symbolKind: "FUNCTION"}).project(results, 10)
+		var names []string
+		for _, r := range got {
+			for _, s := range r.fileMatch.symbols {
+				names = append(names, s.Name())
+			}
+		}
+		if want := []string{"f", "g"}; !reflect.DeepEqual(names, want) || limitHit {
+			t.Errorf("got %v (limitHit=%v), want %v", names, limitHit, want)
+		}
+
+		got, limitHit = (&searchSelector{kind: selectSymbol}).project(results, 2)
+		if len(got) != 1 || len(got[0].fileMatch.symbols) != 2 || !limitHit {
+			t.Errorf("got %d results (limitHit=%v), want 1 file with 2 symbols (limitHit=true)", len(got), limitHit)
+		}
+	})
+}
@@ -105,6 +105,10 @@ func (r *searchResolver) Suggestions(ctx context.Context, args *searchSuggestion
 		if err != nil {
 			return nil, err
 		}
+		if p.BooleanPattern != nil {
+			// Symbol search can't evaluate AND, OR and NOT.
+			return nil, nil
+		}
 
 		ctx, cancel := context.WithTimeout(ctx, 1*time.Second)
 		defer cancel()
@@ -179,6 +183,8 @@ func (r *searchResolver) Suggestions(ctx context.Context, args *searchSuggestion
 	}
 	suggesters = append(suggesters, showFilesWithTextMatches)
 
+	suggesters = append(suggesters, r.suggestRecentSearches)
+
 	// Run suggesters.
 	var (
 		allSuggestions []*searchSuggestionResolver
@@ -224,6 +230,7 @@ func (r *searchResolver) Suggestions(ctx context.Context, args *searchSuggestion
 		repoRev  string
 		file     string
 		symbol   string
+		query    string
 	}
 	seen := make(map[key]struct{}, len(allSuggestions))
 	uniqueSuggestions := allSuggestions[:0]
@@ -243,6 +250,8 @@ func (r *searchResolver) Suggestions(ctx context.Context, args *searchSuggestion
 		case *symbolResolver:
 			k.repoName = s.location.resource.commit.repo.repo.Name
 			k.symbol = s.symbol.Name + s.symbol.ContainerName
+		case *searchHistoryEntryResolver:
+			k.query = s.entry.Query
 		default:
 			panic(fmt.Sprintf("unhandled: %#v", s))
 		}
@@ -6,6 +6,7 @@ import (
 	"net/url"
 	"strings"
 	"sync"
+	"unicode/utf8"
 
 	"github.com/neelance/parallel"
 	opentracing "github.com/opentracing/opentracing-go"
@@ -179,35 +180,70 @@ func makeFileMatchURIFromSymbol(symbolResolver *symbolResolver, inputRev string)
 // symbolToLSPSymbolInformation converts a symbols service Symbol struct to an LSP SymbolInformation
 // baseURI is the git://repo?rev base URI for the symbol that is extended with the file path
 func symbolToLSPSymbolInformation(s protocol.Symbol, baseURI *gituri.URI) lsp.SymbolInformation {
-	ch := ctagsSymbolCharacter(s)
 	return lsp.SymbolInformation{
 		Name:          s.Name + s.Signature,
 		ContainerName: s.Parent,
 		Kind:          ctagsKindToLSPSymbolKind(s.Kind),
 		Location: lsp.Location{
-			URI: lsp.DocumentURI(baseURI.WithFilePath(s.Path).String()),
-			Range: lsp.Range{
-				Start: lsp.Position{Line: s.Line - 1, Character: ch},
-				End:   lsp.Position{Line: s.Line - 1, Character: ch + len(s.Name)},
-			},
+			URI:   lsp.DocumentURI(baseURI.WithFilePath(s.Path).String()),
+			Range: symbolNameRange(s),
 		},
 	}
 }
 
-// ctagsSymbolCharacter only outputs the line number, not the character (or range). Use the regexp it provides to
-// guess the character.
-func ctagsSymbolCharacter(s protocol.Symbol) int {
-	if s.Pattern == "" {
-		return 0
+// symbolNameRange returns the range of the name of a symbols service Symbol.
+// The symbols service reports byte offsets, which are converted to character
+// offsets (like the offsets of search-based code intel) using the line of the
+// symbol in its pattern.
+func symbolNameRange(s protocol.Symbol) lsp.Range {
+	line := symbolPatternLine(s)
+	ch := s.Character
+	if s.EndLine == 0 {
+		// The position is only known for symbols from a native parser.
+		ch = ctagsSymbolCharacter(s, line)
 	}
-	pattern := strings.TrimPrefix(s.Pattern, "/^")
-	i := strings.Index(pattern, s.Name)
+	ch = byteToCharacterOffset(line, ch)
+	return lsp.Range{
+		Start: lsp.Position{Line: s.Line - 1, Character: ch},
+		End:   lsp.Position{Line: s.Line - 1, Character: ch + utf8.RuneCountInString(s.Name)},
+	}
+}
+
+// ctagsSymbolCharacter only outputs the line number, not the character (or range). Use the line in the regexp it
+// provides to guess the byte offset of the symbol in the line.
+func ctagsSymbolCharacter(s protocol.Symbol, line string) int {
+	i := strings.Index(line, s.Name)
 	if i >= 0 {
 		return i
 	}
 	return 0
 }
 
+// symbolPatternLine returns the line of the symbol from its ctags style
+// search pattern (e.g. /^func F() {}$/), or "" if it has none.
+func symbolPatternLine(s protocol.Symbol) string {
+	if !strings.HasPrefix(s.Pattern, "/^") {
+		return ""
+	}
+	line := strings.TrimPrefix(s.Pattern, "/^")
+	if strings.HasSuffix(line, "$/") {
+		line = strings.TrimSuffix(line, "$/")
+	} else {
+		// ctags truncates long lines.
+		line = strings.TrimSuffix(line, "/")
+	}
+	return strings.NewReplacer(`\\`, `\`, `\/`, `/`).Replace(line)
+}
+
+// byteToCharacterOffset returns the character offset of the byte offset in
+// line. Offsets beyond line (e.g. if the line is not known) are returned as is.
+func byteToCharacterOffset(line string, offset int) int {
+	if offset > len(line) {
+		return offset
+	}
+	return utf8.RuneCountInString(line[:offset])
+}
+
 func ctagsKindToLSPSymbolKind(kind string) lsp.SymbolKind {
 	// Ctags kinds are determined by the parser and do not (in general) match LSP symbol kinds.
 	switch kind {
@@ -0,0 +1,270 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/pkg/errors"
+	"github.com/xeonx/timeago"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"github.com/sourcegraph/sourcegraph/pkg/trace"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// historySearchResultResolver is a resolver for the GraphQL type `HistorySearchResult`.
+type historySearchResultResolver struct {
+	repo              *repositoryResolver
+	path              string
+	introducingCommit *gitCommitResolver
+	introducedMatches []*lineMatch
+	removingCommit    *gitCommitResolver
+	lastMatches       []*lineMatch
+	lastMatchesCommit api.CommitID
+}
+
+func toHistorySearchResultResolver(repo *repositoryResolver, result *git.HistorySearchResult) *historySearchResultResolver {
+	r := &historySearchResultResolver{
+		repo:              repo,
+		path:              result.Path,
+		introducingCommit: toGitCommitResolver(repo, result.Introduced),
+		introducedMatches: toHistoryLineMatches(result.IntroducedMatches),
+		lastMatches:       toHistoryLineMatches(result.LastMatches),
+		lastMatchesCommit: result.LastMatchesCommit,
+	}
+	if result.Removed != nil {
+		r.removingCommit = toGitCommitResolver(repo, result.Removed)
+	}
+	return r
+}
+
+// toHistoryLineMatches converts the line matches returned by git.HistorySearch, whose offsets
+// are in bytes, to the GraphQL LineMatch type, whose offsets are in characters.
+func toHistoryLineMatches(matches []*git.LineMatch) []*lineMatch {
+	lineMatches := make([]*lineMatch, len(matches))
+	for i, m := range matches {
+		lm := &lineMatch{JPreview: m.Preview, JLineNumber: int32(m.LineNumber)}
+		for _, ol := range m.OffsetAndLengths {
+			offset := utf8.RuneCountInString(m.Preview[:ol[0]])
+			length := utf8.RuneCountInString(m.Preview[ol[0] : ol[0]+ol[1]])
+			lm.JOffsetAndLengths = append(lm.JOffsetAndLengths, [2]int32{int32(offset), int32(length)})
+		}
+		lineMatches[i] = lm
+	}
+	return lineMatches
+}
+
+func (r *historySearchResultResolver) Repository() *repositoryResolver { return r.repo }
+func (r *historySearchResultResolver) Path() string                    { return r.path }
+func (r *historySearchResultResolver) IntroducingCommit() *gitCommitResolver {
+	return r.introducingCommit
+}
+func (r *historySearchResultResolver) IntroducedMatches() []*lineMatch { return r.introducedMatches }
+func (r *historySearchResultResolver) RemovingCommit() *gitCommitResolver {
+	return r.removingCommit
+}
+func (r *historySearchResultResolver) LastMatches() []*lineMatch { return r.lastMatches }
+
+func (r *historySearchResultResolver) Icon() string {
+	return "data:image/svg+xml;base64,PD94bWwgdmVyc2lvbj0iMS4wIiBlbmNvZGluZz0iVVRGLTgiPz48IURPQ1RZUEUgc3ZnIFBVQkxJQyAiLS8vVzNDLy9EVEQgU1ZHIDEuMS8vRU4iICJodHRwOi8vd3d3LnczLm9yZy9HcmFwaGljcy9TVkcvMS4xL0RURC9zdmcxMS5kdGQiPjxzdmcgeG1sbnM9Imh0dHA6Ly93d3cudzMub3JnLzIwMDAvc3ZnIiB4bWxuczp4bGluaz0iaHR0cDovL3d3dy53My5vcmcvMTk5OS94bGluayIgdmVyc2lvbj0iMS4xIiB3aWR0aD0iMjQiIGhlaWdodD0iMjQiIHZpZXdCb3g9IjAgMCAyNCAyNCI+PHBhdGggZD0iTTE3LDEyQzE3LDE0LjQyIDE1LjI4LDE2LjQ0IDEzLDE2LjlWMjFIMTFWMTYuOUM4LjcyLDE2LjQ0IDcsMTQuNDIgNywxMkM3LDkuNTggOC43Miw3LjU2IDExLDcuMVYzSDEzVjcuMUMxNS4yOCw3LjU2IDE3LDkuNTggMTcsMTJNMTIsOUEzLDMgMCAwLDAgOSwxMkEzLDMgMCAwLDAgMTIsMTVBMywzIDAgMCwwIDE1LDEyQTMsMyAwIDAsMCAxMiw5WiIgLz48L3N2Zz4="
+}
+
+func (r *historySearchResultResolver) Label() *markdownResolver {
+	return &markdownResolver{text: fmt.Sprintf("[%s](%s) › [%s](%s)", displayRepoName(r.repo.Name()), r.repo.URL(), r.path, r.URL())}
+}
+
+// URL returns the URL of the file at the last revision where it matched.
+func (r *historySearchResultResolver) URL() string {
+	return r.fileURL(r.lastMatchesCommit)
+}
+
+func (r *historySearchResultResolver) fileURL(commit api.CommitID) string {
+	if commit == "" {
+		return r.repo.URL()
+	}
+	return r.repo.URL() + "@" + string(commit) + "/-/blob/" + r.path
+}
+
+func (r *historySearchResultResolver) Detail() *markdownResolver {
+	timeagoConfig := timeago.NoMax(timeago.English)
+	describe := func(commit *gitCommitResolver) string {
+		return fmt.Sprintf("[`%s` %s](%s)", commit.AbbreviatedOID(), timeagoConfig.Format(commit.author.date), commit.URL())
+	}
+	detail := "introduced in " + describe(r.introducingCommit)
+	if r.removingCommit != nil {
+		detail += ", removed in " + describe(r.removingCommit)
+	} else {
+		detail += ", still present"
+	}
+	return &markdownResolver{text: detail}
+}
+
+// Matches returns the matching lines of the file when they were introduced, and at the last
+// revision where it matched (if that is a different revision).
+func (r *historySearchResultResolver) Matches() []*searchResultMatchResolver {
+	matches := []*searchResultMatchResolver{
+		historySearchResultMatch(r.path, r.introducedMatches, r.fileURL(api.CommitID(r.introducingCommit.oid))),
+	}
+	if r.lastMatchesCommit != api.CommitID(r.introducingCommit.oid) && len(r.lastMatches) > 0 {
+		matches = append(matches, historySearchResultMatch(r.path, r.lastMatches, r.URL()))
+	}
+	return matches
+}
+
+func historySearchResultMatch(path string, lineMatches []*lineMatch, url string) *searchResultMatchResolver {
+	lines := make([]string, len(lineMatches))
+	var highlights []*highlightedRange
+	for i, lm := range lineMatches {
+		lines[i] = lm.JPreview
+		for _, ol := range lm.JOffsetAndLengths {
+			highlights = append(highlights, &highlightedRange{line: int32(i + 1), character: ol[0], length: ol[1]})
+		}
+	}
+	return &searchResultMatchResolver{
+		body:       "```" + path + "\n" + strings.Join(lines, "\n") + "\n```",
+		highlights: highlights,
+		url:        url,
+	}
+}
+
+// historySearchDate returns the date of the commit that removed the matching lines of the
+// result, or of the commit that introduced them if they are still present, by which history
+// search results are ordered.
+func (r *historySearchResultResolver) historySearchDate() time.Time {
+	if r.removingCommit != nil {
+		return r.removingCommit.author.date
+	}
+	return r.introducingCommit.author.date
+}
+
+var mockSearchHistoryInRepo func(ctx context.Context, repoRevs search.RepositoryRevisions, info *search.PatternInfo, query *query.Query) (results []*historySearchResultResolver, limitHit, timedOut bool, err error)
+
+// searchHistoryInRepo searches the history of a repository for files whose content matched
+// the pattern at any commit.
+func searchHistoryInRepo(ctx context.Context, repoRevs search.RepositoryRevisions, info *search.PatternInfo, query *query.Query) (results []*historySearchResultResolver, limitHit, timedOut bool, err error) {
+	if mockSearchHistoryInRepo != nil {
+		return mockSearchHistoryInRepo(ctx, repoRevs, info, query)
+	}
+
+	tr, ctx := trace.New(ctx, "searchHistoryInRepo", fmt.Sprintf("repoRevs: %v, pattern %+v", repoRevs, info))
+	defer func() {
+		tr.LazyPrintf("%d results, limitHit=%v, timedOut=%v", len(results), limitHit, timedOut)
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	args, err := commitLogRevisionArgs(repoRevs, query)
+	if err != nil {
+		return nil, false, false, err
+	}
+	maxResults := int(info.FileMatchLimit)
+	rawResults, complete, err := git.HistorySearch(ctx, repoRevs.GitserverRepo(), git.HistorySearchOptions{
+		Query: git.TextSearchOptions{
+			Pattern:         info.Pattern,
+			IsRegExp:        info.IsRegExp,
+			IsCaseSensitive: info.IsCaseSensitive,
+		},
+		Paths: git.PathOptions{
+			IncludePatterns: info.IncludePatterns,
+			ExcludePattern:  info.ExcludePattern,
+			IsCaseSensitive: info.PathPatternsAreCaseSensitive,
+			IsRegExp:        info.PathPatternsAreRegExps,
+		},
+		Args:     args,
+		MaxFiles: maxResults + 1,
+	})
+	if err != nil {
+		return nil, false, false, err
+	}
+
+	// if the result is incomplete, git log timed out and the client should be notified of that
+	timedOut = !complete
+	if len(rawResults) > maxResults {
+		limitHit = true
+		rawResults = rawResults[:maxResults]
+	}
+
+	repoResolver := &repositoryResolver{repo: repoRevs.Repo}
+	results = make([]*historySearchResultResolver, len(rawResults))
+	for i, rawResult := range rawResults {
+		results[i] = toHistorySearchResultResolver(repoResolver, rawResult)
+	}
+	return results, limitHit, timedOut, nil
+}
+
+var mockSearchHistoryInRepos func(args *search.Args) ([]*searchResultResolver, *searchResultsCommon, error)
+
+// searchHistoryInRepos searches the history of a set of repos for files whose content matched
+// the pattern at any commit (type:history).
+func searchHistoryInRepos(ctx context.Context, args *search.Args) ([]*searchResultResolver, *searchResultsCommon, error) {
+	if mockSearchHistoryInRepos != nil {
+		return mockSearchHistoryInRepos(args)
+	}
+
+	if args.Pattern.Pattern == "" {
+		return nil, nil, errors.New("history search requires a pattern")
+	}
+
+	var err error
+	tr, ctx := trace.New(ctx, "searchHistoryInRepos", fmt.Sprintf("query: %+v, numRepoRevs: %d", args.Pattern, len(args.Repos)))
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		flattened []*historySearchResultResolver
+		common    = &searchResultsCommon{}
+	)
+	for _, repoRev := range args.Repos {
+		wg.Add(1)
+		go func(repoRev search.RepositoryRevisions) {
+			defer wg.Done()
+			results, repoLimitHit, repoTimedOut, searchErr := searchHistoryInRepo(ctx, repoRev, args.Pattern, args.Query)
+			if ctx.Err() == context.Canceled {
+				// Our request has been canceled (either because another one of args.repos had a
+				// fatal error, or otherwise), so we can just ignore these results.
+				return
+			}
+			repoTimedOut = repoTimedOut || ctx.Err() == context.DeadlineExceeded
+			if searchErr != nil {
+				tr.LogFields(otlog.String("repo", string(repoRev.Repo.Name)), otlog.String("searchErr", searchErr.Error()), otlog.Bool("timeout", errcode.IsTimeout(searchErr)), otlog.Bool("temporary", errcode.IsTemporary(searchErr)))
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if fatalErr := handleRepoSearchResult(common, repoRev, repoLimitHit, repoTimedOut, searchErr); fatalErr != nil {
+				err = errors.Wrapf(searchErr, "failed to search history %s", repoRev.String())
+				cancel()
+			}
+			flattened = append(flattened, results...)
+		}(*repoRev)
+	}
+	wg.Wait()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Show the most recently changed files first.
+	sort.SliceStable(flattened, func(i, j int) bool {
+		return flattened[i].historySearchDate().After(flattened[j].historySearchDate())
+	})
+	results := make([]*searchResultResolver, len(flattened))
+	for i, result := range flattened {
+		results[i] = &searchResultResolver{history: result}
+	}
+	return results, common, nil
+}
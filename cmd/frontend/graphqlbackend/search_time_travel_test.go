@@ -0,0 +1,121 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestSearchHistoryInRepo(t *testing.T) {
+	added := &git.Commit{ID: "1111111111111111111111111111111111111111", Author: git.Signature{Date: time.Now().AddDate(0, 0, -2)}}
+	removed := &git.Commit{ID: "2222222222222222222222222222222222222222", Author: git.Signature{Date: time.Now().AddDate(0, 0, -1)}}
+	git.Mocks.HistorySearch = func(opt git.HistorySearchOptions) ([]*git.HistorySearchResult, bool, error) {
+		if want := (git.TextSearchOptions{Pattern: "p"}); opt.Query != want {
+			t.Errorf("got query %+v, want %+v", opt.Query, want)
+		}
+		if want := []string{"rev", "--until=yesterday"}; !reflect.DeepEqual(opt.Args, want) {
+			t.Errorf("got args %v, want %v", opt.Args, want)
+		}
+		if opt.MaxFiles != 2 {
+			t.Errorf("got MaxFiles %d, want 2 (to detect that the limit is hit)", opt.MaxFiles)
+		}
+		return []*git.HistorySearchResult{
+			{
+				Path:              "f",
+				Introduced:        added,
+				IntroducedMatches: []*git.LineMatch{{LineNumber: 1, Preview: "é p", OffsetAndLengths: [][2]int{{3, 1}}}},
+				Removed:           removed,
+				LastMatches:       []*git.LineMatch{{LineNumber: 2, Preview: "p", OffsetAndLengths: [][2]int{{0, 1}}}},
+				LastMatchesCommit: "3333333333333333333333333333333333333333",
+			},
+			{Path: "g", Introduced: added, LastMatchesCommit: added.ID},
+		}, true, nil
+	}
+	defer git.ResetMocks()
+
+	q, err := query.ParseAndCheck("type:history p before:yesterday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, limitHit, timedOut, err := searchHistoryInRepo(context.Background(), search.RepositoryRevisions{
+		Repo: &types.Repo{ID: 1, Name: "repo"},
+		Revs: []search.RevisionSpecifier{{RevSpec: "rev"}},
+	}, &search.PatternInfo{Pattern: "p", FileMatchLimit: 1}, q)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !limitHit {
+		t.Error("!limitHit")
+	}
+	if timedOut {
+		t.Error("timedOut")
+	}
+	if len(results) != 1 {
+		t.Fatalf("got %d results, want 1", len(results))
+	}
+	r := results[0]
+	if r.Path() != "f" || r.IntroducingCommit().OID() != gitObjectID(added.ID) || r.RemovingCommit().OID() != gitObjectID(removed.ID) {
+		t.Errorf("got path %q, introducing commit %q and removing commit %v", r.Path(), r.IntroducingCommit().OID(), r.RemovingCommit())
+	}
+	if want := "/repo@3333333333333333333333333333333333333333/-/blob/f"; r.URL() != want {
+		t.Errorf("got URL %q, want %q", r.URL(), want)
+	}
+	// Offsets are converted from bytes to characters.
+	if got, want := r.IntroducedMatches()[0].OffsetAndLengths(), [][]int32{{2, 1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got offsets %v, want %v", got, want)
+	}
+	if got := r.Matches(); len(got) != 2 || got[1].url != r.URL() || got[1].body != "```f\np\n```" {
+		t.Errorf("got matches %+v, want the introduced and last matches", got)
+	}
+}
+
+func TestSearchHistoryInRepos(t *testing.T) {
+	older := &git.Commit{ID: "1111111111111111111111111111111111111111", Author: git.Signature{Date: time.Now().AddDate(0, 0, -2)}}
+	newer := &git.Commit{ID: "2222222222222222222222222222222222222222", Author: git.Signature{Date: time.Now().AddDate(0, 0, -1)}}
+	mockSearchHistoryInRepo = func(ctx context.Context, repoRevs search.RepositoryRevisions, info *search.PatternInfo, query *query.Query) ([]*historySearchResultResolver, bool, bool, error) {
+		repo := &repositoryResolver{repo: repoRevs.Repo}
+		if repoRevs.Repo.Name == "a" {
+			return []*historySearchResultResolver{toHistorySearchResultResolver(repo, &git.HistorySearchResult{Path: "a", Introduced: older})}, false, false, nil
+		}
+		return []*historySearchResultResolver{toHistorySearchResultResolver(repo, &git.HistorySearchResult{Path: "b", Introduced: older, Removed: newer})}, false, false, nil
+	}
+	defer func() { mockSearchHistoryInRepo = nil }()
+
+	q, err := query.ParseAndCheck("type:history p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, common, err := searchHistoryInRepos(context.Background(), &search.Args{
+		Pattern: &search.PatternInfo{Pattern: "p", FileMatchLimit: 10},
+		Repos: []*search.RepositoryRevisions{
+			{Repo: &types.Repo{ID: 1, Name: "a"}, Revs: []search.RevisionSpecifier{{RevSpec: ""}}},
+			{Repo: &types.Repo{ID: 2, Name: "b"}, Revs: []search.RevisionSpecifier{{RevSpec: ""}}},
+		},
+		Query: q,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []api.RepoName
+	for _, r := range results {
+		got = append(got, r.history.repo.repo.Name)
+	}
+	// The file whose matching lines changed most recently comes first.
+	if want := []api.RepoName{"b", "a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if common.limitHit {
+		t.Error("limitHit")
+	}
+
+	if _, _, err := searchHistoryInRepos(context.Background(), &search.Args{Pattern: &search.PatternInfo{}, Query: q}); err == nil {
+		t.Error("got no error for an empty pattern")
+	}
+}
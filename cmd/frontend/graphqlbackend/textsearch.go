@@ -21,15 +21,20 @@ import (
 	opentracing "github.com/opentracing/opentracing-go"
 	otlog "github.com/opentracing/opentracing-go/log"
 
+	"github.com/golang/groupcache/lru"
 	"github.com/google/zoekt"
 	zoektquery "github.com/google/zoekt/query"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/errcode"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	searchpkg "github.com/sourcegraph/sourcegraph/pkg/search"
+	searchquery "github.com/sourcegraph/sourcegraph/pkg/search/query"
 	"github.com/sourcegraph/sourcegraph/pkg/trace"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs"
 	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
 )
 
@@ -37,6 +42,17 @@ var (
 	// textSearchLimiter limits the number of open TCP connections created by frontend to searcher.
 	textSearchLimiter = make(semaphore, 500)
 
+	// searcherReposPerSearch limits the number of repositories that a single search searches
+	// with searcher concurrently, so that they are searched in priority order (see
+	// prioritizeSearcherRepos).
+	searcherReposPerSearch = 100
+
+	// recentlySearchedRepos contains the repository revisions (as formatted by
+	// RepositoryRevisions.String) that searcher recently searched successfully, whose archives
+	// searcher probably still has cached.
+	recentlySearchedReposMu sync.Mutex
+	recentlySearchedRepos   = lru.New(2000)
+
 	searchHTTPClient = &http.Client{
 		// nethttp.Transport will propagate opentracing spans
 		Transport: &nethttp.Transport{
@@ -56,6 +72,7 @@ type fileMatchResolver struct {
 	JPath        string       `json:"Path"`
 	JLineMatches []*lineMatch `json:"LineMatches"`
 	JLimitHit    bool         `json:"LimitHit"`
+	JMatchCount  int32        `json:"MatchCount"` // only set (instead of JLineMatches) with PatternInfo.CountOnly
 	symbols      []*symbolResolver
 	uri          string
 	repo         *types.Repo
@@ -64,6 +81,15 @@ type fileMatchResolver struct {
 	// preserve the original revision specifier from the user instead of navigating them to the
 	// absolute commit ID when they select a result.
 	inputRev *string
+	// sourceRefs are the refs (or revspecs) containing this file match when searching multiple
+	// revisions of the repository (e.g. repo:foo@*refs/heads/*).
+	sourceRefs []string
+	// zoektScore is the score that zoekt assigned to this file match, or nil if it was not
+	// returned by zoekt.
+	zoektScore *float64
+	// score is the relevance score of this file match (see rankFileMatches), or nil if it
+	// has not been ranked.
+	score *searchResultScore
 }
 
 func (fm *fileMatchResolver) Key() string {
@@ -101,15 +127,28 @@ func (fm *fileMatchResolver) LineMatches() []*lineMatch {
 	return fm.JLineMatches
 }
 
+func (fm *fileMatchResolver) SourceRefs() []*gitRefResolver {
+	refs := make([]*gitRefResolver, len(fm.sourceRefs))
+	for i, ref := range fm.sourceRefs {
+		refs[i] = &gitRefResolver{repo: &repositoryResolver{repo: fm.repo}, name: ref}
+	}
+	return refs
+}
+
 func (fm *fileMatchResolver) LimitHit() bool {
 	return fm.JLimitHit
 }
 
+func (fm *fileMatchResolver) Score() *searchResultScore {
+	return fm.score
+}
+
 // LineMatch is the struct used by vscode to receive search results for a line
 type lineMatch struct {
 	JPreview          string     `json:"Preview"`
 	JOffsetAndLengths [][2]int32 `json:"OffsetAndLengths"`
 	JLineNumber       int32      `json:"LineNumber"`
+	JDiffOp           string     `json:"DiffOp"`
 	JLimitHit         bool       `json:"LimitHit"`
 }
 
@@ -129,13 +168,22 @@ func (lm *lineMatch) OffsetAndLengths() [][]int32 {
 	return r
 }
 
+func (lm *lineMatch) DiffOp() *string {
+	if lm.JDiffOp == "" {
+		return nil
+	}
+	return &lm.JDiffOp
+}
+
 func (lm *lineMatch) LimitHit() bool {
 	return lm.JLimitHit
 }
 
-// textSearch searches repo@commit with p.
+// textSearch searches repo@commit with p. If diffBase is non-empty, only the
+// files changed between diffBase and commit are searched. rev is the revision
+// which resolved to commit.
 // Note: the returned matches do not set fileMatch.uri
-func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p *search.PatternInfo, fetchTimeout time.Duration) (matches []*fileMatchResolver, limitHit bool, err error) {
+func textSearch(ctx context.Context, repo gitserver.Repo, rev string, commit, diffBase api.CommitID, p *search.PatternInfo, fetchTimeout time.Duration) (matches []*fileMatchResolver, limitHit bool, err error) {
 	tr, ctx := trace.New(ctx, "searcher.client", fmt.Sprintf("%s@%s", repo.Name, commit))
 	defer func() {
 		tr.SetError(err)
@@ -172,6 +220,13 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 		q.Set("Deadline", string(t))
 	}
 	q.Set("FileMatchLimit", strconv.FormatInt(int64(p.FileMatchLimit), 10))
+	if diffBase != "" {
+		q.Set("DiffBase", string(diffBase))
+	} else if p.CountOnly {
+		// searcher can't only count the matches in a diff, so diff matches are
+		// counted below.
+		q.Set("CountOnly", "true")
+	}
 	if p.IsRegExp {
 		q.Set("IsRegExp", "true")
 	}
@@ -197,6 +252,12 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 	// relatively expensive to fetch from gitserver. So we use consistent
 	// hashing to increase cache hits.
 	consistentHashKey := string(repo.Name) + "@" + string(commit)
+	if diffBase == "" && rev != "" && rev != "HEAD" && conf.SearchIndexBranch(rev) {
+		// The branch is in the branch index (see search.index.branches) of
+		// the searcher which its repository hashes to, so that searcher
+		// doesn't need to fetch an archive.
+		consistentHashKey = string(repo.Name)
+	}
 	tr.LazyPrintf("%s", consistentHashKey)
 
 	var (
@@ -229,6 +290,9 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 		//
 		// tr.LazyPrintf("%d matches, limitHit=%v, err=%v, ctx.Err()=%v", len(matches), limitHit, err, ctx.Err())
 		if err == nil || errcode.IsTimeout(err) {
+			if p.CountOnly && diffBase != "" {
+				countLineMatches(matches)
+			}
 			return matches, limitHit, err
 		}
 
@@ -248,6 +312,117 @@ func textSearch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p
 	}
 }
 
+// textSearchBoolean searches repo at commit for the boolean combination of
+// search patterns p.BooleanPattern. Unlike textSearch it uses searcher's
+// pkg/search RPC endpoint, which can evaluate arbitrary boolean queries.
+func textSearchBoolean(ctx context.Context, repo gitserver.Repo, commit api.CommitID, p *search.PatternInfo, fetchTimeout time.Duration) (matches []*fileMatchResolver, limitHit bool, err error) {
+	tr, ctx := trace.New(ctx, "searcher.client.boolean", fmt.Sprintf("%s@%s", repo.Name, commit))
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	and := []searchquery.Q{&searchquery.Ref{Pattern: string(commit)}, p.BooleanPattern}
+	fileRe := func(pattern string) (searchquery.Q, error) {
+		re, err := syntax.Parse(pattern, syntax.Perl)
+		if err != nil {
+			return nil, err
+		}
+		return &searchquery.Regexp{Regexp: re, FileName: true, CaseSensitive: p.PathPatternsAreCaseSensitive}, nil
+	}
+	if !p.PathPatternsAreRegExps {
+		return nil, false, errors.New("boolean search only supports regex path patterns")
+	}
+	includePatterns := p.IncludePatterns
+	if p.IncludePattern != "" {
+		includePatterns = append([]string{p.IncludePattern}, includePatterns...)
+	}
+	for _, pattern := range includePatterns {
+		q, err := fileRe(pattern)
+		if err != nil {
+			return nil, false, err
+		}
+		and = append(and, q)
+	}
+	if p.ExcludePattern != "" {
+		q, err := fileRe(p.ExcludePattern)
+		if err != nil {
+			return nil, false, err
+		}
+		and = append(and, &searchquery.Not{Child: q})
+	}
+	q := searchquery.NewAnd(and...)
+	tr.LazyPrintf("query: %s", q)
+
+	// Limit number of outstanding searcher requests
+	if err := textSearchLimiter.Acquire(ctx); err != nil {
+		return nil, false, err
+	}
+	defer textSearchLimiter.Release()
+
+	result, err := Search().TextJIT.Search(ctx, q, &searchpkg.Options{
+		Repositories:       []api.RepoName{repo.Name},
+		TotalMaxMatchCount: int(p.FileMatchLimit),
+		FetchTimeout:       fetchTimeout,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, s := range result.Stats.Status {
+		switch s.Status {
+		case searchpkg.RepositoryStatusLimitHit, searchpkg.RepositoryStatusTimedOut:
+			limitHit = true
+		case searchpkg.RepositoryStatusCloning, searchpkg.RepositoryStatusMissing:
+			return nil, false, &vcs.RepoNotExistError{Repo: repo.Name, CloneInProgress: s.Status == searchpkg.RepositoryStatusCloning}
+		}
+	}
+	if len(result.Files) > int(p.FileMatchLimit) {
+		result.Files = result.Files[:p.FileMatchLimit]
+		limitHit = true
+	}
+
+	matches = make([]*fileMatchResolver, len(result.Files))
+	for i, file := range result.Files {
+		lines := make([]*lineMatch, len(file.LineMatches))
+		for j, l := range file.LineMatches {
+			offsets := make([][2]int32, len(l.LineFragments))
+			for k, m := range l.LineFragments {
+				offset := utf8.RuneCount(l.Line[:m.LineOffset])
+				length := utf8.RuneCount(l.Line[m.LineOffset : m.LineOffset+m.MatchLength])
+				offsets[k] = [2]int32{int32(offset), int32(length)}
+			}
+			lines[j] = &lineMatch{
+				JPreview:          string(l.Line),
+				JLineNumber:       int32(l.LineNumber - 1),
+				JOffsetAndLengths: offsets,
+			}
+		}
+		matches[i] = &fileMatchResolver{
+			JPath:        file.Path,
+			JLineMatches: lines,
+		}
+	}
+	if p.CountOnly {
+		countLineMatches(matches)
+	}
+	return matches, limitHit, nil
+}
+
+// countLineMatches replaces the line matches of each file match with their
+// number of matches (see PatternInfo.CountOnly). It is used for searches that
+// can't only count matches, so the counts are subject to the limits of those
+// searches.
+func countLineMatches(matches []*fileMatchResolver) {
+	for _, fm := range matches {
+		fm.JMatchCount = 0
+		for _, lm := range fm.JLineMatches {
+			fm.JMatchCount += int32(len(lm.JOffsetAndLengths))
+		}
+		fm.JLineMatches = nil
+	}
+}
+
 func textSearchURL(ctx context.Context, url string) ([]*fileMatchResolver, bool, error) {
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -323,6 +498,14 @@ func searchFilesInRepo(ctx context.Context, repo *types.Repo, gitserverRepo gits
 		return mockSearchFilesInRepo(ctx, repo, gitserverRepo, rev, info, fetchTimeout)
 	}
 
+	// A revision range (e.g. main...feature/x) searches the files changed
+	// between the two revisions.
+	var diffBase api.CommitID
+	base, head, mergeBase, isDiff := search.RevisionSpecifier{RevSpec: rev}.DiffRange()
+	if isDiff {
+		rev = head
+	}
+
 	// Do not trigger a repo-updater lookup (e.g.,
 	// backend.{GitRepo,Repos.ResolveRev}) because that would slow this operation
 	// down by a lot (if we're looping over many repos). This means that it'll fail if a
@@ -332,8 +515,30 @@ func searchFilesInRepo(ctx context.Context, repo *types.Repo, gitserverRepo gits
 		return nil, false, err
 	}
 
-	matches, limitHit, err = textSearch(ctx, gitserverRepo, commit, info, fetchTimeout)
+	if isDiff {
+		diffBase, err = git.ResolveRevision(ctx, gitserverRepo, nil, base, &git.ResolveRevisionOptions{NoEnsureRevision: true})
+		if err != nil {
+			return nil, false, err
+		}
+		if mergeBase {
+			diffBase, err = git.MergeBase(ctx, gitserverRepo, diffBase, commit)
+			if err != nil {
+				return nil, false, err
+			}
+		}
+	}
+
+	if info.BooleanPattern != nil {
+		if isDiff {
+			return nil, false, errors.New("AND, OR and NOT may not be used when searching a revision range")
+		}
+		matches, limitHit, err = textSearchBoolean(ctx, gitserverRepo, commit, info, fetchTimeout)
+	} else {
+		matches, limitHit, err = textSearch(ctx, gitserverRepo, rev, commit, diffBase, info, fetchTimeout)
+	}
 
+	// For diff searches rev is the head revision, so results link to the
+	// file in the head revision.
 	workspace := fileMatchURI(repo.Name, rev, "")
 	for _, fm := range matches {
 		fm.uri = workspace + fm.JPath
@@ -360,7 +565,7 @@ func fileMatchURI(name api.RepoName, ref, path string) string {
 	return b.String()
 }
 
-func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*search.RepositoryRevisions, useFullDeadline bool) (fm []*fileMatchResolver, limitHit bool, reposLimitHit map[string]struct{}, err error) {
+func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*search.RepositoryRevisions, useFullDeadline, selectRepo bool) (fm []*fileMatchResolver, limitHit bool, reposLimitHit map[string]struct{}, err error) {
 	if len(repos) == 0 {
 		return nil, false, nil, nil
 	}
@@ -432,6 +637,34 @@ func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*se
 		searchOpts.MaxWallTime *= time.Duration(3 * float64(query.FileMatchLimit) / float64(defaultMaxSearchResults))
 	}
 
+	if query.CountOnly {
+		// Zoekt can't only count matches, so it returns every match (with
+		// its line) up to these limits. Counts beyond them are reported as
+		// incomplete (see limitHit below).
+		searchOpts.ShardMaxMatchCount = maxZoektCountOnlyMatches
+		searchOpts.TotalMaxMatchCount = maxZoektCountOnlyMatches
+		searchOpts.ShardMaxImportantMatch = maxZoektCountOnlyMatches
+		searchOpts.TotalMaxImportantMatch = maxZoektCountOnlyMatches
+		searchOpts.MaxDocDisplayCount = maxZoektCountOnlyMatches
+	}
+
+	if selectRepo {
+		// Only the first match in each repository is needed, so stop
+		// searching a shard after its first match. The totals must allow a
+		// match in every repository, or the files of a few repositories would
+		// use up the file match limit before it is applied to the
+		// deduplicated repositories below.
+		searchOpts.ShardMaxMatchCount = 1
+		searchOpts.ShardMaxImportantMatch = 1
+		if n := len(repos) + 1; searchOpts.TotalMaxMatchCount < n {
+			searchOpts.TotalMaxMatchCount = n
+			searchOpts.TotalMaxImportantMatch = n
+		}
+		if n := len(repos) + 1; searchOpts.MaxDocDisplayCount < n {
+			searchOpts.MaxDocDisplayCount = n
+		}
+	}
+
 	if useFullDeadline {
 		// If the user manually specified a timeout, allow zoekt to use all of the remaining timeout.
 		deadline, _ := ctx.Deadline()
@@ -462,6 +695,16 @@ func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*se
 		return nil, false, nil, err
 	}
 	limitHit = resp.FilesSkipped+resp.ShardsSkipped > 0
+	if selectRepo {
+		// Files skipped after a repository's first match don't change
+		// whether it matches. Only skipped shards leave repositories
+		// unsearched.
+		limitHit = resp.ShardsSkipped > 0
+	}
+	if query.CountOnly && len(resp.Files) >= searchOpts.MaxDocDisplayCount {
+		// Zoekt doesn't report the files it didn't return.
+		limitHit = true
+	}
 	// Repositories that weren't fully evaluated because they hit the Zoekt or Sourcegraph file match limits.
 	reposLimitHit = make(map[string]struct{})
 	if limitHit {
@@ -479,6 +722,23 @@ func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*se
 		return nil, false, nil, nil
 	}
 
+	if selectRepo {
+		// Only the first file match of each repository is needed.
+		seen := make(map[string]bool, len(resp.Files))
+		files := resp.Files[:0]
+		for _, file := range resp.Files {
+			if !seen[file.Repository] {
+				seen[file.Repository] = true
+				files = append(files, file)
+			}
+		}
+		resp.Files = files
+	}
+
+	if query.CountOnly {
+		return zoektCountMatches(resp.Files, repoMap), limitHit, reposLimitHit, nil
+	}
+
 	maxLineMatches := 25 + k
 	maxLineFragmentMatches := 3 + k
 	if len(resp.Files) > int(query.FileMatchLimit) {
@@ -529,6 +789,7 @@ func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*se
 			}
 		}
 		repo := repoMap[api.RepoName(strings.ToLower(string(file.Repository)))]
+		zoektScore := file.Score
 		matches[i] = &fileMatchResolver{
 			JPath:        file.FileName,
 			JLineMatches: lines,
@@ -536,12 +797,39 @@ func zoektSearchHEAD(ctx context.Context, query *search.PatternInfo, repos []*se
 			uri:          fileMatchURI(repo.Name, "", file.FileName),
 			repo:         repo,
 			commitID:     "", // zoekt only searches default branch
+			zoektScore:   &zoektScore,
 		}
 	}
 
 	return matches, limitHit, reposLimitHit, nil
 }
 
+// maxZoektCountOnlyMatches is the maximum number of matches (and files) that
+// zoekt collects for a count-only search (see PatternInfo.CountOnly).
+const maxZoektCountOnlyMatches = 100000
+
+// zoektCountMatches converts zoekt's file matches to file matches with only
+// their number of matches set (see PatternInfo.CountOnly).
+func zoektCountMatches(files []zoekt.FileMatch, repoMap map[api.RepoName]*types.Repo) []*fileMatchResolver {
+	matches := make([]*fileMatchResolver, len(files))
+	for i, file := range files {
+		var count int32
+		for _, l := range file.LineMatches {
+			if !l.FileName {
+				count += int32(len(l.LineFragments))
+			}
+		}
+		repo := repoMap[api.RepoName(strings.ToLower(string(file.Repository)))]
+		matches[i] = &fileMatchResolver{
+			JPath:       file.FileName,
+			JMatchCount: count,
+			uri:         fileMatchURI(repo.Name, "", file.FileName),
+			repo:        repo,
+		}
+	}
+	return matches
+}
+
 func noOpAnyChar(re *syntax.Regexp) {
 	if re.Op == syntax.OpAnyChar {
 		re.Op = syntax.OpAnyCharNotNL
@@ -582,7 +870,13 @@ func queryToZoektQuery(query *search.PatternInfo) (zoektquery.Q, error) {
 		return parseRe(pattern, true)
 	}
 
-	if query.IsRegExp {
+	if query.BooleanPattern != nil {
+		q, err := booleanPatternToZoektQuery(query.BooleanPattern, parseRe)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, q)
+	} else if query.IsRegExp {
 		q, err := parseRe(query.Pattern, false)
 		if err != nil {
 			return nil, err
@@ -622,19 +916,71 @@ func queryToZoektQuery(query *search.PatternInfo) (zoektquery.Q, error) {
 	return zoektquery.Simplify(zoektquery.NewAnd(and...)), nil
 }
 
+// booleanPatternToZoektQuery converts the boolean combination of search
+// patterns q (see search.PatternInfo.BooleanPattern) to a zoekt query, using
+// parseRe to convert regexp patterns. All patterns only match file contents.
+func booleanPatternToZoektQuery(q searchquery.Q, parseRe func(pattern string, filenameOnly bool) (zoektquery.Q, error)) (zoektquery.Q, error) {
+	convertAll := func(qs []searchquery.Q) ([]zoektquery.Q, error) {
+		zqs := make([]zoektquery.Q, len(qs))
+		for i, q := range qs {
+			var err error
+			if zqs[i], err = booleanPatternToZoektQuery(q, parseRe); err != nil {
+				return nil, err
+			}
+		}
+		return zqs, nil
+	}
+
+	switch s := q.(type) {
+	case *searchquery.And:
+		qs, err := convertAll(s.Children)
+		if err != nil {
+			return nil, err
+		}
+		return zoektquery.NewAnd(qs...), nil
+	case *searchquery.Or:
+		qs, err := convertAll(s.Children)
+		if err != nil {
+			return nil, err
+		}
+		return zoektquery.NewOr(qs...), nil
+	case *searchquery.Not:
+		c, err := booleanPatternToZoektQuery(s.Child, parseRe)
+		if err != nil {
+			return nil, err
+		}
+		return &zoektquery.Not{Child: c}, nil
+	case *searchquery.Substring:
+		return &zoektquery.Substring{Pattern: s.Pattern, CaseSensitive: s.CaseSensitive, Content: true}, nil
+	case *searchquery.Regexp:
+		// Like substring patterns, regexp patterns only match file contents
+		// (parseRe returns queries which also match file names).
+		zq, err := parseRe(s.Regexp.String(), false)
+		if err != nil {
+			return nil, err
+		}
+		switch zs := zq.(type) {
+		case *zoektquery.Substring:
+			zs.Content = true
+		case *zoektquery.Regexp:
+			zs.Content = true
+		}
+		return zq, nil
+	}
+	return nil, errors.Errorf("unexpected search pattern %T: %v", q, q)
+}
+
 func zoektIndexedRepos(ctx context.Context, repos []*search.RepositoryRevisions) (indexed, unindexed []*search.RepositoryRevisions, err error) {
 	if !Search().Index.Enabled() {
 		return nil, repos, nil
 	}
 	for _, repoRev := range repos {
-		// We search HEAD using zoekt
-		if revspecs := repoRev.RevSpecs(); len(revspecs) > 0 {
-			// TODO(sqs): search all revspecs
-			if revspecs[0] == "" {
-				indexed = append(indexed, repoRev)
-			} else {
-				unindexed = append(unindexed, repoRev)
-			}
+		// We search HEAD using zoekt. Other revisions (including ref globs,
+		// which may match HEAD) are searched by searcher.
+		if len(repoRev.Revs) == 1 && repoRev.Revs[0] == (search.RevisionSpecifier{}) {
+			indexed = append(indexed, repoRev)
+		} else if len(repoRev.Revs) > 0 {
+			unindexed = append(unindexed, repoRev)
 		}
 	}
 
@@ -669,6 +1015,46 @@ func zoektIndexedRepos(ctx context.Context, repos []*search.RepositoryRevisions)
 	return indexed, unindexed, nil
 }
 
+// prioritizeSearcherRepos returns a copy of repos in which the repositories that searcher
+// recently searched (and probably has cached) come first. They are usually searched much faster
+// than the others, whose archives must be fetched from gitserver first.
+func prioritizeSearcherRepos(repos []*search.RepositoryRevisions) []*search.RepositoryRevisions {
+	recentlySearchedReposMu.Lock()
+	cached := make(map[*search.RepositoryRevisions]bool, len(repos))
+	for _, repoRev := range repos {
+		_, cached[repoRev] = recentlySearchedRepos.Get(repoRev.String())
+	}
+	recentlySearchedReposMu.Unlock()
+
+	prioritized := make([]*search.RepositoryRevisions, len(repos))
+	copy(prioritized, repos)
+	sort.SliceStable(prioritized, func(i, j int) bool { return cached[prioritized[i]] && !cached[prioritized[j]] })
+	return prioritized
+}
+
+func addRecentlySearchedRepo(repoRev *search.RepositoryRevisions) {
+	recentlySearchedReposMu.Lock()
+	recentlySearchedRepos.Add(repoRev.String(), struct{}{})
+	recentlySearchedReposMu.Unlock()
+}
+
+// searcherFetchTimeout returns how long searcher may wait to fetch the archive of a
+// repository when searching numRepos repositories.
+func searcherFetchTimeout(ctx context.Context, numRepos int, useFullDeadline bool) time.Duration {
+	if numRepos == 1 || useFullDeadline {
+		// When searching a single repo or when an explicit timeout was specified, give it the remaining deadline to fetch the archive.
+		deadline, ok := ctx.Deadline()
+		if ok {
+			return time.Until(deadline)
+		}
+		// In practice, this case should not happen because a deadline should always be set
+		// but if it does happen just set a long but finite timeout.
+		return time.Minute
+	}
+	// When searching many repos, don't wait long for any single repo to fetch.
+	return 500 * time.Millisecond
+}
+
 var mockSearchFilesInRepos func(args *search.Args) ([]*fileMatchResolver, *searchResultsCommon, error)
 
 // searchFilesInRepos searches a set of repos for a pattern.
@@ -676,6 +1062,9 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 	if mockSearchFilesInRepos != nil {
 		return mockSearchFilesInRepos(args)
 	}
+	if conf.UnifiedTextSearchEnabled() && searcherSupports(args) {
+		return searchFilesInReposWithSearcher(ctx, args)
+	}
 
 	tr, ctx := trace.New(ctx, "searchFilesInRepos", fmt.Sprintf("query: %+v, numRepoRevs: %d", args.Pattern, len(args.Repos)))
 	defer func() {
@@ -735,11 +1124,21 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		}
 	}
 
+	// With select:repo each repository is only searched until its first
+	// match.
+	repoPattern := args.Pattern
+	if args.SelectRepo {
+		p := *args.Pattern
+		p.FileMatchLimit = 1
+		repoPattern = &p
+	}
+
 	var (
 		wg                sync.WaitGroup
 		mu                sync.Mutex
 		unflattened       [][]*fileMatchResolver
 		flattenedSize     int
+		reposWithMatches  = map[api.RepoName]struct{}{}
 		overLimitCanceled bool // canceled because we were over the limit
 	)
 
@@ -753,6 +1152,13 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 			})
 			unflattened = append(unflattened, matches)
 			flattenedSize += len(matches)
+			if args.SelectRepo {
+				// The limit applies to the number of repositories.
+				for _, m := range matches {
+					reposWithMatches[m.repo.Name] = struct{}{}
+				}
+				flattenedSize = len(reposWithMatches)
+			}
 
 			// Stop searching once we have found enough matches. This does
 			// lead to potentially unstable result ordering, but is worth
@@ -766,35 +1172,90 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		}
 	}
 
-	var fetchTimeout time.Duration
-	if len(searcherRepos) == 1 || args.UseFullDeadline {
-		// When searching a single repo or when an explicit timeout was specified, give it the remaining deadline to fetch the archive.
-		deadline, ok := ctx.Deadline()
-		if ok {
-			fetchTimeout = time.Until(deadline)
-		} else {
-			// In practice, this case should not happen because a deadline should always be set
-			// but if it does happen just set a long but finite timeout.
-			fetchTimeout = time.Minute
+	fetchTimeout := searcherFetchTimeout(ctx, len(searcherRepos), args.UseFullDeadline)
+
+	// Indexed search is fast, so it is started first.
+	wg.Add(1)
+	go func() {
+		// TODO limitHit, handleRepoSearchResult
+		defer wg.Done()
+		matches, limitHit, reposLimitHit, searchErr := zoektSearchHEAD(ctx, args.Pattern, zoektRepos, args.UseFullDeadline, args.SelectRepo)
+		mu.Lock()
+		defer mu.Unlock()
+		if ctx.Err() == nil {
+			for _, repo := range zoektRepos {
+				common.searched = append(common.searched, repo.Repo)
+				common.indexed = append(common.indexed, repo.Repo)
+			}
+			for repo := range reposLimitHit {
+				// Repos that aren't included in the result set due to exceeded limits are partially searched
+				// for dynamic filter purposes. Note, reposLimitHit may include repos that did not have any results
+				// returned in the original result set, because indexed search has `limitHit` for the
+				// entire search rather than per repo as in non-indexed search.
+				common.partial[api.RepoName(repo)] = struct{}{}
+			}
 		}
-	} else {
-		// When searching many repos, don't wait long for any single repo to fetch.
-		fetchTimeout = 500 * time.Millisecond
-	}
+		if limitHit {
+			common.limitHit = true
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			// Don't discard the results of the unindexed repositories because of the indexed
+			// search timing out. The matches found before the deadline are kept, and the indexed
+			// repositories are reported as timed out, so that they are searched again when the
+			// search is resumed.
+			for _, repo := range zoektRepos {
+				common.timedout = append(common.timedout, repo.Repo)
+			}
+		} else if searchErr != nil && err == nil && !overLimitCanceled {
+			err = searchErr
+			tr.LazyPrintf("cancel indexed search due to error: %v", err)
+			cancel()
+		}
+		addMatches(matches)
+	}()
 
-	for _, repoRev := range searcherRepos {
+	// Search the unindexed repositories in priority order, so that the repositories that are
+	// searched fastest are searched before the deadline. The repositories that weren't searched
+	// before the deadline are reported as timed out, so that the search can be resumed.
+	searcherRepos = prioritizeSearcherRepos(searcherRepos)
+	sem := make(semaphore, searcherReposPerSearch)
+	for i, repoRev := range searcherRepos {
 		if len(repoRev.Revs) == 0 {
 			continue
 		}
-		if len(repoRev.Revs) >= 2 {
-			return nil, common, errMultipleRevsNotSupported
+
+		if ctxErr := sem.Acquire(ctx); ctxErr != nil {
+			if ctxErr == context.DeadlineExceeded {
+				mu.Lock()
+				for _, repoRev := range searcherRepos[i:] {
+					if len(repoRev.Revs) > 0 {
+						common.timedout = append(common.timedout, repoRev.Repo)
+					}
+				}
+				mu.Unlock()
+			}
+			break
 		}
 
 		wg.Add(1)
 		go func(repoRev search.RepositoryRevisions) {
 			defer wg.Done()
-			rev := repoRev.RevSpecs()[0] // TODO(sqs): search multiple revs
-			matches, repoLimitHit, searchErr := searchFilesInRepo(ctx, repoRev.Repo, repoRev.GitserverRepo(), rev, args.Pattern, fetchTimeout)
+			defer sem.Release()
+			var (
+				matches      []*fileMatchResolver
+				repoLimitHit bool
+				searchErr    error
+			)
+			if searchesMultipleRevisions(&repoRev) {
+				matches, repoLimitHit, searchErr = searchFilesInRepoRevisions(ctx, &repoRev, repoPattern, fetchTimeout)
+			} else {
+				rev := repoRev.RevSpecs()[0]
+				matches, repoLimitHit, searchErr = searchFilesInRepo(ctx, repoRev.Repo, repoRev.GitserverRepo(), rev, repoPattern, fetchTimeout)
+			}
+			if args.SelectRepo {
+				// A single match answers whether the repository matches.
+				repoLimitHit = false
+			}
 			if searchErr != nil {
 				tr.LogFields(otlog.String("repo", string(repoRev.Repo.Name)), otlog.String("searchErr", searchErr.Error()), otlog.Bool("timeout", errcode.IsTimeout(searchErr)), otlog.Bool("temporary", errcode.IsTemporary(searchErr)))
 			}
@@ -803,6 +1264,9 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 			if ctx.Err() == nil {
 				common.searched = append(common.searched, repoRev.Repo)
 			}
+			if searchErr == nil {
+				addRecentlySearchedRepo(&repoRev)
+			}
 			if repoLimitHit {
 				// We did not return all results in this repository.
 				common.partial[repoRev.Repo.Name] = struct{}{}
@@ -825,42 +1289,14 @@ func searchFilesInRepos(ctx context.Context, args *search.Args) (res []*fileMatc
 		}(*repoRev)
 	}
 
-	wg.Add(1)
-	go func() {
-		// TODO limitHit, handleRepoSearchResult
-		defer wg.Done()
-		matches, limitHit, reposLimitHit, searchErr := zoektSearchHEAD(ctx, args.Pattern, zoektRepos, args.UseFullDeadline)
-		mu.Lock()
-		defer mu.Unlock()
-		if ctx.Err() == nil {
-			for _, repo := range zoektRepos {
-				common.searched = append(common.searched, repo.Repo)
-				common.indexed = append(common.indexed, repo.Repo)
-			}
-			for repo := range reposLimitHit {
-				// Repos that aren't included in the result set due to exceeded limits are partially searched
-				// for dynamic filter purposes. Note, reposLimitHit may include repos that did not have any results
-				// returned in the original result set, because indexed search has `limitHit` for the
-				// entire search rather than per repo as in non-indexed search.
-				common.partial[api.RepoName(repo)] = struct{}{}
-			}
-		}
-		if limitHit {
-			common.limitHit = true
-		}
-		if searchErr != nil && err == nil && !overLimitCanceled {
-			err = searchErr
-			tr.LazyPrintf("cancel indexed search due to error: %v", err)
-			cancel()
-		}
-		addMatches(matches)
-	}()
-
 	wg.Wait()
 	if err != nil {
 		return nil, common, err
 	}
 
+	if args.SelectRepo {
+		return firstMatchPerRepo(unflattened, int(args.Pattern.FileMatchLimit)), common, nil
+	}
 	flattened := flattenFileMatches(unflattened, int(args.Pattern.FileMatchLimit))
 	return flattened, common, nil
 }
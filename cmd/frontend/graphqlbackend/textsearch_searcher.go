@@ -0,0 +1,353 @@
+package graphqlbackend
+
+import (
+	"context"
+	"fmt"
+	"regexp/syntax"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	searchpkg "github.com/sourcegraph/sourcegraph/pkg/search"
+	"github.com/sourcegraph/sourcegraph/pkg/search/backend"
+	searchquery "github.com/sourcegraph/sourcegraph/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/pkg/trace"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+// This file contains the text search of searchFilesInRepos that is routed through the
+// search.Searcher of pkg/search (Search().Text), which searches the indexed repositories with
+// zoekt and all others with searcher. It is a scoped experiment: it is only used when the
+// experimentalFeatures.unifiedTextSearch site configuration setting is enabled, and only for the
+// single-revision searches it supports (see searcherSupports). The previous code path of
+// searchFilesInRepos remains the default for all searches.
+
+// mockTextSearcher, if non-nil, is used by searchFilesInReposWithSearcher instead of
+// Search().Text.
+var mockTextSearcher searchpkg.Searcher
+
+func textSearcher() searchpkg.Searcher {
+	if mockTextSearcher != nil {
+		return mockTextSearcher
+	}
+	return Search().Text
+}
+
+// searcherSupports reports whether the text search args can be performed by
+// searchFilesInReposWithSearcher. The others are performed by the previous code path of
+// searchFilesInRepos.
+func searcherSupports(args *search.Args) bool {
+	p := args.Pattern
+	if p.CountOnly || p.IsWordMatch || !p.PathPatternsAreRegExps || p.IncludePattern != "" || args.SelectRepo {
+		return false
+	}
+
+	// index:only and index:no choose the searcher of each repository, which Search().Text
+	// does itself.
+	if index, _ := args.Query.StringValues(query.FieldIndex); len(index) > 0 {
+		switch parseYesNoOnly(index[len(index)-1]) {
+		case Yes, True:
+		default:
+			return false
+		}
+	}
+
+	// Search().Text searches a single revision of each repository.
+	for _, repoRev := range args.Repos {
+		if searchesMultipleRevisions(repoRev) {
+			return false
+		}
+		for _, rev := range repoRev.Revs {
+			if _, _, _, ok := rev.DiffRange(); ok {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// patternToSearcherQuery converts the pattern of a text search to a query for a
+// search.Searcher. Like queryToZoektQuery, regexps are parsed with the flags used by zoekt.
+func patternToSearcherQuery(p *search.PatternInfo) (searchquery.Q, error) {
+	parseRe := func(pattern string, caseSensitive, fileName, content bool) (searchquery.Q, error) {
+		re, err := syntax.Parse(pattern, syntax.ClassNL|syntax.PerlX|syntax.UnicodeGroups)
+		if err != nil {
+			return nil, err
+		}
+		noOpAnyChar(re)
+		if re.Op == syntax.OpLiteral {
+			return &searchquery.Substring{Pattern: string(re.Rune), CaseSensitive: caseSensitive, FileName: fileName, Content: content}, nil
+		}
+		return &searchquery.Regexp{Regexp: re, CaseSensitive: caseSensitive, FileName: fileName, Content: content}, nil
+	}
+
+	// A pattern which matches neither only file names nor only contents matches both.
+	fileName, content := p.PatternMatchesPath && !p.PatternMatchesContent, p.PatternMatchesContent && !p.PatternMatchesPath
+
+	var and []searchquery.Q
+	switch {
+	case p.BooleanPattern != nil:
+		// The patterns of a boolean pattern only match file contents.
+		var err error
+		q := searchquery.Map(p.BooleanPattern, nil, func(q searchquery.Q) searchquery.Q {
+			switch s := q.(type) {
+			case *searchquery.Substring:
+				return &searchquery.Substring{Pattern: s.Pattern, CaseSensitive: s.CaseSensitive, Content: true}
+			case *searchquery.Regexp:
+				var re searchquery.Q
+				if re, err = parseRe(s.Regexp.String(), s.CaseSensitive, false, true); err != nil {
+					return q
+				}
+				return re
+			}
+			return q
+		})
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, q)
+	case p.Pattern == "":
+		// Only the file path patterns are matched.
+	case p.IsRegExp:
+		q, err := parseRe(p.Pattern, p.IsCaseSensitive, fileName, content)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, q)
+	default:
+		and = append(and, &searchquery.Substring{Pattern: p.Pattern, CaseSensitive: p.IsCaseSensitive, FileName: fileName, Content: content})
+	}
+
+	for _, pattern := range p.IncludePatterns {
+		q, err := parseRe(pattern, p.PathPatternsAreCaseSensitive, true, false)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, q)
+	}
+	if p.ExcludePattern != "" {
+		q, err := parseRe(p.ExcludePattern, p.PathPatternsAreCaseSensitive, true, false)
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, &searchquery.Not{Child: q})
+	}
+
+	if len(and) == 0 {
+		return &searchquery.Const{Value: true}, nil
+	}
+	return searchquery.Simplify(searchquery.NewAnd(and...)), nil
+}
+
+// searchFilesInReposWithSearcher is like searchFilesInRepos, but searches args.Repos with
+// textSearcher. The caller must check that searcherSupports(args).
+func searchFilesInReposWithSearcher(ctx context.Context, args *search.Args) (res []*fileMatchResolver, common *searchResultsCommon, err error) {
+	tr, ctx := trace.New(ctx, "searchFilesInReposWithSearcher", fmt.Sprintf("query: %+v, numRepoRevs: %d", args.Pattern, len(args.Repos)))
+	defer func() {
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	common = &searchResultsCommon{partial: make(map[api.RepoName]struct{})}
+	common.repos = make([]*types.Repo, len(args.Repos))
+	for i, repo := range args.Repos {
+		common.repos[i] = repo.Repo
+	}
+
+	if args.Pattern.IsEmpty() {
+		// Empty query isn't an error, but it has no results.
+		return nil, common, nil
+	}
+
+	q, err := patternToSearcherQuery(args.Pattern)
+	if err != nil {
+		return nil, common, err
+	}
+
+	// The repositories on their default branch and the repositories at a revision are searched
+	// separately, because the searcher searches every repository that matches no ref: atom of
+	// a query on its default branch. The repositories are listed in the order they should be
+	// searched (see prioritizeSearcherRepos).
+	var (
+		repos         = make(map[api.RepoName]*search.RepositoryRevisions, len(args.Repos))
+		defaultBranch []api.RepoName
+		atRev         []api.RepoName
+		revs          []searchquery.Q
+	)
+	for _, repoRev := range prioritizeSearcherRepos(args.Repos) {
+		if len(repoRev.Revs) == 0 {
+			continue
+		}
+		repos[repoRev.Repo.Name] = repoRev
+		if rev := repoRev.RevSpecs()[0]; rev == "" {
+			defaultBranch = append(defaultBranch, repoRev.Repo.Name)
+		} else {
+			atRev = append(atRev, repoRev.Repo.Name)
+			revs = append(revs, searchquery.NewAnd(searchquery.NewRepoSet(string(repoRev.Repo.Name)), &searchquery.Ref{Pattern: rev}))
+		}
+	}
+
+	opts := &searchpkg.Options{
+		TotalMaxMatchCount: int(args.Pattern.FileMatchLimit),
+		FetchTimeout:       searcherFetchTimeout(ctx, len(repos), args.UseFullDeadline),
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		opts.MaxWallTime = time.Until(deadline)
+	}
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		result    searchpkg.Result
+		searchErr error
+		timedout  = make(map[api.RepoName]bool) // repositories of searches that hit the deadline
+	)
+	searchRepos := func(q searchquery.Q, repos []api.RepoName) {
+		defer wg.Done()
+		o := opts.ShallowCopy()
+		o.Repositories = repos
+		r, err := textSearcher().Search(ctx, q, o)
+		mu.Lock()
+		defer mu.Unlock()
+		if err == context.DeadlineExceeded || (err != nil && ctx.Err() == context.DeadlineExceeded) {
+			// Keep the results found before the deadline. The repositories without a status
+			// are reported as timed out below.
+			for _, repo := range repos {
+				timedout[repo] = true
+			}
+			if r != nil {
+				result.Add(r)
+			}
+			return
+		}
+		if err != nil {
+			if searchErr == nil {
+				searchErr = err
+				tr.LazyPrintf("cancel due to error: %v", err)
+				cancel()
+			}
+			return
+		}
+		result.Add(r)
+	}
+	if len(defaultBranch) > 0 {
+		wg.Add(1)
+		go searchRepos(q, defaultBranch)
+	}
+	if len(atRev) > 0 {
+		wg.Add(1)
+		go searchRepos(searchquery.NewAnd(q, searchquery.NewOr(revs...)), atRev)
+	}
+	wg.Wait()
+	if searchErr != nil {
+		return nil, common, searchErr
+	}
+
+	for _, source := range result.Unavailable {
+		if source == backend.SourceZoekt {
+			common.indexUnavailable = true
+		}
+	}
+
+	hasStatus := make(map[api.RepoName]bool, len(repos))
+	for _, s := range result.Status {
+		repoRev, ok := repos[s.Repository.Name]
+		if !ok {
+			continue
+		}
+		hasStatus[repoRev.Repo.Name] = true
+		if s.Source == backend.SourceZoekt {
+			common.indexed = append(common.indexed, repoRev.Repo)
+		}
+
+		switch s.Status {
+		case searchpkg.RepositoryStatusSearched:
+			common.searched = append(common.searched, repoRev.Repo)
+			if s.Source == backend.SourceSearcher {
+				addRecentlySearchedRepo(repoRev)
+			}
+
+		case searchpkg.RepositoryStatusLimitHit:
+			// We did not return all results in this repository.
+			common.searched = append(common.searched, repoRev.Repo)
+			common.partial[repoRev.Repo.Name] = struct{}{}
+			common.limitHit = true
+
+		case searchpkg.RepositoryStatusTimedOut:
+			common.timedout = append(common.timedout, repoRev.Repo)
+
+		case searchpkg.RepositoryStatusCloning:
+			common.cloning = append(common.cloning, repoRev.Repo)
+
+		case searchpkg.RepositoryStatusMissing:
+			common.missing = append(common.missing, repoRev.Repo)
+
+		case searchpkg.RepositoryStatusCommitMissing:
+			// If we didn't specify an input revision, then the repo is empty and can be ignored.
+			if s.Repository.RefPattern != "" {
+				err := &git.RevisionNotFoundError{Repo: repoRev.Repo.Name, Spec: s.Repository.RefPattern}
+				return nil, common, errors.Wrapf(err, "failed to search %s", repoRev.String())
+			}
+
+		default:
+			return nil, common, errors.Errorf("failed to search %s: %v", repoRev.String(), &s)
+		}
+	}
+	// The repositories that weren't searched before the deadline are reported as timed out,
+	// so that the search can be resumed.
+	for _, repoRev := range args.Repos {
+		name := repoRev.Repo.Name
+		if _, ok := repos[name]; ok && !hasStatus[name] && (timedout[name] || ctx.Err() == context.DeadlineExceeded) {
+			common.timedout = append(common.timedout, repoRev.Repo)
+		}
+	}
+
+	matchesByRepo := make(map[api.RepoName][]*fileMatchResolver)
+	for _, file := range result.Files {
+		repoRev, ok := repos[file.Repository.Name]
+		if !ok {
+			continue
+		}
+		fm := &fileMatchResolver{
+			JPath:        file.Path,
+			JLineMatches: toLineMatches(file.LineMatches),
+			uri:          fileMatchURI(repoRev.Repo.Name, file.Repository.RefPattern, file.Path),
+			repo:         repoRev.Repo,
+			commitID:     file.Repository.Commit,
+		}
+		if rev := file.Repository.RefPattern; rev != "" {
+			fm.inputRev = &rev
+		}
+		matchesByRepo[repoRev.Repo.Name] = append(matchesByRepo[repoRev.Repo.Name], fm)
+	}
+
+	// Like searchFilesInRepos, the matches are grouped per repository (in the order of
+	// args.Repos) before they are flattened.
+	var (
+		unflattened   [][]*fileMatchResolver
+		flattenedSize int
+	)
+	for _, repoRev := range args.Repos {
+		matches := matchesByRepo[repoRev.Repo.Name]
+		if len(matches) == 0 {
+			continue
+		}
+		sort.Slice(matches, func(i, j int) bool { return matches[i].uri > matches[j].uri })
+		unflattened = append(unflattened, matches)
+		flattenedSize += len(matches)
+		delete(matchesByRepo, repoRev.Repo.Name) // a repository may be listed more than once
+	}
+	common.resultCount = int32(flattenedSize)
+	if flattenedSize > int(args.Pattern.FileMatchLimit) {
+		common.limitHit = true
+	}
+	return flattenFileMatches(unflattened, int(args.Pattern.FileMatchLimit)), common, nil
+}
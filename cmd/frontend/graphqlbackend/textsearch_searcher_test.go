@@ -0,0 +1,191 @@
+package graphqlbackend
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	searchpkg "github.com/sourcegraph/sourcegraph/pkg/search"
+	"github.com/sourcegraph/sourcegraph/pkg/search/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+	"github.com/sourcegraph/sourcegraph/schema"
+)
+
+func TestPatternToSearcherQuery(t *testing.T) {
+	cases := []struct {
+		Name    string
+		Pattern search.PatternInfo
+		Query   string
+	}{
+		{
+			Name:    "substring",
+			Pattern: search.PatternInfo{Pattern: "foo", PathPatternsAreRegExps: true},
+			Query:   `substr:"foo"`,
+		},
+		{
+			Name:    "literal regexp",
+			Pattern: search.PatternInfo{Pattern: "foo", IsRegExp: true, IsCaseSensitive: true, PatternMatchesContent: true, PathPatternsAreRegExps: true},
+			Query:   `case_content_substr:"foo"`,
+		},
+		{
+			Name: "path patterns",
+			Pattern: search.PatternInfo{
+				Pattern:                "fo+",
+				IsRegExp:               true,
+				IncludePatterns:        []string{"main"},
+				ExcludePattern:         "vendor",
+				PathPatternsAreRegExps: true,
+			},
+			Query: `(and regex:"fo+" file_substr:"main" (not file_substr:"vendor"))`,
+		},
+		{
+			Name:    "only path patterns",
+			Pattern: search.PatternInfo{IncludePatterns: []string{"main"}, PathPatternsAreRegExps: true},
+			Query:   `file_substr:"main"`,
+		},
+	}
+	for _, tt := range cases {
+		t.Run(tt.Name, func(t *testing.T) {
+			q, err := patternToSearcherQuery(&tt.Pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := q.String(); got != tt.Query {
+				t.Errorf("got %s, want %s", got, tt.Query)
+			}
+		})
+	}
+}
+
+func TestSearchFilesInReposWithSearcher(t *testing.T) {
+	conf.Mock(&conf.Unified{SiteConfiguration: schema.SiteConfiguration{ExperimentalFeatures: &schema.ExperimentalFeatures{UnifiedTextSearch: "enabled"}}})
+	defer conf.Mock(nil)
+
+	status := func(repo string, source searchpkg.Source, status searchpkg.RepositoryStatusType) searchpkg.RepositoryStatus {
+		return searchpkg.RepositoryStatus{Repository: searchpkg.Repository{Name: api.RepoName(repo)}, Source: source, Status: status}
+	}
+	mock := &backend.Mock{
+		Result: &searchpkg.Result{
+			Stats: searchpkg.Stats{
+				MatchCount: 2,
+				Status: []searchpkg.RepositoryStatus{
+					status("foo/one", backend.SourceZoekt, searchpkg.RepositoryStatusSearched),
+					status("foo/two", backend.SourceSearcher, searchpkg.RepositoryStatusLimitHit),
+					status("foo/empty", backend.SourceSearcher, searchpkg.RepositoryStatusCommitMissing),
+					status("foo/cloning", backend.SourceSearcher, searchpkg.RepositoryStatusCloning),
+					status("foo/missing", backend.SourceSearcher, searchpkg.RepositoryStatusMissing),
+					status("foo/timedout", backend.SourceSearcher, searchpkg.RepositoryStatusTimedOut),
+				},
+			},
+			Files: []searchpkg.FileMatch{
+				{
+					Path:        "main.go",
+					Repository:  searchpkg.Repository{Name: "foo/one", Commit: "deadbeef"},
+					LineMatches: []searchpkg.LineMatch{{Line: []byte("føo"), LineNumber: 1, LineFragments: []searchpkg.LineFragmentMatch{{LineOffset: 0, MatchLength: 4}}}},
+				},
+				{
+					Path:       "foo.go",
+					Repository: searchpkg.Repository{Name: "foo/two", Commit: "c0ffee"},
+				},
+			},
+		},
+	}
+	mockTextSearcher = mock
+	defer func() { mockTextSearcher = nil }()
+
+	q, err := query.ParseAndCheck("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := &search.Args{
+		Pattern: &search.PatternInfo{
+			FileMatchLimit:         defaultMaxSearchResults,
+			Pattern:                "foo",
+			PathPatternsAreRegExps: true,
+		},
+		Repos: makeRepositoryRevisions("foo/one", "foo/two", "foo/empty", "foo/cloning", "foo/missing", "foo/timedout"),
+		Query: q,
+	}
+	results, common, err := searchFilesInRepos(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mock.LastQ == nil || mock.LastQ.String() != `substr:"foo"` {
+		t.Errorf("unexpected query: %v", mock.LastQ)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected two results, got %d", len(results))
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].uri < results[j].uri })
+	if got, want := results[0].uri, "git://foo/one#main.go"; got != want {
+		t.Errorf("got uri %q, want %q", got, want)
+	}
+	if got, want := results[0].JLineMatches[0].JOffsetAndLengths, [][2]int32{{0, 3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got offsets %v, want %v", got, want)
+	}
+	if v := toRepoNames(common.searched); !reflect.DeepEqual(v, []api.RepoName{"foo/one", "foo/two"}) {
+		t.Errorf("unexpected searched: %v", v)
+	}
+	if v := toRepoNames(common.indexed); !reflect.DeepEqual(v, []api.RepoName{"foo/one"}) {
+		t.Errorf("unexpected indexed: %v", v)
+	}
+	if _, ok := common.partial["foo/two"]; !ok || !common.limitHit {
+		t.Errorf("expected foo/two to be partially searched, got partial %v and limitHit %v", common.partial, common.limitHit)
+	}
+	if v := toRepoNames(common.cloning); !reflect.DeepEqual(v, []api.RepoName{"foo/cloning"}) {
+		t.Errorf("unexpected cloning: %v", v)
+	}
+	if v := toRepoNames(common.missing); !reflect.DeepEqual(v, []api.RepoName{"foo/missing"}) {
+		t.Errorf("unexpected missing: %v", v)
+	}
+	if v := toRepoNames(common.timedout); !reflect.DeepEqual(v, []api.RepoName{"foo/timedout"}) {
+		t.Errorf("unexpected timedout: %v", v)
+	}
+
+	// A search that hits the deadline keeps the results found before it, and the
+	// repositories that weren't searched are timed out.
+	mock.Result = &searchpkg.Result{
+		Stats: searchpkg.Stats{
+			Status: []searchpkg.RepositoryStatus{status("foo/one", backend.SourceSearcher, searchpkg.RepositoryStatusSearched)},
+		},
+		Files: []searchpkg.FileMatch{{Path: "main.go", Repository: searchpkg.Repository{Name: "foo/one", Commit: "deadbeef"}}},
+	}
+	mock.Error = context.DeadlineExceeded
+	args.Repos = makeRepositoryRevisions("foo/one", "foo/two")
+	results, common, err = searchFilesInRepos(context.Background(), args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 1 || results[0].uri != "git://foo/one#main.go" {
+		t.Errorf("got results %v, want the result in foo/one", results)
+	}
+	if v := toRepoNames(common.timedout); !reflect.DeepEqual(v, []api.RepoName{"foo/two"}) {
+		t.Errorf("unexpected timedout: %v", v)
+	}
+	mock.Error = nil
+
+	// A revision that doesn't exist fails the whole search, like in searchFilesInRepos.
+	mock.Result = &searchpkg.Result{
+		Stats: searchpkg.Stats{
+			Status: []searchpkg.RepositoryStatus{{
+				Repository: searchpkg.Repository{Name: "foo/no-rev", RefPattern: "dev"},
+				Source:     backend.SourceSearcher,
+				Status:     searchpkg.RepositoryStatusCommitMissing,
+			}},
+		},
+	}
+	args.Repos = makeRepositoryRevisions("foo/no-rev@dev")
+	_, _, err = searchFilesInRepos(context.Background(), args)
+	if !git.IsRevisionNotFound(errors.Cause(err)) {
+		t.Fatalf("searching non-existent rev expected to fail with RevisionNotFoundError got: %v", err)
+	}
+	if got, want := mock.LastQ.String(), `(and substr:"foo" (reposet foo/no-rev) ref:"dev")`; got != want {
+		t.Errorf("got query %s, want %s", got, want)
+	}
+}
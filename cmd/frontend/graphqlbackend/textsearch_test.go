@@ -108,6 +108,40 @@ func TestQueryToZoektQuery(t *testing.T) {
 	}
 }
 
+func TestQueryToZoektQuery_booleanPattern(t *testing.T) {
+	// Substring and regexp patterns both match only file contents.
+	q, err := query.ParseAndCheck(`"a" AND b.*c AND NOT d`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	booleanPattern, _, err := booleanPatternToQuery(q.Pattern, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := queryToZoektQuery(&search.PatternInfo{IsRegExp: true, BooleanPattern: booleanPattern, PathPatternsAreRegExps: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var leaves int
+	zoektquery.VisitAtoms(got, func(q zoektquery.Q) {
+		switch s := q.(type) {
+		case *zoektquery.Substring:
+			leaves++
+			if !s.Content || s.FileName {
+				t.Errorf("got %s, want a content-only pattern", s)
+			}
+		case *zoektquery.Regexp:
+			leaves++
+			if !s.Content || s.FileName {
+				t.Errorf("got %s, want a content-only pattern", s)
+			}
+		}
+	})
+	if leaves != 3 {
+		t.Errorf("got %d patterns in %s, want 3", leaves, got)
+	}
+}
+
 func queryEqual(a zoektquery.Q, b zoektquery.Q) bool {
 	sortChildren := func(q zoektquery.Q) zoektquery.Q {
 		switch s := q.(type) {
@@ -217,3 +251,20 @@ func makeRepositoryRevisions(repos ...string) []*search.RepositoryRevisions {
 	}
 	return r
 }
+
+func TestPrioritizeSearcherRepos(t *testing.T) {
+	repos := makeRepositoryRevisions("prioritize/a", "prioritize/b", "prioritize/b@dev")
+	addRecentlySearchedRepo(repos[2])
+
+	var got []string
+	for _, repoRev := range prioritizeSearcherRepos(repos) {
+		got = append(got, repoRev.String())
+	}
+	// Recently searched repositories come first; the order is otherwise unchanged.
+	if want := []string{"prioritize/b@dev", "prioritize/a", "prioritize/b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if repos[0].Repo.Name != "prioritize/a" {
+		t.Error("repos was modified")
+	}
+}
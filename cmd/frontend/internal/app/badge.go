@@ -16,13 +16,18 @@ import (
 // redirect to our more canonical shields.io URLs and remove this badgeValue
 // duplication kludge.
 
+// badgeValue returns the text of the badge. The number of projects is hidden
+// if it is not known (see backend.ErrGoImportersUnknown).
+//
 // NOTE: Keep in sync with services/backend/httpapi/repo_shield.go
-func badgeValue(r *http.Request) (int, error) {
+func badgeValue(r *http.Request) (string, error) {
 	totalRefs, err := backend.CountGoImporters(r.Context(), routevar.ToRepo(mux.Vars(r)))
-	if err != nil {
-		return 0, errors.Wrap(err, "Defs.TotalRefs")
+	if err == backend.ErrGoImportersUnknown {
+		return " unknown", nil
+	} else if err != nil {
+		return "", errors.Wrap(err, "Defs.TotalRefs")
 	}
-	return totalRefs, nil
+	return badgeValueFmt(totalRefs), nil
 }
 
 // NOTE: Keep in sync with services/backend/httpapi/repo_shield.go
@@ -56,7 +61,7 @@ func serveRepoBadge(w http.ResponseWriter, r *http.Request) error {
 	u := &url.URL{
 		Scheme:   "https",
 		Host:     "img.shields.io",
-		Path:     "/badge/used by-" + badgeValueFmt(value) + "-brightgreen.svg",
+		Path:     "/badge/used by-" + value + "-brightgreen.svg",
 		RawQuery: v.Encode(),
 	}
 	http.Redirect(w, r, u.String(), http.StatusTemporaryRedirect)
@@ -0,0 +1,25 @@
+package bg
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// DeleteOldSearchHistory periodically deletes the search history entries that
+// are older than the retention period in the site configuration.
+func DeleteOldSearchHistory() {
+	for {
+		ctx := context.Background()
+		n, err := db.SearchHistory.DeleteOlderThan(ctx, time.Now().Add(-conf.SearchHistoryRetention()))
+		if err != nil {
+			log15.Error("Deleting old search history failed.", "error", err)
+		} else if n > 0 {
+			log15.Debug("Deleted old search history.", "entries", n)
+		}
+		time.Sleep(time.Hour)
+	}
+}
@@ -0,0 +1,20 @@
+package bg
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// RecordSearchInsights periodically records the missing data points of all
+// search insights.
+func RecordSearchInsights() {
+	for {
+		if err := graphqlbackend.RecordSearchInsights(context.Background()); err != nil {
+			log15.Error("Recording search insights failed.", "error", err)
+		}
+		time.Sleep(10 * time.Minute)
+	}
+}
@@ -0,0 +1,25 @@
+package bg
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// UpdateGlobalSymbolIndex periodically updates the global symbol index for
+// the repositories whose default branch changed. Repo-updater also requests
+// an update when it fetches a repository, so this only catches up on missed
+// updates.
+func UpdateGlobalSymbolIndex() {
+	// The index covers all repositories, regardless of repository permissions.
+	ctx := actor.WithActor(context.Background(), &actor.Actor{Internal: true})
+	for {
+		if err := graphqlbackend.UpdateGlobalSymbolIndexes(ctx); err != nil {
+			log15.Error("Updating the global symbol index failed.", "error", err)
+		}
+		time.Sleep(10 * time.Minute)
+	}
+}
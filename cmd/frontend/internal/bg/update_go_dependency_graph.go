@@ -0,0 +1,23 @@
+package bg
+
+import (
+	"context"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// UpdateGoDependencyGraph periodically updates the Go dependency graph for the
+// repositories whose default branch changed.
+func UpdateGoDependencyGraph() {
+	// The graph covers all repositories, regardless of repository permissions.
+	ctx := actor.WithActor(context.Background(), &actor.Actor{Internal: true})
+	for {
+		if err := backend.UpdateGoDependencyGraph(ctx); err != nil {
+			log15.Error("Updating the Go dependency graph failed.", "error", err)
+		}
+		time.Sleep(10 * time.Minute)
+	}
+}
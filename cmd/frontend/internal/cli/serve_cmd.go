@@ -18,6 +18,7 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/hooks"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/app/pkg/updatecheck"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/bg"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/cli/loghandlers"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/discussions/mailreply"
@@ -168,6 +169,11 @@ func Main() error {
 	}
 
 	goroutine.Go(mailreply.StartWorker)
+	goroutine.Go(bg.DeleteOldSearchHistory)
+	goroutine.Go(bg.RecordSearchInsights)
+	goroutine.Go(bg.UpdateGlobalSymbolIndex)
+	goroutine.Go(bg.UpdateGoDependencyGraph)
+	goroutine.Go(bg.UpdatePackageDependencyGraph)
 	go updatecheck.Start()
 	if hooks.AfterDBInit != nil {
 		hooks.AfterDBInit()
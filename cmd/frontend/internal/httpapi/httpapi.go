@@ -37,8 +37,12 @@ func NewHandler(m *mux.Router) http.Handler {
 
 	m.Get(apirouter.RepoRefresh).Handler(trace.TraceRoute(handler(serveRepoRefresh)))
 
+	m.Get(apirouter.RepoLSIFUpload).Handler(trace.TraceRoute(handler(serveLSIFUpload)))
+
 	m.Get(apirouter.Telemetry).Handler(trace.TraceRoute(telemetryHandler))
 
+	m.Get(apirouter.SearchExport).Handler(trace.TraceRoute(handler(serveSearchExport)))
+
 	if envvar.SourcegraphDotComMode() {
 		m.Path("/updates").Methods("GET").Name("updatecheck").Handler(trace.TraceRoute(http.HandlerFunc(updatecheck.Handler)))
 	}
@@ -69,6 +73,7 @@ func NewInternalHandler(m *mux.Router) http.Handler {
 
 	m.Get(apirouter.ExternalServiceConfigs).Handler(trace.TraceRoute(handler(serveExternalServiceConfigs)))
 	m.Get(apirouter.ExternalServicesList).Handler(trace.TraceRoute(handler(serveExternalServicesList)))
+	m.Get(apirouter.GlobalSymbolsUpdate).Handler(trace.TraceRoute(handler(serveGlobalSymbolsUpdate)))
 	m.Get(apirouter.PhabricatorRepoCreate).Handler(trace.TraceRoute(handler(servePhabricatorRepoCreate)))
 	m.Get(apirouter.ReposCreateIfNotExists).Handler(trace.TraceRoute(handler(serveReposCreateIfNotExists)))
 	m.Get(apirouter.ReposUpdateMetadata).Handler(trace.TraceRoute(handler(serveReposUpdateMetadata)))
@@ -1,6 +1,7 @@
 package httpapi
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -15,6 +16,8 @@ import (
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/globals"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/goroutine"
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/conf"
@@ -81,6 +84,29 @@ func serveReposUpdateMetadata(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+func serveGlobalSymbolsUpdate(w http.ResponseWriter, r *http.Request) error {
+	var req api.GlobalSymbolsUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return err
+	}
+	if !conf.GlobalSymbolSearchEnabled() || !conf.SearchIndexEnabled() {
+		return nil // only the repositories that indexed search indexes are in the global symbol index
+	}
+	repo, err := db.Repos.GetByName(r.Context(), req.RepoName)
+	if err != nil {
+		return errors.Wrap(err, "Repos.GetByName failed")
+	}
+
+	// Indexing the symbols of a repository can take a while, so don't make
+	// the caller wait.
+	goroutine.Go(func() {
+		if err := graphqlbackend.UpdateGlobalSymbolIndex(context.Background(), repo); err != nil {
+			log15.Warn("Updating the global symbol index failed.", "repo", repo.Name, "error", err)
+		}
+	})
+	return nil
+}
+
 func servePhabricatorRepoCreate(w http.ResponseWriter, r *http.Request) error {
 	var repo api.PhabricatorRepoCreateRequest
 	err := json.NewDecoder(r.Body).Decode(&repo)
@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"regexp"
+
+	"github.com/gorilla/mux"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/handlerutil"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/lsif"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+)
+
+// maxLSIFUploadSize is the maximum size of an uploaded LSIF dump (after
+// decompression). The dump is converted in memory while handling the request,
+// so this bounds the memory an upload uses.
+const maxLSIFUploadSize = 100 << 20
+
+var commitIDPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// serveLSIFUpload stores the LSIF dump in the request body (optionally
+// gzip-compressed) as the precise code intelligence data of the repository at
+// the commit in the "commit" URL parameter, replacing any previous upload for
+// that commit. The optional "root" URL parameter is the directory of the
+// repository that is the project root of the dump.
+//
+// 🚨 SECURITY: Uploads change the code intelligence data shown to all users,
+// so only site admins may upload, and only with an access token (so that a
+// page cannot upload with the credentials in a site admin's session cookie).
+func serveLSIFUpload(w http.ResponseWriter, r *http.Request) error {
+	if a := actor.FromContext(r.Context()); !a.IsAuthenticated() || a.FromSessionCookie {
+		return &errcode.HTTPErr{Status: http.StatusUnauthorized, Err: errors.New("LSIF uploads must be authenticated with an access token")}
+	}
+	if err := backend.CheckCurrentUserIsSiteAdmin(r.Context()); err == backend.ErrMustBeSiteAdmin {
+		return &errcode.HTTPErr{Status: http.StatusForbidden, Err: err}
+	} else if err != nil {
+		return err
+	}
+
+	q := r.URL.Query()
+	commit := q.Get("commit")
+	if !commitIDPattern.MatchString(commit) {
+		return &errcode.HTTPErr{Status: http.StatusBadRequest, Err: fmt.Errorf("invalid commit %q (must be a 40-character commit ID)", commit)}
+	}
+	root := path.Clean("/" + q.Get("root"))[1:]
+
+	repo, err := handlerutil.GetRepo(r.Context(), mux.Vars(r))
+	if err != nil {
+		return err
+	}
+
+	var body io.Reader = http.MaxBytesReader(w, r.Body, maxLSIFUploadSize)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(body)
+		if err != nil {
+			return &errcode.HTTPErr{Status: http.StatusBadRequest, Err: err}
+		}
+		defer gz.Close()
+		body = io.LimitReader(gz, maxLSIFUploadSize)
+	}
+	converted, err := lsif.Convert(body, root)
+	if err != nil {
+		return &errcode.HTTPErr{Status: http.StatusBadRequest, Err: err}
+	}
+
+	documents := make(map[string][]byte, len(converted.Documents))
+	for documentPath, document := range converted.Documents {
+		if documents[documentPath], err = json.Marshal(document); err != nil {
+			return err
+		}
+	}
+	results := make(map[string][]byte, len(converted.Results))
+	for result, locations := range converted.Results {
+		if results[result], err = json.Marshal(locations); err != nil {
+			return err
+		}
+	}
+	dump, err := db.LSIFDumps.Create(r.Context(), repo.ID, api.CommitID(commit), documents, results)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, struct {
+		ID        int64
+		Commit    api.CommitID
+		Documents int
+	}{ID: dump.ID, Commit: dump.Commit, Documents: len(documents)})
+}
@@ -0,0 +1,144 @@
+package httpapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/backend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/db"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/httpapi/router"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/types"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/httptestutil"
+)
+
+const testLSIFDump = `{"id":1,"type":"vertex","label":"metaData","projectRoot":"file:///src"}
+{"id":2,"type":"vertex","label":"document","uri":"file:///src/a.go"}
+{"id":3,"type":"vertex","label":"range","start":{"line":1,"character":5},"end":{"line":1,"character":8}}
+{"id":4,"type":"edge","label":"contains","outV":2,"inVs":[3]}
+{"id":5,"type":"vertex","label":"hoverResult","result":{"contents":"func Foo()"}}
+{"id":6,"type":"edge","label":"textDocument/hover","outV":3,"inV":5}
+{"id":7,"type":"vertex","label":"definitionResult"}
+{"id":8,"type":"edge","label":"textDocument/definition","outV":3,"inV":7}
+{"id":9,"type":"edge","label":"item","outV":7,"inVs":[3],"document":2}
+`
+
+func TestLSIFUpload(t *testing.T) {
+	defer func() {
+		db.Mocks = db.MockStores{}
+		backend.Mocks = backend.MockServices{}
+	}()
+	db.Mocks.Users.GetByCurrentAuthUser = func(ctx context.Context) (*types.User, error) {
+		a := actor.FromContext(ctx)
+		return &types.User{ID: a.UID, SiteAdmin: a.UID == 1}, nil
+	}
+	backend.Mocks.Repos.GetByName = func(ctx context.Context, name api.RepoName) (*types.Repo, error) {
+		return &types.Repo{ID: 2, Name: name}, nil
+	}
+	var created, createdResults map[string][]byte
+	db.Mocks.LSIFDumps.Create = func(repoID api.RepoID, commit api.CommitID, documents, results map[string][]byte) (*db.LSIFDump, error) {
+		if repoID != 2 || commit != "0123456789012345678901234567890123456789" {
+			t.Errorf("got repo %d commit %q", repoID, commit)
+		}
+		created, createdResults = documents, results
+		return &db.LSIFDump{ID: 3, RepoID: repoID, Commit: commit}, nil
+	}
+
+	upload := func(a *actor.Actor, query string, gzipped bool) *http.Response {
+		h := NewHandler(router.New(mux.NewRouter()))
+		c := httptestutil.NewTest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(w, r.WithContext(actor.WithActor(r.Context(), a)))
+		}))
+		var body bytes.Buffer
+		if gzipped {
+			gz := gzip.NewWriter(&body)
+			gz.Write([]byte(testLSIFDump))
+			gz.Close()
+		} else {
+			body.WriteString(testLSIFDump)
+		}
+		req, _ := http.NewRequest("POST", "/repos/r/-/lsif?"+query, &body)
+		if gzipped {
+			req.Header.Set("Content-Encoding", "gzip")
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+	const commit = "commit=0123456789012345678901234567890123456789"
+
+	t.Run("upload", func(t *testing.T) {
+		for _, gzipped := range []bool{false, true} {
+			created = nil
+			resp := upload(&actor.Actor{UID: 1}, commit+"&root=sub/", gzipped)
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("got status %d, want %d", resp.StatusCode, http.StatusOK)
+			}
+			var result struct {
+				ID        int64
+				Documents int
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+				t.Fatal(err)
+			}
+			if result.ID != 3 || result.Documents != 1 {
+				t.Errorf("got %+v", result)
+			}
+			if want := []string{"sub/a.go"}; !reflect.DeepEqual(keys(created), want) {
+				t.Errorf("got documents %q, want %q", keys(created), want)
+			}
+			if !strings.Contains(string(created["sub/a.go"]), "func Foo()") {
+				t.Errorf("got document %s, want the hover", created["sub/a.go"])
+			}
+			// The locations of the results are stored separately.
+			if want := []string{"7"}; !reflect.DeepEqual(keys(createdResults), want) {
+				t.Errorf("got results %q, want %q", keys(createdResults), want)
+			}
+			if document := string(created["sub/a.go"]); !strings.Contains(document, `"definitionResult":"7"`) || strings.Contains(document, "sub/a.go") {
+				t.Errorf("got document %s, want only the ID of its definition result", document)
+			}
+			if !strings.Contains(string(createdResults["7"]), `"path":"sub/a.go"`) {
+				t.Errorf("got result %s, want the location of the definition", createdResults["7"])
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		for name, test := range map[string]struct {
+			actor      *actor.Actor
+			query      string
+			wantStatus int
+		}{
+			"anonymous":      {actor: &actor.Actor{}, query: commit, wantStatus: http.StatusUnauthorized},
+			"session cookie": {actor: &actor.Actor{UID: 1, FromSessionCookie: true}, query: commit, wantStatus: http.StatusUnauthorized},
+			"not site admin": {actor: &actor.Actor{UID: 2}, query: commit, wantStatus: http.StatusForbidden},
+			"invalid commit": {actor: &actor.Actor{UID: 1}, query: "commit=master", wantStatus: http.StatusBadRequest},
+		} {
+			created = nil
+			if resp := upload(test.actor, test.query, false); resp.StatusCode != test.wantStatus {
+				t.Errorf("%s: got status %d, want %d", name, resp.StatusCode, test.wantStatus)
+			}
+			if created != nil {
+				t.Errorf("%s: dump was created", name)
+			}
+		}
+	})
+}
+
+func keys(m map[string][]byte) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	return keys
+}
@@ -10,13 +10,18 @@ import (
 	"github.com/sourcegraph/sourcegraph/pkg/routevar"
 )
 
+// badgeValue returns the text of the badge. The number of projects is hidden
+// if it is not known (see backend.ErrGoImportersUnknown).
+//
 // NOTE: Keep in sync with services/backend/httpapi/repo_shield.go
-func badgeValue(r *http.Request) (int, error) {
+func badgeValue(r *http.Request) (string, error) {
 	totalRefs, err := backend.CountGoImporters(r.Context(), routevar.ToRepo(mux.Vars(r)))
-	if err != nil {
-		return 0, errors.Wrap(err, "Defs.TotalRefs")
+	if err == backend.ErrGoImportersUnknown {
+		return " unknown", nil
+	} else if err != nil {
+		return "", errors.Wrap(err, "Defs.TotalRefs")
 	}
-	return totalRefs, nil
+	return badgeValueFmt(totalRefs), nil
 }
 
 // NOTE: Keep in sync with services/backend/httpapi/repo_shield.go
@@ -43,6 +48,6 @@ func serveRepoShield(w http.ResponseWriter, r *http.Request) error {
 		// code.
 		Value string `json:"value"`
 	}{
-		Value: badgeValueFmt(value),
+		Value: value,
 	})
 }
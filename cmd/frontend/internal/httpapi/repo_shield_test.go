@@ -65,4 +65,16 @@ func TestRepoShield(t *testing.T) {
 	if !reflect.DeepEqual(resp, wantResp) {
 		t.Errorf("got %+v, want %+v", resp, wantResp)
 	}
+
+	// The number of projects is hidden if it is not known.
+	backend.MockCountGoImporters = func(ctx context.Context, source api.RepoName) (int, error) {
+		return 0, backend.ErrGoImportersUnknown
+	}
+	resp = nil
+	if err := c.GetJSON("/repos/github.com/gorilla/mux/-/shield", &resp); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]interface{}{"value": " unknown"}; !reflect.DeepEqual(resp, want) {
+		t.Errorf("got %+v, want %+v", resp, want)
+	}
 }
@@ -11,9 +11,12 @@ const (
 
 	Registry = "registry"
 
-	RepoShield  = "repo.shield"
-	RepoRefresh = "repo.refresh"
-	Telemetry   = "telemetry"
+	RepoShield     = "repo.shield"
+	RepoRefresh    = "repo.refresh"
+	RepoLSIFUpload = "repo.lsif.upload"
+	Telemetry      = "telemetry"
+
+	SearchExport = "search.export"
 
 	SavedQueriesListAll    = "internal.saved-queries.list-all"
 	SavedQueriesGetInfo    = "internal.saved-queries.get-info"
@@ -33,6 +36,7 @@ const (
 	GitResolveRevision     = "internal.git.resolve-revision"
 	GitTar                 = "internal.git.tar"
 	GitUploadPack          = "internal.git.upload-pack"
+	GlobalSymbolsUpdate    = "internal.global-symbols.update"
 	PhabricatorRepoCreate  = "internal.phabricator.repo.create"
 	ReposCreateIfNotExists = "internal.repos.create-if-not-exists"
 	ReposGetByName         = "internal.repos.get-by-name"
@@ -59,6 +63,8 @@ func New(base *mux.Router) *mux.Router {
 	addGraphQLRoute(base)
 	addTelemetryRoute(base)
 
+	base.Path("/search/export").Methods("GET").Name(SearchExport)
+
 	// repo contains routes that are NOT specific to a revision. In these routes, the URL may not contain a revspec after the repo (that is, no "github.com/foo/bar@myrevspec").
 	repoPath := `/repos/` + routevar.Repo
 
@@ -67,6 +73,7 @@ func New(base *mux.Router) *mux.Router {
 	repo := base.PathPrefix(repoPath + "/" + routevar.RepoPathDelim + "/").Subrouter()
 	repo.Path("/shield").Methods("GET").Name(RepoShield)
 	repo.Path("/refresh").Methods("POST").Name(RepoRefresh)
+	repo.Path("/lsif").Methods("POST").Name(RepoLSIFUpload)
 
 	return base
 }
@@ -97,6 +104,7 @@ func NewInternal(base *mux.Router) *mux.Router {
 	base.Path("/git/{RepoName:.*}/resolve-revision/{Spec}").Methods("GET").Name(GitResolveRevision)
 	base.Path("/git/{RepoName:.*}/tar/{Commit}").Methods("GET").Name(GitTar)
 	base.Path("/git/{RepoName:.*}/git-upload-pack").Methods("POST").Name(GitUploadPack)
+	base.Path("/global-symbols/update").Methods("POST").Name(GlobalSymbolsUpdate)
 	base.Path("/phabricator/repo-create").Methods("POST").Name(PhabricatorRepoCreate)
 	base.Path("/external-services/configs").Methods("POST").Name(ExternalServiceConfigs)
 	base.Path("/external-services/list").Methods("POST").Name(ExternalServicesList)
@@ -0,0 +1,179 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/errcode"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// Each user may start one export per searchExportRateInterval, with
+	// bursts of up to searchExportRateBurst.
+	searchExportRateInterval = time.Minute
+	searchExportRateBurst    = 5
+)
+
+// exportSearchResults is graphqlbackend.ExportSearchResults, which tests may
+// replace.
+var exportSearchResults = graphqlbackend.ExportSearchResults
+
+// serveSearchExport runs the search query in the "q" URL parameter and
+// streams all of its matches in the format given by the "format" URL
+// parameter (see newSearchExportWriter).
+//
+// The response has the HTTP trailer X-Sourcegraph-Export-Incomplete set to
+// "true" if not all matches could be exported.
+func serveSearchExport(w http.ResponseWriter, r *http.Request) error {
+	a, err := checkSearchExportAllowed(r.Context())
+	if err != nil {
+		return err
+	}
+	q := r.URL.Query()
+	format := q.Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if err := checkSearchExportRequest(q.Get("q"), format); err != nil {
+		return err
+	}
+	if !searchExportLimiters.allow(a.UID) {
+		return &errcode.HTTPErr{Status: http.StatusTooManyRequests, Err: errors.New("too many search exports, try again later")}
+	}
+
+	setSearchExportHeaders(w, format)
+	w.Header().Set("Trailer", "X-Sourcegraph-Export-Incomplete")
+	ew := newSearchExportWriter(w, format)
+	incomplete, err := exportSearchResults(r.Context(), q.Get("q"), ew.write)
+	if err != nil {
+		w.Header().Del("Content-Disposition")
+		w.Header().Del("Trailer")
+		return searchExportError(err)
+	}
+	if err := ew.flush(); err != nil {
+		return err
+	}
+	w.Header().Set("X-Sourcegraph-Export-Incomplete", strconv.FormatBool(incomplete))
+	return nil
+}
+
+// checkSearchExportAllowed returns the actor of ctx if it may export search
+// results.
+//
+// 🚨 SECURITY: Exports are only available to authenticated users (even if
+// anonymous users can search), so that they can be rate limited per user.
+// The results are filtered by the same repository permissions as GraphQL
+// search.
+func checkSearchExportAllowed(ctx context.Context) (*actor.Actor, error) {
+	a := actor.FromContext(ctx)
+	if !a.IsAuthenticated() {
+		return nil, &errcode.HTTPErr{Status: http.StatusUnauthorized, Err: errors.New("search exports require an authenticated user")}
+	}
+	return a, nil
+}
+
+func checkSearchExportRequest(query, format string) error {
+	if query == "" {
+		return &errcode.HTTPErr{Status: http.StatusBadRequest, Err: errors.New("search query must not be empty")}
+	}
+	if _, ok := searchExportContentTypes[format]; !ok {
+		return &errcode.HTTPErr{Status: http.StatusBadRequest, Err: fmt.Errorf("invalid export format %q (must be \"csv\" or \"jsonl\")", format)}
+	}
+	return nil
+}
+
+// searchExportError returns err with the HTTP status to respond with.
+func searchExportError(err error) error {
+	if errcode.IsBadRequest(err) {
+		return &errcode.HTTPErr{Status: http.StatusBadRequest, Err: err}
+	}
+	return err
+}
+
+var searchExportContentTypes = map[string]string{
+	"csv":   "text/csv; charset=utf-8",
+	"jsonl": "application/x-ndjson",
+}
+
+func setSearchExportHeaders(w http.ResponseWriter, format string) {
+	w.Header().Set("Content-Type", searchExportContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=search-results.%s", format))
+}
+
+// searchExportWriter writes exported matches in CSV ("csv", the default) or
+// JSON Lines ("jsonl") format.
+type searchExportWriter struct {
+	csv  *csv.Writer   // for CSV
+	json *json.Encoder // for JSON Lines
+}
+
+var searchExportCSVHeader = []string{"repo", "rev", "path", "line", "column", "preview", "match_type"}
+
+func newSearchExportWriter(w io.Writer, format string) *searchExportWriter {
+	if format == "jsonl" {
+		return &searchExportWriter{json: json.NewEncoder(w)}
+	}
+	// The header is written even if there are no matches.
+	cw := csv.NewWriter(w)
+	cw.Write(searchExportCSVHeader)
+	return &searchExportWriter{csv: cw}
+}
+
+func (w *searchExportWriter) write(m *graphqlbackend.ExportedMatch) error {
+	if w.json != nil {
+		return w.json.Encode(m)
+	}
+	var line, column string
+	if m.Line != 0 {
+		line, column = strconv.Itoa(m.Line), strconv.Itoa(m.Column)
+	}
+	return w.csv.Write([]string{m.Repo, m.Rev, m.Path, line, column, m.Preview, m.MatchType})
+}
+
+func (w *searchExportWriter) flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return nil
+}
+
+// searchExportLimiters are the per-user rate limiters of search exports.
+var searchExportLimiters = &userRateLimiters{
+	limit: rate.Every(searchExportRateInterval),
+	burst: searchExportRateBurst,
+}
+
+type userRateLimiters struct {
+	limit rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[int32]*rate.Limiter // by user ID
+}
+
+// allow reports whether the user may perform the rate-limited action now.
+func (l *userRateLimiters) allow(userID int32) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.limiters == nil {
+		l.limiters = map[int32]*rate.Limiter{}
+	}
+	limiter, ok := l.limiters[userID]
+	if !ok {
+		limiter = rate.NewLimiter(l.limit, l.burst)
+		l.limiters[userID] = limiter
+	}
+	return limiter.Allow()
+}
@@ -0,0 +1,99 @@
+package httpapi
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/graphqlbackend"
+	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/httpapi/router"
+	"github.com/sourcegraph/sourcegraph/pkg/actor"
+	"github.com/sourcegraph/sourcegraph/pkg/httptestutil"
+	"golang.org/x/time/rate"
+)
+
+// newSearchExportTest returns a test client whose requests are made by the
+// user with the given ID (or anonymously if 0).
+func newSearchExportTest(userID int32) *httptestutil.Client {
+	h := NewHandler(router.New(mux.NewRouter()))
+	return httptestutil.NewTest(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h.ServeHTTP(w, r.WithContext(actor.WithActor(r.Context(), &actor.Actor{UID: userID})))
+	}))
+}
+
+func mockExportSearchResults(t *testing.T) {
+	exportSearchResults = func(ctx context.Context, query string, emit func(*graphqlbackend.ExportedMatch) error) (bool, error) {
+		if query != "foo" {
+			t.Errorf("got query %q, want %q", query, "foo")
+		}
+		for _, m := range []*graphqlbackend.ExportedMatch{
+			{Repo: "r", Path: "a.go", Line: 3, Column: 5, Preview: `x := "foo"`, MatchType: graphqlbackend.ExportMatchTypeContent},
+			{Repo: "r", Rev: "v1", Path: "foo.go", MatchType: graphqlbackend.ExportMatchTypePath},
+		} {
+			if err := emit(m); err != nil {
+				return false, err
+			}
+		}
+		return true, nil
+	}
+}
+
+func TestSearchExport(t *testing.T) {
+	mockExportSearchResults(t)
+	defer func() { exportSearchResults = graphqlbackend.ExportSearchResults }()
+	searchExportLimiters = &userRateLimiters{limit: rate.Every(time.Hour), burst: 2}
+	c := newSearchExportTest(1)
+
+	resp, err := c.GetOK("/search/export?q=foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	wantCSV := "repo,rev,path,line,column,preview,match_type\n" +
+		`r,,a.go,3,5,"x := ""foo""",content` + "\n" +
+		"r,v1,foo.go,,,,path\n"
+	if string(body) != wantCSV {
+		t.Errorf("got CSV\n%s\nwant\n%s", body, wantCSV)
+	}
+	if got := resp.Trailer.Get("X-Sourcegraph-Export-Incomplete"); got != "true" {
+		t.Errorf("got incomplete trailer %q, want %q", got, "true")
+	}
+
+	resp, err = c.GetOK("/search/export?q=foo&format=jsonl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = ioutil.ReadAll(resp.Body)
+	wantJSONL := `{"repo":"r","rev":"","path":"a.go","line":3,"column":5,"preview":"x := \"foo\"","matchType":"content"}` + "\n" +
+		`{"repo":"r","rev":"v1","path":"foo.go","line":0,"column":0,"preview":"","matchType":"path"}` + "\n"
+	if string(body) != wantJSONL {
+		t.Errorf("got JSON Lines\n%s\nwant\n%s", body, wantJSONL)
+	}
+
+	// The burst of 2 exports is used up.
+	resp, _ = c.Get("/search/export?q=foo")
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("got HTTP %d, want %d", resp.StatusCode, http.StatusTooManyRequests)
+	}
+}
+
+func TestSearchExport_badRequest(t *testing.T) {
+	searchExportLimiters = &userRateLimiters{limit: rate.Every(time.Hour), burst: 10}
+	for url, want := range map[string]int{
+		"/search/export?q=foo&format=xml": http.StatusBadRequest,
+		"/search/export?q=":               http.StatusBadRequest,
+	} {
+		resp, _ := newSearchExportTest(1).Get(url)
+		if resp.StatusCode != want {
+			t.Errorf("%s: got HTTP %d, want %d", url, resp.StatusCode, want)
+		}
+	}
+
+	resp, _ := newSearchExportTest(0).Get("/search/export?q=foo")
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("anonymous: got HTTP %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
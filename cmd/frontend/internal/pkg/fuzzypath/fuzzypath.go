@@ -0,0 +1,273 @@
+// Package fuzzypath implements fuzzy file path matching, like "Go to file" in editors.
+//
+// A query matches a path if the query's characters (ignoring case and spaces) occur in the path in
+// the same order, not necessarily next to each other. Matches are scored by the quality of the
+// subsequence match: characters at the start of path components or words, consecutive characters
+// and characters in the file name score higher, and gaps and long paths score lower.
+package fuzzypath
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Scoring weights. They are arbitrary, but chosen so that (e.g.) "srchres" ranks
+// "search_results.go" above "search/foo/resources.go".
+const (
+	scoreChar           = 1.0  // for each matched character
+	bonusSegmentStart   = 1.0  // character at the start of a path component
+	bonusWordStart      = 1.0  // character after '_', '-', '.' or ' ', or at a camelCase boundary
+	bonusConsecutive    = 1.5  // character immediately after the previous matched character
+	bonusFileName       = 1.0  // character in the last path component
+	penaltyGap          = 0.2  // for each unmatched character between two matched characters
+	maxPenaltyGapPerRun = 1.0  // the maximum gap penalty between two matched characters
+	penaltyLength       = 0.01 // for each character of the path
+)
+
+// A Match is a path that matches a query.
+type Match struct {
+	Path  string
+	Score float64 // higher is better
+
+	// Ranges are the byte ranges of the path that match the query's characters, as [offset,
+	// length] tuples.
+	Ranges [][2]int
+}
+
+// An Index is a list of paths that can be searched with fuzzy queries. It is immutable and safe
+// for concurrent use.
+type Index struct {
+	paths []string
+	lower []string // lowercase paths, to check for subsequence matches quickly
+}
+
+// NewIndex returns an index of the paths.
+func NewIndex(paths []string) *Index {
+	ix := &Index{paths: make([]string, len(paths)), lower: make([]string, len(paths))}
+	copy(ix.paths, paths)
+	sort.Strings(ix.paths)
+	for i, p := range ix.paths {
+		ix.lower[i] = strings.ToLower(p)
+	}
+	return ix
+}
+
+// Len returns the number of paths in the index.
+func (ix *Index) Len() int { return len(ix.paths) }
+
+// Size returns the approximate number of bytes of memory that the index uses.
+func (ix *Index) Size() int {
+	size := 0
+	for i := range ix.paths {
+		// Each path is stored twice (as is and in lowercase), with a string
+		// header for each copy.
+		size += len(ix.paths[i]) + len(ix.lower[i]) + 2*16
+	}
+	return size
+}
+
+// Search returns the best limit matches of the query in the index, best first (as ordered by
+// Less). An empty query matches no paths.
+func (ix *Index) Search(query string, limit int) []Match {
+	q := normalizeQuery(query)
+	if len(q) == 0 || limit <= 0 {
+		return nil
+	}
+
+	var matches []Match
+	for i, lower := range ix.lower {
+		if !isSubsequence(q, lower) {
+			continue
+		}
+		if m, ok := match(q, ix.paths[i]); ok {
+			matches = append(matches, m)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return Less(matches[i], matches[j]) })
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return matches
+}
+
+// Less reports whether a is a better match than b: it has a higher score, or an equal score and a
+// shorter path, or an equal score and path length and a lexicographically smaller path.
+func Less(a, b Match) bool {
+	if a.Score != b.Score {
+		return a.Score > b.Score
+	}
+	if len(a.Path) != len(b.Path) {
+		return len(a.Path) < len(b.Path)
+	}
+	return a.Path < b.Path
+}
+
+// Score returns the match of the query in the path, and whether the path matches.
+func Score(query, path string) (Match, bool) {
+	q := normalizeQuery(query)
+	if len(q) == 0 {
+		return Match{}, false
+	}
+	return match(q, path)
+}
+
+// normalizeQuery returns the lowercase runes of the query, without spaces.
+func normalizeQuery(query string) []rune {
+	var q []rune
+	for _, r := range query {
+		if !unicode.IsSpace(r) {
+			q = append(q, unicode.ToLower(r))
+		}
+	}
+	return q
+}
+
+// isSubsequence reports whether q occurs in s in order.
+func isSubsequence(q []rune, s string) bool {
+	i := 0
+	for _, r := range s {
+		if i == len(q) {
+			break
+		}
+		if r == q[i] {
+			i++
+		}
+	}
+	return i == len(q)
+}
+
+// match scores the two candidate alignments of q in path (the first and the last occurrences,
+// each shrunk to its shortest window) and returns the better one. The last occurrence is usually
+// in the file name.
+func match(q []rune, path string) (Match, bool) {
+	p := []rune(path)
+	lower := make([]rune, len(p))
+	for i, r := range p {
+		lower[i] = unicode.ToLower(r)
+	}
+
+	forward, ok := shortestFirstAlignment(q, lower)
+	if !ok {
+		return Match{}, false
+	}
+	best, bestScore := forward, score(p, forward)
+	if backward, ok := lastAlignment(q, lower); ok {
+		if s := score(p, backward); s > bestScore {
+			best, bestScore = backward, s
+		}
+	}
+	return Match{Path: path, Score: bestScore, Ranges: ranges(p, best)}, true
+}
+
+// shortestFirstAlignment returns the positions in s of the first occurrence of q as a
+// subsequence, moved as close as possible to its end.
+func shortestFirstAlignment(q, s []rune) ([]int, bool) {
+	end := -1
+	for i, j := 0, 0; i < len(s); i++ {
+		if s[i] == q[j] {
+			j++
+			if j == len(q) {
+				end = i
+				break
+			}
+		}
+	}
+	if end == -1 {
+		return nil, false
+	}
+	// Match backward from the end to find the shortest window.
+	pos := make([]int, len(q))
+	for i, j := end, len(q)-1; j >= 0; i-- {
+		if s[i] == q[j] {
+			pos[j] = i
+			j--
+		}
+	}
+	return pos, true
+}
+
+// lastAlignment returns the positions in s of the last occurrence of q as a subsequence, moved
+// as close as possible to its start.
+func lastAlignment(q, s []rune) ([]int, bool) {
+	start := -1
+	for i, j := len(s)-1, len(q)-1; i >= 0; i-- {
+		if s[i] == q[j] {
+			j--
+			if j < 0 {
+				start = i
+				break
+			}
+		}
+	}
+	if start == -1 {
+		return nil, false
+	}
+	// Match forward from the start to find the shortest window.
+	pos := make([]int, len(q))
+	for i, j := start, 0; j < len(q); i++ {
+		if s[i] == q[j] {
+			pos[j] = i
+			j++
+		}
+	}
+	return pos, true
+}
+
+// score returns the score of the alignment pos of a query in the path p.
+func score(p []rune, pos []int) float64 {
+	fileNameStart := 0
+	for i, r := range p {
+		if r == '/' {
+			fileNameStart = i + 1
+		}
+	}
+
+	s := -penaltyLength * float64(len(p))
+	for k, i := range pos {
+		s += scoreChar
+		switch {
+		case i == 0 || p[i-1] == '/':
+			s += bonusSegmentStart
+		case p[i-1] == '_' || p[i-1] == '-' || p[i-1] == '.' || p[i-1] == ' ' || unicode.IsLower(p[i-1]) && unicode.IsUpper(p[i]):
+			s += bonusWordStart
+		}
+		if i >= fileNameStart {
+			s += bonusFileName
+		}
+		if k > 0 {
+			if gap := i - pos[k-1] - 1; gap == 0 {
+				s += bonusConsecutive
+			} else if penalty := penaltyGap * float64(gap); penalty < maxPenaltyGapPerRun {
+				s -= penalty
+			} else {
+				s -= maxPenaltyGapPerRun
+			}
+		}
+	}
+	return s
+}
+
+// ranges returns the byte ranges of the path p covered by the rune positions pos, merging
+// adjacent positions.
+func ranges(p []rune, pos []int) [][2]int {
+	var (
+		rs     [][2]int
+		offset int // byte offset of p[i]
+		k      int // index in pos
+	)
+	for i, r := range p {
+		size := utf8.RuneLen(r)
+		if k < len(pos) && pos[k] == i {
+			if n := len(rs); n > 0 && rs[n-1][0]+rs[n-1][1] == offset {
+				rs[n-1][1] += size
+			} else {
+				rs = append(rs, [2]int{offset, size})
+			}
+			k++
+		}
+		offset += size
+	}
+	return rs
+}
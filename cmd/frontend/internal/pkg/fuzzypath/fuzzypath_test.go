@@ -0,0 +1,70 @@
+package fuzzypath
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIndex_Search(t *testing.T) {
+	ix := NewIndex([]string{
+		"README.md",
+		"cmd/frontend/graphqlbackend/search_results.go",
+		"cmd/frontend/graphqlbackend/search_results_test.go",
+		"cmd/frontend/graphqlbackend/search.go",
+		"search/foo/resources.go",
+		"web/src/search/SearchResults.tsx",
+		"vendor/x/y/s/r/c/h/r/e/s.go",
+	})
+	tests := map[string][]string{
+		"srchres":         {"web/src/search/SearchResults.tsx", "cmd/frontend/graphqlbackend/search_results.go", "cmd/frontend/graphqlbackend/search_results_test.go", "search/foo/resources.go", "vendor/x/y/s/r/c/h/r/e/s.go"},
+		"SearchResults":   {"web/src/search/SearchResults.tsx", "cmd/frontend/graphqlbackend/search_results.go", "cmd/frontend/graphqlbackend/search_results_test.go"},
+		"search results":  {"web/src/search/SearchResults.tsx", "cmd/frontend/graphqlbackend/search_results.go", "cmd/frontend/graphqlbackend/search_results_test.go"},
+		"readme":          {"README.md"},
+		"gqlb/search.go":  {"cmd/frontend/graphqlbackend/search.go", "cmd/frontend/graphqlbackend/search_results.go", "cmd/frontend/graphqlbackend/search_results_test.go"},
+		"nomatch":         nil,
+		"":                nil,
+		"   ":             nil,
+		"search_results_": {"cmd/frontend/graphqlbackend/search_results_test.go"},
+	}
+	for query, want := range tests {
+		var got []string
+		for _, m := range ix.Search(query, 5) {
+			got = append(got, m.Path)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: got %q, want %q", query, got, want)
+		}
+	}
+
+	if got := ix.Search("srchres", 2); len(got) != 2 {
+		t.Errorf("got %d matches, want the limit of 2", len(got))
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		query, path string
+		wantRanges  [][2]int
+	}{
+		{"main", "main.go", [][2]int{{0, 4}}},
+		{"mgo", "main.go", [][2]int{{0, 1}, {5, 2}}},
+		// The last occurrence, in the file name, is preferred.
+		{"foo", "foo/bar/foo.go", [][2]int{{8, 3}}},
+		// Ranges are byte ranges.
+		{"éb", "aé/b", [][2]int{{1, 2}, {4, 1}}},
+	}
+	for _, test := range tests {
+		m, ok := Score(test.query, test.path)
+		if !ok {
+			t.Errorf("%q in %q: no match", test.query, test.path)
+			continue
+		}
+		if !reflect.DeepEqual(m.Ranges, test.wantRanges) {
+			t.Errorf("%q in %q: got ranges %v, want %v", test.query, test.path, m.Ranges, test.wantRanges)
+		}
+	}
+
+	if _, ok := Score("xyz", "main.go"); ok {
+		t.Error("got a match for a query that is not a subsequence of the path")
+	}
+}
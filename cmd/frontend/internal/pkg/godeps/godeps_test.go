@@ -0,0 +1,104 @@
+package godeps
+
+import (
+	"archive/tar"
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestParse(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"go.mod": `module example.com/a // the main module
+
+require (
+	github.com/pkg/errors v0.8.0
+	"golang.org/x/net" v0.0.0-20180724234803-3673e40ba225 // indirect
+)
+
+require github.com/gorilla/mux v1.6.2
+`,
+		"main.go": `package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"example.com/a/internal/b"
+	"github.com/gorilla/mux"
+	_ "github.com/lib/pq"
+)
+`,
+		"internal/b/b.go":        "package b\n\nimport \"golang.org/x/net/context\"\n",
+		"internal/b/b_test.go":   "package b\n\nimport \"github.com/google/go-cmp/cmp\"\n",
+		"tools/go.mod":           "module example.com/a/tools\n",
+		"tools/main.go":          "package main\n\nimport \"example.com/a/tools/x\"\nimport \"example.com/other\"\n",
+		"invalid.go":             "package main\n\nimport \"example.com/invalid\n",
+		"vendor/c/c.go":          "package c\n\nimport \"example.com/vendored\"\n",
+		"internal/testdata/d.go": "package d\n\nimport \"example.com/testdata\"\n",
+		"_examples/e.go":         "package e\n\nimport \"example.com/examples\"\n",
+		"README.md":              "import \"example.com/readme\"\n",
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Deps{
+		ModulePaths: []string{"example.com/a", "example.com/a/tools"},
+		ImportPaths: []string{
+			"example.com/other",
+			"github.com/google/go-cmp/cmp",
+			"github.com/gorilla/mux",
+			"github.com/lib/pq",
+			"github.com/pkg/errors",
+			"golang.org/x/net",
+			"golang.org/x/net/context",
+		},
+	}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("got %+v, want %+v", deps, want)
+	}
+}
+
+func TestResolver(t *testing.T) {
+	r := NewResolver()
+	r.AddRepo(1, "github.com/gorilla/mux")
+	r.AddRepo(2, "github.com/golang/net")
+	r.AddRepo(3, "git.example.com/Team/Proj")
+	r.AddRepo(4, "github.com/example/a")
+	r.AddModules(4, []string{"example.com/a"})
+	r.AddRepo(5, "github.com/example/a-tools")
+	r.AddModules(5, []string{"example.com/a/tools"})
+
+	for importPath, want := range map[string]api.RepoID{
+		"github.com/gorilla/mux":          1,
+		"github.com/Gorilla/mux/sub":      1,
+		"golang.org/x/net/context":        2,
+		"git.example.com/team/proj/pkg/x": 3,
+		"example.com/a/b":                 4,
+		"example.com/a/tools/x":           5,
+		"example.com/ab":                  0,
+		"github.com/other/repo":           0,
+		"net/http":                        0,
+		"./relative":                      0,
+	} {
+		if got := r.Resolve(importPath); got != want {
+			t.Errorf("%s: got %d, want %d", importPath, got, want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+// Package godeps computes the Go dependency graph of repositories: which other
+// repositories the Go code of a repository imports.
+package godeps
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxFileSize is the maximum size of the files that are parsed. Larger files
+// are almost always generated.
+const maxFileSize = 1 << 20
+
+// Deps are the Go dependencies of a repository.
+type Deps struct {
+	// ModulePaths are the module paths of the go.mod files in the repository,
+	// sorted.
+	ModulePaths []string
+
+	// ImportPaths are the import paths of the packages imported by the Go
+	// files in the repository and the module paths required by its go.mod
+	// files, sorted. The standard library and the packages of the
+	// repository's own modules are omitted.
+	ImportPaths []string
+}
+
+// Parse returns the Go dependencies of the files in a tar archive of a
+// repository. The files in vendor and testdata directories and in
+// directories whose names begin with "." or "_" are ignored, as the go tool
+// does.
+func Parse(archive io.Reader) (*Deps, error) {
+	modulePaths := map[string]struct{}{}
+	importPaths := map[string]struct{}{}
+	fset := token.NewFileSet()
+
+	tr := tar.NewReader(archive)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > maxFileSize || ignoredPath(hdr.Name) {
+			continue
+		}
+		name := path.Base(hdr.Name)
+		if name != "go.mod" && !strings.HasSuffix(name, ".go") {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		if name == "go.mod" {
+			module, requires := parseGoMod(data)
+			if module != "" {
+				modulePaths[module] = struct{}{}
+			}
+			for _, r := range requires {
+				importPaths[r] = struct{}{}
+			}
+			continue
+		}
+
+		// Files that don't parse are skipped, as the go tool would fail to
+		// build them too.
+		f, err := parser.ParseFile(fset, hdr.Name, data, parser.ImportsOnly)
+		if err != nil {
+			continue
+		}
+		for _, spec := range f.Imports {
+			if importPath, err := strconv.Unquote(spec.Path.Value); err == nil {
+				importPaths[importPath] = struct{}{}
+			}
+		}
+	}
+
+	deps := &Deps{ModulePaths: sortedKeys(modulePaths)}
+	for importPath := range importPaths {
+		if !isStdlibOrLocal(importPath) && !inModules(importPath, modulePaths) {
+			deps.ImportPaths = append(deps.ImportPaths, importPath)
+		}
+	}
+	sort.Strings(deps.ImportPaths)
+	return deps, nil
+}
+
+// ignoredPath reports whether the file at name is in a directory that the go
+// tool ignores or whose imports are not the repository's own.
+func ignoredPath(name string) bool {
+	dirs := strings.Split(path.Dir(name), "/")
+	for _, dir := range dirs {
+		if dir == "vendor" || dir == "testdata" || (dir != "." && (strings.HasPrefix(dir, ".") || strings.HasPrefix(dir, "_"))) {
+			return true
+		}
+	}
+	return false
+}
+
+// isStdlibOrLocal reports whether the import path is of a package in the
+// standard library (whose first element has no dot), or a relative import
+// path. The go tool uses the same rule for the standard library.
+func isStdlibOrLocal(importPath string) bool {
+	first := importPath
+	if i := strings.Index(importPath, "/"); i >= 0 {
+		first = importPath[:i]
+	}
+	return !strings.Contains(first, ".") || strings.HasPrefix(importPath, ".")
+}
+
+// inModules reports whether the package at importPath is in one of the
+// modules.
+func inModules(importPath string, modulePaths map[string]struct{}) bool {
+	for p := importPath; p != "."; p = path.Dir(p) {
+		if _, ok := modulePaths[p]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseGoMod returns the module path and the required module paths of a
+// go.mod file. Invalid lines are ignored.
+func parseGoMod(data []byte) (module string, requires []string) {
+	inRequireBlock := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		if inRequireBlock {
+			if fields[0] == ")" {
+				inRequireBlock = false
+			} else if p := unquoteModulePath(fields[0]); p != "" {
+				requires = append(requires, p)
+			}
+			continue
+		}
+		switch {
+		case fields[0] == "module" && len(fields) >= 2:
+			module = unquoteModulePath(fields[1])
+		case fields[0] == "require" && len(fields) >= 2:
+			if fields[1] == "(" {
+				inRequireBlock = true
+			} else if p := unquoteModulePath(fields[1]); p != "" {
+				requires = append(requires, p)
+			}
+		}
+	}
+	return module, requires
+}
+
+// unquoteModulePath returns the module path in a go.mod file, which may be
+// quoted, or "" if it is invalid.
+func unquoteModulePath(s string) string {
+	if strings.HasPrefix(s, `"`) || strings.HasPrefix(s, "`") {
+		var err error
+		if s, err = strconv.Unquote(s); err != nil {
+			return ""
+		}
+	}
+	return s
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,73 @@
+package godeps
+
+import (
+	"path"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gosrc"
+)
+
+// A Resolver resolves import paths to the repositories that contain their
+// packages. It uses only what is known about the repositories (their names
+// and module paths), so it works without network access and for private
+// code.
+type Resolver struct {
+	modules map[string]api.RepoID // module path -> repository
+	repos   map[string]api.RepoID // lowercase repository name -> repository
+}
+
+// NewResolver returns a resolver that knows no repositories.
+func NewResolver() *Resolver {
+	return &Resolver{
+		modules: make(map[string]api.RepoID),
+		repos:   make(map[string]api.RepoID),
+	}
+}
+
+// AddRepo adds a repository with its name.
+func (r *Resolver) AddRepo(id api.RepoID, name api.RepoName) {
+	r.repos[strings.ToLower(string(name))] = id
+}
+
+// AddModules adds the module paths of the go.mod files of a repository.
+func (r *Resolver) AddModules(id api.RepoID, modulePaths []string) {
+	for _, p := range modulePaths {
+		r.modules[p] = id
+	}
+}
+
+// Resolve returns the repository that contains the package at the import
+// path, or 0 if it is unknown. The repository with the longest module path
+// that is a prefix of the import path is preferred. Otherwise it is the
+// repository of the import path's project root according to the well-known
+// code host rules of package gosrc (e.g. "golang.org/x/net" is in
+// "github.com/golang/net"), or else the repository with the longest name that
+// is a prefix of the import path.
+func (r *Resolver) Resolve(importPath string) api.RepoID {
+	if isStdlibOrLocal(importPath) {
+		return 0
+	}
+	if id := longestPrefixMatch(importPath, r.modules, false); id != 0 {
+		return id
+	}
+	if dir, err := gosrc.ResolveStaticImportPath(importPath); err == nil && dir.ProjectRoot != "" {
+		name := strings.TrimSuffix(strings.TrimPrefix(dir.CloneURL, "https://"), ".git")
+		if id, ok := r.repos[strings.ToLower(name)]; ok {
+			return id
+		}
+	}
+	return longestPrefixMatch(importPath, r.repos, true)
+}
+
+func longestPrefixMatch(importPath string, m map[string]api.RepoID, lower bool) api.RepoID {
+	if lower {
+		importPath = strings.ToLower(importPath)
+	}
+	for p := importPath; p != "." && p != "/"; p = path.Dir(p) {
+		if id, ok := m[p]; ok {
+			return id
+		}
+	}
+	return 0
+}
@@ -0,0 +1,314 @@
+package lsif
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// An id is the ID of a vertex or edge. LSIF allows both numbers and strings.
+type id string
+
+func (i *id) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*i = id(s)
+		return nil
+	}
+	*i = id(data)
+	return nil
+}
+
+// An element is a vertex or an edge of a dump. Only the properties that are
+// used are decoded.
+type element struct {
+	ID    id     `json:"id"`
+	Type  string `json:"type"`
+	Label string `json:"label"`
+
+	// Vertex properties.
+	ProjectRoot string          `json:"projectRoot"` // metaData
+	URI         string          `json:"uri"`         // document
+	Start       Position        `json:"start"`       // range
+	End         Position        `json:"end"`         // range
+	Result      json.RawMessage `json:"result"`      // hoverResult
+
+	// Edge properties.
+	OutV id   `json:"outV"`
+	InV  id   `json:"inV"`
+	InVs []id `json:"inVs"`
+}
+
+// maxResultSetChain is the maximum number of result sets followed from a
+// range, to guard against cycles.
+const maxResultSetChain = 100
+
+type converter struct {
+	projectRoot string
+	documents   map[id]string // document -> URI
+	ranges      map[id]Range
+	rangeDocs   map[id]id   // range -> document containing it
+	docRanges   map[id][]id // document -> ranges it contains
+
+	next       map[id]id // range or result set -> result set
+	definition map[id]id // range or result set -> definition result
+	reference  map[id]id // range or result set -> reference result
+	hover      map[id]id // range or result set -> hover result
+
+	items  map[id][]id // definition or reference result -> ranges or reference results
+	hovers map[id]string
+
+	paths map[id]string // document -> path in the repository
+}
+
+// Convert reads an LSIF dump (in JSON lines format) and returns the code
+// intelligence data of each of its documents, keyed by their paths in the
+// repository, and the locations of their results. The project root of the
+// dump (the directory its document URIs are relative to) is the directory
+// root of the repository.
+//
+// Documents that are not in the project root are omitted.
+func Convert(r io.Reader, root string) (*Dump, error) {
+	c := &converter{
+		documents:  make(map[id]string),
+		ranges:     make(map[id]Range),
+		rangeDocs:  make(map[id]id),
+		docRanges:  make(map[id][]id),
+		next:       make(map[id]id),
+		definition: make(map[id]id),
+		reference:  make(map[id]id),
+		hover:      make(map[id]id),
+		items:      make(map[id][]id),
+		hovers:     make(map[id]string),
+	}
+	dec := json.NewDecoder(r)
+	for {
+		var e element
+		if err := dec.Decode(&e); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid LSIF dump: %s", err)
+		}
+		if err := c.add(&e); err != nil {
+			return nil, fmt.Errorf("invalid LSIF dump: element %s: %s", e.ID, err)
+		}
+	}
+	if c.projectRoot == "" {
+		return nil, fmt.Errorf("invalid LSIF dump: no metaData vertex with a projectRoot")
+	}
+	if err := c.resolvePaths(root); err != nil {
+		return nil, err
+	}
+	return c.dump(), nil
+}
+
+func (c *converter) add(e *element) error {
+	switch e.Type + ":" + e.Label {
+	case "vertex:metaData":
+		c.projectRoot = e.ProjectRoot
+	case "vertex:document":
+		c.documents[e.ID] = e.URI
+	case "vertex:range":
+		c.ranges[e.ID] = Range{Start: e.Start, End: e.End}
+	case "vertex:hoverResult":
+		text, err := hoverMarkdown(e.Result)
+		if err != nil {
+			return err
+		}
+		c.hovers[e.ID] = text
+	case "edge:contains":
+		if _, ok := c.documents[e.OutV]; ok {
+			c.docRanges[e.OutV] = append(c.docRanges[e.OutV], e.InVs...)
+			for _, r := range e.InVs {
+				c.rangeDocs[r] = e.OutV
+			}
+		}
+	case "edge:next":
+		c.next[e.OutV] = e.InV
+	case "edge:textDocument/definition":
+		c.definition[e.OutV] = e.InV
+	case "edge:textDocument/references":
+		c.reference[e.OutV] = e.InV
+	case "edge:textDocument/hover":
+		c.hover[e.OutV] = e.InV
+	case "edge:item":
+		c.items[e.OutV] = append(c.items[e.OutV], e.InVs...)
+	}
+	return nil
+}
+
+// resolvePaths computes the paths in the repository of the documents from
+// their URIs.
+func (c *converter) resolvePaths(root string) error {
+	projectRoot, err := url.Parse(c.projectRoot)
+	if err != nil {
+		return fmt.Errorf("invalid LSIF dump: invalid projectRoot: %s", err)
+	}
+	prefix := strings.TrimSuffix(projectRoot.Path, "/") + "/"
+	c.paths = make(map[id]string, len(c.documents))
+	for doc, uri := range c.documents {
+		u, err := url.Parse(uri)
+		if err != nil {
+			return fmt.Errorf("invalid LSIF dump: invalid document URI %q: %s", uri, err)
+		}
+		if u.Scheme != projectRoot.Scheme || !strings.HasPrefix(u.Path, prefix) {
+			continue
+		}
+		c.paths[doc] = path.Join(root, strings.TrimPrefix(u.Path, prefix))
+	}
+	return nil
+}
+
+func (c *converter) dump() *Dump {
+	// The locations of a result are the same in all documents, so they are
+	// computed and stored once.
+	results := make(map[string][]Location)
+	locationsOf := func(result id) []Location {
+		if locations, ok := results[string(result)]; ok {
+			return locations
+		}
+		locations := c.locations(result, map[id]bool{})
+		results[string(result)] = locations
+		return locations
+	}
+
+	documents := make(map[string]*Document, len(c.paths))
+	for doc, docPath := range c.paths {
+		d := &Document{Ranges: []DocumentRange{}}
+		for _, r := range c.docRanges[doc] {
+			rng, ok := c.ranges[r]
+			if !ok {
+				continue
+			}
+			dr := DocumentRange{Range: rng}
+			if result := c.lookup(r, c.definition); result != "" {
+				if locations := locationsOf(result); len(locations) > 0 {
+					dr.DefinitionResult = string(result)
+				}
+			}
+			if result := c.lookup(r, c.reference); result != "" {
+				if locations := locationsOf(result); len(locations) > 0 {
+					dr.ReferenceResult = string(result)
+				}
+			}
+			if result := c.lookup(r, c.hover); result != "" {
+				if text := c.hovers[result]; text != "" {
+					dr.HoverResult = string(result)
+					if d.Hovers == nil {
+						d.Hovers = make(map[string]string)
+					}
+					d.Hovers[dr.HoverResult] = text
+				}
+			}
+			if dr.DefinitionResult != "" || dr.ReferenceResult != "" || dr.HoverResult != "" {
+				d.Ranges = append(d.Ranges, dr)
+			}
+		}
+		sort.Slice(d.Ranges, func(i, j int) bool {
+			a, b := d.Ranges[i].Range, d.Ranges[j].Range
+			if a.Start != b.Start {
+				return lessPosition(a.Start, b.Start)
+			}
+			return lessPosition(a.End, b.End)
+		})
+		documents[docPath] = d
+	}
+
+	// Results without locations are not referred to by any range.
+	for result, locations := range results {
+		if len(locations) == 0 {
+			delete(results, result)
+		}
+	}
+	return &Dump{Documents: documents, Results: results}
+}
+
+// lookup returns the result of the range in results, following the chain of
+// result sets of the range. It returns "" if there is none.
+func (c *converter) lookup(r id, results map[id]id) id {
+	v := r
+	for i := 0; i < maxResultSetChain; i++ {
+		if result, ok := results[v]; ok {
+			return result
+		}
+		var ok bool
+		if v, ok = c.next[v]; !ok {
+			break
+		}
+	}
+	return ""
+}
+
+// locations returns the locations of the ranges of a definition or reference
+// result, sorted by path and position. A reference result may include the
+// ranges of other reference results.
+func (c *converter) locations(result id, visited map[id]bool) []Location {
+	visited[result] = true
+	var locations []Location
+	for _, v := range c.items[result] {
+		if rng, ok := c.ranges[v]; ok {
+			if docPath, ok := c.paths[c.rangeDocs[v]]; ok {
+				locations = append(locations, Location{Path: docPath, Range: rng})
+			}
+		} else if !visited[v] {
+			locations = append(locations, c.locations(v, visited)...)
+		}
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		a, b := locations[i], locations[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return lessPosition(a.Range.Start, b.Range.Start)
+	})
+	return locations
+}
+
+// hoverMarkdown returns the contents of a hover result as Markdown. The
+// contents are a MarkupContent, a MarkedString or a list of MarkedStrings
+// (see the Language Server Protocol specification).
+func hoverMarkdown(result json.RawMessage) (string, error) {
+	var hover struct {
+		Contents json.RawMessage `json:"contents"`
+	}
+	if err := json.Unmarshal(result, &hover); err != nil {
+		return "", err
+	}
+	if len(hover.Contents) == 0 {
+		return "", nil
+	}
+	var contents []json.RawMessage
+	if err := json.Unmarshal(hover.Contents, &contents); err != nil {
+		contents = []json.RawMessage{hover.Contents}
+	}
+
+	var parts []string
+	for _, content := range contents {
+		var s string
+		if err := json.Unmarshal(content, &s); err == nil {
+			parts = append(parts, s)
+			continue
+		}
+		var v struct {
+			Kind     string `json:"kind"`
+			Language string `json:"language"`
+			Value    string `json:"value"`
+		}
+		if err := json.Unmarshal(content, &v); err != nil {
+			return "", err
+		}
+		if v.Language != "" {
+			parts = append(parts, "```"+v.Language+"\n"+v.Value+"\n```")
+		} else {
+			parts = append(parts, v.Value)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n\n---\n\n")), nil
+}
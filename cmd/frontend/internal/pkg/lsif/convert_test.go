@@ -0,0 +1,88 @@
+package lsif
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testDump is the dump of a Go project with two files, where b.go uses the
+// function F defined in a.go.
+const testDump = `
+{"id":1,"type":"vertex","label":"metaData","version":"0.4.0","projectRoot":"file:///src/proj","positionEncoding":"utf-16"}
+{"id":2,"type":"vertex","label":"document","uri":"file:///src/proj/a.go","languageId":"go"}
+{"id":3,"type":"vertex","label":"document","uri":"file:///src/proj/sub/b.go","languageId":"go"}
+{"id":4,"type":"vertex","label":"document","uri":"file:///usr/lib/go/src/fmt/print.go","languageId":"go"}
+{"id":"r1","type":"vertex","label":"range","start":{"line":2,"character":5},"end":{"line":2,"character":6}}
+{"id":"r2","type":"vertex","label":"range","start":{"line":4,"character":1},"end":{"line":4,"character":2}}
+{"id":"r3","type":"vertex","label":"range","start":{"line":3,"character":0},"end":{"line":3,"character":10}}
+{"id":7,"type":"edge","label":"contains","outV":2,"inVs":["r1","r3"]}
+{"id":8,"type":"edge","label":"contains","outV":3,"inVs":["r2"]}
+{"id":9,"type":"vertex","label":"resultSet"}
+{"id":10,"type":"edge","label":"next","outV":"r1","inV":9}
+{"id":11,"type":"edge","label":"next","outV":"r2","inV":9}
+{"id":12,"type":"vertex","label":"definitionResult"}
+{"id":13,"type":"edge","label":"textDocument/definition","outV":9,"inV":12}
+{"id":14,"type":"edge","label":"item","outV":12,"inVs":["r1"],"document":2}
+{"id":15,"type":"vertex","label":"referenceResult"}
+{"id":16,"type":"edge","label":"textDocument/references","outV":9,"inV":15}
+{"id":17,"type":"edge","label":"item","outV":15,"inVs":["r1"],"document":2,"property":"definitions"}
+{"id":18,"type":"edge","label":"item","outV":15,"inVs":["r2"],"document":3,"property":"references"}
+{"id":19,"type":"vertex","label":"hoverResult","result":{"contents":[{"language":"go","value":"func F()"},"F does things."]}}
+{"id":20,"type":"edge","label":"textDocument/hover","outV":9,"inV":19}
+`
+
+func TestConvert(t *testing.T) {
+	dump, err := Convert(strings.NewReader(testDump), "dir")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defF := Location{Path: "dir/a.go", Range: Range{Start: Position{2, 5}, End: Position{2, 6}}}
+	useF := Location{Path: "dir/sub/b.go", Range: Range{Start: Position{4, 1}, End: Position{4, 2}}}
+	want := &Dump{
+		Documents: map[string]*Document{
+			"dir/a.go": {
+				Ranges: []DocumentRange{{Range: defF.Range, DefinitionResult: "12", ReferenceResult: "15", HoverResult: "19"}},
+				Hovers: map[string]string{"19": "```go\nfunc F()\n```\n\n---\n\nF does things."},
+			},
+			"dir/sub/b.go": {
+				Ranges: []DocumentRange{{Range: useF.Range, DefinitionResult: "12", ReferenceResult: "15", HoverResult: "19"}},
+				Hovers: map[string]string{"19": "```go\nfunc F()\n```\n\n---\n\nF does things."},
+			},
+		},
+		// The results shared by the documents are stored once.
+		Results: map[string][]Location{
+			"12": {defF},
+			"15": {defF, useF},
+		},
+	}
+	if !reflect.DeepEqual(dump, want) {
+		t.Errorf("got %+v, want %+v", dump, want)
+	}
+
+	d := dump.Documents["dir/sub/b.go"]
+	for _, p := range []Position{{4, 1}, {4, 2}} {
+		r := d.RangeAt(p)
+		if r == nil {
+			t.Fatalf("no range at %v", p)
+		}
+		if r.DefinitionResult != "12" {
+			t.Errorf("got definition result %q, want 12", r.DefinitionResult)
+		}
+	}
+	if r := d.RangeAt(Position{4, 3}); r != nil {
+		t.Errorf("got range %v at a position without a range", r)
+	}
+}
+
+func TestConvert_invalid(t *testing.T) {
+	for name, dump := range map[string]string{
+		"not JSON":        "{",
+		"no project root": `{"id":2,"type":"vertex","label":"document","uri":"file:///a.go"}`,
+	} {
+		if _, err := Convert(strings.NewReader(dump), ""); err == nil {
+			t.Errorf("%s: got no error", name)
+		}
+	}
+}
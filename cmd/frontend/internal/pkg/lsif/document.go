@@ -0,0 +1,90 @@
+// Package lsif converts LSIF dumps (see
+// https://github.com/Microsoft/language-server-protocol/blob/master/indexFormat/specification.md)
+// into the code intelligence data of each document, which can be stored and
+// queried one document at a time. The locations of the definition and
+// reference results, which are shared by many documents, are stored once per
+// dump.
+package lsif
+
+// A Position is a 0-based line and character offset in a document.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// A Range is a range in a document. The end position is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// contains reports whether p is in r. A position at the end of the range is
+// in it too, so that the range of a token contains the position just after
+// the token.
+func (r Range) contains(p Position) bool {
+	return !lessPosition(p, r.Start) && !lessPosition(r.End, p)
+}
+
+func lessPosition(a, b Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
+
+// A Location is a range in a document of the dump.
+type Location struct {
+	Path  string `json:"path"`
+	Range Range  `json:"range"`
+}
+
+// A Dump is the code intelligence data of an LSIF dump.
+type Dump struct {
+	// Documents are the documents of the dump, keyed by their paths in the
+	// repository.
+	Documents map[string]*Document
+
+	// Results are the locations of the definition and reference results of
+	// the ranges of all documents, keyed by result ID.
+	Results map[string][]Location
+}
+
+// A Document is the code intelligence data of a document in a dump: the
+// definitions, references and hover of each range of the document.
+type Document struct {
+	// Ranges are the ranges of the document, sorted by start position. Their
+	// definition and reference results are keys of the dump's Results, and
+	// their hover results are keys of Hovers.
+	Ranges []DocumentRange `json:"ranges"`
+
+	// Hovers are the hover texts (in Markdown) of the ranges.
+	Hovers map[string]string `json:"hovers,omitempty"`
+}
+
+// A DocumentRange is a range of a document (usually the range of a symbol's
+// name) that has code intelligence data.
+type DocumentRange struct {
+	Range
+	DefinitionResult string `json:"definitionResult,omitempty"`
+	ReferenceResult  string `json:"referenceResult,omitempty"`
+	HoverResult      string `json:"hoverResult,omitempty"`
+}
+
+// RangeAt returns the innermost range containing the position, or nil if
+// there is none.
+func (d *Document) RangeAt(p Position) *DocumentRange {
+	var innermost *DocumentRange
+	for i := range d.Ranges {
+		r := &d.Ranges[i]
+		if lessPosition(p, r.Start) {
+			break // the ranges are sorted by start position
+		}
+		if r.contains(p) && (innermost == nil || !lessPosition(r.Start, innermost.Start)) {
+			innermost = r
+		}
+	}
+	return innermost
+}
+
+// Hover returns the hover text (in Markdown) of the range, or "" if there is
+// none.
+func (d *Document) Hover(r *DocumentRange) string {
+	return d.Hovers[r.HoverResult]
+}
@@ -0,0 +1,54 @@
+package pkgdeps
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+type pomArtifact struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+}
+
+// parsePOM returns the artifact that a pom.xml file defines and the artifacts
+// that it depends on (including its parent). References to the project's own
+// group ID are expanded, and artifacts whose coordinates contain other
+// property references are omitted because they can't be resolved from the
+// file alone.
+func parsePOM(data []byte) (defined, required []Package) {
+	var pom struct {
+		pomArtifact
+		Parent       pomArtifact   `xml:"parent"`
+		Dependencies []pomArtifact `xml:"dependencies>dependency"`
+	}
+	if err := xml.Unmarshal(data, &pom); err != nil {
+		return nil, nil
+	}
+
+	groupID := strings.TrimSpace(pom.GroupID)
+	if groupID == "" {
+		// The group ID is inherited from the parent.
+		groupID = strings.TrimSpace(pom.Parent.GroupID)
+	}
+	name := func(a pomArtifact) string {
+		g, id := strings.TrimSpace(a.GroupID), strings.TrimSpace(a.ArtifactID)
+		switch g {
+		case "${project.groupId}", "${pom.groupId}", "${groupId}":
+			g = groupID
+		}
+		if g == "" || id == "" || strings.Contains(g, "${") || strings.Contains(id, "${") {
+			return ""
+		}
+		return g + ":" + id
+	}
+
+	if n := name(pomArtifact{GroupID: groupID, ArtifactID: pom.ArtifactID}); n != "" {
+		defined = append(defined, Package{Manager: Maven, Name: n})
+	}
+	for _, a := range append([]pomArtifact{pom.Parent}, pom.Dependencies...) {
+		if n := name(a); n != "" {
+			required = append(required, Package{Manager: Maven, Name: n})
+		}
+	}
+	return defined, required
+}
@@ -0,0 +1,36 @@
+package pkgdeps
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// parsePackageJSON returns the package that a package.json file defines
+// (unless it is private) and the packages that it depends on. Dependencies
+// on local paths are omitted.
+func parsePackageJSON(data []byte) (defined, required []Package) {
+	var manifest struct {
+		Name                 string
+		Private              bool
+		Dependencies         map[string]string
+		DevDependencies      map[string]string
+		PeerDependencies     map[string]string
+		OptionalDependencies map[string]string
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil
+	}
+
+	if name := strings.TrimSpace(manifest.Name); name != "" && !manifest.Private {
+		defined = append(defined, Package{Manager: NPM, Name: name})
+	}
+	for _, m := range []map[string]string{manifest.Dependencies, manifest.DevDependencies, manifest.PeerDependencies, manifest.OptionalDependencies} {
+		for name, version := range m {
+			if name == "" || strings.HasPrefix(version, "file:") || strings.HasPrefix(version, "link:") {
+				continue
+			}
+			required = append(required, Package{Manager: NPM, Name: name})
+		}
+	}
+	return defined, required
+}
@@ -0,0 +1,144 @@
+// Package pkgdeps computes the package dependency graph of repositories from
+// their npm (package.json), Maven (pom.xml) and pip (requirements.txt and
+// setup.py) manifests: which packages a repository defines, which packages it
+// depends on, and which repositories contain those packages.
+package pkgdeps
+
+import (
+	"archive/tar"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+)
+
+// The package managers whose manifests are parsed.
+const (
+	NPM   = "npm"
+	Maven = "maven"
+	Pip   = "pip"
+)
+
+// maxFileSize is the maximum size of the manifests that are parsed.
+const maxFileSize = 1 << 20
+
+// A Package is a package of a package manager. Maven package names are
+// "groupId:artifactId", and pip package names are normalized (see
+// NormalizePipName).
+type Package struct {
+	Manager string
+	Name    string
+}
+
+// Deps are the packages that a repository defines and depends on.
+type Deps struct {
+	// Packages are the packages that the manifests in the repository define,
+	// sorted. Private npm packages are omitted.
+	Packages []Package
+
+	// Dependencies are the packages that the manifests in the repository
+	// depend on, sorted. The packages that the repository defines are
+	// omitted.
+	Dependencies []Package
+}
+
+// Parse returns the packages that the manifests in a tar archive of a
+// repository define and depend on. Manifests in node_modules, vendor and
+// testdata directories and in directories whose names begin with "." are
+// ignored.
+func Parse(archive io.Reader) (*Deps, error) {
+	packages := map[Package]struct{}{}
+	dependencies := map[Package]struct{}{}
+
+	tr := tar.NewReader(archive)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg || hdr.Size > maxFileSize || ignoredPath(hdr.Name) {
+			continue
+		}
+		parse := manifestParser(hdr.Name)
+		if parse == nil {
+			continue
+		}
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		// Manifests that don't parse are skipped, as the package managers
+		// would fail to install them too.
+		defined, required := parse(data)
+		for _, p := range defined {
+			packages[p] = struct{}{}
+		}
+		for _, p := range required {
+			dependencies[p] = struct{}{}
+		}
+	}
+
+	deps := &Deps{Packages: sortedPackages(packages)}
+	for p := range dependencies {
+		if _, ok := packages[p]; !ok {
+			deps.Dependencies = append(deps.Dependencies, p)
+		}
+	}
+	sortPackages(deps.Dependencies)
+	return deps, nil
+}
+
+// IsManifest reports whether Parse parses the file at name, a path relative to
+// the root of the repository.
+func IsManifest(name string) bool {
+	return manifestParser(name) != nil && !ignoredPath(name)
+}
+
+// manifestParser returns the parser for the manifest at name, or nil if name
+// is not a manifest.
+func manifestParser(name string) func([]byte) (defined, required []Package) {
+	switch name := path.Base(name); {
+	case name == "package.json":
+		return parsePackageJSON
+	case name == "pom.xml":
+		return parsePOM
+	case name == "setup.py":
+		return parseSetupPy
+	case strings.HasPrefix(name, "requirements") && strings.HasSuffix(name, ".txt"):
+		return parseRequirements
+	}
+	return nil
+}
+
+// ignoredPath reports whether the file at name is in a directory whose
+// manifests are not the repository's own.
+func ignoredPath(name string) bool {
+	for _, dir := range strings.Split(path.Dir(name), "/") {
+		if dir == "node_modules" || dir == "vendor" || dir == "testdata" || (dir != "." && strings.HasPrefix(dir, ".")) {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedPackages(m map[Package]struct{}) []Package {
+	packages := make([]Package, 0, len(m))
+	for p := range m {
+		packages = append(packages, p)
+	}
+	sortPackages(packages)
+	return packages
+}
+
+func sortPackages(packages []Package) {
+	sort.Slice(packages, func(i, j int) bool {
+		if packages[i].Manager != packages[j].Manager {
+			return packages[i].Manager < packages[j].Manager
+		}
+		return packages[i].Name < packages[j].Name
+	})
+}
@@ -0,0 +1,160 @@
+package pkgdeps
+
+import (
+	"bufio"
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// parseRequirements returns the packages that a pip requirements file
+// depends on. Options (such as -r and -e) and requirements that are paths or
+// URLs are omitted.
+func parseRequirements(data []byte) (defined, required []Package) {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if name := requirementName(line); name != "" {
+			required = append(required, Package{Manager: Pip, Name: name})
+		}
+	}
+	return nil, required
+}
+
+// parseSetupPy returns the package that a setup.py file defines and the
+// packages that it depends on (install_requires). The file is not executed:
+// only the string literals passed as the name and install_requires keyword
+// arguments of the setup call are used.
+func parseSetupPy(data []byte) (defined, required []Package) {
+	tokens := pythonTokens(string(data))
+	start := -1
+	for i := 0; i+1 < len(tokens); i++ {
+		if tokens[i] == (pythonToken{text: "setup"}) && tokens[i+1] == (pythonToken{text: "("}) {
+			start = i + 2
+			break
+		}
+	}
+	if start == -1 {
+		return nil, nil
+	}
+	tokens = tokens[start:]
+
+	depth := 0
+	for i := 0; i < len(tokens) && depth >= 0; i++ {
+		switch t := tokens[i]; {
+		case t.text == "(" || t.text == "[" || t.text == "{":
+			depth++
+		case t.text == ")" || t.text == "]" || t.text == "}":
+			depth--
+		case depth == 0 && i+2 < len(tokens) && tokens[i+1].text == "=":
+			switch value := tokens[i+2]; t.text {
+			case "name":
+				if value.str {
+					if name := requirementName(value.text); name != "" {
+						defined = append(defined, Package{Manager: Pip, Name: name})
+					}
+				}
+			case "install_requires":
+				if value.text != "[" && value.text != "(" {
+					continue
+				}
+				for _, t := range tokens[i+3:] {
+					if !t.str {
+						if t.text == "]" || t.text == ")" {
+							break
+						}
+						continue
+					}
+					if name := requirementName(t.text); name != "" {
+						required = append(required, Package{Manager: Pip, Name: name})
+					}
+				}
+			}
+		}
+	}
+	return defined, required
+}
+
+var requirementNamePattern = regexp.MustCompile(`^([A-Za-z0-9](?:[A-Za-z0-9._-]*[A-Za-z0-9])?)(?:$|[\s\[;<>=!~@(])`)
+
+// requirementName returns the normalized name of the package of a requirement
+// specifier (such as "requests[security]>=2.8.1"), or "" if it is not a
+// package name (such as a path or a URL).
+func requirementName(spec string) string {
+	m := requirementNamePattern.FindStringSubmatch(strings.TrimSpace(spec))
+	if m == nil {
+		return ""
+	}
+	return NormalizePipName(m[1])
+}
+
+var pipNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// NormalizePipName returns the normalized form of a pip package name, under
+// which names that pip considers equal (such as "Foo_Bar" and "foo-bar") are
+// equal.
+func NormalizePipName(name string) string {
+	return pipNameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+}
+
+// A pythonToken is a string literal (whose text is its value) or any other
+// token of Python code.
+type pythonToken struct {
+	text string
+	str  bool
+}
+
+// pythonTokens splits Python code into tokens, which are enough to find the
+// literal arguments of a call. Comments are skipped, identifiers and numbers
+// are single tokens, and all other characters are tokens of their own.
+func pythonTokens(src string) []pythonToken {
+	var tokens []pythonToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n' || c == '\\':
+			i++
+		case c == '"' || c == '\'':
+			quote := src[i : i+1]
+			if strings.HasPrefix(src[i:], strings.Repeat(quote, 3)) {
+				quote = strings.Repeat(quote, 3)
+			}
+			i += len(quote)
+			var value strings.Builder
+			for i < len(src) && !strings.HasPrefix(src[i:], quote) {
+				if src[i] == '\\' && i+1 < len(src) {
+					i++
+				}
+				value.WriteByte(src[i])
+				i++
+			}
+			i += len(quote)
+			tokens = append(tokens, pythonToken{text: value.String(), str: true})
+		case isPythonIdentByte(c):
+			start := i
+			for i < len(src) && isPythonIdentByte(src[i]) {
+				i++
+			}
+			// String prefixes (such as r and u) are part of the string.
+			if i < len(src) && (src[i] == '"' || src[i] == '\'') && len(src[start:i]) <= 2 && strings.Trim(strings.ToLower(src[start:i]), "rbuf") == "" {
+				continue
+			}
+			tokens = append(tokens, pythonToken{text: src[start:i]})
+		default:
+			tokens = append(tokens, pythonToken{text: src[i : i+1]})
+			i++
+		}
+	}
+	return tokens
+}
+
+func isPythonIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c >= 0x80
+}
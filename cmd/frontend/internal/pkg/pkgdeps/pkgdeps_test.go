@@ -0,0 +1,169 @@
+package pkgdeps
+
+import (
+	"archive/tar"
+	"bytes"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+func TestParse(t *testing.T) {
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"package.json": `{
+	"name": "@example/app",
+	"dependencies": {"left-pad": "^1.3.0", "@example/lib": "file:./lib"},
+	"devDependencies": {"typescript": "^3.0.0"},
+	"peerDependencies": {"react": "^16.0.0"}
+}`,
+		"lib/package.json":     `{"name": "@example/lib", "dependencies": {"@example/app": "^1.0.0"}}`,
+		"site/package.json":    `{"name": "site", "private": true, "dependencies": {"lodash": "4"}}`,
+		"invalid/package.json": `{"name": `,
+		"java/pom.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<project>
+	<parent>
+		<groupId>com.example</groupId>
+		<artifactId>parent</artifactId>
+	</parent>
+	<artifactId>core</artifactId>
+	<dependencies>
+		<dependency>
+			<groupId>junit</groupId>
+			<artifactId>junit</artifactId>
+			<scope>test</scope>
+		</dependency>
+		<dependency>
+			<groupId>${project.groupId}</groupId>
+			<artifactId>util</artifactId>
+		</dependency>
+		<dependency>
+			<groupId>${other.groupId}</groupId>
+			<artifactId>unknown</artifactId>
+		</dependency>
+	</dependencies>
+	<dependencyManagement>
+		<dependencies>
+			<dependency>
+				<groupId>com.google.guava</groupId>
+				<artifactId>guava</artifactId>
+			</dependency>
+		</dependencies>
+	</dependencyManagement>
+</project>
+`,
+		"python/requirements.txt": `# comment
+Flask>=1.0  # web framework
+requests[security] == 2.20.0 ; python_version < "3.7"
+-r requirements-dev.txt
+-e git+https://github.com/example/editable.git#egg=editable
+./local/package
+https://example.com/archive.tar.gz
+`,
+		"python/requirements-dev.txt": "pytest\n",
+		"python/setup.py": `from setuptools import setup, find_packages
+
+VERSION = "1.0"
+
+setup(
+    name='My_Package',
+    version=VERSION,
+    packages=find_packages(exclude=["tests"]),
+    install_requires=[
+        "six>=1.10",  # "commented-out"
+        'python-dateutil',
+    ],
+    extras_require={"docs": ["sphinx"]},
+)
+`,
+		"node_modules/x/package.json": `{"name": "x", "dependencies": {"vendored": "1"}}`,
+		".github/package.json":        `{"name": "hidden", "dependencies": {"hidden-dep": "1"}}`,
+		"README.md":                   "left-pad\n",
+	} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	deps, err := Parse(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Deps{
+		Packages: []Package{
+			{Maven, "com.example:core"},
+			{NPM, "@example/app"},
+			{NPM, "@example/lib"},
+			{Pip, "my-package"},
+		},
+		Dependencies: []Package{
+			{Maven, "com.example:parent"},
+			{Maven, "com.example:util"},
+			{Maven, "junit:junit"},
+			{NPM, "left-pad"},
+			{NPM, "lodash"},
+			{NPM, "react"},
+			{NPM, "typescript"},
+			{Pip, "flask"},
+			{Pip, "pytest"},
+			{Pip, "python-dateutil"},
+			{Pip, "requests"},
+			{Pip, "six"},
+		},
+	}
+	if !reflect.DeepEqual(deps, want) {
+		t.Errorf("got %+v, want %+v", deps, want)
+	}
+}
+
+func TestIsManifest(t *testing.T) {
+	tests := map[string]bool{
+		"package.json":                true,
+		"java/pom.xml":                true,
+		"python/requirements-dev.txt": true,
+		"python/setup.py":             true,
+		"README.md":                   false,
+		"requirements.in":             false,
+		"node_modules/x/package.json": false,
+		".github/package.json":        false,
+	}
+	for name, want := range tests {
+		if got := IsManifest(name); got != want {
+			t.Errorf("%s: got %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestResolver(t *testing.T) {
+	r := NewResolver()
+	r.AddRule(Rule{Manager: NPM, From: regexp.MustCompile(`^@example/(?P<name>.+)$`), To: "github.com/example/{name}"})
+	r.AddRule(Rule{Manager: Maven, From: regexp.MustCompile(`^org\.apache\.(?P<project>[^.:]+)`), To: "github.com/apache/{project}"})
+	r.AddRepo(1, "github.com/example/ui")
+	r.AddRepo(2, "github.com/Apache/Commons")
+	r.AddRepo(3, "github.com/stevemao/left-pad")
+	r.AddPackages(3, []Package{{NPM, "left-pad"}, {NPM, "@example/app"}})
+	r.AddRepo(4, "github.com/fork/left-pad")
+	r.AddPackages(4, []Package{{NPM, "left-pad"}})
+
+	for p, want := range map[Package]api.RepoID{
+		{NPM, "@example/ui"}:                     1,
+		{NPM, "@example/app"}:                    3, // no repository github.com/example/app
+		{Maven, "org.apache.commons:commons-io"}: 2,
+		{NPM, "left-pad"}:                        3,
+		{Pip, "left-pad"}:                        0,
+		{Maven, "org.example:core"}:              0,
+	} {
+		if got := r.Resolve(p); got != want {
+			t.Errorf("%+v: got %d, want %d", p, got, want)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package pkgdeps
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+)
+
+// A Rule maps the names of the packages of a package manager that match a
+// regular expression to repository names. To is a template in which
+// "{group}" is replaced by the submatch of the named capturing group "group"
+// of From.
+type Rule struct {
+	Manager string
+	From    *regexp.Regexp
+	To      string
+}
+
+// A Resolver resolves packages to the repositories that contain them. It uses
+// only the configured rules and the packages that the manifests of the
+// repositories define, so it works without network access and for private
+// packages.
+type Resolver struct {
+	rules    []Rule
+	repos    map[string]api.RepoID  // lowercase repository name -> repository
+	packages map[Package]api.RepoID // package -> repository that defines it
+}
+
+// NewResolver returns a resolver that knows no rules and no repositories.
+func NewResolver() *Resolver {
+	return &Resolver{
+		repos:    make(map[string]api.RepoID),
+		packages: make(map[Package]api.RepoID),
+	}
+}
+
+// AddRule adds a rule. Rules are tried in the order they are added.
+func (r *Resolver) AddRule(rule Rule) {
+	r.rules = append(r.rules, rule)
+}
+
+// AddRepo adds a repository with its name.
+func (r *Resolver) AddRepo(id api.RepoID, name api.RepoName) {
+	r.repos[strings.ToLower(string(name))] = id
+}
+
+// AddPackages adds the packages that the manifests of a repository define.
+// If several repositories define the same package (such as forks), the
+// repository that was added first is kept.
+func (r *Resolver) AddPackages(id api.RepoID, packages []Package) {
+	for _, p := range packages {
+		if _, ok := r.packages[p]; !ok {
+			r.packages[p] = id
+		}
+	}
+}
+
+// Resolve returns the repository that contains the package, or 0 if it is
+// unknown. The first rule that matches the package and maps it to a known
+// repository takes precedence over the repository that defines the package.
+func (r *Resolver) Resolve(p Package) api.RepoID {
+	for _, rule := range r.rules {
+		if rule.Manager != p.Manager {
+			continue
+		}
+		if name := expand(rule.From, p.Name, rule.To); name != "" {
+			if id, ok := r.repos[strings.ToLower(name)]; ok {
+				return id
+			}
+		}
+	}
+	return r.packages[p]
+}
+
+// expand returns the template with the "{group}" references replaced by the
+// submatches of re in s, or "" if re does not match s.
+func expand(re *regexp.Regexp, s, template string) string {
+	m := re.FindStringSubmatch(s)
+	if m == nil {
+		return ""
+	}
+	var pairs []string
+	for i, name := range re.SubexpNames() {
+		if i > 0 && name != "" {
+			pairs = append(pairs, "{"+name+"}", m[i])
+		}
+	}
+	return strings.NewReplacer(pairs...).Replace(template)
+}
@@ -20,6 +20,13 @@ const (
 	FieldArchived  = "archived"
 	FieldLang      = "lang"
 	FieldType      = "type"
+	FieldSelect    = "select"
+	FieldContext   = "context"
+
+	// Repository predicates, which match repositories by their contents:
+	FieldRepoHasFile        = "repohasfile"
+	FieldRepoHasCommitAfter = "repohascommitafter"
+	FieldRepoHasLang        = "repohaslang"
 
 	// For diff and commit search only:
 	FieldBefore    = "before"
@@ -50,6 +57,12 @@ var (
 			FieldArchived:  {Literal: types.StringType, Quoted: types.StringType, Singular: true},
 			FieldLang:      {Literal: types.StringType, Quoted: types.StringType, Negatable: true},
 			FieldType:      stringFieldType,
+			FieldSelect:    {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldContext:   {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+
+			FieldRepoHasFile:        regexpNegatableFieldType,
+			FieldRepoHasCommitAfter: {Literal: types.StringType, Quoted: types.StringType, Singular: true},
+			FieldRepoHasLang:        {Literal: types.StringType, Quoted: types.StringType, Negatable: true},
 
 			FieldBefore:    stringFieldType,
 			FieldAfter:     stringFieldType,
@@ -69,6 +82,7 @@ var (
 			"f":        FieldFile,
 			"l":        FieldLang,
 			"language": FieldLang,
+			"repolang": FieldRepoHasLang,
 			"since":    FieldAfter,
 			"until":    FieldBefore,
 			"m":        FieldMessage,
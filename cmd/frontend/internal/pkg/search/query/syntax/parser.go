@@ -1,6 +1,9 @@
 package syntax
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ParseError describes an error in query parsing.
 type ParseError struct {
@@ -17,6 +20,13 @@ type parser struct {
 	pos    int
 }
 
+// Boolean operator keywords. They must be uppercase.
+const (
+	keywordAnd = "AND"
+	keywordOr  = "OR"
+	keywordNot = "NOT"
+)
+
 // context holds settings active within a given scope during parsing.
 type context struct {
 	field string // name of the field currently in scope (or "")
@@ -32,15 +42,87 @@ type context struct {
 //   expr      := fieldExpr | lit | quoted | pattern
 //   fieldExpr := lit ":" value
 //   value     := lit | quoted
+//
+// If the query contains any of the keywords AND, OR or NOT it is parsed as a
+// boolean query instead, where parentheses group expressions:
+//
+//   orExpr    := andExpr (sep "OR" sep andExpr)*
+//   andExpr   := unaryExpr (sep [ "AND" sep ] unaryExpr)*
+//   unaryExpr := "NOT" sep unaryExpr | "(" orExpr ")" | exprSign
 func Parse(input string) (*Query, error) {
 	tokens := Scan(input)
-	p := parser{tokens: tokens}
 	ctx := context{field: ""}
-	exprs, err := p.parseExprList(ctx)
+
+	if !hasKeyword(tokens) {
+		p := parser{tokens: tokens}
+		exprs, err := p.parseExprList(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var tree Node
+		if len(exprs) > 0 {
+			tree = &Operator{Kind: OperatorAnd, Operands: exprsToNodes(exprs)}
+		}
+		return &Query{Expr: exprs, Tree: tree, Input: input}, nil
+	}
+
+	p := parser{tokens: splitParens(tokens)}
+	p.skipSep()
+	if p.peek().Type == TokenEOF {
+		return &Query{Input: input, Boolean: true}, nil
+	}
+	tree, err := p.parseOr(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return &Query{Expr: exprs, Input: input}, nil
+	p.skipSep()
+	if tok := p.next(); tok.Type != TokenEOF {
+		if tok.Type == TokenRParen {
+			return nil, &ParseError{Pos: tok.Pos, Msg: "unmatched closing parenthesis"}
+		}
+		return nil, &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want EOF", tok.Type)}
+	}
+	return &Query{Expr: leaves(tree, nil), Tree: tree, Input: input, Boolean: true}, nil
+}
+
+// hasKeyword reports whether tokens contain a boolean operator keyword
+// (possibly adjacent to grouping parentheses) which is not a field name or
+// value.
+func hasKeyword(tokens []Token) bool {
+	for i, tok := range tokens {
+		if tok.Type != TokenLiteral {
+			continue
+		}
+		if (i > 0 && tokens[i-1].Type == TokenColon) || (i+1 < len(tokens) && tokens[i+1].Type == TokenColon) {
+			continue
+		}
+		switch strings.Trim(tok.Value, "()") {
+		case keywordAnd, keywordOr, keywordNot:
+			return true
+		}
+	}
+	return false
+}
+
+func exprsToNodes(exprs []*Expr) []Node {
+	nodes := make([]Node, len(exprs))
+	for i, e := range exprs {
+		nodes[i] = e
+	}
+	return nodes
+}
+
+// leaves appends the leaves of n to exprs in order.
+func leaves(n Node, exprs []*Expr) []*Expr {
+	switch n := n.(type) {
+	case *Expr:
+		exprs = append(exprs, n)
+	case *Operator:
+		for _, o := range n.Operands {
+			exprs = leaves(o, exprs)
+		}
+	}
+	return exprs
 }
 
 // peek returns the next token without consuming it. Peeking beyond the end of
@@ -68,6 +150,129 @@ func (p *parser) next() Token {
 	return Token{Type: TokenEOF}
 }
 
+// skipSep consumes separator tokens.
+func (p *parser) skipSep() {
+	for p.peek().Type == TokenSep {
+		p.next()
+	}
+}
+
+// peekKeyword reports whether the next token is the boolean operator keyword.
+func (p *parser) peekKeyword(keyword string) bool {
+	tok := p.peek()
+	if tok.Type != TokenLiteral || tok.Value != keyword {
+		return false
+	}
+	return p.pos+1 >= len(p.tokens) || p.tokens[p.pos+1].Type != TokenColon
+}
+
+// orExpr := andExpr (sep "OR" sep andExpr)*
+func (p *parser) parseOr(ctx context) (Node, error) {
+	pos := p.peek().Pos
+	var operands []Node
+	for {
+		n, err := p.parseAnd(ctx)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, n)
+
+		p.skipSep()
+		if !p.peekKeyword(keywordOr) {
+			break
+		}
+		p.next()
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &Operator{Pos: pos, Kind: OperatorOr, Operands: operands}, nil
+}
+
+// andExpr := unaryExpr (sep [ "AND" sep ] unaryExpr)*
+func (p *parser) parseAnd(ctx context) (Node, error) {
+	p.skipSep()
+	pos := p.peek().Pos
+	var operands []Node
+	for {
+		p.skipSep()
+		explicit := len(operands) > 0 && p.peekKeyword(keywordAnd)
+		if explicit {
+			p.next()
+			p.skipSep()
+		}
+
+		tok := p.peek()
+		if tok.Type == TokenEOF || tok.Type == TokenRParen || p.peekKeyword(keywordOr) || p.peekKeyword(keywordAnd) {
+			if len(operands) == 0 || explicit {
+				return nil, &ParseError{Pos: tok.Pos, Msg: fmt.Sprintf("got %s, want expr", tokenDescription(tok))}
+			}
+			break
+		}
+
+		n, err := p.parseUnary(ctx)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, n)
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return &Operator{Pos: pos, Kind: OperatorAnd, Operands: operands}, nil
+}
+
+// unaryExpr := "NOT" sep unaryExpr | "(" orExpr ")" | exprSign
+func (p *parser) parseUnary(ctx context) (Node, error) {
+	tok := p.peek()
+	switch {
+	case p.peekKeyword(keywordNot):
+		p.next()
+		p.skipSep()
+		if next := p.peek(); next.Type == TokenEOF || next.Type == TokenRParen || p.peekKeyword(keywordAnd) || p.peekKeyword(keywordOr) {
+			return nil, &ParseError{Pos: next.Pos, Msg: fmt.Sprintf("got %s, want expr", tokenDescription(next))}
+		}
+		n, err := p.parseUnary(ctx)
+		if err != nil {
+			return nil, err
+		}
+		// NOT field:value is the same as -field:value.
+		if e, ok := n.(*Expr); ok && e.Field != "" {
+			e.Not = !e.Not
+			e.Pos = tok.Pos
+			return e, nil
+		}
+		return &Operator{Pos: tok.Pos, Kind: OperatorNot, Operands: []Node{n}}, nil
+
+	case tok.Type == TokenLParen:
+		p.next()
+		n, err := p.parseOr(ctx)
+		if err != nil {
+			return nil, err
+		}
+		p.skipSep()
+		if closing := p.next(); closing.Type != TokenRParen {
+			return nil, &ParseError{Pos: tok.Pos, Msg: "unmatched opening parenthesis"}
+		}
+		return n, nil
+
+	case tok.Type == TokenRParen:
+		return nil, &ParseError{Pos: tok.Pos, Msg: "unmatched closing parenthesis"}
+	}
+	return p.parseExprSign(ctx)
+}
+
+// tokenDescription describes tok for use in error messages.
+func tokenDescription(tok Token) string {
+	if tok.Type == TokenLiteral {
+		switch tok.Value {
+		case keywordAnd, keywordOr, keywordNot:
+			return tok.Value
+		}
+	}
+	return tok.Type.String()
+}
+
 // exprList := {exprSign} | exprSign (sep exprSign)*
 func (p *parser) parseExprList(ctx context) (exprList []*Expr, err error) {
 	if p.peek().Type == TokenEOF {
@@ -129,15 +334,23 @@ func (p *parser) parseExpr(ctx context) (*Expr, error) {
 			valueTok := p.next()
 			switch valueTok.Type {
 			case TokenLiteral, TokenQuoted:
-				if tok3 := p.next(); tok3.Type != TokenSep && tok3.Type != TokenEOF {
+				if tok3 := p.next(); tok3.Type == TokenRParen {
+					p.backup()
+				} else if tok3.Type != TokenSep && tok3.Type != TokenEOF {
 					return nil, &ParseError{Pos: tok3.Pos, Msg: fmt.Sprintf("got %s, want separator or EOF", tok3.Type)}
 				}
 				return &Expr{Pos: tok.Pos, Field: tok.Value, Value: valueTok.Value, ValueType: valueTok.Type}, nil
+			case TokenRParen:
+				p.backup()
+				return &Expr{Pos: tok.Pos, Field: tok.Value, Value: "", ValueType: TokenLiteral}, nil
 			case TokenSep, TokenEOF:
 				return &Expr{Pos: tok.Pos, Field: tok.Value, Value: "", ValueType: TokenLiteral}, nil
 			default:
 				return nil, &ParseError{Pos: valueTok.Pos, Msg: fmt.Sprintf("got %s, want value", valueTok.Type)}
 			}
+		case TokenRParen:
+			p.backup()
+			return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
 		case TokenSep, TokenEOF:
 			return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
 		default:
@@ -146,6 +359,9 @@ func (p *parser) parseExpr(ctx context) (*Expr, error) {
 	case TokenQuoted, TokenPattern:
 		tok2 := p.next()
 		switch tok2.Type {
+		case TokenRParen:
+			p.backup()
+			return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
 		case TokenSep, TokenEOF:
 			return &Expr{Pos: tok.Pos, Value: tok.Value, ValueType: tok.Type}, nil
 		default:
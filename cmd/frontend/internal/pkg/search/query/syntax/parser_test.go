@@ -111,3 +111,61 @@ func TestParser(t *testing.T) {
 		})
 	}
 }
+
+func TestParser_boolean(t *testing.T) {
+	tests := map[string]struct {
+		wantTree string
+		wantErr  *ParseError
+	}{
+		"a":                  {wantTree: "(a)"},
+		"a b":                {wantTree: "(a AND b)"},
+		"(a|b)c":             {wantTree: "((a|b)c)"},
+		"a AND b":            {wantTree: "(a AND b)"},
+		"a OR b":             {wantTree: "(a OR b)"},
+		"a OR b c":           {wantTree: "(a OR (b AND c))"},
+		"a b OR c":           {wantTree: "((a AND b) OR c)"},
+		"NOT a":              {wantTree: "NOT a"},
+		"a NOT b":            {wantTree: "(a AND NOT b)"},
+		"NOT NOT a":          {wantTree: "NOT NOT a"},
+		"(a OR b) AND NOT c": {wantTree: "((a OR b) AND NOT c)"},
+		"( a OR b ) c":       {wantTree: "((a OR b) AND c)"},
+		"((a OR b))":         {wantTree: "(a OR b)"},
+		"(a|b) OR c":         {wantTree: "((a|b) OR c)"},
+		"((a|b) OR c)":       {wantTree: "((a|b) OR c)"},
+		`("a b" OR /c d/)`:   {wantTree: `("a b" OR /c d/)`},
+		"(a OR b) file:x":    {wantTree: "((a OR b) AND file:x)"},
+		"(a OR lang:go)":     {wantTree: "(a OR lang:go)"},
+		"NOT file:x a":       {wantTree: "(-file:x AND a)"},
+		"message:OR":         {wantTree: "(message:OR)"},
+		"OR:a":               {wantTree: "(OR:a)"},
+		"a OR":               {wantErr: &ParseError{Pos: 4, Msg: "got TokenEOF, want expr"}},
+		"OR a":               {wantErr: &ParseError{Pos: 0, Msg: "got OR, want expr"}},
+		"a AND OR b":         {wantErr: &ParseError{Pos: 6, Msg: "got OR, want expr"}},
+		"NOT":                {wantErr: &ParseError{Pos: 3, Msg: "got TokenEOF, want expr"}},
+		"(a OR b":            {wantErr: &ParseError{Pos: 0, Msg: "unmatched opening parenthesis"}},
+		"a OR b)":            {wantErr: &ParseError{Pos: 6, Msg: "unmatched closing parenthesis"}},
+		"() OR a":            {wantTree: "(() OR a)"},
+	}
+	for input, test := range tests {
+		t.Run(input, func(t *testing.T) {
+			query, err := Parse(input)
+			if err != nil && test.wantErr == nil {
+				t.Fatal(err)
+			} else if err == nil && test.wantErr != nil {
+				t.Fatalf("got err == nil, want %q", test.wantErr)
+			} else if test.wantErr != nil && !reflect.DeepEqual(err, test.wantErr) {
+				t.Fatalf("got err == %q, want %q", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if tree := query.Tree.String(); tree != test.wantTree {
+				t.Errorf("tree: %s\ngot  %s\nwant %s", input, tree, test.wantTree)
+			}
+			// The leaves of the tree are the query's expressions.
+			if got, want := ExprString(query.Expr), ExprString(leaves(query.Tree, nil)); got != want {
+				t.Errorf("expr: %s\ngot  %s\nwant %s", input, got, want)
+			}
+		})
+	}
+}
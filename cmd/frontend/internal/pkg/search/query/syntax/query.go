@@ -2,13 +2,74 @@ package syntax
 
 import (
 	"bytes"
+	"strconv"
 	"strings"
 )
 
 // A Query contains the parse tree of a query.
 type Query struct {
 	Input string  // the original input query string
-	Expr  []*Expr // expressions in this query
+	Expr  []*Expr // expressions in this query (the leaves of Tree, in order)
+
+	// Tree is the boolean expression tree of the query. Its leaves are the
+	// elements of Expr. If the query does not use boolean operators, Tree is
+	// an OperatorAnd over Expr. It is nil for an empty query.
+	Tree Node
+
+	// Boolean is whether the query uses the boolean operators AND, OR or
+	// NOT. Parentheses are only treated as grouping in boolean queries.
+	Boolean bool
+}
+
+// A Node is a node in the boolean expression tree of a query. It is either
+// an *Expr or an *Operator.
+type Node interface {
+	String() string
+	node()
+}
+
+func (*Expr) node()     {}
+func (*Operator) node() {}
+
+// OperatorKind is the kind of a boolean operator.
+type OperatorKind int
+
+// All OperatorKind values.
+const (
+	OperatorAnd OperatorKind = iota + 1
+	OperatorOr
+	OperatorNot
+)
+
+func (k OperatorKind) String() string {
+	switch k {
+	case OperatorAnd:
+		return "AND"
+	case OperatorOr:
+		return "OR"
+	case OperatorNot:
+		return "NOT"
+	}
+	return "OperatorKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// An Operator is a boolean operator applied to its operands. An OperatorNot
+// has exactly one operand.
+type Operator struct {
+	Pos      int          // the starting character position of the operator expression
+	Kind     OperatorKind // the operator
+	Operands []Node       // the operands
+}
+
+func (o *Operator) String() string {
+	if o.Kind == OperatorNot {
+		return "NOT " + o.Operands[0].String()
+	}
+	s := make([]string, len(o.Operands))
+	for i, n := range o.Operands {
+		s[i] = n.String()
+	}
+	return "(" + strings.Join(s, " "+o.Kind.String()+" ") + ")"
 }
 
 // An Expr describes an expression in a query.
@@ -21,7 +21,9 @@ const (
 	TokenPattern
 	TokenColon
 	TokenMinus
-	TokenSep // separator (like a semicolon)
+	TokenSep    // separator (like a semicolon)
+	TokenLParen // "(" grouping in boolean queries
+	TokenRParen // ")" grouping in boolean queries
 )
 
 var singleCharTokens = map[rune]TokenType{
@@ -118,12 +120,25 @@ func scanDefault(s *scanner) stateFn {
 		if r == '/' {
 			return scanPattern
 		}
+		if r == '(' && parensBeforeQuoteOrPattern(s.input[s.pos:]) {
+			s.next()
+			s.emit(TokenLParen)
+			return scanDefault
+		}
 
 		return scanText
 	}
 	return scanSpace
 }
 
+// parensBeforeQuoteOrPattern reports whether s starts with one or more "("
+// followed by a quoted string or pattern, such as `("a b"`. Otherwise the
+// scanner would scan it as a literal up to the next whitespace.
+func parensBeforeQuoteOrPattern(s string) bool {
+	t := strings.TrimLeft(s, "(")
+	return len(t) < len(s) && t != "" && (t[0] == '"' || t[0] == '\'' || t[0] == '/')
+}
+
 func scanText(s *scanner) stateFn {
 	// Characters that may come before a ':' (TokenColon) in a TokenLiteral.
 	preColonChars := "abcdefghijklmnopqrstuvwxyz0123456789"
@@ -257,3 +272,47 @@ func scanSpace(s *scanner) stateFn {
 	s.emit(TokenSep)
 	return scanDefault
 }
+
+// splitParens splits grouping parentheses out of the literal tokens in
+// tokens. Only unbalanced parentheses at the start or end of a literal are
+// split out, so that regexp groups such as "(a|b)" are kept intact. Literals
+// which are not field values are rescanned after removing the parentheses,
+// since the scanner treats text starting with "(" as a literal (e.g.
+// `("a b"` or `(repo:x`).
+func splitParens(tokens []Token) []Token {
+	split := make([]Token, 0, len(tokens))
+	for i, tok := range tokens {
+		if tok.Type != TokenLiteral || (i+1 < len(tokens) && tokens[i+1].Type == TokenColon) {
+			split = append(split, tok)
+			continue
+		}
+		isValue := i > 0 && tokens[i-1].Type == TokenColon
+
+		v, pos := tok.Value, tok.Pos
+		for strings.HasPrefix(v, "(") && strings.Count(v, "(") > strings.Count(v, ")") {
+			split = append(split, Token{Type: TokenLParen, Value: "(", Pos: pos})
+			v, pos = v[1:], pos+1
+		}
+		var rparens []Token
+		for strings.HasSuffix(v, ")") && strings.Count(v, ")") > strings.Count(v, "(") {
+			v = v[:len(v)-1]
+			rparens = append([]Token{{Type: TokenRParen, Value: ")", Pos: pos + len(v)}}, rparens...)
+		}
+
+		switch {
+		case v == "":
+		case isValue || v == tok.Value:
+			split = append(split, Token{Type: TokenLiteral, Value: v, Pos: pos})
+		default:
+			for _, t := range Scan(v) {
+				if t.Type == TokenEOF {
+					continue
+				}
+				t.Pos += pos
+				split = append(split, t)
+			}
+		}
+		split = append(split, rparens...)
+	}
+	return split
+}
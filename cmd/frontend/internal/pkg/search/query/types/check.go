@@ -44,6 +44,7 @@ func (c *Config) Check(query *syntax.Query) (*Query, error) {
 		Syntax: query,
 		Fields: map[string][]*Value{},
 	}
+	values := make(map[*syntax.Expr]*Value, len(query.Expr))
 	for _, expr := range query.Expr {
 		field, fieldType, value, err := c.checkExpr(expr)
 		if err != nil {
@@ -53,10 +54,58 @@ func (c *Config) Check(query *syntax.Query) (*Query, error) {
 			return nil, &TypeError{Pos: expr.Pos, Err: fmt.Errorf("field %q may not be used more than once", field)}
 		}
 		checkedQuery.Fields[field] = append(checkedQuery.Fields[field], value)
+		value.field = field
+		values[expr] = value
+	}
+	if query.Boolean && query.Tree != nil {
+		pattern, err := checkPattern(query.Tree, values, true)
+		if err != nil {
+			return nil, err
+		}
+		checkedQuery.Pattern = pattern
 	}
 	return &checkedQuery, nil
 }
 
+// checkPattern returns the boolean combination of search patterns (values
+// of the default field) in the tree n. Other fields may only occur in the
+// top-level conjunction of the query (where top is true), since they
+// restrict the whole search and are not matched per line.
+func checkPattern(n syntax.Node, values map[*syntax.Expr]*Value, top bool) (*PatternExpr, error) {
+	switch n := n.(type) {
+	case *syntax.Expr:
+		value := values[n]
+		if value.field == "" {
+			return &PatternExpr{Value: value}, nil
+		}
+		if !top {
+			return nil, &TypeError{Pos: n.Pos, Err: fmt.Errorf("field %q may not be used with OR or NOT; only search patterns may be combined with OR and NOT", n.Field)}
+		}
+		return nil, nil
+
+	case *syntax.Operator:
+		top = top && n.Kind == syntax.OperatorAnd
+		var operands []*PatternExpr
+		for _, o := range n.Operands {
+			p, err := checkPattern(o, values, top)
+			if err != nil {
+				return nil, err
+			}
+			if p != nil {
+				operands = append(operands, p)
+			}
+		}
+		if n.Kind != syntax.OperatorNot && len(operands) <= 1 {
+			if len(operands) == 0 {
+				return nil, nil
+			}
+			return operands[0], nil
+		}
+		return &PatternExpr{Kind: n.Kind, Operands: operands}, nil
+	}
+	panic(fmt.Sprintf("unexpected node type %T", n))
+}
+
 func (c *Config) resolveField(field string, not bool) (resolvedField string, typ FieldType, err error) {
 	// Resolve field alias, if any.
 	if resolvedField, ok := c.FieldAliases[field]; ok {
@@ -125,6 +125,54 @@ func TestCheck(t *testing.T) {
 	}
 }
 
+func TestCheck_pattern(t *testing.T) {
+	conf := Config{
+		FieldTypes: map[string]FieldType{
+			"":  {Literal: RegexpType, Quoted: StringType},
+			"r": {Literal: RegexpType, Quoted: RegexpType, Negatable: true},
+		},
+	}
+	tests := map[string]struct {
+		want    string
+		wantErr string
+	}{
+		"a b":                  {want: ""},
+		"a AND b":              {want: "(a AND b)"},
+		"a OR b":               {want: "(a OR b)"},
+		"r:x (a OR b)":         {want: "(a OR b)"},
+		"r:x AND NOT a":        {want: "NOT a"},
+		`(a OR "b c") AND d`:   {want: "((a OR \"b c\") AND d)"},
+		"r:x AND NOT r:y":      {want: ""},
+		"a OR r:x":             {wantErr: `type error at character 5: field "r" may not be used with OR or NOT; only search patterns may be combined with OR and NOT`},
+		"a AND NOT (r:x OR b)": {wantErr: `type error at character 11: field "r" may not be used with OR or NOT; only search patterns may be combined with OR and NOT`},
+	}
+	for input, test := range tests {
+		t.Run(input, func(t *testing.T) {
+			syntaxQuery, err := syntax.Parse(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			query, err := conf.Check(syntaxQuery)
+			if test.wantErr != "" {
+				if err == nil || err.Error() != test.wantErr {
+					t.Fatalf("got err == %v, want %q", err, test.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got string
+			if query.Pattern != nil {
+				got = query.Pattern.String()
+			}
+			if got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
 func TestUnquoteString(t *testing.T) {
 	tests := map[string]string{
 		`"ab"`:    "ab",
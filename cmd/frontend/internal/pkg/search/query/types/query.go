@@ -2,6 +2,7 @@ package types
 
 import (
 	"regexp"
+	"strings"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query/syntax"
 )
@@ -10,6 +11,46 @@ import (
 type Query struct {
 	Syntax *syntax.Query       // the query syntax
 	Fields map[string][]*Value // map of field name -> values
+
+	// Pattern is the boolean combination of the search patterns in a query
+	// using the boolean operators AND, OR or NOT. It is nil for other
+	// queries, whose search patterns (Fields[""]) must all match.
+	Pattern *PatternExpr
+}
+
+// A PatternExpr is a boolean combination of search patterns. It is either a
+// leaf (with Value set) or an operator applied to its operands.
+type PatternExpr struct {
+	Kind     syntax.OperatorKind // the operator, or 0 for a leaf
+	Value    *Value              // if a leaf, the search pattern
+	Operands []*PatternExpr      // if an operator, its operands (exactly 1 for OperatorNot)
+}
+
+// Leaves returns the search patterns in p, in order.
+func (p *PatternExpr) Leaves() []*Value {
+	if p.Kind == 0 {
+		return []*Value{p.Value}
+	}
+	var values []*Value
+	for _, o := range p.Operands {
+		values = append(values, o.Leaves()...)
+	}
+	return values
+}
+
+// String returns the query syntax for p.
+func (p *PatternExpr) String() string {
+	switch p.Kind {
+	case 0:
+		return p.Value.syntax.String()
+	case syntax.OperatorNot:
+		return "NOT " + p.Operands[0].String()
+	}
+	s := make([]string, len(p.Operands))
+	for i, o := range p.Operands {
+		s[i] = o.String()
+	}
+	return "(" + strings.Join(s, " "+p.Kind.String()+" ") + ")"
 }
 
 // ValueType is the set of types of values in queries.
@@ -25,6 +66,7 @@ const (
 // A Value is a field value in a query.
 type Value struct {
 	syntax *syntax.Expr // the underlying query expression
+	field  string       // the resolved field name
 
 	String *string        // if a string value, the string value (with escape sequences interpreted)
 	Regexp *regexp.Regexp // if a regexp pattern, the compiled regular expression (call its String method to get source pattern string)
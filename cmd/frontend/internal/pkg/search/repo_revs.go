@@ -38,6 +38,22 @@ func (r1 RevisionSpecifier) String() string {
 	return r1.RevSpec
 }
 
+// DiffRange reports whether r1 is a revision range of the form "base...head"
+// or "base..head", which is searched as the diff between base and head. If
+// mergeBase is true ("..."), the diff should be taken from the merge base of
+// base and head, which is what `git diff base...head` does.
+func (r1 RevisionSpecifier) DiffRange() (base, head string, mergeBase, ok bool) {
+	sep, mergeBase := "..", false
+	if strings.Contains(r1.RevSpec, "...") {
+		sep, mergeBase = "...", true
+	}
+	i := strings.Index(r1.RevSpec, sep)
+	if i <= 0 || i+len(sep) >= len(r1.RevSpec) {
+		return "", "", false, false
+	}
+	return r1.RevSpec[:i], r1.RevSpec[i+len(sep):], mergeBase, true
+}
+
 // Less compares two revspecOrRefGlob entities, suitable for use
 // with sort.Slice()
 //
@@ -80,6 +96,9 @@ type RepositoryRevisions struct {
 // - 'foo@*bar' refers to the 'foo' repo and all refs matching the glob 'bar/*',
 //   because git interprets the ref glob 'bar' as being 'bar/*' (see `man git-log`
 //   section on the --glob flag)
+// - 'foo@*refs/heads/*:^refs/heads/wip-*' refers to the 'foo' repo and all
+//   branches except those matching 'refs/heads/wip-*'. A '^' followed by a glob
+//   is the same as '*!' (a plain '^rev' is left to git as a revspec).
 func ParseRepositoryRevisions(repoAndOptionalRev string) (api.RepoName, []RevisionSpecifier) {
 	i := strings.Index(repoAndOptionalRev, "@")
 	if i == -1 {
@@ -110,10 +129,100 @@ func parseRev(spec string) RevisionSpecifier {
 		return RevisionSpecifier{ExcludeRefGlob: spec[2:]}
 	} else if strings.HasPrefix(spec, "*") {
 		return RevisionSpecifier{RefGlob: spec[1:]}
+	} else if strings.HasPrefix(spec, "^") && strings.ContainsAny(spec, globChars) {
+		return RevisionSpecifier{ExcludeRefGlob: spec[1:]}
 	}
 	return RevisionSpecifier{RevSpec: spec}
 }
 
+// globChars are the special characters of ref globs.
+const globChars = "*?["
+
+// RefGlobs matches ref names against the ref globs of a list of revision
+// specifiers, using the semantics of the --glob and --exclude flags of
+// git-log: a leading "refs/" is implied, and a ref glob without any glob
+// characters matches the refs below it (e.g. "heads" is "refs/heads/*"). A
+// "*" may match "/". Unlike git-log, exclusions apply to all ref globs
+// regardless of their order.
+type RefGlobs struct {
+	include, exclude []*regexp.Regexp
+}
+
+// CompileRefGlobs returns the matcher for the ref globs in revs. Revspecs in
+// revs are ignored.
+func CompileRefGlobs(revs []RevisionSpecifier) (*RefGlobs, error) {
+	var g RefGlobs
+	for _, rev := range revs {
+		switch {
+		case rev.RefGlob != "":
+			glob := rev.RefGlob
+			if !strings.ContainsAny(glob, globChars) {
+				glob = strings.TrimSuffix(glob, "/") + "/*"
+			}
+			re, err := compileRefGlob(glob)
+			if err != nil {
+				return nil, err
+			}
+			g.include = append(g.include, re)
+		case rev.ExcludeRefGlob != "":
+			re, err := compileRefGlob(rev.ExcludeRefGlob)
+			if err != nil {
+				return nil, err
+			}
+			g.exclude = append(g.exclude, re)
+		}
+	}
+	return &g, nil
+}
+
+// Match reports whether the ref (e.g. "refs/heads/master") is matched by
+// any ref glob and not excluded.
+func (g *RefGlobs) Match(ref string) bool {
+	for _, re := range g.exclude {
+		if re.MatchString(ref) {
+			return false
+		}
+	}
+	for _, re := range g.include {
+		if re.MatchString(ref) {
+			return true
+		}
+	}
+	return false
+}
+
+// compileRefGlob converts the shell glob to an anchored regexp.
+func compileRefGlob(glob string) (*regexp.Regexp, error) {
+	if !strings.HasPrefix(glob, "refs/") {
+		glob = "refs/" + glob
+	}
+	var b strings.Builder
+	b.WriteByte('^')
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteByte('.')
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				return nil, errors.Errorf("invalid ref glob %q (unterminated character class)", glob)
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteByte('$')
+	return regexp.Compile(b.String())
+}
+
 // GitserverRepo is a convenience function to return the gitserver.Repo for
 // r.Repo. The returned Repo will not have the URL set, only the name.
 func (r RepositoryRevisions) GitserverRepo() gitserver.Repo {
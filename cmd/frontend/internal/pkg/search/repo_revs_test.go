@@ -26,6 +26,10 @@ func TestParseRepositoryRevisions(t *testing.T) {
 			repo: "repo",
 			revs: []RevisionSpecifier{{RevSpec: "rev1"}, {RefGlob: "glob1"}, {RevSpec: "^rev2"}},
 		},
+		"repo@*refs/heads/*:^refs/heads/wip-*": {
+			repo: "repo",
+			revs: []RevisionSpecifier{{RefGlob: "refs/heads/*"}, {ExcludeRefGlob: "refs/heads/wip-*"}},
+		},
 		"repo@rev1:*glob1:*!glob2:rev2:*glob3": {
 			repo: "repo",
 			revs: []RevisionSpecifier{
@@ -50,6 +54,71 @@ func TestParseRepositoryRevisions(t *testing.T) {
 	}
 }
 
+func TestRefGlobs(t *testing.T) {
+	refs := []string{
+		"refs/heads/master",
+		"refs/heads/release-1.0",
+		"refs/heads/release-2.0",
+		"refs/heads/wip-foo",
+		"refs/heads/users/alice/x",
+		"refs/tags/v1.0",
+	}
+	tests := map[string][]string{
+		"*refs/heads/release-*":                {"refs/heads/release-1.0", "refs/heads/release-2.0"},
+		"*heads/release-[!1]*":                 {"refs/heads/release-2.0"},
+		"*refs/heads/*:^refs/heads/wip-*":      {"refs/heads/master", "refs/heads/release-1.0", "refs/heads/release-2.0", "refs/heads/users/alice/x"},
+		"*!refs/heads/wip-*:*refs/heads/wip-*": nil,
+		"*tags":                                {"refs/tags/v1.0"},
+		"*refs/heads/users":                    {"refs/heads/users/alice/x"},
+		"*refs/heads/release-?.0":              {"refs/heads/release-1.0", "refs/heads/release-2.0"},
+		"master":                               nil,
+	}
+	for input, want := range tests {
+		t.Run(input, func(t *testing.T) {
+			_, revs := ParseRepositoryRevisions("repo@" + input)
+			g, err := CompileRefGlobs(revs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, ref := range refs {
+				if g.Match(ref) {
+					got = append(got, ref)
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+
+	if _, err := CompileRefGlobs([]RevisionSpecifier{{RefGlob: "refs/heads/[a"}}); err == nil {
+		t.Error("got nil error for unterminated character class")
+	}
+}
+
+func TestRevisionSpecifierDiffRange(t *testing.T) {
+	tests := map[string]struct {
+		base, head      string
+		mergeBase, isOK bool
+	}{
+		"main":             {},
+		"main...feature/x": {base: "main", head: "feature/x", mergeBase: true, isOK: true},
+		"v1.0..v2.0":       {base: "v1.0", head: "v2.0", isOK: true},
+		"...main":          {},
+		"main..":           {},
+		"a1b2c3...HEAD~1":  {base: "a1b2c3", head: "HEAD~1", mergeBase: true, isOK: true},
+	}
+	for input, want := range tests {
+		t.Run(input, func(t *testing.T) {
+			base, head, mergeBase, ok := RevisionSpecifier{RevSpec: input}.DiffRange()
+			if base != want.base || head != want.head || mergeBase != want.mergeBase || ok != want.isOK {
+				t.Fatalf("got (%q, %q, %v, %v), want (%q, %q, %v, %v)", base, head, mergeBase, ok, want.base, want.head, want.mergeBase, want.isOK)
+			}
+		})
+	}
+}
+
 func TestRepoRevisionsQuery(t *testing.T) {
 	repos := []*types.Repo{{Name: "foo"}, {Name: "bar"}, {Name: "baz"}}
 	cases := map[string]string{
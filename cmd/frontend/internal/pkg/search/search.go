@@ -5,6 +5,7 @@ import (
 	"regexp/syntax"
 
 	"github.com/sourcegraph/sourcegraph/cmd/frontend/internal/pkg/search/query"
+	searchquery "github.com/sourcegraph/sourcegraph/pkg/search/query"
 )
 
 // PatternInfo is the struct used by vscode pass on search queries. Keep it in
@@ -27,6 +28,16 @@ type PatternInfo struct {
 
 	PatternMatchesContent bool
 	PatternMatchesPath    bool
+
+	// CountOnly, if true, only counts the matches in each file instead of
+	// returning them, and the file match limit is not applied.
+	CountOnly bool
+
+	// BooleanPattern, if non-nil, is the boolean combination of search
+	// patterns (using AND, OR and NOT) which file contents must match. In
+	// that case Pattern matches any of the patterns which are not negated and
+	// is only used by code paths which cannot evaluate BooleanPattern.
+	BooleanPattern searchquery.Q
 }
 
 func (p *PatternInfo) IsEmpty() bool {
@@ -82,4 +93,9 @@ type Args struct {
 	// repository if this field is true. Another example is we set this field
 	// to true if the user requests a specific timeout or maximum result size.
 	UseFullDeadline bool
+
+	// SelectRepo indicates that only the repositories containing a match are
+	// needed (select:repo). Each repository is only searched until its first
+	// match, and limits apply to the number of repositories.
+	SelectRepo bool
 }
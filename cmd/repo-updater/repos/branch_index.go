@@ -0,0 +1,72 @@
+package repos
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	searcherprotocol "github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	"github.com/sourcegraph/sourcegraph/pkg/endpoint"
+	"github.com/sourcegraph/sourcegraph/pkg/env"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	"golang.org/x/net/context/ctxhttp"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+var searcherURL = env.Get("SEARCHER_URL", "k8s+http://searcher:3181", "searcher server URL")
+
+var (
+	searcherEndpointsOnce sync.Once
+	searcherEndpoints     *endpoint.Map
+)
+
+// notifyBranchIndex notifies the searcher replica which indexes the branches
+// of repo (see the search.index.branches site configuration) that repo was
+// updated. It does nothing if no branches are indexed.
+func notifyBranchIndex(ctx context.Context, repo api.RepoName, resp *gitserverprotocol.RepoUpdateResponse) {
+	if resp == nil || resp.LastChanged == nil || len(conf.Get().SearchIndexBranches) == 0 {
+		return
+	}
+	if err := postBranchIndexUpdate(ctx, searcherprotocol.BranchIndexUpdateRequest{Repo: repo, LastChanged: *resp.LastChanged}); err != nil {
+		log15.Warn("error notifying searcher of repo update", "repo", repo, "err", err)
+	}
+}
+
+func postBranchIndexUpdate(ctx context.Context, req searcherprotocol.BranchIndexUpdateRequest) error {
+	searcherEndpointsOnce.Do(func() {
+		if len(strings.Fields(searcherURL)) == 0 {
+			searcherEndpoints = endpoint.Empty(errors.New("a searcher service has not been configured"))
+		} else {
+			searcherEndpoints = endpoint.New(searcherURL)
+		}
+	})
+	// The branches of a repository are indexed by the replica its name maps
+	// to, which is also where the frontend searches them.
+	ep, err := searcherEndpoints.Get(string(req.Repo), nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	resp, err := ctxhttp.Post(ctx, nil, ep+searcherprotocol.BranchIndexUpdatePath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return errors.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+	return nil
+}
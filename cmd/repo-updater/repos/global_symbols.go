@@ -0,0 +1,22 @@
+package repos
+
+import (
+	"context"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
+	gitserverprotocol "github.com/sourcegraph/sourcegraph/pkg/gitserver/protocol"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// notifyGlobalSymbolIndex notifies the frontend that repo was updated, so
+// that it updates the global symbol index. It does nothing if global symbol
+// search is disabled.
+func notifyGlobalSymbolIndex(ctx context.Context, repo api.RepoName, resp *gitserverprotocol.RepoUpdateResponse) {
+	if resp == nil || resp.LastChanged == nil || !conf.GlobalSymbolSearchEnabled() {
+		return
+	}
+	if err := api.InternalClient.GlobalSymbolsUpdate(ctx, repo); err != nil {
+		log15.Warn("error notifying frontend of repo update for the global symbol index", "repo", repo, "err", err)
+	}
+}
@@ -461,6 +461,8 @@ func (r *repoList) doUpdate(ctx context.Context, repo *repoData) {
 			log15.Warn("error requesting repo update", "repo", repoName, "err", err)
 			return
 		}
+		notifyBranchIndex(ctx, repoName, resp)
+		notifyGlobalSymbolIndex(ctx, repoName, resp)
 	}
 }
 
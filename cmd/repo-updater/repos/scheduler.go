@@ -154,6 +154,8 @@ func (s *updateScheduler) runUpdateLoop(ctx context.Context) {
 					interval := resp.LastFetched.Sub(*resp.LastChanged) / 2
 					s.schedule.updateInterval(repo, interval)
 				}
+				notifyBranchIndex(ctx, repo.Name, resp)
+				notifyGlobalSymbolIndex(ctx, repo.Name, resp)
 			}(ctx, repo, cancel)
 		}
 	}
@@ -18,8 +18,10 @@ import (
 	opentracing "github.com/opentracing/opentracing-go"
 	log15 "gopkg.in/inconshreveable/log15.v2"
 
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
 	"github.com/sourcegraph/sourcegraph/cmd/searcher/search"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/conf"
 	"github.com/sourcegraph/sourcegraph/pkg/debugserver"
 	"github.com/sourcegraph/sourcegraph/pkg/env"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
@@ -30,6 +32,8 @@ import (
 
 var cacheDir = env.Get("CACHE_DIR", "/tmp", "directory to store cached archives.")
 var cacheSizeMB = env.Get("SEARCHER_CACHE_SIZE_MB", "100000", "maximum size of the on disk cache in megabytes")
+var branchIndexSizeMB = env.Get("SEARCHER_BRANCH_INDEX_SIZE_MB", "10000", "maximum size of the branch index (see search.index.branches) in megabytes")
+var trigramIndex, _ = strconv.ParseBool(env.Get("SEARCHER_TRIGRAM_INDEX", "true", "build in-memory trigram indexes for repeatedly searched archives"))
 
 const port = "3181"
 
@@ -47,14 +51,36 @@ func main() {
 	} else {
 		cacheSizeBytes = i * 1000 * 1000
 	}
+	var branchIndexSizeBytes int64
+	if i, err := strconv.ParseInt(branchIndexSizeMB, 10, 64); err != nil {
+		log.Fatalf("invalid int %q for SEARCHER_BRANCH_INDEX_SIZE_MB: %s", branchIndexSizeMB, err)
+	} else {
+		branchIndexSizeBytes = i * 1000 * 1000
+	}
 
+	fetchTar := func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+		return git.Archive(ctx, repo, git.ArchiveOptions{Treeish: string(commit), Format: "tar"})
+	}
 	service := &search.Service{
 		Store: &search.Store{
-			FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
-				return git.Archive(ctx, repo, git.ArchiveOptions{Treeish: string(commit), Format: "tar"})
+			FetchTar: fetchTar,
+			FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+				// The paths are pathspecs for git archive, so they are marked as literal to not
+				// interpret wildcards and magic in file names.
+				pathspecs := make([]string, len(paths))
+				for i, p := range paths {
+					pathspecs[i] = ":(literal)" + p
+				}
+				return git.Archive(ctx, repo, git.ArchiveOptions{Treeish: string(commit), Format: "tar", Paths: pathspecs})
 			},
 			Path:              filepath.Join(cacheDir, "searcher-archives"),
 			MaxCacheSizeBytes: cacheSizeBytes,
+			TrigramIndex:      trigramIndex,
+		},
+		GitDiff: func(ctx context.Context, repo gitserver.Repo, args ...string) ([]byte, error) {
+			cmd := gitserver.DefaultClient.Command("git", append([]string{"diff"}, args...)...)
+			cmd.Repo = repo
+			return cmd.Output(ctx)
 		},
 		Log: log15.Root(),
 	}
@@ -66,6 +92,34 @@ func main() {
 		log.Fatal(err)
 	}
 
+	branchIndex := &search.BranchIndex{
+		FetchTar: fetchTar,
+		ListBranches: func(ctx context.Context, repo gitserver.Repo) (map[string]api.CommitID, error) {
+			branches, err := git.ListBranches(ctx, repo, git.BranchesOptions{})
+			if err != nil {
+				return nil, err
+			}
+			heads := make(map[string]api.CommitID, len(branches))
+			for _, b := range branches {
+				heads[b.Name] = b.Head
+			}
+			return heads, nil
+		},
+		FetchTarPaths: service.Store.FetchTarPaths,
+		GitDiff:       service.GitDiff,
+		IndexedBranch: conf.SearchIndexBranch,
+		Path:          filepath.Join(cacheDir, "searcher-branches"),
+		MaxSizeBytes:  branchIndexSizeBytes,
+	}
+	if err := branchIndex.Start(); err != nil {
+		log.Fatal(err)
+	}
+	service.BranchIndex = branchIndex
+	branchIndexRPCHandler, err := rpc.Server(branchIndex)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	host := ""
 	if env.InsecureDev {
 		host = "127.0.0.1"
@@ -84,6 +138,14 @@ func main() {
 				rpcHandler.ServeHTTP(w, r)
 				return
 			}
+			if r.URL.Path == rpc.BranchIndexRPCPath {
+				branchIndexRPCHandler.ServeHTTP(w, r)
+				return
+			}
+			if r.URL.Path == protocol.BranchIndexUpdatePath {
+				branchIndex.ServeHTTP(w, r)
+				return
+			}
 
 			handler.ServeHTTP(w, r)
 		}),
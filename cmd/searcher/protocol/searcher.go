@@ -2,6 +2,8 @@
 package protocol
 
 import (
+	"time"
+
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
 )
@@ -20,6 +22,12 @@ type Request struct {
 	// "599cba5e7b6137d46ddf58fb1765f5d928e69604"
 	Commit api.CommitID
 
+	// DiffBase, if set, restricts the search to files changed between
+	// DiffBase and Commit. Like Commit it must be a resolved commit ID. Line
+	// matches on lines added in Commit or removed from DiffBase have
+	// LineMatch.DiffOp set.
+	DiffBase api.CommitID
+
 	PatternInfo
 
 	// The amount of time to wait for a repo archive to fetch.
@@ -97,6 +105,11 @@ type PatternInfo struct {
 	// PatternMatchesPath is whether a file whose path matches Pattern (but whose contents don't) should be
 	// considered a match.
 	PatternMatchesPath bool
+
+	// CountOnly if true only counts the matches in each file. The returned
+	// FileMatches have MatchCount set instead of LineMatches, and neither
+	// FileMatchLimit nor the limits on matches per file and line apply.
+	CountOnly bool
 }
 
 // AllIncludePatterns returns all include patterns (including the deprecated
@@ -130,6 +143,10 @@ type FileMatch struct {
 	Path        string
 	LineMatches []LineMatch
 
+	// MatchCount is the number of matches in the file. It is only set if
+	// PatternInfo.CountOnly is true.
+	MatchCount int
+
 	// LimitHit is true if LineMatches may not include all LineMatches.
 	LimitHit bool
 }
@@ -148,6 +165,30 @@ type LineMatch struct {
 	// Offsets and lengths are measured in characters, not bytes.
 	OffsetAndLengths [][2]int
 
+	// DiffOp is only set when searching a diff (see Request.DiffBase). It is
+	// "+" if the line was added in Commit, or "-" if the line was removed
+	// from DiffBase. For removed lines LineNumber refers to the file in
+	// DiffBase. It is empty for unchanged lines.
+	DiffOp string
+
 	// LimitHit is true if OffsetAndLengths may not include all OffsetAndLengths.
 	LimitHit bool
 }
+
+// BranchIndexUpdatePath is the path of the searcher endpoint which is notified
+// (with a BranchIndexUpdateRequest) that a repository was updated.
+const BranchIndexUpdatePath = "/branch-index/update"
+
+// BranchIndexUpdateRequest notifies the searcher replica which indexes the
+// branches of a repository (see the search.index.branches site
+// configuration) that the repository was updated, so that the branches which
+// moved to a new commit are indexed again.
+type BranchIndexUpdateRequest struct {
+	// Repo is the name of the repository which was updated.
+	Repo api.RepoName
+
+	// LastChanged is when the repository last changed (see
+	// (gitserver/protocol.RepoUpdateResponse).LastChanged). Searcher skips
+	// repositories which did not change since they were last indexed.
+	LastChanged time.Time
+}
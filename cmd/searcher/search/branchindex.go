@@ -0,0 +1,714 @@
+package search
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	srcapi "github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	api "github.com/sourcegraph/sourcegraph/pkg/search"
+	"github.com/sourcegraph/sourcegraph/pkg/search/query"
+	"golang.org/x/sys/unix"
+)
+
+const branchIndexSource = api.Source("textbranchindexed")
+
+// BranchIndex is an indexed pkg/search.Searcher for the branches of
+// repositories other than their default branch, which zoekt does not index.
+//
+// It keeps a shard for every branch which IndexedBranch reports as indexed. A
+// shard is a zip archive of the searchable files of the branch (like the
+// archives of Store), whose comment records the repository, branch and
+// commit it was built from. Shards are kept in memory with a trigram index of
+// their contents, which is used to skip the files which can't match a query.
+//
+// The shards of a repository are refreshed when repo-updater reports that it
+// updated the repository (see ServeHTTP). Only the shards of the branches
+// which moved to another commit are built again, and only the files which
+// changed since the previous shard of the branch are fetched. When the shards
+// exceed MaxSizeBytes, the least recently searched shards are removed.
+//
+// Search only searches the shards of the branches named by the ref: atoms of
+// the query. It reports no status for a repository whose branch is not
+// indexed (yet), so that the caller can search it at the branch's commit
+// instead. The searcher Service also searches the shards when it is asked to
+// search the commit of an indexed branch (see zipFileAt).
+type BranchIndex struct {
+	// FetchTar returns an io.ReadCloser to a tar archive of repo at commit
+	// (see Store.FetchTar).
+	FetchTar func(ctx context.Context, repo gitserver.Repo, commit srcapi.CommitID) (io.ReadCloser, error)
+
+	// FetchTarPaths, if non-nil, returns an io.ReadCloser to a tar archive of
+	// only the files at paths of repo at commit (see Store.FetchTarPaths).
+	// Together with GitDiff it is used to fetch only the files which changed
+	// since the previous shard of a branch.
+	FetchTarPaths func(ctx context.Context, repo gitserver.Repo, commit srcapi.CommitID, paths []string) (io.ReadCloser, error)
+
+	// GitDiff returns the output of running `git diff` with args in repo (see
+	// Service.GitDiff).
+	GitDiff func(ctx context.Context, repo gitserver.Repo, args ...string) ([]byte, error)
+
+	// ListBranches returns the head commit of each branch of repo, keyed by
+	// the branch name.
+	ListBranches func(ctx context.Context, repo gitserver.Repo) (map[string]srcapi.CommitID, error)
+
+	// IndexedBranch reports whether the branch with the given name should be
+	// indexed.
+	IndexedBranch func(name string) bool
+
+	// Path is the directory to store the shards.
+	Path string
+
+	// MaxSizeBytes is the maximum total size of the shards. When it is
+	// exceeded, the least recently searched shards are removed (until the
+	// repository is updated again). If 0, the size is not limited.
+	MaxSizeBytes int64
+
+	// once protects Start
+	once sync.Once
+
+	mu          sync.RWMutex
+	shards      map[srcapi.RepoName][]*branchShard // protected by mu
+	size        int64                              // the total size of shards, protected by mu
+	lastChanged map[srcapi.RepoName]time.Time      // when each repository last changed before it was indexed, protected by mu
+
+	queueMu sync.Mutex
+	queue   map[srcapi.RepoName]time.Time // repositories to index, protected by queueMu
+	queued  chan struct{}                 // signals that queue is non-empty
+}
+
+// branchShardInfo identifies the branch and commit a shard was built from.
+// It is stored as JSON in the comment of the shard's zip archive.
+type branchShardInfo struct {
+	Repo   srcapi.RepoName
+	Branch string
+	Commit srcapi.CommitID
+}
+
+// branchShard is an indexed branch of a repository.
+type branchShard struct {
+	branchShardInfo
+
+	zf       *zipFile
+	trigrams *trigramIndex
+	size     int64 // the size of the shard's file
+
+	// lastUsed is when the shard was last searched (or built), in Unix
+	// nanoseconds. It is accessed atomically.
+	lastUsed int64
+}
+
+// Start loads the shards in Path and starts indexing the repositories that
+// repo-updater reports as updated. It can be called more than once.
+func (x *BranchIndex) Start() error {
+	var err error
+	x.once.Do(func() {
+		x.shards = make(map[srcapi.RepoName][]*branchShard)
+		x.lastChanged = make(map[srcapi.RepoName]time.Time)
+		x.queue = make(map[srcapi.RepoName]time.Time)
+		x.queued = make(chan struct{}, 1)
+
+		if err = os.MkdirAll(x.Path, 0700); err != nil {
+			return
+		}
+		if err = x.load(); err != nil {
+			return
+		}
+		go x.updateLoop()
+	})
+	return err
+}
+
+// load loads the shards in Path. Shards which can't be read, and shards
+// superseded by another shard of the same branch, are removed.
+func (x *BranchIndex) load() error {
+	paths, err := filepath.Glob(filepath.Join(x.Path, "*.zip"))
+	if err != nil {
+		return err
+	}
+	// Load the newest shards first, so that we keep the newest shard of a
+	// branch if searcher stopped before it removed the old one.
+	modTime := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		if fi, err := os.Stat(path); err == nil {
+			modTime[path] = fi.ModTime()
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return modTime[paths[i]].After(modTime[paths[j]]) })
+
+	branches := make(map[branchShardInfo]bool)
+	for _, path := range paths {
+		s, err := openBranchShard(path)
+		if err != nil {
+			log.Printf("removing unreadable branch index shard %q: %v", path, err)
+			os.Remove(path)
+			continue
+		}
+		key := branchShardInfo{Repo: s.Repo, Branch: s.Branch}
+		if branches[key] {
+			s.close()
+			continue
+		}
+		branches[key] = true
+		x.shards[s.Repo] = append(x.shards[s.Repo], s)
+		x.size += s.size
+	}
+	branchIndexShards.Set(float64(len(branches)))
+	x.evictLocked()
+
+	// Remove the temporary files of shards that were being built.
+	tmps, _ := filepath.Glob(filepath.Join(x.Path, "*.tmp"))
+	for _, tmp := range tmps {
+		os.Remove(tmp)
+	}
+	return nil
+}
+
+// ServeHTTP handles the notifications of repo-updater that it updated a
+// repository (see protocol.BranchIndexUpdateRequest). The repository is
+// indexed in the background.
+func (x *BranchIndex) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req protocol.BranchIndexUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Repo == "" {
+		http.Error(w, "Repo must be non-empty", http.StatusBadRequest)
+		return
+	}
+	x.Enqueue(req.Repo, req.LastChanged)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Enqueue schedules repo to be indexed, unless it did not change since it
+// was last indexed.
+func (x *BranchIndex) Enqueue(repo srcapi.RepoName, lastChanged time.Time) {
+	x.mu.RLock()
+	indexed, ok := x.lastChanged[repo]
+	x.mu.RUnlock()
+	if ok && !lastChanged.After(indexed) {
+		return
+	}
+
+	x.queueMu.Lock()
+	x.queue[repo] = lastChanged
+	x.queueMu.Unlock()
+	select {
+	case x.queued <- struct{}{}:
+	default:
+	}
+}
+
+// updateLoop indexes the repositories in the queue, one at a time.
+func (x *BranchIndex) updateLoop() {
+	for range x.queued {
+		for {
+			x.queueMu.Lock()
+			var (
+				repo        srcapi.RepoName
+				lastChanged time.Time
+			)
+			for repo, lastChanged = range x.queue {
+				break
+			}
+			delete(x.queue, repo)
+			x.queueMu.Unlock()
+			if repo == "" {
+				break
+			}
+
+			// We expect a repository's branches to be indexed in a fraction
+			// of this.
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+			err := x.Update(ctx, repo)
+			cancel()
+			if err != nil {
+				log.Printf("failed to index the branches of %s: %v", repo, err)
+				branchIndexUpdateFailed.Inc()
+				continue
+			}
+			x.mu.Lock()
+			x.lastChanged[repo] = lastChanged
+			x.mu.Unlock()
+		}
+	}
+}
+
+// Update refreshes the shards of the branches of repo. The shards of the
+// branches which moved to another commit are built again, and the shards of
+// the branches which were deleted or are no longer indexed are removed. It
+// must not be called concurrently for the same repository.
+func (x *BranchIndex) Update(ctx context.Context, repo srcapi.RepoName) error {
+	branches, err := x.ListBranches(ctx, gitserver.Repo{Name: repo})
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(branches))
+	for name := range branches {
+		if x.IndexedBranch(name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	x.mu.RLock()
+	old := x.shards[repo]
+	for _, s := range old {
+		// Ensure the shard is not closed while we copy its files.
+		s.zf.wg.Add(1)
+	}
+	x.mu.RUnlock()
+	defer func() {
+		for _, s := range old {
+			s.zf.Close()
+		}
+	}()
+	current := make(map[string]*branchShard, len(old))
+	for _, s := range old {
+		current[s.Branch] = s
+	}
+
+	// If we fail to build the shard of a branch, we keep its current shard
+	// (if any) and carry on with the other branches.
+	var (
+		shards   []*branchShard
+		kept     = make(map[*branchShard]bool, len(old))
+		firstErr error
+	)
+	for _, name := range names {
+		info := branchShardInfo{Repo: repo, Branch: name, Commit: branches[name]}
+		if s := current[name]; s != nil && s.Commit == info.Commit {
+			shards = append(shards, s)
+			kept[s] = true
+			continue
+		}
+		s, err := x.buildShard(ctx, info, current[name])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "failed to index %s@%s", repo, name)
+			}
+			if s := current[name]; s != nil {
+				shards = append(shards, s)
+				kept[s] = true
+			}
+			continue
+		}
+		shards = append(shards, s)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	// Some of the old shards may have been evicted in the meantime, so they
+	// must not be kept.
+	present := make(map[*branchShard]bool, len(old))
+	for _, s := range x.shards[repo] {
+		present[s] = true
+		x.size -= s.size
+	}
+	installed := shards[:0]
+	for _, s := range shards {
+		if kept[s] && !present[s] {
+			continue
+		}
+		installed = append(installed, s)
+		x.size += s.size
+	}
+	if len(installed) > 0 {
+		x.shards[repo] = installed
+	} else {
+		delete(x.shards, repo)
+	}
+	branchIndexShards.Add(float64(len(installed) - len(present)))
+	for s := range present {
+		if !kept[s] {
+			// Searches may still be using the shard, so we close it in the
+			// background.
+			go s.close()
+		}
+	}
+	x.evictLocked()
+	return firstErr
+}
+
+// evictLocked removes the least recently used shards until their total size
+// is at most MaxSizeBytes. The caller must hold x.mu.
+func (x *BranchIndex) evictLocked() {
+	for x.MaxSizeBytes > 0 && x.size > x.MaxSizeBytes {
+		var oldest *branchShard
+		for _, shards := range x.shards {
+			for _, s := range shards {
+				if oldest == nil || atomic.LoadInt64(&s.lastUsed) < atomic.LoadInt64(&oldest.lastUsed) {
+					oldest = s
+				}
+			}
+		}
+		if oldest == nil {
+			return
+		}
+
+		shards := x.shards[oldest.Repo]
+		kept := shards[:0:0]
+		for _, s := range shards {
+			if s != oldest {
+				kept = append(kept, s)
+			}
+		}
+		if len(kept) > 0 {
+			x.shards[oldest.Repo] = kept
+		} else {
+			delete(x.shards, oldest.Repo)
+		}
+		x.size -= oldest.size
+		branchIndexShards.Dec()
+		branchIndexEvicted.Inc()
+		// Searches may still be using the shard, so we close it in the
+		// background.
+		go oldest.close()
+	}
+}
+
+// buildShard fetches an archive of the branch and commit described by info,
+// stores it as a shard in Path and opens it. If prev (the previous shard of
+// the branch) is non-nil, the files which didn't change since prev are copied
+// from it, and only the others are fetched.
+func (x *BranchIndex) buildShard(ctx context.Context, info branchShardInfo, prev *branchShard) (*branchShard, error) {
+	start := time.Now()
+	var (
+		unchanged []srcFile // the files of prev to copy
+		r         io.ReadCloser
+		ok        bool
+		err       error
+	)
+	if prev != nil {
+		unchanged, r, ok, err = x.fetchChanged(ctx, prev, info)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !ok {
+		r, err = x.FetchTar(ctx, gitserver.Repo{Name: info.Repo}, info.Commit)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if r != nil {
+		defer r.Close()
+	}
+
+	f, err := ioutil.TempFile(x.Path, "shard")
+	if err != nil {
+		return nil, err
+	}
+	tmp := f.Name() + ".tmp"
+	if err := os.Rename(f.Name(), tmp); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	defer os.Remove(tmp) // no-op once it is renamed to the shard
+
+	comment, err := json.Marshal(info)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	zw := zip.NewWriter(f)
+	if len(unchanged) > 0 {
+		err = copyZipFiles(prev.zf, unchanged, zw)
+	}
+	if err == nil && r != nil {
+		err = copySearchable(tar.NewReader(r), zw)
+	}
+	if err == nil {
+		err = zw.SetComment(string(comment))
+	}
+	if err1 := zw.Close(); err == nil {
+		err = err1
+	}
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(x.Path, info.shardName())
+	if err := os.Rename(tmp, path); err != nil {
+		return nil, err
+	}
+	s, err := openBranchShard(path)
+	if err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	branchIndexBuildDuration.Observe(time.Since(start).Seconds())
+	return s, nil
+}
+
+// fetchChanged returns the files of prev which didn't change between the
+// commit of prev and info.Commit, and an archive of the files which did (nil
+// if only files were deleted). ok is false if the changed files can't be
+// fetched by themselves, in which case the whole archive of info.Commit must
+// be fetched instead.
+func (x *BranchIndex) fetchChanged(ctx context.Context, prev *branchShard, info branchShardInfo) (unchanged []srcFile, changed io.ReadCloser, ok bool, err error) {
+	if x.GitDiff == nil || x.FetchTarPaths == nil {
+		return nil, nil, false, nil
+	}
+	repo := gitserver.Repo{Name: info.Repo}
+	out, err := x.GitDiff(ctx, repo, "--name-status", "-z", "--no-renames", string(prev.Commit), string(info.Commit))
+	if err != nil {
+		// The previous commit may no longer exist (e.g. after a force push).
+		log.Printf("failed to diff %s@%s against its previous shard, fetching all files: %v", info.Repo, info.Branch, err)
+		return nil, nil, false, nil
+	}
+	statuses := parseDiffNameStatus(out)
+	if len(statuses) > maxDiffFetchPaths {
+		return nil, nil, false, nil
+	}
+
+	changedPaths := make(map[string]bool, len(statuses))
+	var paths []string
+	for _, s := range statuses {
+		changedPaths[s.path] = true
+		if s.status != 'D' {
+			paths = append(paths, s.path)
+		}
+	}
+	for _, f := range prev.zf.Files {
+		if !changedPaths[f.Name] {
+			unchanged = append(unchanged, f)
+		}
+	}
+	if len(paths) > 0 {
+		changed, err = x.FetchTarPaths(ctx, repo, info.Commit, paths)
+		if err != nil {
+			return nil, nil, false, err
+		}
+	}
+	branchIndexReusedFiles.Add(float64(len(unchanged)))
+	return unchanged, changed, true, nil
+}
+
+// copyZipFiles writes the files of zf to zw as they are.
+func copyZipFiles(zf *zipFile, files []srcFile, zw *zip.Writer) error {
+	for i := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{
+			Name:   files[i].Name,
+			Method: zip.Store,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(zf.DataFor(&files[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shardName returns the file name of the shard of info. It includes the
+// commit, so that a new shard of a branch doesn't replace the file of the
+// old one while it is still being searched.
+func (info branchShardInfo) shardName() string {
+	h := sha256.Sum256([]byte(string(info.Repo) + " " + info.Branch + " " + string(info.Commit)))
+	return hex.EncodeToString(h[:]) + ".zip"
+}
+
+func openBranchShard(path string) (*branchShard, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	var info branchShardInfo
+	err = json.Unmarshal([]byte(r.Comment), &info)
+	r.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid shard comment")
+	}
+
+	zf, err := readZipFile(path)
+	if err != nil {
+		return nil, err
+	}
+	// The trigram index is also used when the Service searches zf (see
+	// zipFileAt).
+	zf.trigrams = buildTrigramIndex(zf)
+	return &branchShard{
+		branchShardInfo: info,
+		zf:              zf,
+		trigrams:        zf.trigrams,
+		size:            int64(len(zf.Data)),
+		lastUsed:        time.Now().UnixNano(),
+	}, nil
+}
+
+// zipFileAt returns the zip file of the shard of a branch of repo which is at
+// commit, or nil if there is none. The caller must Close the returned zip
+// file.
+func (x *BranchIndex) zipFileAt(repo srcapi.RepoName, commit srcapi.CommitID) *zipFile {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	for _, s := range x.shards[repo] {
+		if s.Commit == commit {
+			// Ensure the shard is not closed while it is searched.
+			s.zf.wg.Add(1)
+			atomic.StoreInt64(&s.lastUsed, time.Now().UnixNano())
+			return s.zf
+		}
+	}
+	return nil
+}
+
+// close waits for the searches of s to finish and then removes its file.
+func (s *branchShard) close() {
+	s.zf.wg.Wait()
+	if err := unix.Munmap(s.zf.Data); err != nil {
+		log.Printf("failed to munmap %q: %v", s.zf.f.Name(), err)
+	}
+	if err := s.zf.f.Close(); err != nil {
+		log.Printf("failed to close %q: %v", s.zf.f.Name(), err)
+	}
+	if err := os.Remove(s.zf.f.Name()); err != nil {
+		log.Printf("failed to remove %q: %v", s.zf.f.Name(), err)
+	}
+}
+
+// Search implements pkg/search.Searcher.
+func (x *BranchIndex) Search(ctx context.Context, q query.Q, opts *api.Options) (*api.Result, error) {
+	// Only the branches named by a ref: atom can match. Without one, the
+	// query is for the default branch.
+	refs := make(map[string]bool)
+	query.VisitAtoms(q, func(q query.Q) {
+		if s, ok := q.(*query.Ref); ok {
+			refs[strings.TrimPrefix(s.Pattern, "refs/heads/")] = true
+		}
+	})
+
+	x.mu.RLock()
+	var shards []*branchShard
+	for _, name := range opts.Repositories {
+		for _, s := range x.shards[name] {
+			if refs[s.Branch] {
+				// Ensure the shard is not closed while we search it.
+				s.zf.wg.Add(1)
+				atomic.StoreInt64(&s.lastUsed, time.Now().UnixNano())
+				shards = append(shards, s)
+			}
+		}
+	}
+	x.mu.RUnlock()
+	defer func() {
+		for _, s := range shards {
+			s.zf.Close()
+		}
+	}()
+
+	var res api.Result
+	for _, s := range shards {
+		repo := api.Repository{Name: s.Repo, Commit: s.Commit, RefPattern: s.Branch}
+
+		var err error
+		sq := query.Simplify(query.Map(q, func(q query.Q) query.Q {
+			switch a := q.(type) {
+			case *query.Repo:
+				err = errors.Errorf("branch index expected repo atom to be expanded: %v", q)
+			case *query.RepoSet:
+				_, ok := a.Set[string(s.Repo)]
+				return &query.Const{Value: ok}
+			case *query.Ref:
+				return &query.Const{Value: strings.TrimPrefix(a.Pattern, "refs/heads/") == s.Branch}
+			}
+			return q
+		}, nil))
+		if err != nil {
+			return nil, err
+		}
+
+		status := api.RepositoryStatusSearched
+		if c, ok := sq.(*query.Const); !ok || c.Value {
+			mt, err := newMatchTree(query.Map(sq, nil, query.ExpandFileContent), s.trigrams)
+			if err != nil {
+				return nil, err
+			}
+			var files []api.FileMatch
+			files, status, err = searchZipFile(ctx, s.zf, mt, repo, opts)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to search %s for %s", repo.String(), q)
+			}
+			res.Files = append(res.Files, files...)
+		}
+		res.Stats.Status = append(res.Stats.Status, api.RepositoryStatus{Repository: repo, Source: branchIndexSource, Status: status})
+	}
+	res.Stats.MatchCount = matchCount(res.Files)
+	return &res, nil
+}
+
+// Close implements pkg/search.Searcher.
+func (x *BranchIndex) Close() {
+	// Like StoreSearcher, BranchIndex lives as long as the process.
+}
+
+func (x *BranchIndex) String() string {
+	return "BranchIndex(" + x.Path + ")"
+}
+
+var (
+	branchIndexShards = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "searcher",
+		Subsystem: "branch_index",
+		Name:      "shards",
+		Help:      "The number of indexed branches.",
+	})
+	branchIndexBuildDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "searcher",
+		Subsystem: "branch_index",
+		Name:      "build_duration_seconds",
+		Help:      "Time taken to fetch and index a branch.",
+		Buckets:   prometheus.ExponentialBuckets(0.1, 2, 12),
+	})
+	branchIndexEvicted = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "searcher",
+		Subsystem: "branch_index",
+		Name:      "evicted_total",
+		Help:      "The number of shards removed because the shards exceeded their maximum size.",
+	})
+	branchIndexReusedFiles = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "searcher",
+		Subsystem: "branch_index",
+		Name:      "reused_files_total",
+		Help:      "The number of files copied from the previous shard of a branch instead of being fetched.",
+	})
+	branchIndexUpdateFailed = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "searcher",
+		Subsystem: "branch_index",
+		Name:      "update_failed_total",
+		Help:      "The number of times indexing the branches of a repository failed.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(branchIndexShards)
+	prometheus.MustRegister(branchIndexBuildDuration)
+	prometheus.MustRegister(branchIndexEvicted)
+	prometheus.MustRegister(branchIndexReusedFiles)
+	prometheus.MustRegister(branchIndexUpdateFailed)
+}
@@ -0,0 +1,270 @@
+package search
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"testing"
+
+	srcapi "github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	api "github.com/sourcegraph/sourcegraph/pkg/search"
+	"github.com/sourcegraph/sourcegraph/pkg/search/query"
+)
+
+func TestBranchIndex(t *testing.T) {
+	d, err := ioutil.TempDir("", "branchindex_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	archives := map[srcapi.CommitID]map[string]string{
+		"c1": {"main.go": "package main\n\nfunc foo() {}\n"},
+		"c2": {"main.go": "package main\n\nvar bar = 1\n", "README": "foo bar\n"},
+		"c3": {"main.go": "package main\n\nfunc foo() { bar() }\n"},
+	}
+	branches := map[string]srcapi.CommitID{
+		"master":    "c1",
+		"release-1": "c1",
+		"release-2": "c2",
+	}
+	var fetched []srcapi.CommitID
+	newIndex := func() *BranchIndex {
+		return &BranchIndex{
+			FetchTar: func(ctx context.Context, repo gitserver.Repo, commit srcapi.CommitID) (io.ReadCloser, error) {
+				fetched = append(fetched, commit)
+				return branchIndexTestTar(t, archives[commit]), nil
+			},
+			ListBranches: func(ctx context.Context, repo gitserver.Repo) (map[string]srcapi.CommitID, error) {
+				return branches, nil
+			},
+			IndexedBranch: func(name string) bool { return name != "master" },
+			Path:          d,
+		}
+	}
+	x := newIndex()
+	if err := x.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := x.Update(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []srcapi.CommitID{"c1", "c2"}; !reflect.DeepEqual(fetched, want) {
+		t.Fatalf("fetched %v, want %v", fetched, want)
+	}
+
+	search := func(x *BranchIndex, q query.Q) []string {
+		t.Helper()
+		res, err := x.Search(ctx, q, &api.Options{Repositories: []srcapi.RepoName{"foo", "bar"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, fm := range res.Files {
+			got = append(got, fm.Repository.String()+"@"+string(fm.Repository.Commit)+":"+fm.Path)
+		}
+		for _, s := range res.Stats.Status {
+			if s.Source != branchIndexSource || s.Status != api.RepositoryStatusSearched {
+				t.Errorf("unexpected status %+v", s)
+			}
+		}
+		sort.Strings(got)
+		return got
+	}
+	foo := &query.Substring{Pattern: "foo"}
+
+	// Only the branches named by a ref are searched, and the default branch
+	// is never searched.
+	if got, want := search(x, query.NewAnd(foo, &query.Ref{Pattern: "release-1"})), []string{"foo@release-1@c1:main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got, want := search(x, query.NewAnd(foo, &query.Ref{Pattern: "refs/heads/release-2"})), []string{"foo@release-2@c2:README"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := search(x, foo); len(got) != 0 {
+		t.Errorf("expected no matches without a ref, got %v", got)
+	}
+	if got := search(x, query.NewAnd(foo, &query.Ref{Pattern: "master"})); len(got) != 0 {
+		t.Errorf("expected no matches on the default branch, got %v", got)
+	}
+
+	// Only the branches which moved are indexed again.
+	fetched = nil
+	branches = map[string]srcapi.CommitID{
+		"master":    "c1",
+		"release-1": "c3",
+		"release-2": "c2",
+	}
+	if err := x.Update(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []srcapi.CommitID{"c3"}; !reflect.DeepEqual(fetched, want) {
+		t.Fatalf("fetched %v, want %v", fetched, want)
+	}
+	q := query.NewAnd(&query.Substring{Pattern: "bar("}, query.NewOr(&query.Ref{Pattern: "release-1"}, &query.Ref{Pattern: "release-2"}))
+	if got, want := search(x, q), []string{"foo@release-1@c3:main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// The shards are loaded again on startup.
+	fetched = nil
+	y := newIndex()
+	if err := y.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := search(y, q), []string{"foo@release-1@c3:main.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if err := y.Update(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if len(fetched) != 0 {
+		t.Errorf("expected loaded shards to be reused, fetched %v", fetched)
+	}
+}
+
+func TestBranchIndex_reuseUnchangedFiles(t *testing.T) {
+	d, err := ioutil.TempDir("", "branchindex_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	archives := map[srcapi.CommitID]map[string]string{
+		"c1": {"a.go": "foo\n", "b.go": "foo bar\n", "c.go": "foo\n"},
+		"c2": {"a.go": "bar\n", "b.go": "foo bar\n", "d.go": "foo\n"},
+	}
+	branches := map[string]srcapi.CommitID{"release": "c1"}
+	var (
+		fetched      []srcapi.CommitID
+		fetchedPaths []string
+	)
+	x := &BranchIndex{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit srcapi.CommitID) (io.ReadCloser, error) {
+			fetched = append(fetched, commit)
+			return branchIndexTestTar(t, archives[commit]), nil
+		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit srcapi.CommitID, paths []string) (io.ReadCloser, error) {
+			fetchedPaths = append(fetchedPaths, paths...)
+			files := make(map[string]string, len(paths))
+			for _, p := range paths {
+				files[p] = archives[commit][p]
+			}
+			return branchIndexTestTar(t, files), nil
+		},
+		GitDiff: func(ctx context.Context, repo gitserver.Repo, args ...string) ([]byte, error) {
+			return []byte("M\x00a.go\x00D\x00c.go\x00A\x00d.go\x00"), nil
+		},
+		ListBranches: func(ctx context.Context, repo gitserver.Repo) (map[string]srcapi.CommitID, error) {
+			return branches, nil
+		},
+		IndexedBranch: func(name string) bool { return true },
+		Path:          d,
+	}
+	if err := x.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := x.Update(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	branches = map[string]srcapi.CommitID{"release": "c2"}
+	if err := x.Update(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []srcapi.CommitID{"c1"}; !reflect.DeepEqual(fetched, want) {
+		t.Errorf("fetched %v, want %v", fetched, want)
+	}
+	if want := []string{"a.go", "d.go"}; !reflect.DeepEqual(fetchedPaths, want) {
+		t.Errorf("fetched paths %v, want %v", fetchedPaths, want)
+	}
+
+	res, err := x.Search(ctx, query.NewAnd(&query.Substring{Pattern: "foo"}, &query.Ref{Pattern: "release"}), &api.Options{Repositories: []srcapi.RepoName{"foo"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, fm := range res.Files {
+		got = append(got, string(fm.Repository.Commit)+":"+fm.Path)
+	}
+	sort.Strings(got)
+	if want := []string{"c2:b.go", "c2:d.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestBranchIndex_evict(t *testing.T) {
+	d, err := ioutil.TempDir("", "branchindex_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(d)
+
+	x := &BranchIndex{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit srcapi.CommitID) (io.ReadCloser, error) {
+			return branchIndexTestTar(t, map[string]string{"main.go": "foo\n"}), nil
+		},
+		ListBranches: func(ctx context.Context, repo gitserver.Repo) (map[string]srcapi.CommitID, error) {
+			return map[string]srcapi.CommitID{"release": "c1"}, nil
+		},
+		IndexedBranch: func(name string) bool { return true },
+		Path:          d,
+	}
+	if err := x.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if err := x.Update(ctx, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	// Only one shard fits, so the least recently used shard is removed.
+	x.MaxSizeBytes = x.size + x.size/2
+	if err := x.Update(ctx, "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	if zf := x.zipFileAt("foo", "c1"); zf != nil {
+		zf.Close()
+		t.Error("expected the shard of foo to be evicted")
+	}
+	zf := x.zipFileAt("bar", "c1")
+	if zf == nil {
+		t.Fatal("expected the shard of bar to be kept")
+	}
+	if len(zf.Files) != 1 || zf.Files[0].Name != "main.go" {
+		t.Errorf("unexpected files %+v", zf.Files)
+	}
+	zf.Close()
+}
+
+func branchIndexTestTar(t *testing.T, files map[string]string) io.ReadCloser {
+	buf := new(bytes.Buffer)
+	w := tar.NewWriter(buf)
+	for name, body := range files {
+		hdr := &tar.Header{
+			Name: name,
+			Mode: 0600,
+			Size: int64(len(body)),
+		}
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return ioutil.NopCloser(bytes.NewReader(buf.Bytes()))
+}
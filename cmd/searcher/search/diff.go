@@ -0,0 +1,280 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/trace"
+
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/pathmatch"
+)
+
+// searchDiff searches the files changed between p.DiffBase and p.Commit.
+//
+// Files are searched in p.Commit, and line matches on added lines are marked
+// with DiffOp "+". Additionally the changed files are searched in p.DiffBase
+// to find matches on removed lines, which are marked with DiffOp "-".
+// Matches on unchanged lines of a changed file are returned unmarked.
+func (s *Service) searchDiff(ctx context.Context, tr trace.Trace, rg *readerGrep, p *protocol.Request, fetchTimeout time.Duration) (matches []protocol.FileMatch, limitHit bool, err error) {
+	if s.GitDiff == nil {
+		return nil, false, badRequestError{"diff search is not supported"}
+	}
+
+	out, err := s.GitDiff(ctx, p.GitserverRepo(), "--name-status", "-z", "--no-renames", string(p.DiffBase), string(p.Commit))
+	if err != nil {
+		return nil, false, errors.Wrap(err, "git diff --name-status")
+	}
+	statuses := parseDiffNameStatus(out)
+	tr.LazyPrintf("changed paths=%d", len(statuses))
+	if len(statuses) == 0 {
+		return nil, false, nil
+	}
+	var paths, headPaths, basePaths []string
+	pathSet := make(map[string]bool, len(statuses))
+	for _, st := range statuses {
+		paths = append(paths, st.path)
+		pathSet[st.path] = true
+		// Added files don't exist in the base, and deleted files don't
+		// exist in the head, so git archive would reject their paths.
+		if st.status != 'D' {
+			headPaths = append(headPaths, st.path)
+		}
+		if st.status != 'A' {
+			basePaths = append(basePaths, st.path)
+		}
+	}
+
+	out, err = s.GitDiff(ctx, p.GitserverRepo(), "-U0", "--no-color", "--no-ext-diff", "--no-renames", string(p.DiffBase), string(p.Commit))
+	if err != nil {
+		return nil, false, errors.Wrap(err, "git diff -U0")
+	}
+	changes, err := parseDiffHunks(out, paths)
+	if err != nil {
+		return nil, false, errors.Wrap(err, "parsing git diff -U0")
+	}
+
+	rg.matchPath = &pathSetMatcher{paths: pathSet, next: rg.matchPath}
+
+	// Only the changed files are fetched, unless there are so many of them
+	// that the whole archives are fetched (and filtered by rg.matchPath).
+	fetchAll := len(paths) > maxDiffFetchPaths
+	if fetchAll {
+		headPaths, basePaths = nil, nil
+	}
+	var head, base []protocol.FileMatch
+	var headLimitHit, baseLimitHit bool
+	if fetchAll || len(headPaths) > 0 {
+		head, headLimitHit, err = s.searchCommit(ctx, tr, rg, p, p.Commit, headPaths, fetchTimeout)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	if fetchAll || len(basePaths) > 0 {
+		base, baseLimitHit, err = s.searchCommit(ctx, tr, rg, p, p.DiffBase, basePaths, fetchTimeout)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	matches, limitHit = mergeDiffMatches(head, base, changes, p.FileMatchLimit)
+	return matches, limitHit || headLimitHit || baseLimitHit, nil
+}
+
+// maxDiffFetchPaths is the maximum number of changed files which are fetched
+// individually for a diff search. If more files changed, the whole archives are
+// fetched instead, which also avoids exceeding the limits on the length of the
+// git archive command line.
+const maxDiffFetchPaths = 1000
+
+// mergeDiffMatches marks the line matches in head and base using changes.
+// Only matches on removed lines are kept from base. The result contains at
+// most fileMatchLimit file matches.
+func mergeDiffMatches(head, base []protocol.FileMatch, changes map[string]*fileChanges, fileMatchLimit int) (matches []protocol.FileMatch, limitHit bool) {
+	if fileMatchLimit > maxFileMatches || fileMatchLimit <= 0 {
+		fileMatchLimit = maxFileMatches
+	}
+
+	byPath := make(map[string]int, len(head))
+	for _, fm := range head {
+		c := changes[fm.Path]
+		for i := range fm.LineMatches {
+			if c != nil && c.added[fm.LineMatches[i].LineNumber+1] {
+				fm.LineMatches[i].DiffOp = "+"
+			}
+		}
+		byPath[fm.Path] = len(matches)
+		matches = append(matches, fm)
+	}
+
+	for _, fm := range base {
+		c := changes[fm.Path]
+		if c == nil {
+			continue
+		}
+		var removed []protocol.LineMatch
+		for _, lm := range fm.LineMatches {
+			if c.removed[lm.LineNumber+1] {
+				lm.DiffOp = "-"
+				removed = append(removed, lm)
+			}
+		}
+		if len(removed) == 0 {
+			continue
+		}
+		if i, ok := byPath[fm.Path]; ok {
+			matches[i].LineMatches = append(matches[i].LineMatches, removed...)
+			matches[i].LimitHit = matches[i].LimitHit || fm.LimitHit
+			continue
+		}
+		fm.LineMatches = removed
+		byPath[fm.Path] = len(matches)
+		matches = append(matches, fm)
+	}
+
+	if len(matches) > fileMatchLimit {
+		matches = matches[:fileMatchLimit]
+		limitHit = true
+	}
+	return matches, limitHit
+}
+
+// fileChanges records the lines changed in a single file of a diff. Line
+// numbers are 1-based, as they appear in hunk headers.
+type fileChanges struct {
+	added   map[int]bool // line numbers in the new file
+	removed map[int]bool // line numbers in the old file
+}
+
+// A diffStatus is a changed path and its status letter (such as 'A' for
+// added, 'D' for deleted or 'M' for modified) in the output of
+// `git diff --name-status`.
+type diffStatus struct {
+	status byte
+	path   string
+}
+
+// parseDiffNameStatus parses the output of `git diff --name-status -z
+// --no-renames`, in which each status is followed by a path, and both are
+// terminated by NUL. Paths are not quoted. The paths are returned in the order
+// git diff lists them.
+func parseDiffNameStatus(out []byte) []diffStatus {
+	var statuses []diffStatus
+	fields := bytes.Split(out, []byte{0})
+	for i := 0; i+1 < len(fields); i += 2 {
+		if len(fields[i]) == 0 || len(fields[i+1]) == 0 {
+			continue
+		}
+		statuses = append(statuses, diffStatus{status: fields[i][0], path: string(fields[i+1])})
+	}
+	return statuses
+}
+
+// parseDiffHunks parses the output of `git diff -U0 --no-renames` into the
+// lines changed per file. paths are the changed paths in the order git diff
+// lists them (see parseDiffNameStatus): they are used instead of the paths in
+// the file headers, which git quotes if they contain unusual characters.
+//
+// Since there are no context lines, every hunk header describes exactly the
+// removed and added lines, and exactly that many lines of the hunk follow. They
+// are skipped, so that a changed line whose content looks like a header (such
+// as "+++ x" added or "--- x" removed) is never taken for one.
+//
+// An error is returned if a line is too long to be read, since the lines after
+// it would be missing.
+func parseDiffHunks(out []byte, paths []string) (map[string]*fileChanges, error) {
+	var (
+		changes = map[string]*fileChanges{}
+		file    = -1 // index in paths of the current file
+		c       *fileChanges
+		skip    int // number of lines of the current hunk left
+	)
+	s := bufio.NewScanner(bytes.NewReader(out))
+	s.Buffer(make([]byte, 64*1024), maxFileSize)
+	for s.Scan() {
+		line := s.Text()
+		if skip > 0 {
+			// "\ No newline at end of file" follows a line of the hunk
+			// without being counted.
+			if !strings.HasPrefix(line, "\\") {
+				skip--
+			}
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			file++
+			if file >= len(paths) {
+				return changes, nil
+			}
+			c = &fileChanges{added: map[int]bool{}, removed: map[int]bool{}}
+			changes[paths[file]] = c
+		case strings.HasPrefix(line, "@@ ") && c != nil:
+			oldStart, oldCount, newStart, newCount, ok := parseHunkHeader(line)
+			if !ok {
+				continue
+			}
+			for i := 0; i < oldCount; i++ {
+				c.removed[oldStart+i] = true
+			}
+			for i := 0; i < newCount; i++ {
+				c.added[newStart+i] = true
+			}
+			skip = oldCount + newCount
+		}
+	}
+	return changes, s.Err()
+}
+
+// parseHunkHeader parses a hunk header of the form
+// "@@ -oldStart[,oldCount] +newStart[,newCount] @@".
+func parseHunkHeader(line string) (oldStart, oldCount, newStart, newCount int, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 4 || fields[0] != "@@" || fields[3] != "@@" {
+		return 0, 0, 0, 0, false
+	}
+	parseRange := func(s, prefix string) (start, count int, ok bool) {
+		if !strings.HasPrefix(s, prefix) {
+			return 0, 0, false
+		}
+		s = s[len(prefix):]
+		count = 1
+		if i := strings.IndexByte(s, ','); i >= 0 {
+			var err error
+			if count, err = strconv.Atoi(s[i+1:]); err != nil {
+				return 0, 0, false
+			}
+			s = s[:i]
+		}
+		start, err := strconv.Atoi(s)
+		return start, count, err == nil
+	}
+	oldStart, oldCount, ok1 := parseRange(fields[1], "-")
+	newStart, newCount, ok2 := parseRange(fields[2], "+")
+	return oldStart, oldCount, newStart, newCount, ok1 && ok2
+}
+
+// pathSetMatcher is a pathmatch.PathMatcher which only matches paths in
+// paths which also match next.
+type pathSetMatcher struct {
+	paths map[string]bool
+	next  pathmatch.PathMatcher
+}
+
+func (m *pathSetMatcher) MatchPath(path string) bool {
+	return m.paths[path] && m.next.MatchPath(path)
+}
+
+func (m *pathSetMatcher) Copy() pathmatch.PathMatcher {
+	return &pathSetMatcher{paths: m.paths, next: m.next.Copy()}
+}
+
+func (m *pathSetMatcher) String() string {
+	return fmt.Sprintf("diff(%d paths) && %s", len(m.paths), m.next.String())
+}
@@ -0,0 +1,127 @@
+package search
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+)
+
+func TestParseDiffNameStatus(t *testing.T) {
+	got := parseDiffNameStatus([]byte("M\x00a.go\x00A\x00dir/with space.go\x00D\x00caf\xc3\xa9.go\x00"))
+	want := []diffStatus{{'M', "a.go"}, {'A', "dir/with space.go"}, {'D', "café.go"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestParseDiffHunks(t *testing.T) {
+	out := []byte(`diff --git a/a.go b/a.go
+index 1111111..2222222 100644
+--- a/a.go
++++ b/a.go
+@@ -3 +3,2 @@ func a() {
+-	foo()
++	bar()
++	baz()
+@@ -10,0 +12 @@ func b() {
++	qux()
+diff --git a/b.go b/b.go
+deleted file mode 100644
+index 3333333..0000000
+--- a/b.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package b
+-
+diff --git a/c.go b/c.go
+new file mode 100644
+index 0000000..4444444
+--- /dev/null
++++ b/c.go
+@@ -0,0 +1 @@
++package c
+diff --git "a/caf\303\251.md" "b/caf\303\251.md"
+index 5555555..6666666 100644
+--- "a/caf\303\251.md"
++++ "b/caf\303\251.md"
+@@ -1,2 +1,2 @@
+--- x
+-diff --git a/d b/d
+\ No newline at end of file
++++ y
++@@ -1 +1 @@
+\ No newline at end of file
+`)
+	got, err := parseDiffHunks(out, []string{"a.go", "b.go", "c.go", "café.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*fileChanges{
+		"a.go":    {added: map[int]bool{3: true, 4: true, 12: true}, removed: map[int]bool{3: true}},
+		"b.go":    {added: map[int]bool{}, removed: map[int]bool{1: true, 2: true}},
+		"c.go":    {added: map[int]bool{1: true}, removed: map[int]bool{}},
+		"café.md": {added: map[int]bool{1: true, 2: true}, removed: map[int]bool{1: true, 2: true}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		for path, c := range got {
+			t.Logf("%s: %+v", path, *c)
+		}
+		t.Fatal("unexpected changes")
+	}
+
+	// A line which is too long to be read fails the parse instead of
+	// truncating the diff.
+	long := "diff --git a/a.go b/a.go\n@@ -1 +1 @@\n-" + strings.Repeat("x", maxFileSize) + "\n+y\n"
+	if _, err := parseDiffHunks([]byte(long), []string{"a.go"}); err == nil {
+		t.Error("got no error for a line longer than maxFileSize")
+	}
+}
+
+func TestMergeDiffMatches(t *testing.T) {
+	changes := map[string]*fileChanges{
+		"a.go": {added: map[int]bool{3: true}, removed: map[int]bool{3: true}},
+		"b.go": {added: map[int]bool{}, removed: map[int]bool{1: true}},
+	}
+	head := []protocol.FileMatch{{
+		Path: "a.go",
+		LineMatches: []protocol.LineMatch{
+			{Preview: "func a() {", LineNumber: 1},
+			{Preview: "	bar()", LineNumber: 2},
+		},
+	}}
+	base := []protocol.FileMatch{{
+		Path: "a.go",
+		LineMatches: []protocol.LineMatch{
+			{Preview: "func a() {", LineNumber: 1},
+			{Preview: "	foo()", LineNumber: 2},
+		},
+	}, {
+		Path:        "b.go",
+		LineMatches: []protocol.LineMatch{{Preview: "package b", LineNumber: 0}},
+	}}
+
+	got, limitHit := mergeDiffMatches(head, base, changes, 0)
+	want := []protocol.FileMatch{{
+		Path: "a.go",
+		LineMatches: []protocol.LineMatch{
+			{Preview: "func a() {", LineNumber: 1},
+			{Preview: "	bar()", LineNumber: 2, DiffOp: "+"},
+			{Preview: "	foo()", LineNumber: 2, DiffOp: "-"},
+		},
+	}, {
+		Path:        "b.go",
+		LineMatches: []protocol.LineMatch{{Preview: "package b", LineNumber: 0, DiffOp: "-"}},
+	}}
+	if limitHit {
+		t.Error("unexpected limitHit")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got, limitHit := mergeDiffMatches(head, base, changes, 1); len(got) != 1 || !limitHit {
+		t.Errorf("got %d matches (limitHit=%v), want 1 (limitHit=true)", len(got), limitHit)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"math"
 	"regexp"
 	"sort"
 
@@ -112,7 +113,7 @@ func (s *StoreSearcher) Search(ctx context.Context, q query.Q, opts *api.Options
 
 	q = query.Map(q, nil, query.ExpandFileContent)
 
-	mt, err := newMatchTree(q)
+	mt, err := newMatchTree(q, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -140,6 +141,23 @@ func (s *StoreSearcher) Search(ctx context.Context, q query.Q, opts *api.Options
 	}
 	defer zf.Close()
 
+	res.Files, status, err = searchZipFile(ctx, zf, mt, repo, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Stats = api.Stats{
+		MatchCount: matchCount(res.Files),
+		Status:     []api.RepositoryStatus{{Repository: repo, Source: source, Status: status}},
+	}
+
+	return &res, nil
+}
+
+// searchZipFile returns the files in zf which match mt, and the status of
+// searching repo (the repository zf is an archive of).
+func searchZipFile(ctx context.Context, zf *zipFile, mt matchtree.MatchTree, repo api.Repository, opts *api.Options) (files []api.FileMatch, status api.RepositoryStatusType, err error) {
+	status = api.RepositoryStatusSearched
 	cp := &contentProvider{zf: zf}
 	minDoc := uint32(0)
 	maxDoc := cp.docCount()
@@ -175,31 +193,25 @@ nextFileMatch:
 			}
 
 			if cost == costMax && !ok {
-				return nil, errors.Errorf("did not decide. doc %d, known %v", nextDoc, known)
+				return nil, status, errors.Errorf("did not decide. doc %d, known %v", nextDoc, known)
 			}
 		}
 
 		candidates := gatherMatches(mt, known)
 		matches := cp.fillMatches(candidates)
 
-		res.Files = append(res.Files, api.FileMatch{
+		files = append(files, api.FileMatch{
 			Path:        cp.file.Name,
 			Repository:  repo,
 			LineMatches: matches,
 		})
 
-		if opts.TotalMaxMatchCount > 0 && len(res.Files) > opts.TotalMaxMatchCount {
+		if opts.TotalMaxMatchCount > 0 && len(files) > opts.TotalMaxMatchCount {
 			status = api.RepositoryStatusLimitHit
 			break
 		}
 	}
-
-	res.Stats = api.Stats{
-		MatchCount: matchCount(res.Files),
-		Status:     []api.RepositoryStatus{{Repository: repo, Source: source, Status: status}},
-	}
-
-	return &res, nil
+	return files, status, nil
 }
 
 // Close implements pkg/search.Close
@@ -345,7 +357,34 @@ func (t *substrMatchTree) Matches(cp matchtree.ContentProvider, cost int, known
 	return len(t.found) > 0, true
 }
 
-func newMatchTree(q query.Q) (matchtree.MatchTree, error) {
+// indexedSubstrMatchTree is a substrMatchTree for file contents which only
+// visits the documents that may contain the substring according to a
+// trigramIndex.
+type indexedSubstrMatchTree struct {
+	*substrMatchTree
+
+	// docs are the remaining candidate documents, in increasing order.
+	docs []uint32
+}
+
+func (t *indexedSubstrMatchTree) NextDoc() uint32 {
+	if len(t.docs) == 0 {
+		return math.MaxUint32
+	}
+	return t.docs[0]
+}
+
+func (t *indexedSubstrMatchTree) Prepare(doc uint32) {
+	for len(t.docs) > 0 && t.docs[0] <= doc {
+		t.docs = t.docs[1:]
+	}
+	t.substrMatchTree.Prepare(doc)
+}
+
+// newMatchTree returns the match tree for q. If idx is non-nil, it is the
+// trigram index of the documents to search, which is used to skip the
+// documents which can't contain a content substring.
+func newMatchTree(q query.Q, idx *trigramIndex) (matchtree.MatchTree, error) {
 	atom := func(q query.Q) (matchtree.MatchTree, error) {
 		switch s := q.(type) {
 		case *query.Regexp:
@@ -358,7 +397,7 @@ func newMatchTree(q query.Q) (matchtree.MatchTree, error) {
 				return q
 			})
 
-			subMT, err := newMatchTree(subQ)
+			subMT, err := newMatchTree(subQ, idx)
 			if err != nil {
 				return nil, err
 			}
@@ -383,11 +422,17 @@ func newMatchTree(q query.Q) (matchtree.MatchTree, error) {
 			if len(b) == 0 {
 				return nil, errors.Errorf("expected non-empty substring query")
 			}
-			return &substrMatchTree{
+			st := &substrMatchTree{
 				needle:        b,
 				caseSensitive: s.CaseSensitive,
 				fileName:      s.FileName,
-			}, nil
+			}
+			if idx != nil && !s.FileName {
+				if docs, ok := idx.candidates(b); ok {
+					return &indexedSubstrMatchTree{substrMatchTree: st, docs: docs}, nil
+				}
+			}
+			return st, nil
 
 		}
 		return nil, errors.Errorf("unexpected query atom %T: %v", q, q)
@@ -491,6 +536,9 @@ func gatherMatches(mt matchtree.MatchTree, known map[matchtree.MatchTree]bool) [
 		if smt, ok := mt.(*substrMatchTree); ok {
 			cands = append(cands, smt.found...)
 		}
+		if imt, ok := mt.(*indexedSubstrMatchTree); ok {
+			cands = append(cands, imt.found...)
+		}
 		if rmt, ok := mt.(*regexpMatchTree); ok {
 			cands = append(cands, rmt.found...)
 		}
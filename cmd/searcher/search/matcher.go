@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"math"
 	"regexp"
 	"regexp/syntax"
 	"strings"
@@ -86,6 +87,15 @@ type readerGrep struct {
 	// re. It is the output of the longestLiteral function. It is only set if
 	// the regex has an empty LiteralPrefix.
 	literalSubstring []byte
+
+	// trigramLiteral is the output of the longestLiteral function. Unlike
+	// literalSubstring it is always set, since it is used to look up
+	// candidate files in a zipFile's trigram index.
+	trigramLiteral []byte
+
+	// countOnly if true means FindZip only counts the matches in a file
+	// (see protocol.PatternInfo.CountOnly).
+	countOnly bool
 }
 
 // compile returns a readerGrep for matching p.
@@ -93,6 +103,7 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 	var (
 		re               *regexp.Regexp
 		literalSubstring []byte
+		trigramLiteral   []byte
 	)
 	if p.Pattern != "" {
 		expr := p.Pattern
@@ -125,15 +136,16 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 			return nil, err
 		}
 
+		ast, err := syntax.Parse(expr, syntax.Perl)
+		if err != nil {
+			return nil, err
+		}
+		trigramLiteral = []byte(longestLiteral(ast.Simplify()))
+
 		// Only use literalSubstring optimization if the regex engine doesn't
 		// have a prefix to use.
 		if pre, _ := re.LiteralPrefix(); pre == "" {
-			ast, err := syntax.Parse(expr, syntax.Perl)
-			if err != nil {
-				return nil, err
-			}
-			ast = ast.Simplify()
-			literalSubstring = []byte(longestLiteral(ast))
+			literalSubstring = trigramLiteral
 		}
 	}
 
@@ -151,6 +163,8 @@ func compile(p *protocol.PatternInfo) (*readerGrep, error) {
 		ignoreCase:       !p.IsCaseSensitive,
 		matchPath:        matchPath,
 		literalSubstring: literalSubstring,
+		trigramLiteral:   trigramLiteral,
+		countOnly:        p.CountOnly,
 	}, nil
 }
 
@@ -166,6 +180,8 @@ func (rg *readerGrep) Copy() *readerGrep {
 		ignoreCase:       rg.ignoreCase,
 		matchPath:        rg.matchPath.Copy(),
 		literalSubstring: rg.literalSubstring,
+		trigramLiteral:   rg.trigramLiteral,
+		countOnly:        rg.countOnly,
 	}
 }
 
@@ -185,37 +201,8 @@ func (rg *readerGrep) matchString(s string) bool {
 // LimitHit is true if some matches may not have been included in the result.
 // NOTE: This is not safe to use concurrently.
 func (rg *readerGrep) Find(zf *zipFile, f *srcFile) (matches []protocol.LineMatch, limitHit bool, err error) {
-	if rg.ignoreCase && rg.transformBuf == nil {
-		rg.transformBuf = make([]byte, zf.MaxLen)
-	}
-
-	// fileMatchBuf is what we run match on, fileBuf is the original
-	// data (for Preview).
-	fileBuf := zf.DataFor(f)
-	fileMatchBuf := fileBuf
-
-	// If we are ignoring case, we transform the input instead of
-	// relying on the regular expression engine which can be
-	// slow. compile has already lowercased the pattern. We also
-	// trade some correctness for perf by using a non-utf8 aware
-	// lowercase function.
-	if rg.ignoreCase {
-		fileMatchBuf = rg.transformBuf[:len(fileBuf)]
-		bytesToLowerASCII(fileMatchBuf, fileBuf)
-	}
-
-	// Most files will not have a match and we bound the number of matched
-	// files we return. So we can avoid the overhead of parsing out new lines
-	// and repeatedly running the regex engine by running a single match over
-	// the whole file. This does mean we duplicate work when actually
-	// searching for results. We use the same approach when we search
-	// per-line. Additionally if we have a non-empty literalSubstring, we use
-	// that to prune out files since doing bytes.Index is very fast.
-	if !bytes.Contains(fileMatchBuf, rg.literalSubstring) {
-		return nil, false, nil
-	}
-	first := rg.re.FindIndex(fileMatchBuf)
-	if first == nil {
+	fileBuf, fileMatchBuf, first := rg.prepare(zf, f)
+	if first < 0 {
 		return nil, false, nil
 	}
 
@@ -241,7 +228,7 @@ func (rg *readerGrep) Find(zf *zipFile, f *srcFile) (matches []protocol.LineMatc
 
 		// Check whether we're before the first match.
 		idx += advance
-		if idx < first[0] {
+		if idx < first {
 			continue
 		}
 
@@ -278,8 +265,87 @@ func (rg *readerGrep) Find(zf *zipFile, f *srcFile) (matches []protocol.LineMatc
 	return matches, limitHit, nil
 }
 
-// FindZip is a convenience function to run Find on f.
+// Count returns the number of matches of rg in f. Unlike Find it counts all
+// matches, since it does not need to return them. Like Find it does not count
+// matches on lines longer than maxLineSize.
+// NOTE: This is not safe to use concurrently.
+func (rg *readerGrep) Count(zf *zipFile, f *srcFile) (int, error) {
+	_, fileMatchBuf, first := rg.prepare(zf, f)
+	if first < 0 {
+		return 0, nil
+	}
+
+	count, idx := 0, 0
+	for {
+		advance, lineBuf, err := bufio.ScanLines(fileMatchBuf, true)
+		if err != nil {
+			// ScanLines should never return an err
+			return 0, err
+		}
+		if advance == 0 { // EOF
+			break
+		}
+		fileMatchBuf = fileMatchBuf[advance:]
+
+		idx += advance
+		if idx < first || len(lineBuf) > maxLineSize {
+			continue
+		}
+		count += len(rg.re.FindAllIndex(lineBuf, -1))
+	}
+	return count, nil
+}
+
+// prepare returns the contents of f (fileBuf) and what we run the match on
+// (fileMatchBuf), which differ if we are ignoring case. first is the offset
+// of the first match in fileMatchBuf, or -1 if f does not match.
+func (rg *readerGrep) prepare(zf *zipFile, f *srcFile) (fileBuf, fileMatchBuf []byte, first int) {
+	if rg.ignoreCase && rg.transformBuf == nil {
+		rg.transformBuf = make([]byte, zf.MaxLen)
+	}
+
+	// fileMatchBuf is what we run match on, fileBuf is the original
+	// data (for Preview).
+	fileBuf = zf.DataFor(f)
+	fileMatchBuf = fileBuf
+
+	// If we are ignoring case, we transform the input instead of
+	// relying on the regular expression engine which can be
+	// slow. compile has already lowercased the pattern. We also
+	// trade some correctness for perf by using a non-utf8 aware
+	// lowercase function.
+	if rg.ignoreCase {
+		fileMatchBuf = rg.transformBuf[:len(fileBuf)]
+		bytesToLowerASCII(fileMatchBuf, fileBuf)
+	}
+
+	// Most files will not have a match and we bound the number of matched
+	// files we return. So we can avoid the overhead of parsing out new lines
+	// and repeatedly running the regex engine by running a single match over
+	// the whole file. This does mean we duplicate work when actually
+	// searching for results. We use the same approach when we search
+	// per-line. Additionally if we have a non-empty literalSubstring, we use
+	// that to prune out files since doing bytes.Index is very fast.
+	if !bytes.Contains(fileMatchBuf, rg.literalSubstring) {
+		return nil, nil, -1
+	}
+	loc := rg.re.FindIndex(fileMatchBuf)
+	if loc == nil {
+		return nil, nil, -1
+	}
+	return fileBuf, fileMatchBuf, loc[0]
+}
+
+// FindZip is a convenience function to run Find on f. If rg only counts
+// matches, it runs Count instead.
 func (rg *readerGrep) FindZip(zf *zipFile, f *srcFile) (protocol.FileMatch, error) {
+	if rg.countOnly {
+		count, err := rg.Count(zf, f)
+		return protocol.FileMatch{
+			Path:       f.Name,
+			MatchCount: count,
+		}, err
+	}
 	lm, limitHit, err := rg.Find(zf, f)
 	return protocol.FileMatch{
 		Path:        f.Name,
@@ -308,7 +374,11 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *zipFile, fileMatchL
 		patternMatchesContent = true
 	}
 
-	if fileMatchLimit > maxFileMatches || fileMatchLimit <= 0 {
+	if rg.countOnly {
+		// Counting is cheap enough to do for every matching file, and the
+		// counts are only exact if we do.
+		fileMatchLimit = math.MaxInt32
+	} else if fileMatchLimit > maxFileMatches || fileMatchLimit <= 0 {
 		fileMatchLimit = maxFileMatches
 	}
 
@@ -331,6 +401,15 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *zipFile, fileMatchL
 		matches   = []protocol.FileMatch{}
 	)
 
+	// If only file contents can match, we can use the trigram index (if
+	// built) to skip files which can't contain a match.
+	if !patternMatchesPaths && len(rg.trigramLiteral) >= 3 {
+		if candidates, ok := zf.trigramCandidates(rg.trigramLiteral); ok {
+			span.LogFields(otlog.Int("trigramCandidates", len(candidates)))
+			files = candidates
+		}
+	}
+
 	if patternMatchesPaths && (!patternMatchesContent || rg.re == nil) {
 		// Fast path for only matching file paths (or with a nil pattern, which matches all files,
 		// so is effectively matching only on file paths).
@@ -397,7 +476,7 @@ func concurrentFind(ctx context.Context, rg *readerGrep, zf *zipFile, fileMatchL
 					})
 					return
 				}
-				match := len(fm.LineMatches) > 0
+				match := len(fm.LineMatches) > 0 || fm.MatchCount > 0
 				if !match && patternMatchesPaths {
 					// Try matching against the file path.
 					match = rg.matchString(f.Name)
@@ -10,6 +10,7 @@ import (
 	"regexp/syntax"
 	"sort"
 	"strconv"
+	"strings"
 	"testing"
 	"testing/iotest"
 	"testing/quick"
@@ -529,6 +530,55 @@ func TestMaxMatches(t *testing.T) {
 	}
 }
 
+// Tests that counting matches ignores all our limits.
+func TestCountOnly(t *testing.T) {
+	pattern := "foo"
+	line := strings.Repeat(pattern+" ", maxOffsets+1) + "\n"
+	files := map[string]string{
+		"long": strings.Repeat("Foo", maxLineSize) + "\n" + pattern + "\n",
+		"none": "bar\n",
+	}
+	for i := 0; i < maxFileMatches+1; i++ {
+		files[strconv.Itoa(i)] = strings.Repeat(line, maxLineMatches+1)
+	}
+	zipData, err := createZip(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zf, err := mockZipFile(zipData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rg, err := compile(&protocol.PatternInfo{Pattern: pattern, CountOnly: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileMatches, limitHit, err := concurrentFind(context.Background(), rg, zf, 10, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if limitHit {
+		t.Fatalf("expected no limitHit on concurrentFind")
+	}
+	if len(fileMatches) != maxFileMatches+2 {
+		t.Fatalf("expected %d file matches, got %d", maxFileMatches+2, len(fileMatches))
+	}
+	for _, fm := range fileMatches {
+		want := (maxLineMatches + 1) * (maxOffsets + 1)
+		if fm.Path == "long" {
+			// Lines longer than maxLineSize are not searched.
+			want = 1
+		}
+		if fm.MatchCount != want {
+			t.Fatalf("%s: expected %d matches, got %d", fm.Path, want, fm.MatchCount)
+		}
+		if len(fm.LineMatches) != 0 || fm.LimitHit {
+			t.Fatalf("%s: expected only a match count, got %+v", fm.Path, fm)
+		}
+	}
+}
+
 // Tests that:
 //
 // - IncludePatterns can match the path in any order
@@ -24,6 +24,8 @@ import (
 	log15 "gopkg.in/inconshreveable/log15.v2"
 
 	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
 
 	"github.com/pkg/errors"
 
@@ -38,6 +40,15 @@ import (
 type Service struct {
 	Store *Store
 	Log   log15.Logger
+
+	// GitDiff returns the output of running `git diff` with args in repo. It
+	// is used to find the files changed for diff searches (see
+	// protocol.Request.DiffBase). If nil, diff searches are rejected.
+	GitDiff func(ctx context.Context, repo gitserver.Repo, args ...string) ([]byte, error)
+
+	// BranchIndex, if non-nil, is searched instead of fetching an archive
+	// when the commit to search is that of an indexed branch.
+	BranchIndex *BranchIndex
 }
 
 var decoder = schema.NewDecoder()
@@ -133,6 +144,7 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	span.SetTag("fileMatchLimit", p.FileMatchLimit)
 	span.SetTag("patternMatchesContent", p.PatternMatchesContent)
 	span.SetTag("patternMatchesPath", p.PatternMatchesPath)
+	span.SetTag("countOnly", p.CountOnly)
 	span.SetTag("deadline", p.Deadline)
 	defer func(start time.Time) {
 		code := "200"
@@ -183,29 +195,57 @@ func (s *Service) search(ctx context.Context, p *protocol.Request) (matches []pr
 	if err != nil {
 		return nil, false, false, err
 	}
-	prepareCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
-	defer cancel()
-	path, err := s.Store.prepareZip(prepareCtx, p.GitserverRepo(), p.Commit)
-	if err != nil {
-		return nil, false, false, err
+	if p.DiffBase != "" {
+		tr.LazyPrintf("diffBase=%s", p.DiffBase)
+		span.SetTag("diffBase", p.DiffBase)
+		matches, limitHit, err = s.searchDiff(ctx, tr, rg, p, fetchTimeout)
+		return matches, limitHit, false, err
 	}
-	zf, err := s.Store.zipCache.get(path)
-	if err != nil {
-		return nil, false, false, err
+	matches, limitHit, err = s.searchCommit(ctx, tr, rg, p, p.Commit, nil, fetchTimeout)
+	return matches, limitHit, false, err
+}
+
+// searchCommit searches the archive of p.Repo at commit with rg. If paths is
+// non-empty, only the files at paths are fetched.
+func (s *Service) searchCommit(ctx context.Context, tr trace.Trace, rg *readerGrep, p *protocol.Request, commit api.CommitID, paths []string, fetchTimeout time.Duration) (matches []protocol.FileMatch, limitHit bool, err error) {
+	var zf *zipFile
+	if s.BranchIndex != nil && paths == nil {
+		zf = s.BranchIndex.zipFileAt(p.Repo, commit)
+	}
+	if zf != nil {
+		tr.LazyPrintf("branch index")
+	} else {
+		prepareCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+		defer cancel()
+		path, err := s.Store.prepareZipPaths(prepareCtx, p.GitserverRepo(), commit, paths)
+		if err != nil {
+			return nil, false, err
+		}
+		zf, err = s.Store.zipCache.get(path)
+		if err != nil {
+			return nil, false, err
+		}
 	}
 	defer zf.Close()
 
 	nFiles := uint64(len(zf.Files))
 	bytes := int64(len(zf.Data))
 	tr.LazyPrintf("files=%d bytes=%d", nFiles, bytes)
-	span.LogFields(
+	opentracing.SpanFromContext(ctx).LogFields(
 		otlog.Uint64("archive.files", nFiles),
 		otlog.Int64("archive.size", bytes))
 	archiveFiles.Observe(float64(nFiles))
 	archiveSize.Observe(float64(bytes))
 
+	cache := "cold"
+	if s.Store.TrigramIndex && zf.maybeIndexTrigrams() {
+		cache = "warm"
+	}
+	tr.LazyPrintf("trigram cache=%s", cache)
+	start := time.Now()
 	matches, limitHit, err = concurrentFind(ctx, rg, zf, p.FileMatchLimit, p.PatternMatchesContent, p.PatternMatchesPath)
-	return matches, limitHit, false, err
+	trigramSearchDuration.WithLabelValues(cache).Observe(time.Since(start).Seconds())
+	return matches, limitHit, err
 }
 
 func validateParams(p *protocol.Request) error {
@@ -216,6 +256,13 @@ func validateParams(p *protocol.Request) error {
 	if len(p.Commit) != 40 {
 		return errors.Errorf("Commit must be resolved (Commit=%q)", p.Commit)
 	}
+	if p.DiffBase != "" && len(p.DiffBase) != 40 {
+		return errors.Errorf("DiffBase must be resolved (DiffBase=%q)", p.DiffBase)
+	}
+	if p.CountOnly && p.DiffBase != "" {
+		// Diff searches need the line matches to find the changed lines.
+		return errors.New("CountOnly may not be used with DiffBase")
+	}
 	if p.Pattern == "" && p.ExcludePattern == "" && len(p.IncludePatterns) == 0 && p.IncludePattern == "" {
 		return errors.New("At least one of pattern and include/exclude pattners must be non-empty")
 	}
@@ -9,6 +9,8 @@ import (
 	"encoding/hex"
 	"io"
 	"log"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -45,6 +47,11 @@ type Store struct {
 	// determine if the error is a bad request (eg invalid repo).
 	FetchTar func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error)
 
+	// FetchTarPaths is like FetchTar, but the archive only contains the files
+	// at paths. If nil, archives of paths are not fetched and the whole
+	// archive is used instead.
+	FetchTarPaths func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error)
+
 	// Path is the directory to store the cache
 	Path string
 
@@ -54,6 +61,11 @@ type Store struct {
 	// MaxCacheSizeBytes.
 	MaxCacheSizeBytes int64
 
+	// TrigramIndex enables building a trigram index for archives which are
+	// searched repeatedly. The index is kept in memory alongside the zip
+	// file, and is used to skip files which can't contain a match.
+	TrigramIndex bool
+
 	// once protects Start
 	once sync.Once
 
@@ -101,6 +113,13 @@ func (s *Store) Start() {
 // prepareZip returns the path to a local zip archive of repo at commit.
 // It will first consult the local cache, otherwise will fetch from the network.
 func (s *Store) prepareZip(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (path string, err error) {
+	return s.prepareZipPaths(ctx, repo, commit, nil)
+}
+
+// prepareZipPaths is like prepareZip, but if paths is non-empty the archive
+// only contains the files at paths (if FetchTarPaths is set). Such archives
+// are cached separately from the whole archive.
+func (s *Store) prepareZipPaths(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (path string, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "Store.prepareZip")
 	ext.Component.Set(span, "store")
 	defer func() {
@@ -120,8 +139,18 @@ func (s *Store) prepareZip(ctx context.Context, repo gitserver.Repo, commit api.
 		return "", errors.Errorf("commit must be resolved (repo=%q, commit=%q)", repo.Name, commit)
 	}
 
+	if s.FetchTarPaths == nil {
+		paths = nil
+	}
+
 	// key is a sha256 hash since we want to use it for the disk name
-	h := sha256.Sum256([]byte(string(repo.Name) + " " + string(commit)))
+	keyText := string(repo.Name) + " " + string(commit)
+	if len(paths) > 0 {
+		sorted := append([]string(nil), paths...)
+		sort.Strings(sorted)
+		keyText += " " + strings.Join(sorted, "\x00")
+	}
+	h := sha256.Sum256([]byte(keyText))
 	key := hex.EncodeToString(h[:])
 	span.LogKV("key", key)
 
@@ -137,7 +166,7 @@ func (s *Store) prepareZip(ctx context.Context, repo gitserver.Repo, commit api.
 		// since we're just going to close it again immediately.
 		bgctx := opentracing.ContextWithSpan(context.Background(), opentracing.SpanFromContext(ctx))
 		f, err := s.cache.Open(bgctx, key, func(ctx context.Context) (io.ReadCloser, error) {
-			return s.fetch(ctx, repo, commit)
+			return s.fetch(ctx, repo, commit, paths)
 		})
 		var path string
 		if f != nil {
@@ -161,10 +190,10 @@ func (s *Store) prepareZip(ctx context.Context, repo gitserver.Repo, commit api.
 	}
 }
 
-// fetch fetches an archive from the network and stores it on disk. It does
-// not populate the in-memory cache. You should probably be calling
-// prepareZip.
-func (s *Store) fetch(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (rc io.ReadCloser, err error) {
+// fetch fetches an archive from the network and stores it on disk. If paths
+// is non-empty, the archive only contains the files at paths. It does not
+// populate the in-memory cache. You should probably be calling prepareZip.
+func (s *Store) fetch(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (rc io.ReadCloser, err error) {
 	fetchQueueSize.Inc()
 	ctx, releaseFetchLimiter, err := s.fetchLimiter.Acquire(ctx) // Acquire concurrent fetches semaphore
 	if err != nil {
@@ -182,6 +211,7 @@ func (s *Store) fetch(ctx context.Context, repo gitserver.Repo, commit api.Commi
 	span.SetTag("repo", repo.Name)
 	span.SetTag("repoURL", repo.URL)
 	span.SetTag("commit", commit)
+	span.SetTag("paths", len(paths))
 
 	// Done is called when the returned reader is closed, or if this function
 	// returns an error. It should always be called once.
@@ -208,7 +238,12 @@ func (s *Store) fetch(ctx context.Context, repo gitserver.Repo, commit api.Commi
 		}
 	}()
 
-	r, err := s.FetchTar(ctx, repo, commit)
+	var r io.ReadCloser
+	if len(paths) > 0 {
+		r, err = s.FetchTarPaths(ctx, repo, commit, paths)
+	} else {
+		r, err = s.FetchTar(ctx, repo, commit)
+	}
 	if err != nil {
 		return nil, err
 	}
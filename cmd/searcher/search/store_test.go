@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"reflect"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -82,6 +83,34 @@ func TestPrepareZip(t *testing.T) {
 	}
 }
 
+func TestPrepareZipPaths(t *testing.T) {
+	s, cleanup := tmpStore(t)
+	defer cleanup()
+
+	var gotPaths [][]string
+	s.FetchTar = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+		gotPaths = append(gotPaths, nil)
+		return emptyTar(t), nil
+	}
+	s.FetchTarPaths = func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+		gotPaths = append(gotPaths, paths)
+		return emptyTar(t), nil
+	}
+
+	repo := gitserver.Repo{Name: "foo"}
+	commit := api.CommitID("deadbeefdeadbeefdeadbeefdeadbeefdeadbeef")
+	for _, paths := range [][]string{{"b", "a"}, {"a", "b"}, nil} {
+		if _, err := s.prepareZipPaths(context.Background(), repo, commit, paths); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The archive of the same paths in another order is cached, and the whole
+	// archive is cached separately.
+	if want := [][]string{{"b", "a"}, nil}; !reflect.DeepEqual(gotPaths, want) {
+		t.Errorf("got fetches of %q, want %q", gotPaths, want)
+	}
+}
+
 func TestPrepareZip_fetchTarFail(t *testing.T) {
 	fetchErr := errors.New("test")
 	s, cleanup := tmpStore(t)
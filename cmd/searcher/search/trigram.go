@@ -0,0 +1,220 @@
+package search
+
+import (
+	"encoding/binary"
+	"log"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// A trigramIndex maps every trigram found in the files of a zipFile to the
+// list of files containing it. It lets us skip files which cannot contain a
+// literal required by a pattern, which avoids scanning most of an archive on
+// repeated searches.
+//
+// The index is built over the ASCII lowercased contents of each file, so it
+// can be consulted for both case sensitive and case insensitive searches. For
+// case sensitive searches this means we may consider more files than
+// necessary, but never fewer.
+type trigramIndex struct {
+	// postings maps a trigram to the sorted list of indexes into
+	// zipFile.Files which contain it. To keep the index compact each list is
+	// encoded as a sequence of uvarint deltas.
+	postings map[trigram][]byte
+}
+
+// trigram is three consecutive bytes packed into the low 24 bits.
+type trigram uint32
+
+func newTrigram(b []byte) trigram {
+	return trigram(b[0])<<16 | trigram(b[1])<<8 | trigram(b[2])
+}
+
+// buildTrigramIndex builds a trigramIndex over the contents of zf.
+func buildTrigramIndex(zf *zipFile) *trigramIndex {
+	var (
+		postings = make(map[trigram][]byte)
+		// last is the most recent file (plus one) appended to each posting
+		// list. We use it to encode deltas and to avoid adding a file twice.
+		last   = make(map[trigram]uint32)
+		varint [binary.MaxVarintLen32]byte
+		buf    []byte
+	)
+	for i := range zf.Files {
+		data := zf.DataFor(&zf.Files[i])
+		if len(data) < 3 {
+			continue
+		}
+		if cap(buf) < len(data) {
+			buf = make([]byte, len(data))
+		}
+		lower := buf[:len(data)]
+		bytesToLowerASCII(lower, data)
+
+		doc := uint32(i) + 1
+		for j := 0; j+3 <= len(lower); j++ {
+			t := newTrigram(lower[j : j+3])
+			prev := last[t]
+			if prev == doc {
+				continue
+			}
+			n := binary.PutUvarint(varint[:], uint64(doc-prev))
+			postings[t] = append(postings[t], varint[:n]...)
+			last[t] = doc
+		}
+	}
+	return &trigramIndex{postings: postings}
+}
+
+// candidates returns the indexes into zipFile.Files which may contain
+// literal. ok is false if the index can't be used to narrow down the files
+// for literal, in which case every file needs to be searched.
+func (idx *trigramIndex) candidates(literal []byte) (docs []uint32, ok bool) {
+	lower := make([]byte, len(literal))
+	bytesToLowerASCII(lower, literal)
+
+	for j := 0; j+3 <= len(lower); j++ {
+		// The literal may have been lowercased with unicode rules while the
+		// index only lowercases ASCII, so we can't rely on trigrams which
+		// contain non-ASCII bytes.
+		if lower[j] >= 0x80 || lower[j+1] >= 0x80 || lower[j+2] >= 0x80 {
+			continue
+		}
+		postings, present := idx.postings[newTrigram(lower[j:j+3])]
+		if !present {
+			return nil, true
+		}
+		if !ok {
+			docs = decodePostings(postings)
+			ok = true
+		} else {
+			docs = intersectPostings(docs, postings)
+		}
+		if len(docs) == 0 {
+			return nil, true
+		}
+	}
+	return docs, ok
+}
+
+func decodePostings(b []byte) []uint32 {
+	var (
+		docs []uint32
+		doc  uint32
+	)
+	for len(b) > 0 {
+		delta, n := binary.Uvarint(b)
+		b = b[n:]
+		doc += uint32(delta)
+		docs = append(docs, doc-1)
+	}
+	return docs
+}
+
+// intersectPostings returns the docs which are also present in the encoded
+// posting list b. It reuses the storage of docs.
+func intersectPostings(docs []uint32, b []byte) []uint32 {
+	var (
+		res = docs[:0]
+		doc uint32
+	)
+	for len(b) > 0 && len(docs) > 0 {
+		delta, n := binary.Uvarint(b)
+		b = b[n:]
+		doc += uint32(delta)
+		for len(docs) > 0 && docs[0] < doc-1 {
+			docs = docs[1:]
+		}
+		if len(docs) > 0 && docs[0] == doc-1 {
+			res = append(res, docs[0])
+			docs = docs[1:]
+		}
+	}
+	return res
+}
+
+// searchedTrigramThreshold is the number of times a zipFile needs to be
+// searched before we start building a trigramIndex for it. Archives which
+// are only searched once (the common case when searching over many repos)
+// never pay the memory cost of an index.
+const searchedTrigramThreshold = 2
+
+// maybeIndexTrigrams records a search against f. Once f has been searched
+// searchedTrigramThreshold times a trigramIndex is built in the background.
+// It reports whether the index was ready to be used.
+func (f *zipFile) maybeIndexTrigrams() (ready bool) {
+	f.trigramsMu.Lock()
+	defer f.trigramsMu.Unlock()
+	if f.trigrams != nil {
+		return true
+	}
+	if f.searches < searchedTrigramThreshold {
+		f.searches++
+	}
+	if f.searches < searchedTrigramThreshold || f.indexingTrigrams {
+		return false
+	}
+
+	f.indexingTrigrams = true
+	// Ensure the underlying file is not munmap'd while we read it.
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		start := time.Now()
+		idx := buildTrigramIndex(f)
+		trigramIndexBuildDuration.Observe(time.Since(start).Seconds())
+		if f.f != nil {
+			log.Printf("built trigram index for %q in %s (%d trigrams)", f.f.Name(), time.Since(start), len(idx.postings))
+		}
+
+		f.trigramsMu.Lock()
+		f.trigrams = idx
+		f.indexingTrigrams = false
+		f.trigramsMu.Unlock()
+	}()
+	return false
+}
+
+// trigramCandidates returns the files in f which may contain literal. ok is
+// false if f has no trigramIndex yet or the index can't be used for literal.
+func (f *zipFile) trigramCandidates(literal []byte) (files []srcFile, ok bool) {
+	f.trigramsMu.Lock()
+	idx := f.trigrams
+	f.trigramsMu.Unlock()
+	if idx == nil {
+		return nil, false
+	}
+
+	docs, ok := idx.candidates(literal)
+	if !ok {
+		return nil, false
+	}
+	files = make([]srcFile, len(docs))
+	for i, doc := range docs {
+		files[i] = f.Files[doc]
+	}
+	return files, true
+}
+
+var (
+	trigramIndexBuildDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: "searcher",
+		Subsystem: "trigram",
+		Name:      "index_build_duration_seconds",
+		Help:      "Time taken to build the trigram index for an archive.",
+		Buckets:   prometheus.ExponentialBuckets(0.05, 2, 12),
+	})
+	trigramSearchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "searcher",
+		Subsystem: "trigram",
+		Name:      "search_duration_seconds",
+		Help:      "Time taken to search an archive. cache is warm if a trigram index was used.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 16),
+	}, []string{"cache"})
+)
+
+func init() {
+	prometheus.MustRegister(trigramIndexBuildDuration)
+	prometheus.MustRegister(trigramSearchDuration)
+}
@@ -0,0 +1,112 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sourcegraph/sourcegraph/cmd/searcher/protocol"
+)
+
+func TestTrigramIndex(t *testing.T) {
+	zf := trigramTestZip(t)
+	idx := buildTrigramIndex(zf)
+
+	cases := []struct {
+		literal string
+		want    []string
+		ok      bool
+	}{
+		{literal: "fo", ok: false},
+		{literal: "foo", want: []string{"a.go", "c.go"}, ok: true},
+		{literal: "FOO", want: []string{"a.go", "c.go"}, ok: true},
+		{literal: "foobar", want: []string{"c.go"}, ok: true},
+		{literal: "bar", want: []string{"b.go", "c.go"}, ok: true},
+		{literal: "quux", ok: true},
+		{literal: "oob", want: []string{"c.go"}, ok: true},
+	}
+	for _, c := range cases {
+		docs, ok := idx.candidates([]byte(c.literal))
+		if ok != c.ok {
+			t.Errorf("%q: got ok=%v, want %v", c.literal, ok, c.ok)
+			continue
+		}
+		var got []string
+		for _, doc := range docs {
+			got = append(got, zf.Files[doc].Name)
+		}
+		sort.Strings(got)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%q: got %v, want %v", c.literal, got, c.want)
+		}
+	}
+}
+
+func TestTrigramIndexConcurrentFind(t *testing.T) {
+	zf := trigramTestZip(t)
+
+	patterns := []protocol.PatternInfo{
+		{Pattern: "foo"},
+		{Pattern: "FOO", IsCaseSensitive: true},
+		{Pattern: "foo", IsCaseSensitive: true},
+		{Pattern: "foo.*bar", IsRegExp: true},
+		{Pattern: "ba", IsRegExp: true},
+		{Pattern: "missing"},
+	}
+	search := func(p *protocol.PatternInfo) []protocol.FileMatch {
+		rg, err := compile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fm, _, err := concurrentFind(context.Background(), rg, zf, 0, true, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sort.Slice(fm, func(i, j int) bool { return fm[i].Path < fm[j].Path })
+		return fm
+	}
+
+	var want [][]protocol.FileMatch
+	for i := range patterns {
+		want = append(want, search(&patterns[i]))
+	}
+
+	// The index is only built once the archive has been searched
+	// repeatedly.
+	if zf.maybeIndexTrigrams() {
+		t.Fatal("trigram index ready after first search")
+	}
+	zf.maybeIndexTrigrams()
+	for deadline := time.Now().Add(5 * time.Second); !zf.maybeIndexTrigrams(); {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for trigram index")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	for i := range patterns {
+		if got := search(&patterns[i]); !reflect.DeepEqual(got, want[i]) {
+			t.Errorf("%+v: got %v, want %v", patterns[i], got, want[i])
+		}
+	}
+}
+
+func trigramTestZip(t *testing.T) *zipFile {
+	data, err := createZip(map[string]string{
+		"a.go":   "package a\n\nfunc Foo() {}\n",
+		"b.go":   "package b\n\nvar Bar = 1\n",
+		"c.go":   "package c\n\n// foobar\n",
+		"d.go":   "x",
+		"README": "hello world\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	zf, err := mockZipFile(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zf
+}
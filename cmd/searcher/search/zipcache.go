@@ -102,6 +102,11 @@ type zipFile struct {
 	Data   []byte
 	f      *os.File
 	wg     sync.WaitGroup // ensures underlying file is not munmap'd or closed while in use
+
+	trigramsMu       sync.Mutex    // protects the fields below
+	trigrams         *trigramIndex // built lazily, see maybeIndexTrigrams
+	searches         uint8
+	indexingTrigrams bool
 }
 
 func readZipFile(path string) (*zipFile, error) {
@@ -0,0 +1,36 @@
+package ctags
+
+import "path"
+
+// ByExtension returns a Parser which parses the files with an extension in
+// parsers (e.g. ".go") with the corresponding parser, and all other files
+// with fallback. Files which the parser for their extension fails to parse
+// are parsed with fallback too, since ctags is more forgiving of syntax
+// errors.
+//
+// This allows parsing the languages which we have a native parser for more
+// accurately than ctags.
+func ByExtension(fallback Parser, parsers map[string]Parser) Parser {
+	return &byExtension{fallback: fallback, parsers: parsers}
+}
+
+type byExtension struct {
+	fallback Parser
+	parsers  map[string]Parser
+}
+
+func (p *byExtension) Parse(name string, content []byte) ([]Entry, error) {
+	if parser, ok := p.parsers[path.Ext(name)]; ok {
+		if entries, err := parser.Parse(name, content); err == nil {
+			return entries, nil
+		}
+	}
+	return p.fallback.Parse(name, content)
+}
+
+func (p *byExtension) Close() {
+	for _, parser := range p.parsers {
+		parser.Close()
+	}
+	p.fallback.Close()
+}
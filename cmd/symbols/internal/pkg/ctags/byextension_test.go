@@ -0,0 +1,49 @@
+package ctags
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeParser struct {
+	name   string
+	err    error
+	closed bool
+}
+
+func (p *fakeParser) Parse(path string, content []byte) ([]Entry, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return []Entry{{Name: p.name, Path: path}}, nil
+}
+
+func (p *fakeParser) Close() { p.closed = true }
+
+func TestByExtension(t *testing.T) {
+	fallback := &fakeParser{name: "fallback"}
+	native := &fakeParser{name: "native"}
+	broken := &fakeParser{err: errors.New("syntax error")}
+	p := ByExtension(fallback, map[string]Parser{".go": native, ".rs": broken})
+
+	for path, want := range map[string]string{
+		"a.go":     "native",
+		"a.java":   "fallback",
+		"go":       "fallback",
+		"a.rs":     "fallback", // native parser failed
+		"x/y/b.go": "native",
+	} {
+		entries, err := p.Parse(path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 || entries[0].Name != want {
+			t.Errorf("%s: got %+v, want entries of the %s parser", path, entries, want)
+		}
+	}
+
+	p.Close()
+	if !fallback.closed || !native.closed || !broken.closed {
+		t.Error("expected all parsers to be closed")
+	}
+}
@@ -25,6 +25,21 @@ type Entry struct {
 	Pattern    string
 	Signature  string
 
+	// Character is the 0-based column (in bytes) of Name on Line. EndLine
+	// and EndCharacter are where the declaration of the symbol ends. They are
+	// only set by parsers which know the exact position of a symbol (not
+	// ctags), in which case EndLine is non-zero.
+	Character    int
+	EndLine      int
+	EndCharacter int
+
+	// Doc is the documentation comment of the symbol, if any.
+	Doc string
+
+	// Access is the visibility of the symbol as reported by ctags (e.g.
+	// "public" or "private"), if the language has one.
+	Access string
+
 	FileLimited bool
 }
 
@@ -218,6 +233,7 @@ func (p *ctagsProcess) Parse(name string, content []byte) (entries []Entry, err
 			ParentKind: rep.ScopeKind,
 			Pattern:    rep.Pattern,
 			Signature:  rep.Signature,
+			Access:     rep.Access,
 		})
 	}
 
@@ -0,0 +1,277 @@
+// Package goparser parses the symbols of Go files with go/parser.
+//
+// Unlike ctags, it reports the receivers of methods, the methods of embedded
+// interfaces (declared in the same file), exact positions, full signatures
+// and doc comments. Like with ctags, top-level symbols have no parent. The
+// parents of other symbols are qualified with the package name, e.g. the
+// parent of method M of type T in package p is "p.T".
+package goparser
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strings"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+)
+
+// NewParser returns a ctags.Parser for Go files. It is safe to use the parser
+// concurrently.
+func NewParser() ctags.Parser {
+	return goParser{}
+}
+
+type goParser struct{}
+
+func (goParser) Close() {}
+
+// Parse implements ctags.Parser. It returns an error if content is not valid
+// Go.
+func (goParser) Parse(path string, content []byte) ([]ctags.Entry, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, path, content, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	p := &fileParser{
+		fset:       fset,
+		path:       path,
+		lines:      bytes.Split(content, []byte("\n")),
+		pkg:        f.Name.Name,
+		interfaces: make(map[string]*ast.InterfaceType),
+		typeKinds:  make(map[string]string),
+	}
+	p.parseFile(f)
+	return p.entries, nil
+}
+
+// fileParser collects the symbols of a single file.
+type fileParser struct {
+	fset  *token.FileSet
+	path  string
+	lines [][]byte
+	pkg   string
+
+	// interfaces are the interface types declared in the file, used to find
+	// the methods of embedded interfaces.
+	interfaces map[string]*ast.InterfaceType
+
+	// typeKinds are the kinds of the types declared in the file, used for
+	// the ParentKind of methods.
+	typeKinds map[string]string
+
+	entries []ctags.Entry
+}
+
+func (p *fileParser) parseFile(f *ast.File) {
+	// Collect the types first, since methods and interfaces may refer to
+	// types declared after them.
+	for _, decl := range f.Decls {
+		if d, ok := decl.(*ast.GenDecl); ok && d.Tok == token.TYPE {
+			for _, spec := range d.Specs {
+				ts := spec.(*ast.TypeSpec)
+				p.typeKinds[ts.Name.Name] = typeKind(ts)
+				if it, ok := ts.Type.(*ast.InterfaceType); ok {
+					p.interfaces[ts.Name.Name] = it
+				}
+			}
+		}
+	}
+
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			p.funcDecl(d)
+		case *ast.GenDecl:
+			p.genDecl(d)
+		}
+	}
+}
+
+func (p *fileParser) funcDecl(d *ast.FuncDecl) {
+	kind, parent, parentKind := "func", "", ""
+	if d.Recv != nil && len(d.Recv.List) > 0 {
+		recv := receiverTypeName(d.Recv.List[0].Type)
+		if recv == "" {
+			return
+		}
+		kind, parent, parentKind = "method", p.pkg+"."+recv, p.typeKinds[recv]
+		if parentKind == "" {
+			// The type is declared in another file of the package.
+			parentKind = "type"
+		}
+	}
+	p.add(d.Name, d, kind, parent, parentKind, p.signature(d.Type), d.Doc)
+}
+
+func (p *fileParser) genDecl(d *ast.GenDecl) {
+	for _, spec := range d.Specs {
+		switch s := spec.(type) {
+		case *ast.TypeSpec:
+			doc := s.Doc
+			if doc == nil && len(d.Specs) == 1 {
+				doc = d.Doc
+			}
+			kind := typeKind(s)
+			p.add(s.Name, s, kind, "", "", "", doc)
+
+			parent := p.pkg + "." + s.Name.Name
+			switch t := s.Type.(type) {
+			case *ast.StructType:
+				p.fields(t.Fields, parent, kind)
+			case *ast.InterfaceType:
+				p.interfaceMethods(t, parent, map[string]bool{s.Name.Name: true})
+			}
+
+		case *ast.ValueSpec:
+			kind := "var"
+			if d.Tok == token.CONST {
+				kind = "const"
+			}
+			doc := s.Doc
+			if doc == nil && len(d.Specs) == 1 {
+				doc = d.Doc
+			}
+			for _, name := range s.Names {
+				p.add(name, s, kind, "", "", "", doc)
+			}
+		}
+	}
+}
+
+// fields adds the fields of a struct type. Embedded fields are named after
+// their type, like in the Go spec.
+func (p *fileParser) fields(fields *ast.FieldList, parent, parentKind string) {
+	for _, field := range fields.List {
+		doc := field.Doc
+		if doc == nil {
+			doc = field.Comment
+		}
+		if len(field.Names) == 0 {
+			if name := embeddedName(field.Type); name != nil {
+				p.add(name, field, "member", parent, parentKind, "", doc)
+			}
+			continue
+		}
+		for _, name := range field.Names {
+			p.add(name, field, "member", parent, parentKind, "", doc)
+		}
+	}
+}
+
+// interfaceMethods adds the methods of an interface type, including the
+// methods of the interfaces it embeds which are declared in the same file.
+// seen guards against invalid recursive embedding.
+func (p *fileParser) interfaceMethods(t *ast.InterfaceType, parent string, seen map[string]bool) {
+	for _, field := range t.Methods.List {
+		if len(field.Names) == 0 {
+			ident, ok := field.Type.(*ast.Ident)
+			if !ok || seen[ident.Name] {
+				continue
+			}
+			if embedded, ok := p.interfaces[ident.Name]; ok {
+				seen[ident.Name] = true
+				p.interfaceMethods(embedded, parent, seen)
+			}
+			continue
+		}
+		ft, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			continue
+		}
+		doc := field.Doc
+		if doc == nil {
+			doc = field.Comment
+		}
+		for _, name := range field.Names {
+			p.add(name, field, "methodSpec", parent, "interface", p.signature(ft), doc)
+		}
+	}
+}
+
+// add adds the symbol declared by name. decl is the node of the whole
+// declaration.
+func (p *fileParser) add(name *ast.Ident, decl ast.Node, kind, parent, parentKind, signature string, doc *ast.CommentGroup) {
+	if name.Name == "_" {
+		return
+	}
+	start := p.fset.Position(name.Pos())
+	end := p.fset.Position(decl.End())
+	p.entries = append(p.entries, ctags.Entry{
+		Name:         name.Name,
+		Path:         p.path,
+		Line:         start.Line,
+		Kind:         kind,
+		Language:     "Go",
+		Parent:       parent,
+		ParentKind:   parentKind,
+		Pattern:      p.pattern(start.Line),
+		Signature:    signature,
+		Character:    start.Column - 1,
+		EndLine:      end.Line,
+		EndCharacter: end.Column - 1,
+		Doc:          strings.TrimSpace(doc.Text()),
+	})
+}
+
+// signature returns the parameters and results of a function, e.g.
+// "(ctx context.Context) (int, error)".
+func (p *fileParser) signature(t *ast.FuncType) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, p.fset, &ast.FuncType{Params: t.Params, Results: t.Results}); err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(buf.String(), "func")
+}
+
+// pattern returns a ctags style search pattern for line.
+func (p *fileParser) pattern(line int) string {
+	if line < 1 || line > len(p.lines) {
+		return ""
+	}
+	text := strings.TrimSuffix(string(p.lines[line-1]), "\r")
+	text = strings.NewReplacer(`\`, `\\`, `/`, `\/`).Replace(text)
+	return "/^" + text + "$/"
+}
+
+// typeKind returns the kind of the type declared by ts.
+func typeKind(ts *ast.TypeSpec) string {
+	switch ts.Type.(type) {
+	case *ast.StructType:
+		return "struct"
+	case *ast.InterfaceType:
+		return "interface"
+	}
+	return "type"
+}
+
+// receiverTypeName returns the name of the type of a method receiver, e.g. T
+// for (t *T).
+func receiverTypeName(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.StarExpr:
+		return receiverTypeName(e.X)
+	case *ast.ParenExpr:
+		return receiverTypeName(e.X)
+	}
+	return ""
+}
+
+// embeddedName returns the identifier an embedded field is named after, e.g.
+// T for *pkg.T.
+func embeddedName(expr ast.Expr) *ast.Ident {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e
+	case *ast.StarExpr:
+		return embeddedName(e.X)
+	case *ast.SelectorExpr:
+		return e.Sel
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package goparser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+)
+
+func TestParser(t *testing.T) {
+	src := `package p
+
+import "context"
+
+// T is a type.
+type T struct {
+	A int // A is a field.
+	*Embedded
+}
+
+// M is a method.
+func (t *T) M(ctx context.Context, n int) (int, error) {
+	return 0, nil
+}
+
+type Reader interface {
+	Read(p []byte) (int, error)
+}
+
+// ReadCloser embeds Reader.
+type ReadCloser interface {
+	Reader
+	Close() error
+}
+
+const (
+	C = 1
+	_ = 2
+)
+
+var V, W string
+
+func F() {}
+`
+	got, err := NewParser().Parse("p/p.go", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range got {
+		if got[i].Path != "p/p.go" || got[i].Language != "Go" {
+			t.Errorf("unexpected path or language: %+v", got[i])
+		}
+		got[i].Path, got[i].Language = "", ""
+	}
+
+	want := []ctags.Entry{
+		{Name: "M", Kind: "method", Parent: "p.T", ParentKind: "struct", Line: 12, Character: 12, EndLine: 14, EndCharacter: 1, Signature: "(ctx context.Context, n int) (int, error)", Pattern: `/^func (t *T) M(ctx context.Context, n int) (int, error) {$/`, Doc: "M is a method."},
+		{Name: "F", Kind: "func", Line: 33, Character: 5, EndLine: 33, EndCharacter: 11, Signature: "()", Pattern: `/^func F() {}$/`},
+	}
+	var gotFuncs []ctags.Entry
+	for _, e := range got {
+		if e.Kind == "func" || e.Kind == "method" {
+			gotFuncs = append(gotFuncs, e)
+		}
+	}
+	if !reflect.DeepEqual(gotFuncs, want) {
+		t.Errorf("got funcs\n%+v\nwant\n%+v", gotFuncs, want)
+	}
+
+	// The remaining symbols, identified by parent, name, kind and doc.
+	type symbol struct{ Parent, Name, Kind, Doc string }
+	var gotSymbols []symbol
+	for _, e := range got {
+		if e.Kind != "func" && e.Kind != "method" {
+			gotSymbols = append(gotSymbols, symbol{e.Parent, e.Name, e.Kind, e.Doc})
+		}
+	}
+	wantSymbols := []symbol{
+		{"", "T", "struct", "T is a type."},
+		{"p.T", "A", "member", "A is a field."},
+		{"p.T", "Embedded", "member", ""},
+		{"", "Reader", "interface", ""},
+		{"p.Reader", "Read", "methodSpec", ""},
+		{"", "ReadCloser", "interface", "ReadCloser embeds Reader."},
+		{"p.ReadCloser", "Read", "methodSpec", ""},
+		{"p.ReadCloser", "Close", "methodSpec", ""},
+		{"", "C", "const", ""},
+		{"", "V", "var", ""},
+		{"", "W", "var", ""},
+	}
+	if !reflect.DeepEqual(gotSymbols, wantSymbols) {
+		t.Errorf("got symbols\n%+v\nwant\n%+v", gotSymbols, wantSymbols)
+	}
+}
+
+func TestParser_syntaxError(t *testing.T) {
+	if _, err := NewParser().Parse("a.go", []byte("package p\n\nfunc {")); err == nil {
+		t.Error("expected an error for invalid Go")
+	}
+}
@@ -19,7 +19,10 @@ type parseRequest struct {
 	data []byte
 }
 
-func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName, commitID api.CommitID) (<-chan parseRequest, <-chan error, error) {
+// fetchRepositoryArchive fetches the archive of repo@commitID and sends the
+// files to parse on the returned channel. If paths is non-nil, only those
+// paths are fetched (see FetchTarPaths).
+func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName, commitID api.CommitID, paths []string) (<-chan parseRequest, <-chan error, error) {
 	fetchQueueSize.Inc()
 	s.fetchSem <- 1 // acquire concurrent fetches semaphore
 	fetchQueueSize.Dec()
@@ -59,7 +62,13 @@ func (s *Service) fetchRepositoryArchive(ctx context.Context, repo api.RepoName,
 		span.Finish()
 	}
 
-	r, err := s.FetchTar(ctx, gitserver.Repo{Name: repo}, commitID)
+	var r io.ReadCloser
+	var err error
+	if paths != nil {
+		r, err = s.FetchTarPaths(ctx, gitserver.Repo{Name: repo}, commitID, paths)
+	} else {
+		r, err = s.FetchTar(ctx, gitserver.Repo{Name: repo}, commitID)
+	}
 	if err != nil {
 		return nil, nil, err
 	}
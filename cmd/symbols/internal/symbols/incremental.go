@@ -0,0 +1,234 @@
+package symbols
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// maxIncrementalAncestors is the number of ancestors of a commit we look
+// through for one whose symbols database is in the disk cache.
+const maxIncrementalAncestors = 100
+
+// maxIncrementalChangedFiles is the maximum number of files which may have
+// changed since the ancestor for us to update its database. Beyond that we
+// parse all files, which is not much slower.
+const maxIncrementalChangedFiles = 1000
+
+// writeSymbolsToNewDB writes the symbols of repo@commitID to the blank
+// database file dbFile. If the database of a nearby ancestor of commitID is
+// in the disk cache, it is copied and only the files which changed since the
+// ancestor are parsed. Otherwise all files are parsed.
+func (s *Service) writeSymbolsToNewDB(ctx context.Context, dbFile string, repo api.RepoName, commitID api.CommitID) error {
+	if !s.incrementalEnabled() {
+		return s.writeAllSymbolsToNewDB(ctx, dbFile, repo, commitID)
+	}
+
+	ok, err := s.writeSymbolsIncrementally(ctx, dbFile, repo, commitID)
+	if ok {
+		incrementalIndexHits.Inc()
+		return nil
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		log15.Warn("Unable to update the symbols of an ancestor commit, parsing all symbols.", "repo", repo, "commitID", commitID, "error", err)
+	}
+	incrementalIndexMisses.Inc()
+
+	// We may have copied the database of an ancestor before failing.
+	if err := os.Truncate(dbFile, 0); err != nil {
+		return err
+	}
+	return s.writeAllSymbolsToNewDB(ctx, dbFile, repo, commitID)
+}
+
+// incrementalEnabled reports whether s can update the database of an
+// ancestor commit.
+func (s *Service) incrementalEnabled() bool {
+	return s.ListAncestors != nil && s.GitDiff != nil && s.FetchTarPaths != nil
+}
+
+// writeSymbolsIncrementally copies the database of the nearest ancestor of
+// commitID in the disk cache to dbFile, and updates it with the files which
+// changed between the ancestor and commitID. It returns false if there is no
+// such ancestor or too many files changed.
+func (s *Service) writeSymbolsIncrementally(ctx context.Context, dbFile string, repo api.RepoName, commitID api.CommitID) (ok bool, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "writeSymbolsIncrementally")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+	span.SetTag("repo", string(repo))
+	span.SetTag("commit", string(commitID))
+
+	base, err := s.copyNearestIndexedAncestor(ctx, dbFile, repo, commitID)
+	if err != nil || base == "" {
+		return false, err
+	}
+	span.SetTag("base", string(base))
+
+	out, err := s.GitDiff(ctx, gitserver.Repo{Name: repo}, "--name-status", "-z", "--no-renames", string(base), string(commitID), "--")
+	if err != nil {
+		return false, err
+	}
+	changed, deleted, err := parseNameStatus(out)
+	if err != nil {
+		return false, err
+	}
+	span.SetTag("changed", len(changed))
+	span.SetTag("deleted", len(deleted))
+	if len(changed)+len(deleted) > maxIncrementalChangedFiles {
+		return false, nil
+	}
+
+	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+
+	tx, err := db.Beginx()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	for _, paths := range [][]string{changed, deleted} {
+		for _, path := range paths {
+			if _, err := tx.Exec(`DELETE FROM symbols WHERE path = ?`, path); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	if len(changed) > 0 {
+		insertStatement, err := prepareInsertSymbol(tx)
+		if err != nil {
+			return false, err
+		}
+		err = s.parseUncached(ctx, repo, commitID, changed, func(symbol protocol.Symbol) error {
+			symbolInDBValue := symbolToSymbolInDB(symbol)
+			_, err := insertStatement.Exec(&symbolInDBValue)
+			return err
+		})
+		if err != nil {
+			return false, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// copyNearestIndexedAncestor copies the database of the nearest ancestor of
+// commitID in the disk cache to dbFile, and returns the ancestor. It returns
+// an empty commit ID if no ancestor's database is in the disk cache.
+func (s *Service) copyNearestIndexedAncestor(ctx context.Context, dbFile string, repo api.RepoName, commitID api.CommitID) (api.CommitID, error) {
+	ancestors, err := s.ListAncestors(ctx, gitserver.Repo{Name: repo}, commitID, maxIncrementalAncestors)
+	if err != nil {
+		return "", err
+	}
+	for _, ancestor := range ancestors {
+		f, err := s.cache.OpenCached(symbolsDBKey(repo, ancestor))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		err = copyToFile(dbFile, f.File)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		return ancestor, nil
+	}
+	return "", nil
+}
+
+func copyToFile(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(f, r)
+	if err1 := f.Close(); err == nil {
+		err = err1
+	}
+	return err
+}
+
+// parseNameStatus parses the output of `git diff --name-status -z`. changed
+// are the paths which were added or modified, deleted are the paths which
+// were deleted. A rename or copy is reported as a deletion (for renames) of
+// the old path and a change of the new path.
+func parseNameStatus(out []byte) (changed, deleted []string, err error) {
+	fields := strings.Split(string(bytes.TrimSuffix(out, []byte{0})), "\x00")
+	if len(fields) == 1 && fields[0] == "" {
+		return nil, nil, nil
+	}
+	for i := 0; i < len(fields); {
+		status := fields[i]
+		if status == "" || i+1 >= len(fields) {
+			return nil, nil, errors.Errorf("unexpected git diff --name-status output: %q", out)
+		}
+		switch status[0] {
+		case 'D':
+			deleted = append(deleted, fields[i+1])
+			i += 2
+		case 'R', 'C':
+			if i+2 >= len(fields) {
+				return nil, nil, errors.Errorf("unexpected git diff --name-status output: %q", out)
+			}
+			if status[0] == 'R' {
+				deleted = append(deleted, fields[i+1])
+			}
+			changed = append(changed, fields[i+2])
+			i += 3
+		default:
+			changed = append(changed, fields[i+1])
+			i += 2
+		}
+	}
+	return changed, deleted, nil
+}
+
+var (
+	incrementalIndexHits = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "symbols",
+		Subsystem: "store",
+		Name:      "incremental_index_hits",
+		Help:      "The total number of symbol databases built by updating the database of an ancestor commit.",
+	})
+	incrementalIndexMisses = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "symbols",
+		Subsystem: "store",
+		Name:      "incremental_index_misses",
+		Help:      "The total number of symbol databases built by parsing all files, because the database of no nearby ancestor commit could be updated.",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(incrementalIndexHits)
+	prometheus.MustRegister(incrementalIndexMisses)
+}
@@ -0,0 +1,139 @@
+package symbols
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+)
+
+func TestParseNameStatus(t *testing.T) {
+	out := "M\x00a.go\x00D\x00b.go\x00A\x00c d.go\x00R100\x00e.go\x00f.go\x00C075\x00g.go\x00h.go\x00"
+	changed, deleted, err := parseNameStatus([]byte(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.go", "c d.go", "f.go", "h.go"}; !reflect.DeepEqual(changed, want) {
+		t.Errorf("got changed %q, want %q", changed, want)
+	}
+	if want := []string{"b.go", "e.go"}; !reflect.DeepEqual(deleted, want) {
+		t.Errorf("got deleted %q, want %q", deleted, want)
+	}
+
+	if changed, deleted, err := parseNameStatus(nil); err != nil || changed != nil || deleted != nil {
+		t.Errorf("expected nothing for empty output, got %q %q %v", changed, deleted, err)
+	}
+	if _, _, err := parseNameStatus([]byte("M\x00")); err == nil {
+		t.Error("expected an error for truncated output")
+	}
+}
+
+func TestWriteSymbolsIncrementally(t *testing.T) {
+	MustRegisterSqlite3WithPcre()
+
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	archives := map[api.CommitID]map[string]string{
+		"c1": {"a.js": "x", "b.js": "y", "c.js": "z"},
+		"c2": {"a.js": "x", "b.js": "y2", "d.js": "w"},
+	}
+	var fetchedAll []api.CommitID
+	var fetchedPaths []string
+	service := Service{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+			fetchedAll = append(fetchedAll, commit)
+			return createTar(archives[commit])
+		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			fetchedPaths = append(fetchedPaths, paths...)
+			files := map[string]string{}
+			for _, p := range paths {
+				files[p] = archives[commit][p]
+			}
+			return createTar(files)
+		},
+		ListAncestors: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error) {
+			if commit == "c2" {
+				return []api.CommitID{"c1"}, nil
+			}
+			return nil, nil
+		},
+		GitDiff: func(ctx context.Context, repo gitserver.Repo, args ...string) ([]byte, error) {
+			if want := []string{"--name-status", "-z", "--no-renames", "c1", "c2", "--"}; !reflect.DeepEqual(args, want) {
+				t.Errorf("got git diff args %q, want %q", args, want)
+			}
+			return []byte("M\x00b.js\x00D\x00c.js\x00A\x00d.js\x00"), nil
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return wordParser{}, nil
+		},
+		Path: tmpDir,
+	}
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	symbols := func(commitID api.CommitID) []string {
+		t.Helper()
+		ctx := context.Background()
+		dbFile, err := service.getDBFile(ctx, protocol.SearchArgs{Repo: "foo", CommitID: commitID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		res, err := filterSymbols(ctx, db, protocol.SearchArgs{First: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, s := range res {
+			got = append(got, s.Path+":"+s.Name)
+		}
+		sort.Strings(got)
+		return got
+	}
+
+	if got, want := symbols("c1"), []string{"a.js:x", "b.js:y", "c.js:z"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got, want := symbols("c2"), []string{"a.js:x", "b.js:y2", "d.js:w"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if want := []api.CommitID{"c1"}; !reflect.DeepEqual(fetchedAll, want) {
+		t.Errorf("fetched all files of %v, want %v", fetchedAll, want)
+	}
+	if want := []string{"b.js", "d.js"}; !reflect.DeepEqual(fetchedPaths, want) {
+		t.Errorf("fetched paths %q, want %q", fetchedPaths, want)
+	}
+}
+
+// wordParser returns a symbol for each word of a file.
+type wordParser struct{}
+
+func (wordParser) Parse(name string, content []byte) ([]ctags.Entry, error) {
+	var entries []ctags.Entry
+	for _, word := range strings.Fields(string(content)) {
+		entries = append(entries, ctags.Entry{Name: word, Path: name})
+	}
+	return entries, nil
+}
+
+func (wordParser) Close() {}
@@ -0,0 +1,98 @@
+package symbols
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+	"golang.org/x/net/trace"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+// maxListFirst is the maximum number of symbols a list request returns.
+const maxListFirst = 100000
+
+func (s *Service) handleList(w http.ResponseWriter, r *http.Request) {
+	var args protocol.ListArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.list(r.Context(), args)
+	if err != nil {
+		if err == context.Canceled && r.Context().Err() == context.Canceled {
+			return // client went away
+		}
+		log15.Error("Symbol list failed", "args", args, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// list returns all the symbols of repo@commit ordered by path and line, up to
+// args.First.
+func (s *Service) list(ctx context.Context, args protocol.ListArgs) (result *protocol.SearchResult, err error) {
+	// Listing the symbols of a commit that isn't cached requires parsing the
+	// whole repository, so allow as long as a search.
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "list")
+	span.SetTag("repo", args.Repo)
+	span.SetTag("commitID", args.CommitID)
+	span.SetTag("first", args.First)
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+
+	tr := trace.New("symbols.list", fmt.Sprintf("args:%+v", args))
+	defer func() {
+		if err != nil {
+			tr.LazyPrintf("error: %v", err)
+			tr.SetError()
+		}
+		tr.Finish()
+	}()
+
+	if args.First <= 0 || args.First > maxListFirst {
+		args.First = maxListFirst
+	}
+
+	dbFile, err := s.getDBFile(ctx, protocol.SearchArgs{Repo: args.Repo, CommitID: args.CommitID})
+	if err != nil {
+		return nil, err
+	}
+	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var symbolsInDB []symbolInDB
+	if err := db.SelectContext(ctx, &symbolsInDB, `SELECT * FROM symbols ORDER BY path, line LIMIT ?`, args.First); err != nil {
+		return nil, err
+	}
+	result = &protocol.SearchResult{Symbols: make([]protocol.Symbol, 0, len(symbolsInDB))}
+	for _, symbolInDB := range symbolsInDB {
+		result.Symbols = append(result.Symbols, symbolInDBToSymbol(symbolInDB))
+	}
+	span.SetTag("symbols", len(result.Symbols))
+	return result, nil
+}
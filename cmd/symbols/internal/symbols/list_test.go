@@ -0,0 +1,60 @@
+package symbols
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	symbolsclient "github.com/sourcegraph/sourcegraph/pkg/symbols"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+)
+
+func TestList(t *testing.T) {
+	MustRegisterSqlite3WithPcre()
+
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	service := Service{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+			return createTar(map[string]string{"b.js": "z", "a.js": "x", "c.js": "y"})
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return wordParser{}, nil
+		},
+		Path: tmpDir,
+	}
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(service.Handler())
+	defer server.Close()
+	client := symbolsclient.Client{URL: server.URL}
+
+	for first, want := range map[int][]string{
+		0: {"a.js:x", "b.js:z", "c.js:y"},
+		2: {"a.js:x", "b.js:z"},
+	} {
+		result, err := client.List(context.Background(), protocol.ListArgs{Repo: "foo", CommitID: "c", First: first})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, s := range result.Symbols {
+			got = append(got, s.Path+":"+s.Name)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("first %d: got %q, want %q", first, got, want)
+		}
+	}
+}
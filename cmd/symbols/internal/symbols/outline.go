@@ -0,0 +1,127 @@
+package symbols
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	opentracing "github.com/opentracing/opentracing-go"
+	"github.com/opentracing/opentracing-go/ext"
+	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+	"golang.org/x/net/trace"
+	log15 "gopkg.in/inconshreveable/log15.v2"
+)
+
+func (s *Service) handleOutline(w http.ResponseWriter, r *http.Request) {
+	var args protocol.OutlineArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	result, err := s.outline(r.Context(), args)
+	if err != nil {
+		if err == context.Canceled && r.Context().Err() == context.Canceled {
+			return // client went away
+		}
+		log15.Error("Symbol outline failed", "args", args, "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// outline returns the symbols of the file at args.Path in repo@commit, in the
+// order they are declared. If the symbols database of the commit is in the
+// disk cache the symbols are read from it. Otherwise only the file is fetched
+// and parsed, so that the outline of a file does not wait for the whole
+// repository to be parsed.
+func (s *Service) outline(ctx context.Context, args protocol.OutlineArgs) (result *protocol.SearchResult, err error) {
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "outline")
+	span.SetTag("repo", args.Repo)
+	span.SetTag("commitID", args.CommitID)
+	span.SetTag("path", args.Path)
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+
+	tr := trace.New("symbols.outline", fmt.Sprintf("args:%+v", args))
+	defer func() {
+		if err != nil {
+			tr.LazyPrintf("error: %v", err)
+			tr.SetError()
+		}
+		tr.Finish()
+	}()
+
+	var symbols []protocol.Symbol
+	f, err := s.cache.OpenCached(symbolsDBKey(args.Repo, args.CommitID))
+	switch {
+	case err == nil:
+		tr.LazyPrintf("cached")
+		symbols, err = outlineFromDB(ctx, f.File.Name(), args.Path)
+		f.Close()
+	case os.IsNotExist(err) && s.FetchTarPaths != nil:
+		tr.LazyPrintf("parse")
+		err = s.parseUncached(ctx, args.Repo, args.CommitID, []string{args.Path}, func(symbol protocol.Symbol) error {
+			symbols = append(symbols, symbol)
+			return nil
+		})
+	case os.IsNotExist(err):
+		// We can't fetch a single file, so parse the whole repository.
+		var dbFile string
+		dbFile, err = s.getDBFile(ctx, protocol.SearchArgs{Repo: args.Repo, CommitID: args.CommitID})
+		if err == nil {
+			symbols, err = outlineFromDB(ctx, dbFile, args.Path)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(symbols, func(i, j int) bool {
+		if symbols[i].Line != symbols[j].Line {
+			return symbols[i].Line < symbols[j].Line
+		}
+		return symbols[i].Character < symbols[j].Character
+	})
+	span.SetTag("symbols", len(symbols))
+	return &protocol.SearchResult{Symbols: symbols}, nil
+}
+
+// outlineFromDB returns the symbols of the file at path in the symbols
+// database dbFile.
+func outlineFromDB(ctx context.Context, dbFile, path string) ([]protocol.Symbol, error) {
+	db, err := sqlx.Open("sqlite3_with_pcre", dbFile)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var symbolsInDB []symbolInDB
+	if err := db.SelectContext(ctx, &symbolsInDB, `SELECT * FROM symbols WHERE path = ?`, path); err != nil {
+		return nil, err
+	}
+	symbols := make([]protocol.Symbol, 0, len(symbolsInDB))
+	for _, symbolInDB := range symbolsInDB {
+		symbols = append(symbols, symbolInDBToSymbol(symbolInDB))
+	}
+	return symbols, nil
+}
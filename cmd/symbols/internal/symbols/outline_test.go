@@ -0,0 +1,89 @@
+package symbols
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	symbolsclient "github.com/sourcegraph/sourcegraph/pkg/symbols"
+	"github.com/sourcegraph/sourcegraph/pkg/symbols/protocol"
+)
+
+func TestOutline(t *testing.T) {
+	MustRegisterSqlite3WithPcre()
+
+	tmpDir, err := ioutil.TempDir("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { os.RemoveAll(tmpDir) }()
+
+	files := map[string]string{"a.js": "x y", "b.js": "z"}
+	var fetchedAll int
+	var fetchedPaths []string
+	service := Service{
+		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
+			fetchedAll++
+			return createTar(files)
+		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			fetchedPaths = append(fetchedPaths, paths...)
+			subset := map[string]string{}
+			for _, p := range paths {
+				subset[p] = files[p]
+			}
+			return createTar(subset)
+		},
+		NewParser: func() (ctags.Parser, error) {
+			return wordParser{}, nil
+		},
+		Path: tmpDir,
+	}
+	if err := service.Start(); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(service.Handler())
+	defer server.Close()
+	client := symbolsclient.Client{URL: server.URL}
+	ctx := context.Background()
+
+	outline := func(path string) []string {
+		t.Helper()
+		result, err := client.Outline(ctx, protocol.OutlineArgs{Repo: "foo", CommitID: "c", Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, s := range result.Symbols {
+			names = append(names, s.Name)
+		}
+		return names
+	}
+
+	// Without a symbols database only the file is parsed.
+	if got, want := outline("a.js"), []string{"x", "y"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if want := []string{"a.js"}; fetchedAll != 0 || !reflect.DeepEqual(fetchedPaths, want) {
+		t.Errorf("fetched all files %d times and paths %q, want only paths %q", fetchedAll, fetchedPaths, want)
+	}
+
+	// Once the symbols database is built, it is used instead.
+	if _, err := client.Search(ctx, protocol.SearchArgs{Repo: "foo", CommitID: "c", First: 10}); err != nil {
+		t.Fatal(err)
+	}
+	fetchedPaths = nil
+	if got, want := outline("b.js"), []string{"z"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if fetchedPaths != nil {
+		t.Errorf("fetched paths %q, want the symbols database to be used", fetchedPaths)
+	}
+}
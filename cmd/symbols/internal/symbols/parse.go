@@ -37,7 +37,9 @@ func (s *Service) startParsers() error {
 	return nil
 }
 
-func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, callback func(symbol protocol.Symbol) error) (err error) {
+// parseUncached parses the symbols of repo@commitID and calls callback for
+// each of them. If paths is non-nil, only the files at those paths are parsed.
+func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID api.CommitID, paths []string, callback func(symbol protocol.Symbol) error) (err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "parseUncached")
 	defer func() {
 		if err != nil {
@@ -63,7 +65,7 @@ func (s *Service) parseUncached(ctx context.Context, repo api.RepoName, commitID
 	}()
 
 	tr.LazyPrintf("fetch")
-	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID)
+	parseRequests, errChan, err := s.fetchRepositoryArchive(ctx, repo, commitID, paths)
 	tr.LazyPrintf("fetch (returned chans)")
 	if err != nil {
 		return err
@@ -183,15 +185,22 @@ func (s *Service) parse(ctx context.Context, req parseRequest) (entries []ctags.
 
 func entryToSymbol(e ctags.Entry) protocol.Symbol {
 	return protocol.Symbol{
-		Name:        e.Name,
-		Path:        e.Path,
-		Line:        e.Line,
-		Kind:        e.Kind,
-		Language:    e.Language,
-		Parent:      e.Parent,
-		ParentKind:  e.ParentKind,
-		Signature:   e.Signature,
-		Pattern:     e.Pattern,
+		Name:       e.Name,
+		Path:       e.Path,
+		Line:       e.Line,
+		Kind:       e.Kind,
+		Language:   e.Language,
+		Parent:     e.Parent,
+		ParentKind: e.ParentKind,
+		Signature:  e.Signature,
+		Pattern:    e.Pattern,
+
+		Character:    e.Character,
+		EndLine:      e.EndLine,
+		EndCharacter: e.EndCharacter,
+		Doc:          e.Doc,
+		Access:       e.Access,
+
 		FileLimited: e.FileLimited,
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"regexp/syntax"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/sourcegraph/sourcegraph/pkg/env"
@@ -32,14 +33,18 @@ const maxFileSize = 1 << 19 // 512KB
 
 var libSqlite3Pcre = env.Get("LIBSQLITE3_PCRE", "", "path to the libsqlite3-pcre library")
 
+var registerSqlite3WithPcreOnce sync.Once
+
 // MustRegisterSqlite3WithPcre registers a sqlite3 driver with PCRE support and
-// panics if it can't.
+// panics if it can't. It can be called more than once.
 func MustRegisterSqlite3WithPcre() {
-	if libSqlite3Pcre == "" {
-		env.PrintHelp()
-		log.Fatal("can't find the libsqlite3-pcre library because LIBSQLITE3_PCRE was not set")
-	}
-	sql.Register("sqlite3_with_pcre", &sqlite3.SQLiteDriver{Extensions: []string{libSqlite3Pcre}})
+	registerSqlite3WithPcreOnce.Do(func() {
+		if libSqlite3Pcre == "" {
+			env.PrintHelp()
+			log.Fatal("can't find the libsqlite3-pcre library because LIBSQLITE3_PCRE was not set")
+		}
+		sql.Register("sqlite3_with_pcre", &sqlite3.SQLiteDriver{Extensions: []string{libSqlite3Pcre}})
+	})
 }
 
 func (s *Service) handleSearch(w http.ResponseWriter, r *http.Request) {
@@ -114,10 +119,11 @@ func (s *Service) search(ctx context.Context, args protocol.SearchArgs) (result
 
 // getDBFile returns the path to the sqlite3 database for the repo@commit
 // specified in `args`. If the database doesn't already exist in the disk cache,
-// it will create a new one and write all the symbols into it.
+// it will create a new one and write all the symbols into it (see
+// writeSymbolsToNewDB).
 func (s *Service) getDBFile(ctx context.Context, args protocol.SearchArgs) (string, error) {
-	diskcacheFile, err := s.cache.OpenWithPath(ctx, fmt.Sprintf("%d-%s@%s", symbolsDBVersion, args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
-		err := s.writeAllSymbolsToNewDB(fetcherCtx, tempDBFile, args.Repo, args.CommitID)
+	diskcacheFile, err := s.cache.OpenWithPath(ctx, symbolsDBKey(args.Repo, args.CommitID), func(fetcherCtx context.Context, tempDBFile string) error {
+		err := s.writeSymbolsToNewDB(fetcherCtx, tempDBFile, args.Repo, args.CommitID)
 		if err != nil {
 			if err == context.Canceled {
 				log15.Error("Unable to parse repository symbols within the context", "repo", args.Repo, "commit", args.CommitID, "query", args.Query)
@@ -234,7 +240,13 @@ func filterSymbols(ctx context.Context, db *sqlx.DB, args protocol.SearchArgs) (
 // filenames to prevent a newer version of the symbols service attempting to
 // read from a database created by an older (and likely incompatible) symbols
 // service. Increment this when you change the database schema.
-const symbolsDBVersion = 1
+const symbolsDBVersion = 3
+
+// symbolsDBKey returns the disk cache key of the symbols database of
+// repo@commitID.
+func symbolsDBKey(repo api.RepoName, commitID api.CommitID) string {
+	return fmt.Sprintf("%d-%s@%s", symbolsDBVersion, repo, commitID)
+}
 
 // symbolInDB is the same as `protocol.Symbol`, but with two additional columns:
 // namelowercase and pathlowercase, which enable indexed case insensitive
@@ -252,6 +264,12 @@ type symbolInDB struct {
 	Signature     string
 	Pattern       string
 
+	Character    int
+	EndLine      int
+	EndCharacter int
+	Doc          string
+	Access       string
+
 	FileLimited bool
 }
 
@@ -269,6 +287,12 @@ func symbolToSymbolInDB(symbol protocol.Symbol) symbolInDB {
 		Signature:     symbol.Signature,
 		Pattern:       symbol.Pattern,
 
+		Character:    symbol.Character,
+		EndLine:      symbol.EndLine,
+		EndCharacter: symbol.EndCharacter,
+		Doc:          symbol.Doc,
+		Access:       symbol.Access,
+
 		FileLimited: symbol.FileLimited,
 	}
 }
@@ -285,6 +309,12 @@ func symbolInDBToSymbol(symbolInDB symbolInDB) protocol.Symbol {
 		Signature:  symbolInDB.Signature,
 		Pattern:    symbolInDB.Pattern,
 
+		Character:    symbolInDB.Character,
+		EndLine:      symbolInDB.EndLine,
+		EndCharacter: symbolInDB.EndCharacter,
+		Doc:          symbolInDB.Doc,
+		Access:       symbolInDB.Access,
+
 		FileLimited: symbolInDB.FileLimited,
 	}
 }
@@ -320,6 +350,11 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 			parentkind VARCHAR(255) NOT NULL,
 			signature VARCHAR(255) NOT NULL,
 			pattern VARCHAR(255) NOT NULL,
+			character INT NOT NULL,
+			endline INT NOT NULL,
+			endcharacter INT NOT NULL,
+			doc TEXT NOT NULL,
+			access VARCHAR(255) NOT NULL,
 			filelimited BOOLEAN NOT NULL
 		)`)
 	if err != nil {
@@ -347,16 +382,12 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 		return err
 	}
 
-	insertStatement, err := tx.PrepareNamed(
-		fmt.Sprintf(
-			"INSERT INTO symbols %s VALUES %s",
-			"( name,  namelowercase,  path,  pathlowercase,  line,  kind,  language,  parent,  parentkind,  signature,  pattern,  filelimited)",
-			"(:name, :namelowercase, :path, :pathlowercase, :line, :kind, :language, :parent, :parentkind, :signature, :pattern, :filelimited)"))
+	insertStatement, err := prepareInsertSymbol(tx)
 	if err != nil {
 		return err
 	}
 
-	err = s.parseUncached(ctx, repoName, commitID, func(symbol protocol.Symbol) error {
+	err = s.parseUncached(ctx, repoName, commitID, nil, func(symbol protocol.Symbol) error {
 		symbolInDBValue := symbolToSymbolInDB(symbol)
 		_, err := insertStatement.Exec(&symbolInDBValue)
 		return err
@@ -372,3 +403,13 @@ func (s *Service) writeAllSymbolsToNewDB(ctx context.Context, dbFile string, rep
 
 	return nil
 }
+
+// prepareInsertSymbol prepares the statement which inserts a symbolInDB into
+// the symbols table.
+func prepareInsertSymbol(tx *sqlx.Tx) (*sqlx.NamedStmt, error) {
+	return tx.PrepareNamed(
+		fmt.Sprintf(
+			"INSERT INTO symbols %s VALUES %s",
+			"( name,  namelowercase,  path,  pathlowercase,  line,  kind,  language,  parent,  parentkind,  signature,  pattern,  character,  endline,  endcharacter,  doc,  access,  filelimited)",
+			"(:name, :namelowercase, :path, :pathlowercase, :line, :kind, :language, :parent, :parentkind, :signature, :pattern, :character, :endline, :endcharacter, :doc, :access, :filelimited)"))
+}
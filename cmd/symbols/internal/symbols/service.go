@@ -22,6 +22,20 @@ type Service struct {
 	// determine if the error is a bad request (eg invalid repo).
 	FetchTar func(context.Context, gitserver.Repo, api.CommitID) (io.ReadCloser, error)
 
+	// FetchTarPaths is like FetchTar, but the archive only includes the files
+	// at the given paths.
+	FetchTarPaths func(context.Context, gitserver.Repo, api.CommitID, []string) (io.ReadCloser, error)
+
+	// ListAncestors returns up to n ancestors of commit, nearest first.
+	ListAncestors func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error)
+
+	// GitDiff returns the output of running git diff with args in repo.
+	//
+	// If FetchTarPaths, ListAncestors and GitDiff are set, the symbols of a
+	// commit are found by updating the cached symbols of an ancestor commit
+	// with the files that changed since, instead of parsing all files.
+	GitDiff func(ctx context.Context, repo gitserver.Repo, args ...string) ([]byte, error)
+
 	// MaxConcurrentFetchTar is the maximum number of concurrent calls allowed
 	// to FetchTar. It defaults to 15.
 	MaxConcurrentFetchTar int
@@ -81,6 +95,8 @@ func (s *Service) Handler() http.Handler {
 	mux := http.NewServeMux()
 
 	mux.HandleFunc("/search", s.handleSearch)
+	mux.HandleFunc("/outline", s.handleOutline)
+	mux.HandleFunc("/list", s.handleList)
 	mux.HandleFunc("/healthz", s.handleHealthCheck)
 
 	return mux
@@ -21,6 +21,7 @@ import (
 	log15 "gopkg.in/inconshreveable/log15.v2"
 
 	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/ctags"
+	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/pkg/goparser"
 	"github.com/sourcegraph/sourcegraph/cmd/symbols/internal/symbols"
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/debugserver"
@@ -52,12 +53,41 @@ func main() {
 		FetchTar: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID) (io.ReadCloser, error) {
 			return git.Archive(ctx, repo, git.ArchiveOptions{Treeish: string(commit), Format: "tar"})
 		},
+		FetchTarPaths: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (io.ReadCloser, error) {
+			// The paths are pathspecs for git archive, so they are marked as literal to not
+			// interpret wildcards and magic in file names.
+			pathspecs := make([]string, len(paths))
+			for i, p := range paths {
+				pathspecs[i] = ":(literal)" + p
+			}
+			return git.Archive(ctx, repo, git.ArchiveOptions{Treeish: string(commit), Format: "tar", Paths: pathspecs})
+		},
+		ListAncestors: func(ctx context.Context, repo gitserver.Repo, commit api.CommitID, n int) ([]api.CommitID, error) {
+			commits, err := git.Commits(ctx, repo, git.CommitsOptions{Range: string(commit), N: uint(n), Skip: 1})
+			if err != nil {
+				return nil, err
+			}
+			ancestors := make([]api.CommitID, len(commits))
+			for i, c := range commits {
+				ancestors[i] = c.ID
+			}
+			return ancestors, nil
+		},
+		GitDiff: func(ctx context.Context, repo gitserver.Repo, args ...string) ([]byte, error) {
+			cmd := gitserver.DefaultClient.Command("git", append([]string{"diff"}, args...)...)
+			cmd.Repo = repo
+			return cmd.Output(ctx)
+		},
 		NewParser: func() (ctags.Parser, error) {
 			parser, err := ctags.NewParser(ctags.GetCommand())
 			if err != nil {
 				return nil, errors.Wrap(err, fmt.Sprintf("command: %s", ctags.GetCommand()))
 			}
-			return parser, nil
+			// Languages with a native parser are parsed more accurately than
+			// with ctags.
+			return ctags.ByExtension(parser, map[string]ctags.Parser{
+				".go": goparser.NewParser(),
+			}), nil
 		},
 		Path: cacheDir,
 	}
@@ -0,0 +1,5 @@
+BEGIN;
+DROP TABLE search_context_default;
+DROP TABLE search_context_repos;
+DROP TABLE search_contexts;
+END;
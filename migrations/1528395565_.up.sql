@@ -0,0 +1,30 @@
+BEGIN;
+CREATE TABLE search_contexts (
+    id serial PRIMARY KEY,
+    name citext NOT NULL,
+    description text NOT NULL DEFAULT '',
+    namespace_user_id integer REFERENCES users(id) ON DELETE CASCADE,
+    namespace_org_id integer REFERENCES orgs(id) ON DELETE CASCADE,
+    public boolean NOT NULL DEFAULT false,
+    repository_queries text[] NOT NULL DEFAULT '{}',
+    created_at timestamp with time zone NOT NULL DEFAULT now(),
+    updated_at timestamp with time zone NOT NULL DEFAULT now(),
+    deleted_at timestamp with time zone,
+    CONSTRAINT search_contexts_single_namespace CHECK ((namespace_user_id IS NULL) <> (namespace_org_id IS NULL)),
+    CONSTRAINT search_contexts_name_length CHECK (char_length(name::text) > 0 AND char_length(name::text) <= 128),
+    CONSTRAINT search_contexts_name_valid_chars CHECK (name ~ '^[a-zA-Z0-9](?:[a-zA-Z0-9]|[_.-](?=[a-zA-Z0-9]))*$'::citext)
+);
+CREATE UNIQUE INDEX search_contexts_namespace_name ON search_contexts(COALESCE(namespace_user_id, 0), COALESCE(namespace_org_id, 0), name) WHERE deleted_at IS NULL;
+
+CREATE TABLE search_context_repos (
+    search_context_id integer NOT NULL REFERENCES search_contexts(id) ON DELETE CASCADE,
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    revision text NOT NULL,
+    CONSTRAINT search_context_repos_unique UNIQUE (search_context_id, repo_id, revision)
+);
+
+CREATE TABLE search_context_default (
+    user_id integer PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    search_context_id integer NOT NULL REFERENCES search_contexts(id) ON DELETE CASCADE
+);
+END;
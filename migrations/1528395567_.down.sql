@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE search_insight_points;
+DROP TABLE search_insights;
+END;
@@ -0,0 +1,21 @@
+BEGIN;
+CREATE TABLE search_insights (
+    id serial PRIMARY KEY,
+    user_id integer NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    title text NOT NULL,
+    query text NOT NULL,
+    interval_days integer NOT NULL CHECK (interval_days > 0),
+    num_points integer NOT NULL CHECK (num_points > 0),
+    created_at timestamp with time zone NOT NULL DEFAULT now()
+);
+CREATE INDEX search_insights_user_id ON search_insights(user_id);
+
+CREATE TABLE search_insight_points (
+    insight_id integer NOT NULL REFERENCES search_insights(id) ON DELETE CASCADE,
+    time timestamp with time zone NOT NULL,
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    commit text NOT NULL,
+    count integer NOT NULL,
+    PRIMARY KEY (insight_id, time, repo_id)
+);
+END;
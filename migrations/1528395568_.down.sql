@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE global_symbol_repos;
+DROP TABLE global_symbols;
+END;
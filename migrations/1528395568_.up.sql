@@ -0,0 +1,22 @@
+BEGIN;
+CREATE TABLE global_symbols (
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    name text NOT NULL,
+    kind text NOT NULL,
+    language text NOT NULL,
+    path text NOT NULL,
+    line integer NOT NULL,
+    character integer NOT NULL,
+    parent text NOT NULL,
+    signature text NOT NULL,
+    exported boolean NOT NULL
+);
+CREATE INDEX global_symbols_repo_id ON global_symbols(repo_id);
+CREATE INDEX global_symbols_name_trgm ON global_symbols USING gin (name gin_trgm_ops);
+
+CREATE TABLE global_symbol_repos (
+    repo_id integer PRIMARY KEY REFERENCES repo(id) ON DELETE CASCADE,
+    commit text NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+END;
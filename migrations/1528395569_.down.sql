@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE lsif_documents;
+DROP TABLE lsif_dumps;
+END;
@@ -0,0 +1,16 @@
+BEGIN;
+CREATE TABLE lsif_dumps (
+    id serial PRIMARY KEY,
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    commit text NOT NULL,
+    uploaded_at timestamp with time zone NOT NULL DEFAULT now(),
+    CONSTRAINT lsif_dumps_repo_id_commit_unique UNIQUE (repo_id, commit)
+);
+
+CREATE TABLE lsif_documents (
+    dump_id integer NOT NULL REFERENCES lsif_dumps(id) ON DELETE CASCADE,
+    path text NOT NULL,
+    data jsonb NOT NULL,
+    PRIMARY KEY (dump_id, path)
+);
+END;
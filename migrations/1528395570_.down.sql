@@ -0,0 +1,4 @@
+BEGIN;
+DROP TABLE go_dependencies;
+DROP TABLE go_dependency_indexes;
+END;
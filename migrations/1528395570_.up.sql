@@ -0,0 +1,16 @@
+BEGIN;
+CREATE TABLE go_dependency_indexes (
+    repo_id integer PRIMARY KEY REFERENCES repo(id) ON DELETE CASCADE,
+    commit text NOT NULL,
+    module_paths text[] NOT NULL,
+    import_paths text[] NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE go_dependencies (
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    dependency_repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    PRIMARY KEY (repo_id, dependency_repo_id)
+);
+CREATE INDEX go_dependencies_dependency_repo_id ON go_dependencies(dependency_repo_id);
+END;
@@ -0,0 +1,6 @@
+BEGIN;
+DROP TABLE package_repos;
+DROP TABLE package_dependencies;
+DROP TABLE package_definitions;
+DROP TABLE package_manifest_indexes;
+END;
@@ -0,0 +1,30 @@
+BEGIN;
+CREATE TABLE package_manifest_indexes (
+    repo_id integer PRIMARY KEY REFERENCES repo(id) ON DELETE CASCADE,
+    commit text NOT NULL,
+    updated_at timestamp with time zone NOT NULL DEFAULT now()
+);
+
+CREATE TABLE package_definitions (
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    manager text NOT NULL,
+    name text NOT NULL,
+    PRIMARY KEY (repo_id, manager, name)
+);
+
+CREATE TABLE package_dependencies (
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    manager text NOT NULL,
+    name text NOT NULL,
+    PRIMARY KEY (repo_id, manager, name)
+);
+CREATE INDEX package_dependencies_manager_name ON package_dependencies(manager, name);
+
+CREATE TABLE package_repos (
+    manager text NOT NULL,
+    name text NOT NULL,
+    repo_id integer NOT NULL REFERENCES repo(id) ON DELETE CASCADE,
+    PRIMARY KEY (manager, name)
+);
+CREATE INDEX package_repos_repo_id ON package_repos(repo_id);
+END;
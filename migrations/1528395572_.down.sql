@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE search_insight_points DROP COLUMN partial;
+END;
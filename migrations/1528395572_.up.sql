@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE search_insight_points ADD COLUMN partial boolean NOT NULL DEFAULT false;
+END;
@@ -0,0 +1,6 @@
+BEGIN;
+-- The documents of the dumps don't contain the locations of their results.
+DELETE FROM lsif_dumps;
+
+DROP TABLE lsif_results;
+END;
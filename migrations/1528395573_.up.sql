@@ -0,0 +1,13 @@
+BEGIN;
+-- The locations of the results of the dumps are stored once per result instead
+-- of in every document that refers to them. The documents of the existing dumps
+-- still contain the locations, so the dumps must be uploaded again.
+DELETE FROM lsif_dumps;
+
+CREATE TABLE lsif_results (
+    dump_id integer NOT NULL REFERENCES lsif_dumps(id) ON DELETE CASCADE,
+    result_id text NOT NULL,
+    locations jsonb NOT NULL,
+    PRIMARY KEY (dump_id, result_id)
+);
+END;
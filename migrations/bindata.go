@@ -192,6 +192,24 @@
 // 1528395563_.up.sql (181B)
 // 1528395564_.down.sql (0)
 // 1528395564_.up.sql (0)
+// 1528395565_.down.sql (108B)
+// 1528395565_.up.sql (1.58kB)
+// 1528395566_.down.sql (39B)
+// 1528395566_.up.sql (509B)
+// 1528395567_.down.sql (74B)
+// 1528395567_.up.sql (772B)
+// 1528395568_.down.sql (71B)
+// 1528395568_.up.sql (707B)
+// 1528395569_.down.sql (62B)
+// 1528395569_.up.sql (489B)
+// 1528395570_.down.sql (74B)
+// 1528395570_.up.sql (596B)
+// 1528395571_.down.sql (140B)
+// 1528395571_.up.sql (947B)
+// 1528395572_.down.sql (67B)
+// 1528395572_.up.sql (97B)
+// 1528395573_.down.sql (138B)
+// 1528395573_.up.sql (468B)
 
 package migrations
 
@@ -4100,6 +4118,366 @@ func _1528395564_UpSql() (*asset, error) {
 	return a, nil
 }
 
+var __1528395565_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x6c\x00\x93\xff\x42\x45\x47\x49\x4e\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x65\x61\x72\x63\x68\x5f\x63\x6f\x6e\x74\x65\x78\x74\x5f\x64\x65\x66\x61\x75\x6c\x74\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x65\x61\x72\x63\x68\x5f\x63\x6f\x6e\x74\x65\x78\x74\x5f\x72\x65\x70\x6f\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x65\x61\x72\x63\x68\x5f\x63\x6f\x6e\x74\x65\x78\x74\x73\x3b\x0a\x45\x4e\x44\x3b\x0a\x03\x00\xae\x39\x5d\x30\x6c\x00\x00\x00")
+
+func _1528395565_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395565_DownSql,
+		"1528395565_.down.sql",
+	)
+}
+
+func _1528395565_DownSql() (*asset, error) {
+	bytes, err := _1528395565_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395565_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x2f, 0x7c, 0x18, 0x69, 0x9a, 0xe3, 0xf8, 0x20, 0x7f, 0xc, 0xb0, 0x69, 0x27, 0xb4, 0xf6, 0x51, 0x8, 0x99, 0x56, 0xa5, 0x98, 0x69, 0x43, 0x6d, 0x8d, 0x71, 0x9, 0x9f, 0xd6, 0x45, 0xea, 0xfc}}
+	return a, nil
+}
+
+var __1528395565_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xa4\x54\x5b\x6f\xd3\x4c\x10\x7d\xf7\xaf\x98\x87\x4f\x8a\xf7\x53\x82\x0a\x4f\x90\xf4\x22\xd7\xd9\xd2\xa8\x61\x03\x8e\x23\x28\x55\x59\x6d\xed\x69\xb2\x92\x6b\xbb\xbb\xeb\x16\xca\xe5\xb7\x23\xdf\x82\x1b\xa7\x4e\x11\x8f\xbb\x67\x66\xce\xcc\x99\xcb\x31\x7d\x3b\x61\x23\xcb\xf5\xa8\xe3\x53\xf0\x9d\xe3\x29\x05\x8d\x42\x05\x2b\x1e\x24\xb1\xc1\xaf\x46\x83\x6d\x01\x00\xc8\x10\x34\x2a\x29\x22\x78\xef\x4d\xde\x39\xde\x39\x9c\xd1\xf3\x7e\x01\xc5\xe2\x06\x21\x90\xb9\x35\xb0\x99\x0f\x6c\x31\x9d\x96\x48\x88\x3a\x50\x32\x35\x32\x89\xe1\x11\x0c\x63\x7a\xe2\x2c\xa6\x3e\xf4\x7a\x7f\x62\xe8\x54\x04\xc8\x33\x8d\x8a\xcb\x10\x64\x6c\x70\x89\x0a\x3c\x7a\x42\x3d\xca\x5c\x3a\x87\x1c\xd2\xb6\x0c\x09\xcc\x18\x8c\xe9\x94\xfa\x14\x5c\x67\xee\x3a\x63\xba\x19\x25\x51\xcb\x27\x82\x24\x6a\xd9\x19\x23\xcd\xae\x22\x19\xc0\x55\x92\x44\x28\xe2\x76\xc6\xd7\x22\xd2\x58\xd2\x29\x4c\x13\x2d\x4d\xa2\xbe\xf1\xdb\x0c\x95\x44\x5d\x54\x79\x71\xb9\xa5\xce\xef\x3f\xab\x4a\x03\x85\xc2\x60\xc8\x85\x01\x23\x6f\x50\x1b\x71\x93\xc2\xbd\x34\xab\xe2\x09\x0f\x49\x8c\x6d\xf7\x38\xb9\xb7\x49\xe9\x9f\xa5\xe1\x3f\xf9\x87\x18\xe1\x0e\xff\x92\xc8\x9d\xb1\xb9\xef\x39\x13\xe6\x6f\x8e\x04\xd7\x32\x5e\x46\xc8\xd7\x72\x83\x7b\x4a\xdd\x33\xb0\xed\x76\x1b\x27\xf3\x22\x13\x02\xfb\x87\x60\xb7\xfa\x53\xa3\x64\x27\x65\xee\xca\x23\x8c\x97\x66\x55\xb3\x05\x2b\xa1\xaa\xaf\x22\xf2\x70\x98\x9b\x12\x38\x84\x3d\x70\xd8\x18\x9e\xc2\xf7\x0f\xe0\xe5\xab\xd7\xcf\xa4\xbc\x13\x91\x0c\x79\x1e\x4a\xd7\xbc\xf9\x3f\xfc\x82\xde\x97\x0b\x31\x78\x70\x06\x9f\xf7\x06\x6f\x2e\xed\xa3\x61\xe3\xf5\xe3\x82\xbf\x18\x5c\xda\x47\x07\x8d\x3f\x42\xfe\xff\xaf\x37\x1c\x96\x7b\x42\x2c\xb2\xde\xba\x05\x9b\x7c\x58\x50\x98\xb0\x31\xfd\xb4\x35\x87\x52\xd0\x82\x75\xc6\x36\x2d\x6c\x77\xe6\x4c\xe9\xdc\xa5\x6d\xed\xfb\xb0\x47\xfa\xb0\x05\x2f\xc5\x2f\xe1\xdc\x8b\xc0\xc7\x53\xea\xd1\xe6\x68\x54\x8d\x19\x59\x5d\xb7\x81\x17\x0b\x50\x1d\x88\x0d\xa8\xb1\x7c\xeb\x69\x6c\x6c\xe1\x63\xeb\xce\x85\xcc\x49\x76\x85\xcb\x6d\xba\x63\xdc\x49\xdd\xba\x42\x3b\x26\xa0\x2c\x8f\x67\xb1\xbc\xcd\xb0\x6e\x93\xbd\x61\x93\xcb\x5c\x65\xd8\x5f\xd3\x14\xed\xed\x54\x2e\xc4\x6b\x91\x45\xa6\xd2\xae\x5e\x96\xba\xc4\xc6\x89\xfd\xab\xfb\xd7\xca\xad\x53\xb4\x67\xf5\xc0\x22\x23\x8b\xb2\xf1\xc8\xfa\x3d\x00\xa9\x0d\xb7\x95\x2b\x06\x00\x00")
+
+func _1528395565_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395565_UpSql,
+		"1528395565_.up.sql",
+	)
+}
+
+func _1528395565_UpSql() (*asset, error) {
+	bytes, err := _1528395565_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395565_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xa2, 0xf0, 0x29, 0x0, 0xc0, 0x45, 0x2a, 0x75, 0x46, 0x7, 0xf9, 0x8a, 0x60, 0xdb, 0xbe, 0x92, 0xad, 0xc5, 0x8b, 0x7f, 0xf1, 0x35, 0xb8, 0xdf, 0x5, 0x53, 0x1e, 0x58, 0xba, 0xa4, 0xf1, 0xb0}}
+	return a, nil
+}
+
+var __1528395566_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x27\x00\xd8\xff\x42\x45\x47\x49\x4e\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x65\x61\x72\x63\x68\x5f\x68\x69\x73\x74\x6f\x72\x79\x3b\x0a\x45\x4e\x44\x3b\x0a\x03\x00\xd8\x44\x43\x1f\x27\x00\x00\x00")
+
+func _1528395566_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395566_DownSql,
+		"1528395566_.down.sql",
+	)
+}
+
+func _1528395566_DownSql() (*asset, error) {
+	bytes, err := _1528395566_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395566_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xca, 0xdd, 0x4c, 0x61, 0x8f, 0x11, 0xd1, 0xc8, 0xf5, 0x3f, 0xef, 0xa8, 0x78, 0x61, 0x70, 0xa9, 0x3a, 0x6d, 0x4b, 0xa, 0xb8, 0x15, 0x7a, 0x4a, 0xb3, 0x6a, 0x39, 0x39, 0xce, 0xe7, 0x3a, 0x97}}
+	return a, nil
+}
+
+var __1528395566_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x90\xc1\x6e\xea\x30\x10\x45\xf7\xf9\x8a\x59\x26\x12\x7f\xc0\xca\xc4\xc3\x13\x7a\xa9\xa9\x42\x90\xca\xca\x32\x78\x0a\x96\x82\xdd\xda\x63\x51\xfa\xf5\x95\x12\x44\x11\xa8\xed\xd2\xba\xe7\x9e\xb1\xee\x0c\xff\x2d\xd4\xb4\xa8\x5b\x14\x1d\x42\x27\x66\x0d\x42\x22\x13\x77\x07\x7d\x70\x89\x43\x3c\x43\x59\x00\x00\x38\x0b\x5b\xb7\x4f\x14\x9d\xe9\xe1\xb9\x5d\x3c\x89\x76\x03\xff\x71\x33\x19\xd2\x9c\x28\x6a\x67\xc1\x79\xa6\x3d\x45\x50\xcb\x0e\xd4\xba\x69\xa0\xc5\x39\xb6\xa8\x6a\x5c\x0d\x4c\x2a\x9d\xad\x60\xa9\x40\x62\x83\x1d\x42\x2d\x56\xb5\x90\x38\x4a\xde\x33\xc5\x33\x30\x7d\xf0\xb5\x3f\x06\x91\x52\xee\x59\xef\x42\xf6\xfc\x70\x62\x44\x6c\x8e\x86\x5d\xf0\xfa\x98\x7e\x20\xd8\x1d\xc9\xea\x90\x19\xb6\x21\xf4\x64\xfc\x35\x07\x89\x73\xb1\x6e\x3a\x78\x35\x7d\xa2\xd1\xb7\x8b\x64\x98\xac\x36\x3c\x14\x13\x9b\xe3\x1b\x9c\x1c\x1f\x86\x27\x7c\x06\x4f\x8f\x7d\x1f\x4e\x65\x55\x54\xd7\x39\x17\x4a\xe2\xcb\xdd\x9c\xfa\x32\x95\xbe\xb9\xb0\x54\x77\x50\x79\x81\x26\xb7\xff\x90\xb8\xaa\xff\x70\xff\xea\xfc\x0e\xab\x69\x81\x4a\x4e\x8b\xaf\x01\x00\x74\x83\x99\x7e\xfd\x01\x00\x00")
+
+func _1528395566_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395566_UpSql,
+		"1528395566_.up.sql",
+	)
+}
+
+func _1528395566_UpSql() (*asset, error) {
+	bytes, err := _1528395566_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395566_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xd3, 0x90, 0x91, 0x5c, 0x60, 0xb, 0x46, 0x87, 0x84, 0x8b, 0xcd, 0x55, 0xc5, 0xdd, 0xc9, 0xee, 0x89, 0xe6, 0x9f, 0xe5, 0x78, 0x23, 0xe, 0x78, 0x6b, 0xa8, 0xaa, 0x22, 0x0, 0x4c, 0xa0, 0xb8}}
+	return a, nil
+}
+
+var __1528395567_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4a\x00\xb5\xff\x42\x45\x47\x49\x4e\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x65\x61\x72\x63\x68\x5f\x69\x6e\x73\x69\x67\x68\x74\x5f\x70\x6f\x69\x6e\x74\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x73\x65\x61\x72\x63\x68\x5f\x69\x6e\x73\x69\x67\x68\x74\x73\x3b\x0a\x45\x4e\x44\x3b\x0a\x03\x00\x7b\x33\x09\xa3\x4a\x00\x00\x00")
+
+func _1528395567_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395567_DownSql,
+		"1528395567_.down.sql",
+	)
+}
+
+func _1528395567_DownSql() (*asset, error) {
+	bytes, err := _1528395567_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395567_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xac, 0xa2, 0x72, 0x67, 0x2e, 0x8e, 0x90, 0x83, 0xef, 0xd5, 0x5c, 0x64, 0x1d, 0x22, 0x11, 0xff, 0x6e, 0x3f, 0xb6, 0x90, 0xde, 0x26, 0x6f, 0x78, 0x9b, 0x84, 0x44, 0x5e, 0x70, 0xb9, 0x9, 0x8b}}
+	return a, nil
+}
+
+var __1528395567_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x52\x4d\x6f\xea\x30\x10\xbc\xfb\x57\xec\x31\x96\x38\xbc\x7b\xa4\x27\x85\x64\x79\x0f\x91\x9a\x2a\x04\xa9\x9c\x22\x2b\x59\x81\x25\xe2\x50\x7b\x53\x4a\x7f\x7d\x45\xc2\x57\x81\xc2\x31\x99\x99\x9d\xf5\xcc\x0e\xf1\xdf\x58\x85\x22\xce\x30\xca\x11\xf2\x68\x98\x22\x78\xd2\xae\x5c\x15\xc6\x7a\xb3\x5c\xb1\x87\x40\x00\x00\x98\x0a\x3c\x39\xa3\xd7\xf0\x9a\x8d\x5f\xa2\x6c\x01\x13\x5c\x0c\x3a\xa8\xf5\xe4\x0a\x53\x81\xb1\x4c\x4b\x72\xa0\xa6\x39\xa8\x79\x9a\x42\x86\x23\xcc\x50\xc5\x38\xeb\x38\x3e\x30\x95\x84\xa9\x82\x04\x53\xcc\x11\xe2\x68\x16\x47\x09\xf6\x43\xd8\xf0\x9a\x80\xe9\x93\x4f\xfa\x1e\x78\x6f\xc9\xed\xee\x01\x7b\x3b\xf7\xa1\xd7\x45\xa5\x77\xfe\xd6\x3c\xfe\x8f\xf1\x04\x82\x9f\xac\xbf\xf0\x47\xf6\x6a\xdb\xd6\xc5\xa6\x31\x96\x7f\x97\x5e\x50\xce\xba\xd2\x91\x66\xaa\x0a\xcd\xc0\xa6\x26\xcf\xba\xde\xc0\xd6\xf0\xaa\xfb\x84\xaf\xc6\xd2\x79\x50\x82\xa3\x68\x9e\xe6\x60\x9b\x6d\x20\x85\x3c\x05\x3d\x56\x09\xbe\x5d\x07\x5d\x1c\x73\x9c\xaa\x6b\x28\x38\x40\x32\x14\x8f\xba\x3a\x6e\x7b\x68\xec\xf0\xf3\x49\x33\xd7\x56\x0f\x3b\xaa\xe9\xf9\xab\xfb\x9c\x1c\x6d\x9a\x67\x47\xb1\xe7\x3c\xf2\x2b\x9b\xba\x36\x7c\xaf\xfb\xb2\x69\x2d\xdf\xcc\xee\xb1\x8b\xfb\x84\xe0\x1c\xc2\xa0\xdb\x7c\x70\x5c\x4c\x0a\x19\x0a\x54\x49\x28\xbe\x07\x00\x1e\x95\xa0\x08\x04\x03\x00\x00")
+
+func _1528395567_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395567_UpSql,
+		"1528395567_.up.sql",
+	)
+}
+
+func _1528395567_UpSql() (*asset, error) {
+	bytes, err := _1528395567_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395567_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x27, 0xa8, 0x4d, 0x93, 0x73, 0xb6, 0x88, 0xe5, 0x49, 0xb1, 0xca, 0xb8, 0xa9, 0xf5, 0x1, 0x81, 0x48, 0xc3, 0xf9, 0xa8, 0x2, 0xb7, 0xcd, 0xc4, 0xba, 0x3f, 0x14, 0x4a, 0x1, 0xa8, 0x4e, 0xb8}}
+	return a, nil
+}
+
+var __1528395568_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x47\x00\xb8\xff\x42\x45\x47\x49\x4e\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x67\x6c\x6f\x62\x61\x6c\x5f\x73\x79\x6d\x62\x6f\x6c\x5f\x72\x65\x70\x6f\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x67\x6c\x6f\x62\x61\x6c\x5f\x73\x79\x6d\x62\x6f\x6c\x73\x3b\x0a\x45\x4e\x44\x3b\x0a\x03\x00\x10\x08\x2c\x1c\x47\x00\x00\x00")
+
+func _1528395568_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395568_DownSql,
+		"1528395568_.down.sql",
+	)
+}
+
+func _1528395568_DownSql() (*asset, error) {
+	bytes, err := _1528395568_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395568_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb8, 0x7b, 0xab, 0x9b, 0xad, 0x7e, 0x45, 0x7f, 0xba, 0x8a, 0xdd, 0x1f, 0x47, 0x26, 0x62, 0x73, 0x8e, 0x1e, 0x64, 0xe4, 0x43, 0xd3, 0xf9, 0x7, 0xe1, 0xf1, 0x5d, 0xf5, 0x16, 0xe7, 0x77, 0x9f}}
+	return a, nil
+}
+
+var __1528395568_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x94\x51\x4d\x6f\x82\x40\x10\xbd\xef\xaf\x98\x23\x24\xfd\x07\x9c\x10\x46\x43\x4a\xd7\x06\x31\xa9\x27\xb2\xca\x64\xdd\x94\xfd\xc8\xb2\x46\xdb\x5f\xdf\x80\x2d\x07\xc5\x36\xbd\x4d\xe6\x7d\xe4\xe5\xbd\x05\xae\x0a\x9e\xb0\xac\xc2\xb4\x46\xa8\xd3\x45\x89\x20\x3b\xbb\x17\x5d\xd3\x7f\xe8\xbd\xed\x7a\x88\x18\x00\x80\x27\x67\x1b\xd5\x82\x32\x81\x24\x79\xe0\xeb\x1a\xf8\xb6\x2c\xa1\xc2\x25\x56\xc8\x33\xdc\x8c\x9c\x48\xb5\x31\xac\x39\xe4\x58\x62\x8d\x90\xa5\x9b\x2c\xcd\xf1\x69\xf4\x30\x42\x13\x04\xba\x84\x49\x7d\xfd\xbf\x2b\xd3\xce\xfd\x3b\x61\xe4\x49\xc8\x59\x8d\x13\xe1\x38\xab\x51\x86\xee\x42\x5e\xa1\xc3\x51\x78\x71\x08\xe4\x1f\xe0\x4e\x78\x32\x61\xce\xb4\x57\xd2\x88\x70\xf2\xb3\x49\xe8\xe2\xac\x0f\xd4\xc2\xde\xda\x8e\x84\x99\x60\x16\x4f\xc5\x16\x3c\xc7\xb7\x9b\x62\x9b\x9f\x4a\xd7\xfc\x06\x89\xbe\x91\x3f\xf4\x43\x9d\x4d\xf0\x52\xdf\x3b\xc0\x76\x53\xf0\x15\x48\x65\x20\x1a\x68\xc3\x35\x52\x1b\xeb\xfa\x38\x61\xbf\x0c\x3e\xc6\x7a\xb4\xfa\x6b\x55\xbc\xa4\xd5\x0e\x9e\x71\xf7\x9f\xe1\x0f\x56\x6b\x35\xdb\xec\xc9\xb5\x22\x50\xdb\x88\x00\x41\x69\xea\x83\xd0\x0e\xce\x6a\xd8\x56\x69\x82\x4f\x6b\x68\x52\x40\x8e\xcb\x74\x5b\xd6\x60\xec\x39\x8a\x59\x9c\x30\xe4\x79\xc2\xbe\x06\x00\x50\x57\xb4\x53\xc3\x02\x00\x00")
+
+func _1528395568_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395568_UpSql,
+		"1528395568_.up.sql",
+	)
+}
+
+func _1528395568_UpSql() (*asset, error) {
+	bytes, err := _1528395568_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395568_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x50, 0x4a, 0xfd, 0x89, 0x3c, 0x67, 0x26, 0x96, 0xb7, 0xf, 0x31, 0x7d, 0xbb, 0xf0, 0x18, 0xa6, 0xa, 0x89, 0x34, 0x42, 0x4a, 0x36, 0xf7, 0x9e, 0x82, 0x57, 0x11, 0xd9, 0x87, 0x88, 0xaf, 0xa}}
+	return a, nil
+}
+
+var __1528395569_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x3e\x00\xc1\xff\x42\x45\x47\x49\x4e\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6c\x73\x69\x66\x5f\x64\x6f\x63\x75\x6d\x65\x6e\x74\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x6c\x73\x69\x66\x5f\x64\x75\x6d\x70\x73\x3b\x0a\x45\x4e\x44\x3b\x0a\x03\x00\xcb\x66\x0e\xf4\x3e\x00\x00\x00")
+
+func _1528395569_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395569_DownSql,
+		"1528395569_.down.sql",
+	)
+}
+
+func _1528395569_DownSql() (*asset, error) {
+	bytes, err := _1528395569_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395569_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x53, 0x2d, 0xce, 0x84, 0xf6, 0xe7, 0x12, 0x31, 0x27, 0xc4, 0x9d, 0x8b, 0xb9, 0xbe, 0x19, 0x12, 0xb6, 0x1, 0x79, 0xfe, 0x69, 0x51, 0x67, 0x8d, 0xc, 0xf4, 0xe0, 0x7c, 0x1d, 0xea, 0x82, 0x47}}
+	return a, nil
+}
+
+var __1528395569_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x84\x91\x41\x4e\xeb\x30\x14\x45\xe7\x5e\xc5\x1d\x26\x52\x77\x90\x91\x9b\xbc\x7e\x45\x3f\xb8\x90\x3a\x83\x8e\x22\x53\x1b\x6a\x54\xdb\x21\x71\x54\xc4\xea\x11\x49\xa0\x15\xaa\x60\x68\x5d\xfb\xbe\xe3\xf3\xd6\xf4\xaf\x14\x19\xcb\x6b\xe2\x92\x20\xf9\xba\x22\x9c\x06\xfb\xd4\xea\xd1\x75\x03\x12\x06\x00\x56\x63\x30\xbd\x55\x27\xdc\xd7\xe5\x1d\xaf\xf7\xf8\x4f\xfb\xd5\x14\xf5\xa6\x0b\xad\xd5\xb0\x3e\x9a\x67\xd3\x43\x6c\x25\x44\x53\x55\xa8\x69\x43\x35\x89\x9c\x76\xd3\x9d\xc4\xea\x14\x5b\x81\x82\x2a\x92\x84\x9c\xef\x72\x5e\xd0\xdc\x71\x08\xce\xd9\x88\x68\xde\xe2\xf7\xfb\x39\x19\xbb\x53\x50\xda\xe8\x56\x45\x44\xeb\xcc\x10\x95\xeb\x70\xb6\xf1\x38\x1d\xf1\x1e\xbc\xb9\x8c\x2c\x68\xc3\x9b\x4a\xc2\x87\x73\x92\xce\x05\xf9\x56\xec\x64\xcd\x4b\x21\xaf\x7e\xd5\x2e\xd0\xed\x3c\xb8\x1d\xbd\x7d\x1d\x0d\x1a\x51\x3e\x34\x84\x64\x49\x57\x0b\x57\xca\xd2\x8c\xdd\x12\x14\x0e\xa3\x33\x3e\x7e\x49\xfa\xac\xfe\xcb\xc4\x85\xe1\x37\x1f\x9d\x8a\xc7\x5b\x36\xb4\x8a\x0a\x2f\x43\xf0\x8f\x3f\x82\xab\xad\x20\x59\x38\x56\x53\x4d\xca\xd2\x8c\x91\x28\x32\xf6\x31\x00\x56\xb1\x6a\x6f\xe9\x01\x00\x00")
+
+func _1528395569_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395569_UpSql,
+		"1528395569_.up.sql",
+	)
+}
+
+func _1528395569_UpSql() (*asset, error) {
+	bytes, err := _1528395569_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395569_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xaa, 0x6, 0xa9, 0x7d, 0xcc, 0x25, 0x10, 0xa0, 0x54, 0x17, 0xe6, 0xe2, 0x34, 0x68, 0xbf, 0x8, 0x57, 0xf3, 0x3b, 0xab, 0xe4, 0x86, 0xfa, 0xa, 0xfd, 0x95, 0xb9, 0xf5, 0xa5, 0x9d, 0x29, 0x7}}
+	return a, nil
+}
+
+var __1528395570_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x00\x4a\x00\xb5\xff\x42\x45\x47\x49\x4e\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x67\x6f\x5f\x64\x65\x70\x65\x6e\x64\x65\x6e\x63\x69\x65\x73\x3b\x0a\x44\x52\x4f\x50\x20\x54\x41\x42\x4c\x45\x20\x67\x6f\x5f\x64\x65\x70\x65\x6e\x64\x65\x6e\x63\x79\x5f\x69\x6e\x64\x65\x78\x65\x73\x3b\x0a\x45\x4e\x44\x3b\x0a\x03\x00\x63\x8f\xfe\x23\x4a\x00\x00\x00")
+
+func _1528395570_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395570_DownSql,
+		"1528395570_.down.sql",
+	)
+}
+
+func _1528395570_DownSql() (*asset, error) {
+	bytes, err := _1528395570_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395570_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x22, 0x3d, 0x54, 0x30, 0x3e, 0x1e, 0x9f, 0x30, 0x95, 0xa2, 0xea, 0xec, 0xc8, 0x63, 0x36, 0x4f, 0xc2, 0xe4, 0x20, 0xa8, 0xc5, 0x53, 0x15, 0xb5, 0xce, 0x2b, 0x38, 0x86, 0xf8, 0xe1, 0x6e, 0xac}}
+	return a, nil
+}
+
+var __1528395570_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xac\x91\xdf\x6a\xf2\x40\x10\xc5\xef\xf7\x29\xce\x65\x02\xbe\x41\xae\xd6\x64\xfc\x90\x2f\x5d\x4b\x8c\x50\x29\x65\x09\xee\xa0\x0b\xdd\x3f\x98\x15\x6d\x9f\xbe\x34\x15\x91\x58\x0b\x85\x5e\x2e\xf3\x9b\x33\x9c\xdf\x4e\xe9\xdf\x5c\x15\xa2\x6c\x48\xb6\x84\x56\x4e\x6b\xc2\x36\x68\xc3\x91\xbd\x61\xbf\x79\xd3\xd6\x1b\x3e\x71\x8f\x4c\x00\xc0\x9e\x63\xd0\xd6\xc0\xfa\xc4\x5b\xde\xe3\xb1\x99\x3f\xc8\x66\x8d\xff\xb4\x46\x43\x33\x6a\x48\x95\xb4\x1c\xb0\xcc\x9a\x1c\x0b\x85\x8a\x6a\x6a\x09\xa5\x5c\x96\xb2\xa2\xc9\x10\xb3\x09\xce\xd9\x84\xc4\xa7\x04\xb5\x68\xa1\x56\x75\xfd\x35\x71\xc1\x1c\x5e\x59\xc7\x2e\xed\xfa\x61\xfe\xfc\x32\x22\xac\x8b\x61\x9f\x7e\x22\x0e\xd1\x74\x89\x8d\xee\x12\x92\x75\xdc\xa7\xce\x45\x1c\x6d\xda\x0d\x4f\xbc\x07\xcf\x97\x0d\x54\x34\x93\xab\xba\x85\x0f\xc7\x2c\x17\x79\x21\xee\xcb\xb0\x77\x35\x5c\xd2\x7e\xe1\xe0\xca\xf1\x5f\xc4\x5d\xff\x44\x76\x0e\x9c\x7c\x73\x64\xe8\x78\xae\x38\x57\x15\x3d\x8d\x2b\xea\xdb\x9d\xcf\x9b\x23\x2a\xbb\xa5\xf2\x42\x90\xaa\x0a\xf1\x31\x00\x41\xc5\xc8\x03\x54\x02\x00\x00")
+
+func _1528395570_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395570_UpSql,
+		"1528395570_.up.sql",
+	)
+}
+
+func _1528395570_UpSql() (*asset, error) {
+	bytes, err := _1528395570_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395570_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x70, 0x8c, 0x8c, 0x14, 0xc2, 0x8e, 0xad, 0xb5, 0x60, 0x42, 0x9, 0xdb, 0xcd, 0xf5, 0x27, 0x4c, 0x8, 0x77, 0x58, 0x78, 0x38, 0xcf, 0x4f, 0xe7, 0xd5, 0x86, 0x4, 0x99, 0xe6, 0xcd, 0x67, 0xc9}}
+	return a, nil
+}
+
+var __1528395571_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x72\x72\x75\xf7\xf4\xb3\xe6\x72\x09\xf2\x0f\x50\x08\x71\x74\xf2\x71\x55\x28\x48\x4c\xce\x4e\x4c\x4f\x8d\x2f\x4a\x2d\xc8\x2f\xc6\x2a\x93\x92\x5a\x90\x9a\x97\x92\x9a\x97\x9c\x99\x8a\x4b\x41\x5a\x66\x5e\x66\x49\x66\x7e\x1e\x76\xf9\xdc\xc4\xbc\xcc\xb4\xd4\xe2\x92\xf8\xcc\xbc\x94\xd4\x8a\xd4\x62\x6b\x2e\x57\x3f\x17\x6b\x2e\xc0\x00\x05\x17\x5a\x8a\x8c\x00\x00\x00")
+
+func _1528395571_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395571_DownSql,
+		"1528395571_.down.sql",
+	)
+}
+
+func _1528395571_DownSql() (*asset, error) {
+	bytes, err := _1528395571_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395571_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x54, 0x6b, 0x99, 0x93, 0x8a, 0x74, 0x26, 0xb7, 0x18, 0xd2, 0x11, 0x79, 0xce, 0x59, 0x54, 0xa4, 0x98, 0xa1, 0xb2, 0x11, 0x47, 0x74, 0x6a, 0x2f, 0xf4, 0x77, 0x82, 0x24, 0xaf, 0xfb, 0x24, 0xc3}}
+	return a, nil
+}
+
+var __1528395571_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\xcc\x91\xc1\x6a\xeb\x30\x10\x45\xf7\xfa\x8a\x59\xda\x90\x3f\xf0\x4a\xb1\x27\x8f\xf0\x5c\xa5\x38\x0e\x34\x2b\x21\xa2\x49\x3a\x14\xc9\x26\x56\x49\xe8\xd7\x17\xb9\x71\x48\xc0\x2e\x6d\xe9\xa2\x4b\xcb\xba\x77\xe6\x1c\xcd\xf1\xdf\x52\x65\x22\xaf\x50\xd6\x08\xb5\x9c\x97\x08\xad\xd9\xbd\x98\x03\x69\x67\x3c\xef\xa9\x0b\x9a\xbd\xa5\x33\x75\x90\x08\x00\x80\x23\xb5\x8d\x66\x0b\xec\x03\x1d\xe8\x08\x8f\xd5\xf2\x41\x56\x5b\xf8\x8f\x5b\xa8\x70\x81\x15\xaa\x1c\xd7\xfd\xb5\x84\x6d\x0a\x2b\x05\x05\x96\x58\x23\xe4\x72\x9d\xcb\x02\x67\x7d\xcd\xae\x71\x8e\x03\x04\x3a\x07\x50\xab\x1a\xd4\xa6\x2c\x3f\xfe\xbc\xb6\xd6\x04\xb2\xda\x04\x08\xec\xa8\x0b\xc6\xb5\x70\xe2\xf0\xdc\x7f\xc2\x5b\xe3\xe9\x9a\x80\x02\x17\x72\x53\xd6\xe0\x9b\x53\x92\x8a\x34\x13\xe3\x2c\x96\xf6\xec\x39\x70\xe3\xa7\x30\xae\x8d\xdf\x60\x70\xc6\x9b\x98\x1d\x81\xf0\xc6\xd1\xd8\xf9\xad\xad\xe4\xb2\xc3\x6c\x28\x9a\xf5\xb1\x4f\x31\x5a\xf2\x96\xfc\x8e\x27\x9f\x63\x18\xf7\x47\x38\x2e\x18\x4b\x55\xe0\xd3\x28\x86\xbe\x64\x74\x8c\xc4\x05\xc7\x2e\x25\xf7\xc5\x53\x7a\xe2\x1e\x83\x97\x1f\x30\xfd\x86\xca\x3b\x2f\x5f\xd4\x11\x3b\x3b\x3d\x4c\xbf\x51\x10\x8f\xba\xc1\x6e\x9a\x09\x54\x45\x26\xde\x07\x00\x02\x8e\x2b\xf4\xb3\x03\x00\x00")
+
+func _1528395571_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395571_UpSql,
+		"1528395571_.up.sql",
+	)
+}
+
+func _1528395571_UpSql() (*asset, error) {
+	bytes, err := _1528395571_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395571_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xd5, 0xd8, 0x10, 0x8d, 0x4f, 0x5a, 0xda, 0x1d, 0x12, 0x40, 0xf0, 0x16, 0xa, 0x57, 0x38, 0x4e, 0x4, 0xb2, 0x11, 0xc8, 0x87, 0x8, 0xfa, 0x2f, 0x1, 0xee, 0x36, 0xb7, 0x31, 0x6d, 0x3c, 0xb2}}
+	return a, nil
+}
+
+var __1528395572_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x73\x72\x75\xf7\xf4\xb3\xe6\x72\xf4\x09\x71\x0d\x52\x08\x71\x74\xf2\x71\x55\x28\x4e\x4d\x2c\x4a\xce\x88\xcf\xcc\x2b\xce\x4c\xcf\x28\x89\x2f\xc8\xcf\xcc\x2b\x29\x56\x70\x09\xf2\x0f\x50\x70\xf6\xf7\x09\xf5\xf5\x53\x28\x48\x2c\x2a\xc9\x4c\xcc\xb1\xe6\x72\xf5\x73\xb1\xe6\x02\x00\x8d\xff\xa6\x74\x43\x00\x00\x00")
+
+func _1528395572_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395572_DownSql,
+		"1528395572_.down.sql",
+	)
+}
+
+func _1528395572_DownSql() (*asset, error) {
+	bytes, err := _1528395572_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395572_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0xb6, 0x5, 0x8a, 0x1a, 0x73, 0xb7, 0xbe, 0x18, 0x7e, 0x21, 0xfc, 0xc5, 0x98, 0xa0, 0xf8, 0x48, 0xd5, 0xac, 0x4c, 0xe7, 0xed, 0x3b, 0xa6, 0xb9, 0x2a, 0x39, 0x16, 0xbb, 0x36, 0x64, 0x4e, 0xa6}}
+	return a, nil
+}
+
+var __1528395572_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x05\xc1\x41\x0a\xc3\x20\x10\x05\xd0\xbd\xa7\xf8\xf7\x70\x65\xea\xa4\x04\xa6\x13\x08\xba\x0e\xd3\x60\x1b\x41\x34\x44\xef\x4f\xde\x9b\xe8\xbd\x88\x35\x8e\x03\x6d\x08\x6e\x62\x42\x4f\x7a\x1f\xe7\x9e\x6b\xcf\xff\x73\xec\x57\xcb\x75\x74\x38\xef\xf1\x5a\x39\x7e\x04\x97\xde\x23\x6b\xc1\xb7\xb5\x92\xb4\x42\xd6\x00\x89\xcc\xf0\x34\xbb\xc8\x01\x3f\x2d\x3d\x59\x43\xe2\xad\x79\x00\xba\xe5\x5c\x56\x61\x00\x00\x00")
+
+func _1528395572_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395572_UpSql,
+		"1528395572_.up.sql",
+	)
+}
+
+func _1528395572_UpSql() (*asset, error) {
+	bytes, err := _1528395572_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395572_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x96, 0xa0, 0xeb, 0x38, 0xa5, 0x6b, 0xad, 0xad, 0xce, 0x9a, 0xa0, 0x33, 0x64, 0x3, 0x50, 0x5f, 0x24, 0xfc, 0x46, 0xd6, 0x35, 0x75, 0xe1, 0x14, 0x78, 0x39, 0xe0, 0xa8, 0xb, 0x2e, 0xea, 0x7a}}
+	return a, nil
+}
+
+var __1528395573_DownSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x35\xcc\x31\x0e\xc2\x30\x0c\x85\xe1\xdd\xa7\xf0\xc6\x54\x2e\x90\x89\x2a\x06\x21\x95\x16\x55\xd9\x51\x15\x52\x11\x29\xb5\x51\xed\xdc\x1f\x28\xb0\x7e\xfa\xdf\x6b\xe9\x74\xee\x1d\x34\x0d\x86\x47\xc2\xbb\xc4\xba\x24\x36\x45\x99\xd1\x3e\x50\x97\xa7\xbe\x99\x77\x86\x51\xd8\xa6\xcc\x9b\x17\x89\x93\x65\xe1\x7f\x98\x57\x5c\x93\xd6\x62\xba\x07\x4f\x1d\x05\xc2\xe3\x38\x5c\xb0\x68\x9e\x6f\xdb\x89\x03\xf0\xe3\x70\xc5\x70\x68\x3b\xfa\xfa\x6f\xe1\x80\x7a\xef\xe0\x05\x41\x20\x09\x99\x8a\x00\x00\x00")
+
+func _1528395573_DownSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395573_DownSql,
+		"1528395573_.down.sql",
+	)
+}
+
+func _1528395573_DownSql() (*asset, error) {
+	bytes, err := _1528395573_DownSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395573_.down.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x5d, 0x73, 0x52, 0x44, 0xf0, 0xb0, 0x8e, 0x18, 0x48, 0x5f, 0x35, 0x65, 0xe, 0xca, 0xf9, 0x63, 0x39, 0xe2, 0x4, 0x7a, 0xe3, 0xb2, 0x80, 0x93, 0xa7, 0xaf, 0xea, 0xb1, 0x93, 0xad, 0x73, 0xf}}
+	return a, nil
+}
+
+var __1528395573_UpSql = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x5d\x90\xcd\x6e\x83\x30\x10\x84\xef\x7e\x8a\x3d\x12\x29\xc9\x0b\x70\x22\xb0\xa9\xa2\x12\xa8\x08\x3d\xe4\x14\x39\x78\x93\xba\x02\x1b\xe1\xa5\x4a\xdf\xbe\xe6\x27\x3f\xaa\x6f\xeb\x19\x7f\x33\xeb\x0d\xbe\xed\xb2\x50\xac\x56\x50\x7e\x11\xd4\xb6\x92\xac\xad\x71\x60\x2f\xc0\xfe\xa2\x23\xd7\xd7\xfc\x18\x55\xdf\xb4\x0e\x64\x47\xe0\xd8\x76\xa4\xc0\x9a\x8a\xa0\xa5\x6e\x36\x82\x36\x8e\x49\xaa\x81\xe7\x9f\x68\x03\xf4\x43\xdd\x2f\x28\x5b\xf5\x0d\x19\xf6\x10\xc9\xde\x7b\xa1\xce\x01\xdb\x81\xd9\xac\xc7\xe4\xbb\xe3\x11\x45\x37\xed\x58\x9b\xeb\x94\x39\x00\xfd\x58\xd7\x50\x59\xc3\xd2\x83\xf9\xb5\xee\x12\x9c\x7d\x29\xd8\xf4\x8e\xe1\x4c\xd0\xb7\xb5\x95\xca\xd7\x94\x57\xff\x64\x2d\x12\x4c\xb1\x44\xd8\x16\xf9\x1e\x6a\xa7\x2f\xa7\xd1\x1e\x0a\x11\x17\x18\x79\xa1\x8c\x36\x29\x4e\xca\x7d\xf1\x40\x80\x3f\x83\xef\xa4\x95\x5f\x88\xe9\xea\xb7\xcd\xf2\x12\xb2\xcf\x34\x85\x02\xb7\x58\x60\x16\xe3\xe1\x05\x18\x68\xb5\x80\x3c\x83\x39\x2e\x8e\x0e\x71\x94\xe0\x72\x24\x4d\xdc\x81\xc5\x74\xe3\x07\x68\x12\x9f\xdf\xff\xed\xac\x39\xff\x53\x3f\x8a\xdd\x3e\x2a\x8e\xf0\x8e\x47\x08\xe6\x46\xcb\x27\x70\x21\x16\xa1\xc0\x2c\x09\xc5\x1f\xad\x29\xe2\x1b\xd4\x01\x00\x00")
+
+func _1528395573_UpSqlBytes() ([]byte, error) {
+	return bindataRead(
+		__1528395573_UpSql,
+		"1528395573_.up.sql",
+	)
+}
+
+func _1528395573_UpSql() (*asset, error) {
+	bytes, err := _1528395573_UpSqlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "1528395573_.up.sql", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x87, 0x5b, 0x82, 0x63, 0x10, 0x5e, 0x6c, 0x39, 0x13, 0xbf, 0x48, 0x8c, 0x61, 0xbe, 0x89, 0x26, 0xa7, 0x86, 0x84, 0xd9, 0x80, 0xc0, 0x55, 0x96, 0x21, 0x35, 0x39, 0xe1, 0x6c, 0x53, 0x44, 0x24}}
+	return a, nil
+}
+
 // Asset loads and returns the asset for the given name.
 // It returns an error if the asset could not be found or
 // could not be loaded.
@@ -4574,6 +4952,42 @@ var _bindata = map[string]func() (*asset, error){
 	"1528395564_.down.sql": _1528395564_DownSql,
 
 	"1528395564_.up.sql": _1528395564_UpSql,
+
+	"1528395565_.down.sql": _1528395565_DownSql,
+
+	"1528395565_.up.sql": _1528395565_UpSql,
+
+	"1528395566_.down.sql": _1528395566_DownSql,
+
+	"1528395566_.up.sql": _1528395566_UpSql,
+
+	"1528395567_.down.sql": _1528395567_DownSql,
+
+	"1528395567_.up.sql": _1528395567_UpSql,
+
+	"1528395568_.down.sql": _1528395568_DownSql,
+
+	"1528395568_.up.sql": _1528395568_UpSql,
+
+	"1528395569_.down.sql": _1528395569_DownSql,
+
+	"1528395569_.up.sql": _1528395569_UpSql,
+
+	"1528395570_.down.sql": _1528395570_DownSql,
+
+	"1528395570_.up.sql": _1528395570_UpSql,
+
+	"1528395571_.down.sql": _1528395571_DownSql,
+
+	"1528395571_.up.sql": _1528395571_UpSql,
+
+	"1528395572_.down.sql": _1528395572_DownSql,
+
+	"1528395572_.up.sql": _1528395572_UpSql,
+
+	"1528395573_.down.sql": _1528395573_DownSql,
+
+	"1528395573_.up.sql": _1528395573_UpSql,
 }
 
 // AssetDir returns the file names below a certain
@@ -4809,6 +5223,24 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"1528395563_.up.sql":                                          {_1528395563_UpSql, map[string]*bintree{}},
 	"1528395564_.down.sql":                                        {_1528395564_DownSql, map[string]*bintree{}},
 	"1528395564_.up.sql":                                          {_1528395564_UpSql, map[string]*bintree{}},
+	"1528395565_.down.sql":                                        {_1528395565_DownSql, map[string]*bintree{}},
+	"1528395565_.up.sql":                                          {_1528395565_UpSql, map[string]*bintree{}},
+	"1528395566_.down.sql":                                        {_1528395566_DownSql, map[string]*bintree{}},
+	"1528395566_.up.sql":                                          {_1528395566_UpSql, map[string]*bintree{}},
+	"1528395567_.down.sql":                                        {_1528395567_DownSql, map[string]*bintree{}},
+	"1528395567_.up.sql":                                          {_1528395567_UpSql, map[string]*bintree{}},
+	"1528395568_.down.sql":                                        {_1528395568_DownSql, map[string]*bintree{}},
+	"1528395568_.up.sql":                                          {_1528395568_UpSql, map[string]*bintree{}},
+	"1528395569_.down.sql":                                        {_1528395569_DownSql, map[string]*bintree{}},
+	"1528395569_.up.sql":                                          {_1528395569_UpSql, map[string]*bintree{}},
+	"1528395570_.down.sql":                                        {_1528395570_DownSql, map[string]*bintree{}},
+	"1528395570_.up.sql":                                          {_1528395570_UpSql, map[string]*bintree{}},
+	"1528395571_.down.sql":                                        {_1528395571_DownSql, map[string]*bintree{}},
+	"1528395571_.up.sql":                                          {_1528395571_UpSql, map[string]*bintree{}},
+	"1528395572_.down.sql":                                        {_1528395572_DownSql, map[string]*bintree{}},
+	"1528395572_.up.sql":                                          {_1528395572_UpSql, map[string]*bintree{}},
+	"1528395573_.down.sql":                                        {_1528395573_DownSql, map[string]*bintree{}},
+	"1528395573_.up.sql":                                          {_1528395573_UpSql, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory.
@@ -48,6 +48,10 @@ type ReposUpdateMetadataRequest struct {
 	Archived    bool   `json:"Archived"`
 }
 
+type GlobalSymbolsUpdateRequest struct {
+	RepoName `json:"repo"`
+}
+
 type ReposGetInventoryRequest struct {
 	Repo RepoID
 	CommitID
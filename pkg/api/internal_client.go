@@ -288,6 +288,12 @@ func (c *internalClient) ReposUpdateMetadata(ctx context.Context, repo RepoName,
 	}, nil)
 }
 
+// GlobalSymbolsUpdate requests an update of the global symbol index for the
+// repository. The update happens asynchronously.
+func (c *internalClient) GlobalSymbolsUpdate(ctx context.Context, repo RepoName) error {
+	return c.postInternal(ctx, "global-symbols/update", GlobalSymbolsUpdateRequest{RepoName: repo}, nil)
+}
+
 func (c *internalClient) ReposGetByName(ctx context.Context, repoName RepoName) (*Repo, error) {
 	var repo Repo
 	err := c.postInternal(ctx, "repos/"+string(repoName), nil, &repo)
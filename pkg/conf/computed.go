@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"log"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sourcegraph/sourcegraph/pkg/api"
 	"github.com/sourcegraph/sourcegraph/pkg/conf/confdefaults"
@@ -98,6 +100,40 @@ func UpdateScheduler2Enabled() bool {
 	return p != "disabled"
 }
 
+// UnifiedTextSearchEnabled returns true if the unifiedTextSearch experiment is enabled.
+func UnifiedTextSearchEnabled() bool {
+	p := Get().ExperimentalFeatures
+	return p != nil && p.UnifiedTextSearch == "enabled"
+}
+
+// GlobalSymbolSearchEnabled returns true if the globalSymbolSearch experiment
+// is enabled.
+func GlobalSymbolSearchEnabled() bool {
+	p := Get().ExperimentalFeatures
+	return p != nil && p.GlobalSymbolSearch == "enabled"
+}
+
+// GoDependencyGraphEnabled returns true if the goDependencyGraph experiment is
+// enabled.
+func GoDependencyGraphEnabled() bool {
+	p := Get().ExperimentalFeatures
+	return p != nil && p.GoDependencyGraph == "enabled"
+}
+
+// PackageDependencyGraphEnabled returns true if the packageDependencyGraph
+// experiment is enabled.
+func PackageDependencyGraphEnabled() bool {
+	p := Get().ExperimentalFeatures
+	return p != nil && p.PackageDependencyGraph == "enabled"
+}
+
+// SearchRankingEnabled returns true if the searchRanking experiment is
+// enabled.
+func SearchRankingEnabled() bool {
+	p := Get().ExperimentalFeatures
+	return p != nil && p.SearchRanking == "enabled"
+}
+
 func AWSCodeCommitConfigs(ctx context.Context) ([]*schema.AWSCodeCommitConnection, error) {
 	var config []*schema.AWSCodeCommitConnection
 	if err := api.InternalClient.ExternalServiceConfigs(ctx, "AWSCODECOMMIT", &config); err != nil {
@@ -263,6 +299,36 @@ func SearchIndexEnabled() bool {
 	return DeployType() != DeployDocker
 }
 
+// SearchIndexBranch reports whether the branch named by ref (a branch name,
+// optionally prefixed with "refs/heads/") is indexed by searcher according to
+// the search.index.branches site configuration.
+func SearchIndexBranch(ref string) bool {
+	name := strings.TrimPrefix(ref, "refs/heads/")
+	for _, pattern := range Get().SearchIndexBranches {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// SearchHistoryEnabled returns whether the search queries that users run are
+// recorded in their search history.
+func SearchHistoryEnabled() bool {
+	cfg := Get().SearchHistory
+	return cfg == nil || !cfg.Disabled
+}
+
+// SearchHistoryRetention returns how long search history entries are kept
+// before they are deleted.
+func SearchHistoryRetention() time.Duration {
+	days := 90
+	if cfg := Get().SearchHistory; cfg != nil && cfg.RetentionDays > 0 {
+		days = cfg.RetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
 // SrcGitServers represents the SRC_GIT_SERVERS environment variable.
 //
 // Non-frontend callers should go through api.InternalClient.GitServerAddrs() instead.
@@ -5,6 +5,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sourcegraph/sourcegraph/schema"
 )
@@ -52,6 +53,26 @@ func TestComputed(t *testing.T) {
 		env:  []string{"DEPLOY_TYPE=docker-container", "INDEXED_SEARCH=t"},
 		fun:  SearchIndexEnabled,
 		want: true,
+	}, {
+		name: "SearchHistory defaults to enabled",
+		sc:   &Unified{},
+		fun:  SearchHistoryEnabled,
+		want: true,
+	}, {
+		name: "SearchHistory disabled",
+		sc:   &Unified{SiteConfiguration: schema.SiteConfiguration{SearchHistory: &schema.SearchHistory{Disabled: true}}},
+		fun:  SearchHistoryEnabled,
+		want: false,
+	}, {
+		name: "SearchHistory retention defaults to 90 days",
+		sc:   &Unified{},
+		fun:  SearchHistoryRetention,
+		want: 90 * 24 * time.Hour,
+	}, {
+		name: "SearchHistory retention",
+		sc:   &Unified{SiteConfiguration: schema.SiteConfiguration{SearchHistory: &schema.SearchHistory{RetentionDays: 7}}},
+		fun:  SearchHistoryRetention,
+		want: 7 * 24 * time.Hour,
 	}}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -137,6 +137,22 @@ func (s *Store) OpenWithPath(ctx context.Context, key string, fetcher FetcherWit
 	}
 }
 
+// OpenCached opens the file for key if it is already in the cache. Unlike
+// Open it never fetches. If key is not in the cache, the returned error
+// satisfies os.IsNotExist.
+func (s *Store) OpenCached(key string) (*File, error) {
+	if s.Dir == "" {
+		return nil, errors.New("diskcache.Store.Dir must be set")
+	}
+	path := s.path(key)
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	touch(path)
+	return &File{File: f, Path: path}, nil
+}
+
 // path returns the path for key.
 func (s *Store) path(key string) string {
 	// path uses a sha256 hash of the key since we want to use it for the
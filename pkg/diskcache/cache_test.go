@@ -61,3 +61,41 @@ func TestOpen(t *testing.T) {
 		t.Fatal("Item was not properly evicted")
 	}
 }
+
+func TestOpenCached(t *testing.T) {
+	dir, err := ioutil.TempDir("", "diskcache_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := &Store{
+		Dir:       dir,
+		Component: "test",
+	}
+
+	if _, err := store.OpenCached("key"); !os.IsNotExist(err) {
+		t.Fatalf("expected a not exist error for an empty cache, got %v", err)
+	}
+
+	f, err := store.Open(context.Background(), "key", func(ctx context.Context) (io.ReadCloser, error) {
+		return ioutil.NopCloser(bytes.NewReader([]byte("foobar"))), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	f, err = store.OpenCached("key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := ioutil.ReadAll(f.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "foobar" {
+		t.Fatalf("got %q, want %q", string(got), "foobar")
+	}
+}
@@ -37,6 +37,13 @@ func ResolveImportPath(client *http.Client, importPath string) (*Directory, erro
 	return resolveDynamicImportPath(client, importPath)
 }
 
+// ResolveStaticImportPath is like ResolveImportPath, but it only resolves the
+// import paths of the standard library and of well-known code hosts, without
+// making any network requests. It returns an error for other import paths.
+func ResolveStaticImportPath(importPath string) (*Directory, error) {
+	return resolveStaticImportPath(importPath)
+}
+
 func resolveStaticImportPath(importPath string) (*Directory, error) {
 	if IsStdlibPkg(importPath) {
 		return &Directory{
@@ -104,8 +104,15 @@ type TextJIT struct {
 	Endpoints *endpoint.Map
 	Resolve   func(ctx context.Context, name api.RepoName, spec string) (api.CommitID, error)
 
-	mu      sync.Mutex
-	clients map[string]search.Searcher
+	// IndexedBranch if non-nil reports whether searcher indexes the branch
+	// with the given name (see the search.index.branches site
+	// configuration). Such branches are first searched via searcher's
+	// branch index.
+	IndexedBranch func(name string) bool
+
+	mu            sync.Mutex
+	clients       map[string]search.Searcher
+	branchClients map[string]search.Searcher
 }
 
 // Search distributes the search across the searcher replicas, and merges the
@@ -147,6 +154,13 @@ func (t *TextJIT) Search(ctx context.Context, q query.Q, opts *search.Options) (
 				defer sem.Release()
 				defer wg.Done()
 
+				if r.RefPattern != "" && t.IndexedBranch != nil && t.IndexedBranch(r.RefPattern) {
+					if result := t.searchBranchIndex(ctx, q, r, origOpts); result != nil {
+						resC <- searchResponse{Result: result}
+						return
+					}
+				}
+
 				commit, err := t.Resolve(ctx, r.Name, r.RefPattern)
 				if err != nil {
 					if s, err := handleError(SourceSearcher, r, err); err != nil {
@@ -244,7 +258,53 @@ func (t *TextJIT) maybeGC(expectedClientCount int) {
 	}
 }
 
+// searchBranchIndex searches r in searcher's index of branches. It returns
+// nil if the branch of r is not indexed (yet) or the index failed, in which
+// case r should be searched like any other revision.
+func (t *TextJIT) searchBranchIndex(ctx context.Context, q query.Q, r search.Repository, origOpts *search.Options) *search.Result {
+	opts := *origOpts
+	opts.Repositories = []api.RepoName{r.Name}
+
+	qSearcher, err := expandForRepoAt(q, r, r.RefPattern)
+	if err != nil {
+		return nil
+	}
+
+	client, err := t.branchClient(r)
+	if err != nil {
+		return nil
+	}
+
+	result, err := client.Search(ctx, qSearcher, &opts)
+	if err != nil {
+		if ctx.Err() == nil {
+			log15.Warn("TextJIT: searching branch index failed", "repo", r.String(), "error", err)
+		}
+		return nil
+	}
+	if len(result.Stats.Status) == 0 {
+		return nil
+	}
+
+	// The branch index reports the commit it searched, and like searcher
+	// doesn't know the repo.RefPattern used.
+	r.Commit = result.Stats.Status[0].Repository.Commit
+	for i := range result.Stats.Status {
+		result.Stats.Status[i].Repository = r
+	}
+	for i := range result.Files {
+		result.Files[i].Repository = r
+	}
+	return result
+}
+
 func expandForRepoAtCommit(q query.Q, repo search.Repository) (query.Q, error) {
+	return expandForRepoAt(q, repo, string(repo.Commit))
+}
+
+// expandForRepoAt returns q for searching repo at ref. ref is either the
+// commit or the branch of repo.
+func expandForRepoAt(q query.Q, repo search.Repository, ref string) (query.Q, error) {
 	var err error
 	q = query.Map(q, func(q query.Q) query.Q {
 		switch s := q.(type) {
@@ -263,8 +323,8 @@ func expandForRepoAtCommit(q query.Q, repo search.Repository) (query.Q, error) {
 	if err != nil {
 		return nil, err
 	}
-	// Include the commit as the only ref
-	q = query.NewAnd(&query.Ref{Pattern: string(repo.Commit)}, q)
+	// Include ref as the only ref
+	q = query.NewAnd(&query.Ref{Pattern: ref}, q)
 	return query.Simplify(q), nil
 }
 
@@ -274,7 +334,11 @@ func (t *TextJIT) Close() {
 	for _, c := range t.clients {
 		c.Close()
 	}
+	for _, c := range t.branchClients {
+		c.Close()
+	}
 	t.clients = make(map[string]search.Searcher)
+	t.branchClients = make(map[string]search.Searcher)
 }
 
 func (t *TextJIT) String() string {
@@ -305,6 +369,33 @@ func (t *TextJIT) client(r search.Repository) (search.Searcher, error) {
 	return client, nil
 }
 
+// branchClient returns the client of the branch index of the searcher
+// replica which indexes the branches of r. Unlike client, the replica only
+// depends on the repository name, since repo-updater notifies it about
+// updates to the repository.
+func (t *TextJIT) branchClient(r search.Repository) (search.Searcher, error) {
+	ep, err := t.Endpoints.Get(string(r.Name), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	if t.branchClients == nil {
+		t.branchClients = make(map[string]search.Searcher)
+	}
+	client, ok := t.branchClients[ep]
+	if !ok {
+		// Creating a client is non-blocking so can hold lock.
+		addr := strings.TrimPrefix(ep, "http://")
+		addr = strings.TrimPrefix(addr, "https://")
+		client = rpc.ClientAtPath(addr, rpc.BranchIndexRPCPath)
+		t.branchClients[ep] = client
+	}
+	t.mu.Unlock()
+
+	return client, nil
+}
+
 func expandRepoRefs(q query.Q, repoNames []api.RepoName) ([]search.Repository, error) {
 	// Implementation Note: If a query does not have a "ref:" pattern, it
 	// implies that the user wants to search the default branch. This gives us
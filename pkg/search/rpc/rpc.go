@@ -18,6 +18,11 @@ import (
 // DefaultRPCPath is the rpc path
 const DefaultRPCPath = "/rpc"
 
+// BranchIndexRPCPath is the rpc path of searcher's index of the branches
+// other than the default branch (see the search.index.branches site
+// configuration).
+const BranchIndexRPCPath = "/rpc/branches"
+
 // Server returns an http.Handler for searcher which is the server side of the
 // RPC calls.
 func Server(searcher search.Searcher) (http.Handler, error) {
@@ -101,6 +101,69 @@ func (c *Client) Search(ctx context.Context, args protocol.SearchArgs) (result *
 	return result, err
 }
 
+// Outline returns the symbols of a single file, in the order they are
+// declared in the file.
+func (c *Client) Outline(ctx context.Context, args protocol.OutlineArgs) (result *protocol.SearchResult, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "symbols.Client.Outline")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+	span.SetTag("Repo", string(args.Repo))
+	span.SetTag("CommitID", string(args.CommitID))
+	span.SetTag("Path", args.Path)
+
+	// Use the same key as Search, so that the symbols database of the commit
+	// is found if it was already built.
+	resp, err := c.httpPost(ctx, "outline", key{repo: args.Repo, commitID: args.CommitID}, args)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return nil, errors.Errorf("Symbol.Outline http status %d for %+v: %s", resp.StatusCode, args, string(body))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
+
+// List returns all the symbols of a commit, ordered by path and line, up to
+// args.First.
+func (c *Client) List(ctx context.Context, args protocol.ListArgs) (result *protocol.SearchResult, err error) {
+	span, ctx := opentracing.StartSpanFromContext(ctx, "symbols.Client.List")
+	defer func() {
+		if err != nil {
+			ext.Error.Set(span, true)
+			span.LogFields(otlog.Error(err))
+		}
+		span.Finish()
+	}()
+	span.SetTag("Repo", string(args.Repo))
+	span.SetTag("CommitID", string(args.CommitID))
+
+	resp, err := c.httpPost(ctx, "list", key{repo: args.Repo, commitID: args.CommitID}, args)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		// best-effort inclusion of body in error message
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 200))
+		return nil, errors.Errorf("Symbol.List http status %d for %+v: %s", resp.StatusCode, args, string(body))
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	return result, err
+}
+
 func (c *Client) httpPost(ctx context.Context, method string, key key, payload interface{}) (resp *http.Response, err error) {
 	span, ctx := opentracing.StartSpanFromContext(ctx, "symbols.Client.httpPost")
 	defer func() {
@@ -39,6 +39,33 @@ type SearchArgs struct {
 	First int
 }
 
+// OutlineArgs are the arguments to list the symbols of a single file on the
+// symbols service.
+type OutlineArgs struct {
+	// Repo is the name of the repository the file is in.
+	Repo api.RepoName `json:"repo"`
+
+	// CommitID is the commit the file is at.
+	CommitID api.CommitID `json:"commitID"`
+
+	// Path is the path of the file.
+	Path string `json:"path"`
+}
+
+// ListArgs are the arguments to list all the symbols of a commit on the
+// symbols service.
+type ListArgs struct {
+	// Repo is the name of the repository.
+	Repo api.RepoName `json:"repo"`
+
+	// CommitID is the commit to list the symbols of.
+	CommitID api.CommitID `json:"commitID"`
+
+	// First indicates that only the first n symbols (ordered by path and line)
+	// should be returned.
+	First int `json:"first"`
+}
+
 // SearchResult is the result of a search on the symbols service.
 type SearchResult struct {
 	Symbols []Symbol // code symbols
@@ -56,5 +83,20 @@ type Symbol struct {
 	Signature  string
 	Pattern    string
 
+	// Character is the 0-based column (in bytes) of Name on Line. EndLine
+	// and EndCharacter are where the declaration of the symbol ends. They are
+	// only known for the languages the symbols service has a native parser
+	// for (e.g. Go), in which case EndLine is non-zero.
+	Character    int
+	EndLine      int
+	EndCharacter int
+
+	// Doc is the documentation comment of the symbol, if any.
+	Doc string
+
+	// Access is the visibility of the symbol (e.g. "public" or "private"),
+	// if the language has one and the parser reports it.
+	Access string
+
 	FileLimited bool
 }
@@ -41,6 +41,7 @@ type CommitsOptions struct {
 
 	Author string // include only commits whose author matches this
 	After  string // include only commits after this date
+	Before string // include only commits before this date
 
 	Path string // only commits modifying the given path are selected (optional)
 }
@@ -83,6 +84,10 @@ func GetCommit(ctx context.Context, repo gitserver.Repo, id api.CommitID) (*Comm
 
 // Commits returns all commits matching the options.
 func Commits(ctx context.Context, repo gitserver.Repo, opt CommitsOptions) ([]*Commit, error) {
+	if Mocks.Commits != nil {
+		return Mocks.Commits(opt)
+	}
+
 	span, ctx := opentracing.StartSpanFromContext(ctx, "Git: Commits")
 	span.SetTag("Opt", opt)
 	defer span.Finish()
@@ -159,6 +164,10 @@ func commitLogArgs(initialArgs []string, opt CommitsOptions) (args []string, err
 		args = append(args, "--after="+opt.After)
 	}
 
+	if opt.Before != "" {
+		args = append(args, "--before="+opt.Before)
+	}
+
 	if opt.MessageQuery != "" {
 		args = append(args, "--fixed-strings", "--regexp-ignore-case", "--grep="+opt.MessageQuery)
 	}
@@ -198,38 +198,16 @@ func RawLogDiffSearch(ctx context.Context, repo gitserver.Repo, opt RawLogDiffSe
 	}
 
 	appendCommonDashDashArgs := func(args *[]string) {
-		// If we have exclude paths, we need to effectively unset the --max-count because we can't
-		// filter out changes that match the exclude path (because there's no way to use full
-		// regexps in git pathspecs).
-		//
-		// TODO(sqs): use git pathspec %(...) extensions to reduce the number of cases where this is
-		// necessary; see https://git-scm.com/docs/gitglossary.html#def_pathspec.
-		var addMaxCount500 bool
-		if opt.Paths.ExcludePattern != "" {
-			addMaxCount500 = true
+		// If the pathspecs match more paths than the path options, we need to effectively unset
+		// the --max-count because we can't filter out changes that don't match the path options
+		// in git.
+		pathspecs, exact := gitPathspecs(opt.Paths)
+		if !exact {
+			*args = append(*args, "--max-count=500") // TODO(sqs): 500 is arbitrary high number
 		}
 
 		// Args we append after this don't need to be checked for whitelisting because "--"
 		// precedes them.
-		var pathspecs []string
-		for _, p := range opt.Paths.IncludePatterns {
-			// Roughly try to convert IncludePatterns (regexps) to git pathspecs (globs).
-			glob, equiv := regexpToGlobBestEffort(p)
-			if !opt.Paths.IsCaseSensitive && glob != "" {
-				// This relies on regexpToGlobBestEffort not returning `:`-prefixed globs.
-				glob = ":(icase)" + glob
-			}
-			if !equiv {
-				addMaxCount500 = true
-			}
-			if glob != "" {
-				pathspecs = append(pathspecs, glob)
-			}
-		}
-
-		if addMaxCount500 {
-			*args = append(*args, "--max-count=500") // TODO(sqs): 500 is arbitrary high number
-		}
 		*args = append(*args, "--")
 		*args = append(*args, pathspecs...)
 	}
@@ -437,6 +415,32 @@ func RawLogDiffSearch(ctx context.Context, repo gitserver.Repo, opt RawLogDiffSe
 	return results, complete, nil
 }
 
+// gitPathspecs roughly converts the path options to git pathspecs (globs). If exact is false,
+// the pathspecs match more paths than the path options, so the paths must be filtered afterwards.
+func gitPathspecs(opt PathOptions) (pathspecs []string, exact bool) {
+	// There's no way to use full regexps in git pathspecs, so exclude paths can't be converted.
+	//
+	// TODO(sqs): use git pathspec %(...) extensions to reduce the number of cases where this is
+	// necessary; see https://git-scm.com/docs/gitglossary.html#def_pathspec.
+	exact = opt.ExcludePattern == ""
+
+	for _, p := range opt.IncludePatterns {
+		// Roughly try to convert IncludePatterns (regexps) to git pathspecs (globs).
+		glob, equiv := regexpToGlobBestEffort(p)
+		if !opt.IsCaseSensitive && glob != "" {
+			// This relies on regexpToGlobBestEffort not returning `:`-prefixed globs.
+			glob = ":(icase)" + glob
+		}
+		if !equiv {
+			exact = false
+		}
+		if glob != "" {
+			pathspecs = append(pathspecs, glob)
+		}
+	}
+	return pathspecs, exact
+}
+
 // cachedRefResolver is a short-lived cache for ref resolutions. Only use it for the lifetime of a
 // single request and for a single repo.
 type refResolveCache struct {
@@ -128,6 +128,8 @@ var (
 		"--find-copies",
 		"--find-renames",
 		"--inter-hunk-context",
+		"--name-only",
+		"--no-renames",
 	}
 )
 
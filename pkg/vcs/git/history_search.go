@@ -0,0 +1,295 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/gitserver"
+	"github.com/sourcegraph/sourcegraph/pkg/trace"
+)
+
+// HistorySearchOptions specifies options for HistorySearch.
+type HistorySearchOptions struct {
+	// Query specifies the pattern to find in file contents. It must not be empty.
+	Query TextSearchOptions
+
+	// Paths specifies the paths to include/exclude.
+	Paths PathOptions
+
+	// Args is a list of args that are passed to the `git log` command to select the
+	// commits whose history is searched (e.g., revisions, --glob and --since). It should
+	// not contain any "--" elements; those should be passed using the Paths field.
+	Args []string
+
+	// MaxFiles is the maximum number of files to return (0 for no limit).
+	MaxFiles int
+}
+
+// HistorySearchResult describes when lines matching a query were added to and removed
+// from a file, as found by HistorySearch.
+type HistorySearchResult struct {
+	Path string
+
+	// Introduced is the oldest commit that added a line matching the query to the file,
+	// and IntroducedMatches are the matching lines of the file at that commit.
+	Introduced        *Commit
+	IntroducedMatches []*LineMatch
+
+	// Removed is the commit that removed the last lines matching the query from the file
+	// (or deleted it), or nil if the file still matches.
+	Removed *Commit
+
+	// LastMatches are the matching lines of the file at LastMatchesCommit, the last revision
+	// where it matched: the parent of Removed, or the newest commit that changed matching
+	// lines if the file still matches.
+	LastMatches       []*LineMatch
+	LastMatchesCommit api.CommitID
+}
+
+// LineMatch is a line of a file that matches a query.
+type LineMatch struct {
+	LineNumber       int      // the 0-indexed line number
+	Preview          string   // the line's content
+	OffsetAndLengths [][2]int // the byte offset and length of each match in Preview
+}
+
+const (
+	maxHistoryLineMatches = 10  // the maximum number of matching lines returned per file content
+	maxHistoryLineLength  = 500 // lines longer than this are truncated in LineMatch.Preview
+)
+
+// HistorySearch finds the files whose contents matched the query at any commit of the
+// history (including files where the matching lines have since been removed, and deleted
+// files). It runs `git log -G` to find the commits that added or removed matching lines,
+// and returns the oldest and newest of those commits for each file, with the matching
+// lines of the file at those commits.
+//
+// The files are returned in the order of their newest change of matching lines, most
+// recent first. If complete is false, the `git log` command timed out and only part of
+// the history was searched.
+func HistorySearch(ctx context.Context, repo gitserver.Repo, opt HistorySearchOptions) (results []*HistorySearchResult, complete bool, err error) {
+	if Mocks.HistorySearch != nil {
+		return Mocks.HistorySearch(opt)
+	}
+
+	tr, ctx := trace.New(ctx, "Git: HistorySearch", fmt.Sprintf("%+v", opt))
+	defer func() {
+		tr.LazyPrintf("%d results, complete=%v, err=%v", len(results), complete, err)
+		tr.SetError(err)
+		tr.Finish()
+	}()
+
+	if opt.Query.Pattern == "" {
+		return nil, false, errors.New("history search requires a pattern")
+	}
+	for _, arg := range opt.Args {
+		if arg == "--" {
+			return nil, false, fmt.Errorf("invalid Args (must not contain \"--\" element): %q", opt.Args)
+		}
+	}
+
+	// `git log -G` always interprets the pattern as an extended regexp.
+	pattern := opt.Query.Pattern
+	if !opt.Query.IsRegExp {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	args := []string{"log", "--no-merges", "--no-renames", "-z", "--name-only", logFormatWithoutRefs, "-G" + pattern}
+	if !opt.Query.IsCaseSensitive {
+		args = append(args, "--regexp-ignore-case")
+	}
+	args = append(args, opt.Args...)
+	if !isWhitelistedGitCmd(args) {
+		return nil, false, fmt.Errorf("command failed: %q is not a whitelisted git command", args)
+	}
+	pathspecs, _ := gitPathspecs(opt.Paths)
+	args = append(args, "--")
+	args = append(args, pathspecs...)
+
+	// Leave time to read the file contents at the commits that are found.
+	logCtx := ctx
+	if deadline, ok := ctx.Deadline(); ok {
+		var cancel context.CancelFunc
+		logCtx, cancel = context.WithTimeout(ctx, time.Until(deadline)*3/4)
+		defer cancel()
+	}
+	cmd := gitserver.DefaultClient.Command("git", args...)
+	cmd.Repo = repo
+	tr.LazyPrintf("git log %v", cmd.Args)
+	data, complete, err := readUntilTimeout(logCtx, cmd)
+	if err != nil {
+		// Don't fail if the repository is empty.
+		if strings.Contains(err.Error(), "does not have any commits yet") {
+			return nil, true, nil
+		}
+		return nil, complete, err
+	}
+	files, err := parseHistorySearchLog(data)
+	if err != nil && complete {
+		// Tolerate parse errors only when we received incomplete data.
+		return nil, complete, err
+	}
+
+	if !opt.Query.IsCaseSensitive {
+		pattern = "(?i:" + pattern + ")"
+	}
+	query, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, false, err
+	}
+	pathMatcher, err := compilePathMatcher(opt.Paths)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, f := range files {
+		if opt.MaxFiles > 0 && len(results) == opt.MaxFiles {
+			break
+		}
+		if !pathMatcher.MatchPath(f.path) {
+			continue
+		}
+		result, err := historySearchResult(ctx, repo, query, f)
+		if err != nil {
+			if ctx.Err() != nil {
+				// Return partial data.
+				return results, false, nil
+			}
+			return nil, false, err
+		}
+		results = append(results, result)
+	}
+	return results, complete, nil
+}
+
+// historySearchFile is a file with the commits that added or removed lines matching the
+// query in it, most recent first.
+type historySearchFile struct {
+	path    string
+	commits []*Commit
+}
+
+// parseHistorySearchLog parses the output of `git log -z --name-only` with the
+// logFormatWithoutRefs format into the files changed by the commits, in the order of
+// their first appearance.
+func parseHistorySearchLog(data []byte) ([]*historySearchFile, error) {
+	var files []*historySearchFile
+	byPath := map[string]*historySearchFile{}
+	for len(data) > 0 {
+		commit, _, rest, err := parseCommitFromLog(data)
+		if err != nil {
+			return files, err
+		}
+
+		// The NUL-separated file names follow the commit on a new line, and are terminated
+		// by an empty name.
+		rest = bytes.TrimPrefix(rest, []byte{'\n'})
+		for len(rest) > 0 {
+			var name []byte
+			if i := bytes.IndexByte(rest, '\x00'); i == -1 {
+				name, rest = rest, nil
+			} else {
+				name, rest = rest[:i], rest[i+1:]
+			}
+			if len(name) == 0 {
+				break
+			}
+			f, ok := byPath[string(name)]
+			if !ok {
+				f = &historySearchFile{path: string(name)}
+				byPath[f.path] = f
+				files = append(files, f)
+			}
+			f.commits = append(f.commits, commit)
+		}
+		data = rest
+	}
+	return files, nil
+}
+
+func historySearchResult(ctx context.Context, repo gitserver.Repo, query *regexp.Regexp, f *historySearchFile) (*HistorySearchResult, error) {
+	newest, oldest := f.commits[0], f.commits[len(f.commits)-1]
+	result := &HistorySearchResult{Path: f.path, Introduced: oldest}
+
+	var err error
+	result.IntroducedMatches, err = historyLineMatches(ctx, repo, oldest.ID, f.path, query)
+	if err != nil {
+		return nil, err
+	}
+	result.LastMatchesCommit = newest.ID
+	if newest == oldest {
+		result.LastMatches = result.IntroducedMatches
+	} else {
+		result.LastMatches, err = historyLineMatches(ctx, repo, newest.ID, f.path, query)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(result.LastMatches) == 0 {
+		// The newest commit removed the last matching lines, so the file last matched at
+		// its parent.
+		result.Removed = newest
+		result.LastMatches, result.LastMatchesCommit = nil, ""
+		if len(newest.Parents) > 0 {
+			result.LastMatchesCommit = newest.Parents[0]
+			result.LastMatches, err = historyLineMatches(ctx, repo, newest.Parents[0], f.path, query)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// historyLineMatches returns the lines of the file at the commit that match the query. A
+// file that does not exist at the commit has no matching lines.
+func historyLineMatches(ctx context.Context, repo gitserver.Repo, commit api.CommitID, path string, query *regexp.Regexp) ([]*LineMatch, error) {
+	content, err := ReadFile(ctx, repo, commit, path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var matches []*LineMatch
+	for i, line := range bytes.Split(content, []byte("\n")) {
+		if len(matches) == maxHistoryLineMatches {
+			break
+		}
+		locs := query.FindAllIndex(line, -1)
+		if len(locs) == 0 {
+			continue
+		}
+
+		// Long lines are matched in full but truncated for display (at a
+		// character boundary), keeping the matches in the truncated part.
+		preview := line
+		if len(preview) > maxHistoryLineLength {
+			n := maxHistoryLineLength
+			for n > 0 && !utf8.RuneStart(preview[n]) {
+				n--
+			}
+			preview = preview[:n]
+		}
+		m := &LineMatch{LineNumber: i, Preview: string(preview)}
+		for _, loc := range locs {
+			if loc[0] >= len(preview) {
+				break
+			}
+			if loc[1] > len(preview) {
+				loc[1] = len(preview)
+			}
+			m.OffsetAndLengths = append(m.OffsetAndLengths, [2]int{loc[0], loc[1] - loc[0]})
+		}
+		matches = append(matches, m)
+	}
+	return matches, nil
+}
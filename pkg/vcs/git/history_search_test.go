@@ -0,0 +1,122 @@
+package git_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sourcegraph/sourcegraph/pkg/api"
+	"github.com/sourcegraph/sourcegraph/pkg/vcs/git"
+)
+
+func TestHistorySearch(t *testing.T) {
+	t.Parallel()
+
+	repo := makeGitRepository(t,
+		"printf 'x\\nfunc foo() {}\\n' > f",
+		"echo foo > g",
+		"git add f g",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m add --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+		"echo x > f",
+		"git add f",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:06Z git commit -m remove --author='a <a@a.com>' --date 2006-01-02T15:04:06Z",
+		"printf 'foo\\nbar\\n' > g",
+		"git add g",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:07Z git commit -m other --author='a <a@a.com>' --date 2006-01-02T15:04:07Z",
+		"git rm g",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:08Z git commit -m delete --author='a <a@a.com>' --date 2006-01-02T15:04:08Z",
+	)
+	const (
+		addCommit    api.CommitID = "bfa5763ab924a6d4a57f378fa6302032a6b35f40"
+		removeCommit api.CommitID = "4ce62ee7d75745e00777efcb0d430557fad0855e"
+		otherCommit  api.CommitID = "711f606dbed65c7362ad8c919cddd2ec02ebba87"
+		deleteCommit api.CommitID = "8a6446820b50ae82db5a9ac21c521513a60c67a6"
+	)
+
+	type result struct {
+		path                            string
+		introduced, removed, lastCommit api.CommitID
+		introducedMatches, last         []git.LineMatch
+	}
+	toResults := func(results []*git.HistorySearchResult) []result {
+		var got []result
+		for _, r := range results {
+			res := result{path: r.Path, introduced: r.Introduced.ID, lastCommit: r.LastMatchesCommit}
+			if r.Removed != nil {
+				res.removed = r.Removed.ID
+			}
+			for _, m := range r.IntroducedMatches {
+				res.introducedMatches = append(res.introducedMatches, *m)
+			}
+			for _, m := range r.LastMatches {
+				res.last = append(res.last, *m)
+			}
+			got = append(got, res)
+		}
+		return got
+	}
+
+	tests := map[string]struct {
+		opt          git.HistorySearchOptions
+		want         []result
+		wantComplete bool
+	}{
+		"removed lines and deleted file": {
+			opt: git.HistorySearchOptions{Query: git.TextSearchOptions{Pattern: "foo", IsCaseSensitive: true}},
+			want: []result{
+				{
+					path:              "g",
+					introduced:        addCommit,
+					removed:           deleteCommit,
+					lastCommit:        otherCommit,
+					introducedMatches: []git.LineMatch{{LineNumber: 0, Preview: "foo", OffsetAndLengths: [][2]int{{0, 3}}}},
+					last:              []git.LineMatch{{LineNumber: 0, Preview: "foo", OffsetAndLengths: [][2]int{{0, 3}}}},
+				},
+				{
+					path:              "f",
+					introduced:        addCommit,
+					removed:           removeCommit,
+					lastCommit:        addCommit,
+					introducedMatches: []git.LineMatch{{LineNumber: 1, Preview: "func foo() {}", OffsetAndLengths: [][2]int{{5, 3}}}},
+					last:              []git.LineMatch{{LineNumber: 1, Preview: "func foo() {}", OffsetAndLengths: [][2]int{{5, 3}}}},
+				},
+			},
+			wantComplete: true,
+		},
+		"case-insensitive regexp with path and limit": {
+			opt: git.HistorySearchOptions{
+				Query:    git.TextSearchOptions{Pattern: "FUNC F.O", IsRegExp: true},
+				Paths:    git.PathOptions{IncludePatterns: []string{"^f$"}, IsRegExp: true},
+				MaxFiles: 1,
+			},
+			want: []result{
+				{
+					path:              "f",
+					introduced:        addCommit,
+					removed:           removeCommit,
+					lastCommit:        addCommit,
+					introducedMatches: []git.LineMatch{{LineNumber: 1, Preview: "func foo() {}", OffsetAndLengths: [][2]int{{0, 8}}}},
+					last:              []git.LineMatch{{LineNumber: 1, Preview: "func foo() {}", OffsetAndLengths: [][2]int{{0, 8}}}},
+				},
+			},
+			wantComplete: true,
+		},
+		"no matches": {
+			opt:          git.HistorySearchOptions{Query: git.TextSearchOptions{Pattern: "qux"}},
+			wantComplete: true,
+		},
+	}
+	for label, test := range tests {
+		results, complete, err := git.HistorySearch(context.Background(), repo, test.opt)
+		if err != nil {
+			t.Errorf("%s: HistorySearch: %s", label, err)
+			continue
+		}
+		if complete != test.wantComplete {
+			t.Errorf("%s: got complete %v, want %v", label, complete, test.wantComplete)
+		}
+		if got := toResults(results); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %+v, want %+v", label, got, test.want)
+		}
+	}
+}
@@ -11,7 +11,10 @@ import (
 //
 // (The emptyMocks is used by ResetMocks to zero out Mocks without needing to use a named type.)
 var Mocks, emptyMocks struct {
+	BlobOIDs         func(commit api.CommitID, paths []string) (map[string]OID, error)
+	Commits          func(opt CommitsOptions) ([]*Commit, error)
 	GetCommit        func(api.CommitID) (*Commit, error)
+	HistorySearch    func(opt HistorySearchOptions) ([]*HistorySearchResult, bool, error)
 	ExecSafe         func(params []string) (stdout, stderr []byte, exitCode int, err error)
 	RawLogDiffSearch func(opt RawLogDiffSearchOptions) ([]*LogCommitSearchResult, bool, error)
 	ReadDir          func(commit api.CommitID, name string, recurse bool) ([]os.FileInfo, error)
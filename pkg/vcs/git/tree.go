@@ -3,6 +3,7 @@ package git
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"fmt"
 	"os"
 	stdlibpath "path"
@@ -293,3 +294,58 @@ func lsTreeUncached(ctx context.Context, repo gitserver.Repo, commit api.CommitI
 
 	return fis, nil
 }
+
+// BlobOIDs returns the object IDs of the blobs at the given paths in commit, keyed by path. Paths
+// that do not exist in commit (or that are not files) are omitted.
+func BlobOIDs(ctx context.Context, repo gitserver.Repo, commit api.CommitID, paths []string) (map[string]OID, error) {
+	if Mocks.BlobOIDs != nil {
+		return Mocks.BlobOIDs(commit, paths)
+	}
+
+	span, ctx := opentracing.StartSpanFromContext(ctx, "Git: BlobOIDs")
+	span.SetTag("Commit", commit)
+	span.SetTag("NumPaths", len(paths))
+	defer span.Finish()
+
+	if err := checkSpecArgSafety(string(commit)); err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return map[string]OID{}, nil
+	}
+
+	args := append([]string{"ls-tree", "--full-name", "-z", string(commit), "--"}, paths...)
+	cmd := gitserver.DefaultClient.Command("git", args...)
+	cmd.Repo = repo
+	out, err := cmd.CombinedOutput(ctx)
+	if err != nil {
+		return nil, errors.WithMessage(err, fmt.Sprintf("git command %v failed (output: %q)", cmd.Args, out))
+	}
+
+	oids := make(map[string]OID, len(paths))
+	for _, line := range strings.Split(string(out), "\x00") {
+		if line == "" {
+			continue
+		}
+		// Each line is "<mode> SP <type> SP <oid> TAB <path>".
+		tabPos := strings.IndexByte(line, '\t')
+		if tabPos == -1 {
+			return nil, fmt.Errorf("invalid `git ls-tree` output: %q", out)
+		}
+		info := strings.Split(line[:tabPos], " ")
+		if len(info) != 3 {
+			return nil, fmt.Errorf("invalid `git ls-tree` output: %q", out)
+		}
+		if info[1] != string(ObjectTypeBlob) {
+			continue
+		}
+		oidBytes, err := hex.DecodeString(info[2])
+		if err != nil || len(oidBytes) != len(OID{}) {
+			return nil, fmt.Errorf("invalid `git ls-tree` oid output: %q", info[2])
+		}
+		var oid OID
+		copy(oid[:], oidBytes)
+		oids[line[tabPos+1:]] = oid
+	}
+	return oids, nil
+}
@@ -452,3 +452,38 @@ func TestRepository_FileSystem_gitSubmodules(t *testing.T) {
 		}
 	}
 }
+
+func TestBlobOIDs(t *testing.T) {
+	t.Parallel()
+
+	repo := makeGitRepository(t,
+		"mkdir dir",
+		"echo a > a.txt",
+		"echo a > dir/b.txt",
+		"echo c > dir/c.txt",
+		"git add a.txt dir",
+		"GIT_COMMITTER_NAME=a GIT_COMMITTER_EMAIL=a@a.com GIT_COMMITTER_DATE=2006-01-02T15:04:05Z git commit -m commit1 --author='a <a@a.com>' --date 2006-01-02T15:04:05Z",
+	)
+	commitID, err := git.ResolveRevision(ctx, repo, nil, "master", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oids, err := git.BlobOIDs(ctx, repo, commitID, []string{"a.txt", "dir/b.txt", "dir/c.txt", "dir", "doesntexist"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(oids) != 3 {
+		t.Fatalf("got %d blobs (%v), want 3 (directories and missing paths are omitted)", len(oids), oids)
+	}
+	// `echo a | git hash-object --stdin`
+	if got, want := oids["a.txt"].String(), "78981922613b2afb6025042ff6bd878ac1994e85"; got != want {
+		t.Errorf("got a.txt blob %s, want %s", got, want)
+	}
+	if oids["a.txt"] != oids["dir/b.txt"] {
+		t.Error("got different blobs for files with the same contents")
+	}
+	if oids["a.txt"] == oids["dir/c.txt"] {
+		t.Error("got the same blob for files with different contents")
+	}
+}
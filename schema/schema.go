@@ -133,8 +133,13 @@ type Discussions struct {
 
 // ExperimentalFeatures description: Experimental features to enable or disable. Features that are now enabled by default are marked as deprecated.
 type ExperimentalFeatures struct {
-	Discussions      string `json:"discussions,omitempty"`
-	UpdateScheduler2 string `json:"updateScheduler2,omitempty"`
+	Discussions            string `json:"discussions,omitempty"`
+	GlobalSymbolSearch     string `json:"globalSymbolSearch,omitempty"`
+	GoDependencyGraph      string `json:"goDependencyGraph,omitempty"`
+	PackageDependencyGraph string `json:"packageDependencyGraph,omitempty"`
+	SearchRanking          string `json:"searchRanking,omitempty"`
+	UnifiedTextSearch      string `json:"unifiedTextSearch,omitempty"`
+	UpdateScheduler2       string `json:"updateScheduler2,omitempty"`
 }
 
 // Extensions description: Configures Sourcegraph extensions.
@@ -291,6 +296,13 @@ type OtherExternalServiceConnection struct {
 	Url   string   `json:"url,omitempty"`
 }
 
+// PackageToRepositoryName description: Describes a mapping from package name to repository name. The `from` field contains a regular expression with named capturing groups. The `to` field contains a template string that references capturing group names. For instance, if `manager` is "npm", `from` is "^@myorg/(?P<name>.+)$" and `to` is "github.com/myorg/{name}", the npm package "@myorg/ui" would be mapped to the repository name "github.com/myorg/ui".
+type PackageToRepositoryName struct {
+	From    string `json:"from"`
+	Manager string `json:"manager"`
+	To      string `json:"to"`
+}
+
 // ParentSourcegraph description: URL to fetch unreachable repository details from. Defaults to "https://sourcegraph.com"
 type ParentSourcegraph struct {
 	Url string `json:"url,omitempty"`
@@ -339,6 +351,12 @@ type SMTPServerConfig struct {
 	Port           int    `json:"port"`
 	Username       string `json:"username,omitempty"`
 }
+
+// SearchHistory description: Settings for recording the search queries that users run. Users can view and delete their search history, and recent queries are suggested as they type. Site admins can view all search history, which shows which queries are slow or time out.
+type SearchHistory struct {
+	Disabled      bool `json:"disabled,omitempty"`
+	RetentionDays int  `json:"retentionDays,omitempty"`
+}
 type SearchSavedQueries struct {
 	Description    string `json:"description"`
 	Key            string `json:"key"`
@@ -388,9 +406,13 @@ type SiteConfiguration struct {
 	GithubClientID                    string                      `json:"githubClientID,omitempty"`
 	GithubClientSecret                string                      `json:"githubClientSecret,omitempty"`
 	MaxReposToSearch                  int                         `json:"maxReposToSearch,omitempty"`
+	PackageToRepositoryName           []*PackageToRepositoryName  `json:"packageToRepositoryName,omitempty"`
 	ParentSourcegraph                 *ParentSourcegraph          `json:"parentSourcegraph,omitempty"`
 	RepoListUpdateInterval            int                         `json:"repoListUpdateInterval,omitempty"`
+	SearchHistory                     *SearchHistory              `json:"search.history,omitempty"`
+	SearchIndexBranches               []string                    `json:"search.index.branches,omitempty"`
 	SearchIndexEnabled                *bool                       `json:"search.index.enabled,omitempty"`
+	SearchRepositoryPopularity        map[string]float64          `json:"search.repositoryPopularity,omitempty"`
 }
 
 // SlackNotificationsConfig description: Configuration for sending notifications to Slack.
@@ -21,12 +21,45 @@ const SiteSchemaJSON = `{
       "type": "boolean",
       "group": "Search"
     },
+    "search.index.branches": {
+      "description": "Glob patterns (as in Go's path.Match) of the branch names which searcher indexes in addition to the default branch of each repository, e.g. \"release-*\". Indexed branches are searched much faster than other revisions. The index of each searcher is limited to SEARCHER_BRANCH_INDEX_SIZE_MB, and the least recently searched branches are dropped from it when it is full.",
+      "type": "array",
+      "items": { "type": "string" },
+      "examples": [["release-*", "release/*"]],
+      "group": "Search"
+    },
     "search.index.enabled": {
       "description": "Whether indexed search is enabled. If unset Sourcegraph detects the environment to decide if indexed search is enabled. Indexed search is RAM heavy, and is disabled by default in the single docker image. All other environments will have it enabled by default. The size of all your repository working copies is the amount of additional RAM required.",
       "type": "boolean",
       "!go": { "pointer": true },
       "group": "Search"
     },
+    "search.repositoryPopularity": {
+      "description": "The popularity of repositories (such as their number of stars or dependents), which is used to rank search results when experimentalFeatures.searchRanking is enabled. Maps regular expressions matching repository names to popularity scores. Results in more popular repositories are ranked higher. If a repository matches multiple regular expressions, the highest popularity score is used.",
+      "type": "object",
+      "additionalProperties": { "type": "number", "minimum": 0 },
+      "examples": [{ "^github\\.com/myorg/core$": 1000, "^github\\.com/myorg/": 10 }],
+      "group": "Search"
+    },
+    "search.history": {
+      "description": "Settings for recording the search queries that users run. Users can view and delete their search history, and recent queries are suggested as they type. Site admins can view all search history, which shows which queries are slow or time out.",
+      "type": "object",
+      "additionalProperties": false,
+      "properties": {
+        "disabled": {
+          "description": "Do not record search history. Existing search history is kept until it expires.",
+          "type": "boolean",
+          "default": false
+        },
+        "retentionDays": {
+          "description": "The number of days after which search history entries are deleted.",
+          "type": "integer",
+          "minimum": 1,
+          "default": 90
+        }
+      },
+      "group": "Search"
+    },
     "experimentalFeatures": {
       "description": "Experimental features to enable or disable. Features that are now enabled by default are marked as deprecated.",
       "type": "object",
@@ -43,6 +76,36 @@ const SiteSchemaJSON = `{
           "type": "string",
           "enum": ["enabled", "disabled"],
           "default": "disabled"
+        },
+        "unifiedTextSearch": {
+          "description": "Scoped experiment: runs single-revision text searches with the search provider that is shared by indexed search and searcher (instead of the separate code paths for each). Match counts, word matches, glob file patterns, select:repo, index:only, index:no, and searches of multiple revisions, revision ranges or ref globs always use the previous code paths, which remain the default.",
+          "type": "string",
+          "enum": ["enabled", "disabled"],
+          "default": "disabled"
+        },
+        "globalSymbolSearch": {
+          "description": "Indexes the symbols of the default branch of the repositories that indexed search indexes in a global symbol index, and answers symbol searches (type:symbol) that are not restricted to some repositories from it instead of querying each repository.",
+          "type": "string",
+          "enum": ["enabled", "disabled"],
+          "default": "disabled"
+        },
+        "goDependencyGraph": {
+          "description": "Indexes the Go imports and go.mod files of the default branch of all repositories to build a dependency graph of the repositories, which is shown in the dependents and dependencies of repositories and in repository badges.",
+          "type": "string",
+          "enum": ["enabled", "disabled"],
+          "default": "disabled"
+        },
+        "packageDependencyGraph": {
+          "description": "Indexes the npm (package.json), Maven (pom.xml) and pip (requirements.txt and setup.py) manifests of the default branch of all repositories to build a dependency graph of packages, which is shown in the packages of repositories and their dependents. Packages are mapped to repositories by the packageToRepositoryName rules and by the manifests that define them.",
+          "type": "string",
+          "enum": ["enabled", "disabled"],
+          "default": "disabled"
+        },
+        "searchRanking": {
+          "description": "Ranks file matches in search results by relevance (see search.repositoryPopularity). Ranking looks up the symbol definitions and last changes of the highest-ranked file matches in the symbols service and gitserver, and caches them for 1 hour.",
+          "type": "string",
+          "enum": ["enabled", "disabled"],
+          "default": "disabled"
         }
       },
       "group": "Experimental",
@@ -88,6 +151,34 @@ const SiteSchemaJSON = `{
       },
       "group": "External services"
     },
+    "packageToRepositoryName": {
+      "description": "JSON array of configuration that maps from package names to repository names, for the package dependency graph (experimentalFeatures.packageDependencyGraph). Packages are automatically mapped to the repository whose package.json, pom.xml or setup.py defines them. Use this field to map packages whose manifests are not in a repository on Sourcegraph, or which are defined by several repositories (such as forks). The mappings are tried in the order they are specified and take precedence over automatic mappings.",
+      "type": "array",
+      "items": {
+        "title": "PackageToRepositoryName",
+        "description": "Describes a mapping from package name to repository name. The ` + "`" + `from` + "`" + ` field contains a regular expression with named capturing groups. The ` + "`" + `to` + "`" + ` field contains a template string that references capturing group names. For instance, if ` + "`" + `manager` + "`" + ` is \"npm\", ` + "`" + `from` + "`" + ` is \"^@myorg/(?P<name>.+)$\" and ` + "`" + `to` + "`" + ` is \"github.com/myorg/{name}\", the npm package \"@myorg/ui\" would be mapped to the repository name \"github.com/myorg/ui\".",
+        "type": "object",
+        "additionalProperties": false,
+        "required": ["manager", "from", "to"],
+        "properties": {
+          "manager": {
+            "description": "The package manager of the packages. Maven package names are \"groupId:artifactId\", and pip package names are normalized to lowercase with runs of \"-\", \"_\" and \".\" replaced by \"-\".",
+            "type": "string",
+            "enum": ["npm", "maven", "pip"]
+          },
+          "from": {
+            "description": "A regular expression that matches a set of package names. The regular expression should use the Go regular expression syntax (https://golang.org/pkg/regexp/). The regular expression matches partially by default, so use \"^...$\" if whole-string matching is desired.",
+            "type": "string"
+          },
+          "to": {
+            "description": "The repository name output pattern. This should use ` + "`" + `{matchGroup}` + "`" + ` syntax to reference the capturing groups from the ` + "`" + `from` + "`" + ` field.",
+            "type": "string"
+          }
+        }
+      },
+      "examples": [[{ "manager": "npm", "from": "^@myorg/(?P<name>.+)$", "to": "github.com/myorg/{name}" }]],
+      "group": "External services"
+    },
     "githubClientID": {
       "description": "Client ID for GitHub.",
       "type": "string",
@@ -201,6 +201,10 @@ export function fetchSuggestions(query: string): Observable<GQL.SearchSuggestion
                                 }
                             }
                         }
+                        ... on SearchHistoryEntry {
+                            query
+                            resultCount
+                        }
                     }
                 }
             }
@@ -1,8 +1,10 @@
 import FolderIcon from 'mdi-react/FolderIcon'
+import HistoryIcon from 'mdi-react/HistoryIcon'
 import * as React from 'react'
 import { RepositoryIcon } from '../../../../shared/src/components/icons' // TODO: Switch to mdi icon
 import * as GQL from '../../../../shared/src/graphql/schema'
 import { SymbolIcon } from '../../../../shared/src/symbols/SymbolIcon'
+import { buildSearchURLQuery } from '../../../../shared/src/util/url'
 import { basename, dirname } from '../../util/path'
 
 interface BaseSuggestion {
@@ -39,7 +41,11 @@ interface DirSuggestion extends BaseSuggestion {
     type: 'dir'
 }
 
-export type Suggestion = SymbolSuggestion | RepoSuggestion | FileSuggestion | DirSuggestion
+interface RecentSearchSuggestion extends BaseSuggestion {
+    type: 'recentSearch'
+}
+
+export type Suggestion = SymbolSuggestion | RepoSuggestion | FileSuggestion | DirSuggestion | RecentSearchSuggestion
 
 export function createSuggestion(item: GQL.SearchSuggestion): Suggestion {
     switch (item.__typename) {
@@ -87,6 +93,15 @@ export function createSuggestion(item: GQL.SearchSuggestion): Suggestion {
                 urlLabel: 'go to definition',
             }
         }
+        case 'SearchHistoryEntry': {
+            return {
+                type: 'recentSearch',
+                title: item.query,
+                description: `recent search — ${item.resultCount} results`,
+                url: `/search?${buildSearchURLQuery(item.query)}`,
+                urlLabel: 'search',
+            }
+        }
     }
 }
 
@@ -105,6 +120,8 @@ const SuggestionIcon: React.FunctionComponent<SuggestionIconProps> = ({ suggesti
             return <SymbolIcon kind={GQL.SymbolKind.FILE} {...passThru} />
         case 'symbol':
             return <SymbolIcon kind={suggestion.kind} {...passThru} />
+        case 'recentSearch':
+            return <HistoryIcon {...passThru} />
     }
 }
 